@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// backfillDays is how far back days are accepted, as the daily history
+// keeps 90 days
+const backfillDays = 90
+
+// backfillChecksPerDay stands in for the check count of a day given only as
+// an uptime percentage, so SLA reports weigh such days equally and to a
+// hundredth of a percent
+const backfillChecksPerDay = 10000
+
+// backfillColumns maps accepted CSV headings, in lower case without
+// spaces or punctuation, to the fields of a day
+var backfillColumns = map[string]string{
+	"date":           "date",
+	"day":            "date",
+	"service":        "service",
+	"name":           "service",
+	"uptime":         "uptime_percent",
+	"uptimepercent":  "uptime_percent",
+	"avgresponse":    "avg_response_ms",
+	"avgresponsems":  "avg_response_ms",
+	"responsems":     "avg_response_ms",
+	"responsetimems": "avg_response_ms",
+	"checks":         "total_checks",
+	"totalchecks":    "total_checks",
+	"successchecks":  "success_checks",
+	"incidents":      "incidents",
+}
+
+// backfillDay is a day of history as read from a file
+type backfillDay struct {
+	Service       string   `json:"service"`
+	Date          string   `json:"date"`
+	UptimePercent *float64 `json:"uptime_percent"`
+	AvgResponseMs float64  `json:"avg_response_ms"`
+	TotalChecks   int      `json:"total_checks"`
+	SuccessChecks *int     `json:"success_checks"`
+	Incidents     int      `json:"incidents"`
+}
+
+// runBackfill implements "status backfill": it loads daily uptime and
+// response times from before the page was deployed into the history
+func runBackfill(args []string) int {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Configuration file naming the data directory and services")
+	dataDir := fs.String("data-dir", os.Getenv("STATUS_DATA_DIR"), "Data directory, overriding the config file's storage.data_dir (env STATUS_DATA_DIR)")
+	service := fs.String("service", "", "Service for rows that don't name one")
+	format := fs.String("format", "", "Input format, csv or json (default from the file name or contents)")
+	overwrite := fs.Bool("overwrite", false, "Replace days already in the history, instead of keeping them")
+	dryRun := fs.Bool("dry-run", false, "Read the files and report what would be loaded without writing")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s backfill [flags] file...\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Loads daily uptime and average response times from CSV or JSON into each")
+		fmt.Fprintln(fs.Output(), "service's 90-day history. CSV needs a header naming date, service,")
+		fmt.Fprintln(fs.Output(), "uptime and avg_response_ms columns; JSON is a list of objects with")
+		fmt.Fprintln(fs.Output(), "service, date, uptime_percent and avg_response_ms, or /api/history's")
+		fmt.Fprintln(fs.Output(), "output. Use - to read standard input. Stop the server first.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 || (*format != "" && *format != "csv" && *format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	var days []backfillDay
+	for _, name := range fs.Args() {
+		read, err := readBackfillFile(name, *format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "backfill: %s: %v\n", name, err)
+			return 1
+		}
+		days = append(days, read...)
+	}
+	history, skipped, err := backfillHistory(days, *service, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: %v\n", err)
+		return 1
+	}
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d days older than %d days, which the history doesn't keep\n", skipped, backfillDays)
+	}
+
+	services := make([]string, 0, len(history))
+	total := 0
+	for name, h := range history {
+		services = append(services, name)
+		total += len(h)
+	}
+	sort.Strings(services)
+	if *dryRun {
+		fmt.Printf("Would load %d days for %d services\n", total, len(services))
+		return 0
+	}
+
+	cfg, err := loadCommandConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: %v\n", err)
+		return 1
+	}
+	dir := *dataDir
+	if dir == "" {
+		dir = cfg.Storage.DataDir
+	}
+	store, err := openStorage(dir, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backfill: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	var merge func(existing, day storage.DailyStatus) storage.DailyStatus
+	kept := 0
+	if !*overwrite {
+		merge = func(existing, day storage.DailyStatus) storage.DailyStatus {
+			kept++
+			return existing
+		}
+	}
+	for _, name := range services {
+		if err := store.MergeHistory(name, history[name], merge); err != nil {
+			fmt.Fprintf(os.Stderr, "backfill: %s: %v\n", name, err)
+			return 1
+		}
+	}
+	fmt.Printf("Loaded %d days for %d services\n", total-kept, len(services))
+	if kept > 0 {
+		fmt.Fprintf(os.Stderr, "Kept %d days already in the history; use -overwrite to replace them\n", kept)
+	}
+
+	configured := make(map[string]bool)
+	for _, svc := range cfg.Services {
+		configured[svc.Name] = true
+	}
+	var missing []string
+	for _, name := range services {
+		if !configured[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Services not in %s: %s\n", *configPath, strings.Join(missing, ", "))
+	}
+	return 0
+}
+
+// readBackfillFile reads days from a CSV or JSON file, or standard input
+// for "-". Without a format, a .json or .csv name decides, then whether the
+// contents start like JSON.
+func readBackfillFile(name, format string) ([]backfillDay, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		switch ext := strings.ToLower(filepath.Ext(name)); {
+		case ext == ".json":
+			format = "json"
+		case ext == ".csv":
+			format = "csv"
+		case bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) || bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+			format = "json"
+		default:
+			format = "csv"
+		}
+	}
+	if format == "json" {
+		return readBackfillJSON(data)
+	}
+	return readBackfillCSV(data)
+}
+
+// readBackfillJSON reads a list of days, or days listed by service as
+// /api/history returns them, with or without the API's envelope
+func readBackfillJSON(data []byte) ([]backfillDay, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		var days []backfillDay
+		return days, json.Unmarshal(data, &days)
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &envelope); err == nil && len(envelope.Data) > 0 {
+		return readBackfillJSON(envelope.Data)
+	}
+	var byService map[string][]backfillDay
+	if err := json.Unmarshal(data, &byService); err != nil {
+		return nil, err
+	}
+	var days []backfillDay
+	for name, list := range byService {
+		for _, d := range list {
+			d.Service = name
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+// readBackfillCSV reads days from CSV with a header row, matching headings
+// in backfillColumns regardless of case, spaces and punctuation, so
+// "Uptime %" and "avg_response_ms" are both understood. Values may end in
+// "%".
+func readBackfillCSV(data []byte) ([]backfillDay, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		key := strings.Map(func(c rune) rune {
+			if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' {
+				return c
+			}
+			return -1
+		}, strings.ToLower(h))
+		if field, ok := backfillColumns[key]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, errors.New("no date column in the header")
+	}
+	if _, ok := columns["uptime_percent"]; !ok {
+		return nil, errors.New("no uptime column in the header")
+	}
+
+	var days []backfillDay
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return days, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		number := func(name string) (float64, error) {
+			v := strings.TrimSuffix(field(name), "%")
+			if v == "" {
+				return 0, nil
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: %s %q is not a number", line, name, field(name))
+			}
+			return f, nil
+		}
+
+		if strings.Join(record, "") == "" {
+			continue
+		}
+		day := backfillDay{Service: field("service"), Date: field("date")}
+		uptime, err := number("uptime_percent")
+		if err != nil {
+			return nil, err
+		}
+		if field("uptime_percent") == "" {
+			return nil, fmt.Errorf("line %d: no uptime", line)
+		}
+		day.UptimePercent = &uptime
+		if day.AvgResponseMs, err = number("avg_response_ms"); err != nil {
+			return nil, err
+		}
+		checks, err := number("total_checks")
+		if err != nil {
+			return nil, err
+		}
+		day.TotalChecks = int(checks)
+		if field("success_checks") != "" {
+			success, err := number("success_checks")
+			if err != nil {
+				return nil, err
+			}
+			n := int(success)
+			day.SuccessChecks = &n
+		}
+		incidents, err := number("incidents")
+		if err != nil {
+			return nil, err
+		}
+		day.Incidents = int(incidents)
+		days = append(days, day)
+	}
+}
+
+// backfillHistory validates days and converts them to daily history by
+// service, in date order. Days before the last backfillDays are dropped and
+// counted. Days without check counts get backfillChecksPerDay checks, as
+// many successful as the uptime says.
+func backfillHistory(days []backfillDay, defaultService string, now time.Time) (map[string][]storage.DailyStatus, int, error) {
+	cutoff := now.AddDate(0, 0, -backfillDays).Format("2006-01-02")
+	today := now.Format("2006-01-02")
+	history := make(map[string][]storage.DailyStatus)
+	seen := make(map[string]bool)
+	skipped := 0
+
+	for _, d := range days {
+		if d.Service == "" {
+			d.Service = defaultService
+		}
+		date := d.Date
+		if t, err := time.Parse(time.RFC3339, date); err == nil {
+			date = t.Format("2006-01-02")
+		}
+		switch _, err := time.Parse("2006-01-02", date); {
+		case d.Service == "":
+			return nil, 0, fmt.Errorf("%s: no service; set -service or add a service column", d.Date)
+		case err != nil:
+			return nil, 0, fmt.Errorf("%s: date %q is not YYYY-MM-DD", d.Service, d.Date)
+		case date > today:
+			return nil, 0, fmt.Errorf("%s: date %s is in the future", d.Service, date)
+		case d.UptimePercent == nil:
+			return nil, 0, fmt.Errorf("%s %s: no uptime", d.Service, date)
+		case *d.UptimePercent < 0 || *d.UptimePercent > 100:
+			return nil, 0, fmt.Errorf("%s %s: uptime %g is not a percentage", d.Service, date, *d.UptimePercent)
+		case d.AvgResponseMs < 0 || d.TotalChecks < 0 || d.Incidents < 0:
+			return nil, 0, fmt.Errorf("%s %s: negative value", d.Service, date)
+		case d.SuccessChecks != nil && d.TotalChecks > 0 && (*d.SuccessChecks < 0 || *d.SuccessChecks > d.TotalChecks):
+			return nil, 0, fmt.Errorf("%s %s: success_checks must be between 0 and total_checks", d.Service, date)
+		case seen[d.Service+"\x00"+date]:
+			return nil, 0, fmt.Errorf("%s: %s appears twice", d.Service, date)
+		}
+		seen[d.Service+"\x00"+date] = true
+		if date < cutoff {
+			skipped++
+			continue
+		}
+
+		day := storage.DailyStatus{
+			Date:          date,
+			UptimePercent: *d.UptimePercent,
+			AvgResponseMs: int64(math.Round(d.AvgResponseMs)),
+			TotalChecks:   d.TotalChecks,
+			Incidents:     d.Incidents,
+		}
+		if day.TotalChecks == 0 {
+			day.TotalChecks = backfillChecksPerDay
+		}
+		if d.SuccessChecks != nil && d.TotalChecks > 0 {
+			day.SuccessChecks = *d.SuccessChecks
+		} else {
+			day.SuccessChecks = int(math.Round(day.UptimePercent / 100 * float64(day.TotalChecks)))
+		}
+		history[d.Service] = append(history[d.Service], day)
+	}
+
+	for _, h := range history {
+		sort.Slice(h, func(i, j int) bool { return h[i].Date < h[j].Date })
+	}
+	return history, skipped, nil
+}
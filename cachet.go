@@ -0,0 +1,480 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// sourceCachet marks incidents and maintenance imported from Cachet
+const sourceCachet = "cachet"
+
+// cachetHistoryDays is how far back metric points are imported, as the
+// daily history keeps 90 days
+const cachetHistoryDays = 90
+
+// cachetIncidentStatuses maps Cachet's incident status codes. Status 0 was
+// scheduled maintenance before Cachet 2.4 added schedules.
+var cachetIncidentStatuses = map[int64]string{
+	1: "investigating",
+	2: "identified",
+	3: "monitoring", // Watching
+	4: "resolved",   // Fixed
+}
+
+// cachetScheduleStatuses maps Cachet's schedule status codes
+var cachetScheduleStatuses = map[int64]string{
+	0: "scheduled", // Upcoming
+	1: "in_progress",
+	2: "completed",
+}
+
+// runImportCachet implements "status import-cachet": it copies component
+// groups, incidents, maintenance and chosen metrics from a dump of a Cachet
+// database into storage
+func runImportCachet(args []string) int {
+	fs := flag.NewFlagSet("import-cachet", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Configuration file naming the data directory and services")
+	dataDir := fs.String("data-dir", os.Getenv("STATUS_DATA_DIR"), "Data directory, overriding the config file's storage.data_dir (env STATUS_DATA_DIR)")
+	prefix := fs.String("prefix", "", "Table prefix set in Cachet's DB_PREFIX")
+	timezone := fs.String("timezone", "UTC", "Time zone of the dump's times, Cachet's APP_TIMEZONE")
+	servicesOut := fs.String("services-out", "", "Write the components as a services list for the config file")
+	dryRun := fs.Bool("dry-run", false, "Read the dump and report what would be imported without writing")
+	metrics := make(map[string]string)
+	fs.Func("metric", "Import a metric's daily averages as a service's response times, as \"metric=service\" (repeatable)", func(v string) error {
+		metric, service, ok := strings.Cut(v, "=")
+		if !ok || strings.TrimSpace(metric) == "" || strings.TrimSpace(service) == "" {
+			return fmt.Errorf("want metric=service")
+		}
+		metrics[strings.TrimSpace(metric)] = strings.TrimSpace(service)
+		return nil
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s import-cachet [flags] dump.sql\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Copies component groups, incidents with their updates and scheduled")
+		fmt.Fprintln(fs.Output(), "maintenance from a Cachet database dump, made with mysqldump or SQLite's")
+		fmt.Fprintln(fs.Output(), ".dump, into the database. Running it again updates rather than duplicates")
+		fmt.Fprintln(fs.Output(), "them. Use - to read the dump from standard input. Incidents name")
+		fmt.Fprintln(fs.Output(), "components as affected services; name services in the config file after")
+		fmt.Fprintln(fs.Output(), "the components to link them. Stop the server first.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	loc, err := time.LoadLocation(*timezone)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-cachet: %v\n", err)
+		return 2
+	}
+
+	var data []byte
+	if path := fs.Arg(0); path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-cachet: %v\n", err)
+		return 1
+	}
+	tables := []string{"component_groups", "components", "incidents", "incident_updates", "schedules", "schedule_components", "metrics", "metric_points"}
+	for i, t := range tables {
+		tables[i] = *prefix + t
+	}
+	dump, err := readSQLDump(data, tables)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-cachet: %v\n", err)
+		return 1
+	}
+	table := func(name string) []sqlRow { return dump[*prefix+name] }
+	if len(table("components")) == 0 && len(table("incidents")) == 0 {
+		fmt.Fprintf(os.Stderr, "import-cachet: no Cachet components or incidents in %s; check -prefix\n", fs.Arg(0))
+		return 1
+	}
+
+	components := cachetComponents(table("component_groups"), table("components"))
+	groups := cachetGroups(table("component_groups"), components)
+	names := make(map[string]string)
+	for _, c := range components {
+		names[c.ID] = c.Name
+	}
+	incidents, maintenance := cachetIncidents(table("incidents"), table("incident_updates"), names, loc)
+	maintenance = append(maintenance, cachetSchedules(table("schedules"), table("schedule_components"), names, loc)...)
+	history, err := cachetMetricHistory(table("metrics"), table("metric_points"), metrics, loc)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-cachet: %v\n", err)
+		return 1
+	}
+	days := 0
+	for _, h := range history {
+		days += len(h)
+	}
+
+	if *servicesOut != "" {
+		if err := writeImportedServices(*servicesOut, "Cachet", components); err != nil {
+			fmt.Fprintf(os.Stderr, "import-cachet: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Wrote %d services to %s\n", len(components)-len(groups), *servicesOut)
+	}
+	if *dryRun {
+		fmt.Printf("Would import %d groups, %d incidents, %d maintenance windows and %d days of metrics\n", len(groups), len(incidents), len(maintenance), days)
+		return 0
+	}
+
+	cfg, err := loadCommandConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-cachet: %v\n", err)
+		return 1
+	}
+	dir := *dataDir
+	if dir == "" {
+		dir = cfg.Storage.DataDir
+	}
+	store, err := openStorage(dir, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-cachet: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	for _, g := range groups {
+		if _, err := store.SaveGroup(g); err != nil {
+			fmt.Fprintf(os.Stderr, "import-cachet: group %s: %v\n", g.Name, err)
+			return 1
+		}
+	}
+	for _, inc := range incidents {
+		if _, _, err := store.PutIncident(inc); err != nil {
+			fmt.Fprintf(os.Stderr, "import-cachet: incident %s: %v\n", inc.ID, err)
+			return 1
+		}
+	}
+	for _, m := range maintenance {
+		if _, err := store.PutMaintenance(m); err != nil {
+			fmt.Fprintf(os.Stderr, "import-cachet: maintenance %s: %v\n", m.ID, err)
+			return 1
+		}
+		// As with Statuspage, keep Cachet's status so the scheduler doesn't
+		// announce windows that have long finished
+		if _, err := store.UpdateMaintenance(m.ID, m.Status); err != nil {
+			fmt.Fprintf(os.Stderr, "import-cachet: maintenance %s: %v\n", m.ID, err)
+			return 1
+		}
+	}
+	for service, h := range history {
+		// Days already monitored here keep their own measurements
+		err := store.MergeHistory(service, h, func(existing, day storage.DailyStatus) storage.DailyStatus {
+			if existing.TotalChecks > 0 {
+				return existing
+			}
+			day.Incidents = existing.Incidents
+			return day
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "import-cachet: history for %s: %v\n", service, err)
+			return 1
+		}
+	}
+	fmt.Printf("Imported %d groups, %d incidents, %d maintenance windows and %d days of metrics\n", len(groups), len(incidents), len(maintenance), days)
+
+	if missing := unconfiguredComponents(cfg, components); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Components without a service of the same name in %s: %s\n", *configPath, strings.Join(missing, ", "))
+	}
+	return 0
+}
+
+// cachetComponents converts component groups and components that weren't
+// deleted to Statuspage's shape, so they share its group and services
+// handling. Group IDs are prefixed to keep them apart from component IDs.
+// Positions follow Cachet's page: groups in order, each with its components
+// in order, then components outside any group.
+func cachetComponents(groupRows, componentRows []sqlRow) []statuspageComponent {
+	groupRows = append([]sqlRow(nil), groupRows...)
+	sort.SliceStable(groupRows, func(i, j int) bool { return groupRows[i].int("order") < groupRows[j].int("order") })
+	rank := make(map[string]int)
+	var components []statuspageComponent
+	for i, g := range groupRows {
+		rank[g.str("id")] = i
+		components = append(components, statuspageComponent{
+			ID:    "group-" + g.str("id"),
+			Name:  g.str("name"),
+			Group: true,
+		})
+	}
+
+	var rows []sqlRow
+	for _, c := range componentRows {
+		if _, deleted := c["deleted_at"]; !deleted {
+			rows = append(rows, c)
+		}
+	}
+	groupRank := func(c sqlRow) int {
+		if i, ok := rank[c.str("group_id")]; ok {
+			return i
+		}
+		return len(groupRows)
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if gi, gj := groupRank(rows[i]), groupRank(rows[j]); gi != gj {
+			return gi < gj
+		}
+		return rows[i].int("order") < rows[j].int("order")
+	})
+	for _, c := range rows {
+		component := statuspageComponent{
+			ID:          c.str("id"),
+			Name:        c.str("name"),
+			Description: c.str("description"),
+		}
+		if _, ok := rank[c.str("group_id")]; ok {
+			component.GroupID = "group-" + c.str("group_id")
+		}
+		components = append(components, component)
+	}
+	for i := range components {
+		components[i].Position = i + 1
+	}
+	return components
+}
+
+// cachetGroups converts component groups. Groups Cachet collapses, always
+// or while their components are operational, start collapsed.
+func cachetGroups(groupRows []sqlRow, components []statuspageComponent) []storage.ComponentGroup {
+	collapsed := make(map[string]bool)
+	for _, g := range groupRows {
+		collapsed[g.str("name")] = g.int("collapsed") != 0
+	}
+	groups := statuspageGroups(components)
+	for i := range groups {
+		groups[i].Collapsed = collapsed[groups[i].Name]
+	}
+	return groups
+}
+
+// cachetIncidents converts visible incidents that weren't deleted, with
+// their updates. Incidents of the old scheduled status become maintenance.
+func cachetIncidents(rows, updateRows []sqlRow, components map[string]string, loc *time.Location) ([]storage.Incident, []storage.Maintenance) {
+	updates := make(map[string][]sqlRow)
+	for _, u := range updateRows {
+		updates[u.str("incident_id")] = append(updates[u.str("incident_id")], u)
+	}
+
+	var incidents []storage.Incident
+	var maintenance []storage.Maintenance
+	for _, r := range rows {
+		if _, deleted := r["deleted_at"]; deleted {
+			continue
+		}
+		if v, ok := r["visible"]; ok && v == "0" {
+			continue
+		}
+		affected := []string{}
+		if name, ok := components[r.str("component_id")]; ok {
+			affected = append(affected, name)
+		}
+		created := cachetTime(r, loc, "occurred_at", "created_at")
+		updated := cachetTime(r, loc, "updated_at", "created_at")
+
+		if r.int("status") == 0 {
+			m := storage.Maintenance{
+				ID:               "cachet-" + r.str("id"),
+				Title:            r.str("name"),
+				Description:      r.str("message"),
+				AffectedServices: affected,
+				ScheduledStart:   cachetTime(r, loc, "scheduled_at", "created_at"),
+				Status:           "scheduled",
+				Source:           sourceCachet,
+				CreatedAt:        created,
+				UpdatedAt:        updated,
+			}
+			m.ScheduledEnd = m.ScheduledStart.Add(time.Hour)
+			if m.ScheduledEnd.Before(time.Now()) {
+				m.Status = "completed"
+			}
+			maintenance = append(maintenance, m)
+			continue
+		}
+
+		inc := storage.Incident{
+			ID:               "cachet-" + r.str("id"),
+			Title:            r.str("name"),
+			Status:           cachetIncidentStatuses[r.int("status")],
+			Severity:         "minor",
+			Message:          r.str("message"),
+			AffectedServices: affected,
+			Source:           sourceCachet,
+			CreatedAt:        created,
+			UpdatedAt:        updated,
+		}
+		if inc.Status == "" {
+			inc.Status = "investigating"
+		}
+
+		later := updates[r.str("id")]
+		sort.SliceStable(later, func(i, j int) bool {
+			return cachetTime(later[i], loc, "created_at").Before(cachetTime(later[j], loc, "created_at"))
+		})
+		// Cachet doesn't record the status the incident was opened with
+		first := inc.Status
+		if len(later) > 0 {
+			first = "investigating"
+		}
+		inc.Updates = []storage.IncidentUpdate{{ID: inc.ID, Status: first, Message: inc.Message, CreatedAt: created}}
+		for _, u := range later {
+			status := cachetIncidentStatuses[u.int("status")]
+			if status == "" {
+				status = inc.Status
+			}
+			inc.Updates = append(inc.Updates, storage.IncidentUpdate{
+				ID:        "cachet-update-" + u.str("id"),
+				Status:    status,
+				Message:   u.str("message"),
+				CreatedAt: cachetTime(u, loc, "created_at"),
+			})
+		}
+
+		if inc.Status == "resolved" {
+			resolved := inc.UpdatedAt
+			for _, u := range inc.Updates {
+				if u.Status == "resolved" {
+					resolved = u.CreatedAt
+					break
+				}
+			}
+			inc.ResolvedAt = &resolved
+		}
+		incidents = append(incidents, inc)
+	}
+	return incidents, maintenance
+}
+
+// cachetSchedules converts scheduled maintenance that wasn't deleted. A
+// schedule without a completion time is taken to last an hour.
+func cachetSchedules(rows, componentRows []sqlRow, components map[string]string, loc *time.Location) []storage.Maintenance {
+	affected := make(map[string][]string)
+	for _, c := range componentRows {
+		if name, ok := components[c.str("component_id")]; ok {
+			affected[c.str("schedule_id")] = append(affected[c.str("schedule_id")], name)
+		}
+	}
+
+	var maintenance []storage.Maintenance
+	for _, r := range rows {
+		if _, deleted := r["deleted_at"]; deleted {
+			continue
+		}
+		m := storage.Maintenance{
+			ID:               "cachet-schedule-" + r.str("id"),
+			Title:            r.str("name"),
+			Description:      r.str("message"),
+			AffectedServices: affected[r.str("id")],
+			ScheduledStart:   cachetTime(r, loc, "scheduled_at", "created_at"),
+			Status:           cachetScheduleStatuses[r.int("status")],
+			Source:           sourceCachet,
+			CreatedAt:        cachetTime(r, loc, "created_at"),
+			UpdatedAt:        cachetTime(r, loc, "updated_at", "created_at"),
+		}
+		if m.AffectedServices == nil {
+			m.AffectedServices = []string{}
+		}
+		if m.Status == "" {
+			m.Status = "scheduled"
+		}
+		m.ScheduledEnd = m.ScheduledStart.Add(time.Hour)
+		if end := r.time("completed_at", loc); end != nil && end.After(m.ScheduledStart) {
+			m.ScheduledEnd = *end
+		}
+		maintenance = append(maintenance, m)
+	}
+	return maintenance
+}
+
+// cachetMetricHistory averages the points of each metric named in mapping
+// by day, weighting them by Cachet's counter, as response times for the
+// service it maps to. Days carry no checks, so reports treat their uptime
+// as unknown.
+func cachetMetricHistory(metricRows, pointRows []sqlRow, mapping map[string]string, loc *time.Location) (map[string][]storage.DailyStatus, error) {
+	services := make(map[string]string) // By metric ID
+	for _, m := range metricRows {
+		if service, ok := mapping[m.str("name")]; ok {
+			services[m.str("id")] = service
+		}
+	}
+	for metric := range mapping {
+		found := false
+		for _, m := range metricRows {
+			found = found || m.str("name") == metric
+		}
+		if !found {
+			return nil, fmt.Errorf("no metric named %q in the dump", metric)
+		}
+	}
+
+	type sum struct{ total, count float64 }
+	sums := make(map[string]map[string]*sum) // By service, then date
+	cutoff := time.Now().In(loc).AddDate(0, 0, -cachetHistoryDays).Format("2006-01-02")
+	for _, p := range pointRows {
+		service, ok := services[p.str("metric_id")]
+		if !ok {
+			continue
+		}
+		at := p.time("created_at", loc)
+		if at == nil {
+			continue
+		}
+		date := at.Format("2006-01-02")
+		if date < cutoff {
+			continue
+		}
+		counter := 1.0
+		if _, ok := p["counter"]; ok {
+			counter = p.float("counter")
+		}
+		if sums[service] == nil {
+			sums[service] = make(map[string]*sum)
+		}
+		if sums[service][date] == nil {
+			sums[service][date] = &sum{}
+		}
+		sums[service][date].total += p.float("value") * counter
+		sums[service][date].count += counter
+	}
+
+	history := make(map[string][]storage.DailyStatus)
+	for service, byDate := range sums {
+		for date, s := range byDate {
+			if s.count <= 0 {
+				continue
+			}
+			history[service] = append(history[service], storage.DailyStatus{
+				Date:          date,
+				UptimePercent: 100,
+				AvgResponseMs: int64(math.Round(s.total / s.count)),
+			})
+		}
+		sort.Slice(history[service], func(i, j int) bool { return history[service][i].Date < history[service][j].Date })
+	}
+	return history, nil
+}
+
+// cachetTime returns the first of the columns that holds a time, or the
+// zero time
+func cachetTime(r sqlRow, loc *time.Location, columns ...string) time.Time {
+	for _, col := range columns {
+		if t := r.time(col, loc); t != nil {
+			return *t
+		}
+	}
+	return time.Time{}
+}
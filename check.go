@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/status/config"
+	"github.com/status/monitor"
+)
+
+// runCheck implements "status check": it runs each configured check once
+// and prints the results, exiting non-zero if a service is down
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	asJSON := fs.Bool("json", false, "Print the results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s check [-config path] [-json] [service...]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Runs the configured checks once, or those of the named services, and")
+		fmt.Fprintln(fs.Output(), "exits non-zero if any service is down. Nothing is stored or notified.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", config.Redact(*configPath), err)
+		return 1
+	}
+
+	services := cfg.Services
+	if fs.NArg() > 0 {
+		services = nil
+		for _, name := range fs.Args() {
+			svc := findService(cfg.Services, name)
+			if svc == nil {
+				fmt.Fprintf(os.Stderr, "check: no service named %q\n", name)
+				return 1
+			}
+			services = append(services, *svc)
+		}
+	}
+	if len(services) == 0 {
+		fmt.Fprintln(os.Stderr, "check: no services configured")
+		return 1
+	}
+
+	statuses := monitor.NewMonitor(services, nil).CheckOnce()
+
+	down := 0
+	for _, st := range statuses {
+		if st.Status == monitor.StatusDown {
+			down++
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(statuses)
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "SERVICE\tTYPE\tSTATUS\tTIME\tDETAIL")
+		for i, st := range statuses {
+			typ := services[i].Type
+			if typ == "" {
+				typ = config.CheckHTTP
+			}
+			detail := st.ErrorMessage
+			if detail == "" && st.StatusCode != 0 {
+				detail = fmt.Sprintf("HTTP %d", st.StatusCode)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%dms\t%s\n", st.Name, typ, st.Status, st.ResponseTimeMs, detail)
+		}
+		tw.Flush()
+	}
+
+	if down > 0 {
+		return 1
+	}
+	return 0
+}
+
+// findService returns the configured service of that name, or nil
+func findService(services []config.Service, name string) *config.Service {
+	for i := range services {
+		if services[i].Name == name {
+			return &services[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// command is a subcommand of the status binary. Commands that only group
+// others, such as incident, have subcommands instead of run.
+type command struct {
+	name     string
+	summary  string
+	run      func(args []string) int
+	commands []command
+}
+
+// commands lists the subcommands; filled in by init, as help refers to it
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "serve", summary: "Run the status page server (the default)", run: runServe},
+		{name: "validate", summary: "Check a configuration file", run: runValidate},
+		{name: "check", summary: "Run the configured checks once and print the results", run: runCheck},
+		{name: "incident", summary: "Declare, update and list incidents", commands: []command{
+			{name: "create", summary: "Declare an incident", run: runIncidentCreate},
+			{name: "update", summary: "Post an update to an incident", run: runIncidentUpdate},
+			{name: "resolve", summary: "Resolve an incident", run: runIncidentResolve},
+			{name: "list", summary: "List incidents, newest first", run: runIncidentList},
+		}},
+		{name: "maintenance", summary: "Inspect maintenance windows in storage", commands: []command{
+			{name: "list", summary: "List maintenance windows", run: runMaintenanceList},
+		}},
+		{name: "token", summary: "Create API tokens", commands: []command{
+			{name: "create", summary: "Create an API token, such as the first admin token", run: runTokenCreate},
+		}},
+		{name: "export", summary: "Write the database to a JSON file", run: runExport},
+		{name: "import", summary: "Load a JSON export into the database", run: runImport},
+		{name: "import-statuspage", summary: "Copy groups, incidents and maintenance from Atlassian Statuspage", run: runImportStatuspage},
+		{name: "import-cachet", summary: "Copy groups, incidents, maintenance and metrics from a Cachet database dump", run: runImportCachet},
+		{name: "backfill", summary: "Load historical daily uptime from CSV or JSON", run: runBackfill},
+		{name: "export-site", summary: "Write a static copy of the page for a CDN", run: runExportSite},
+		{name: "schema", summary: "Print a JSON Schema for the configuration file", run: runSchema},
+		{name: "version", summary: "Print the version", run: runVersion},
+		{name: "help", summary: "Show help for a command", run: runHelp},
+	}
+}
+
+// runCLI runs the subcommand named by the first argument, returning the
+// process exit code. Without a subcommand, or with flags only, the server
+// starts, so "status -config config.yaml" keeps working.
+func runCLI(args []string) int {
+	if len(args) == 0 || (strings.HasPrefix(args[0], "-") && !isHelpFlag(args[0])) {
+		return runServe(args)
+	}
+	return dispatch(nil, commands, args)
+}
+
+// dispatch runs the command named by args[0] among cmds; path holds the
+// names of the enclosing commands
+func dispatch(path []string, cmds []command, args []string) int {
+	if len(args) == 0 || isHelpFlag(args[0]) {
+		printCommands(os.Stderr, path, cmds)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	c := findCommand(cmds, args[0])
+	if c == nil {
+		fmt.Fprintf(os.Stderr, "%s: unknown command %q\n\n", commandName(path), args[0])
+		printCommands(os.Stderr, path, cmds)
+		return 2
+	}
+	if c.commands != nil {
+		return dispatch(append(path, c.name), c.commands, args[1:])
+	}
+	return c.run(args[1:])
+}
+
+// runHelp implements "status help [command...]"
+func runHelp(args []string) int {
+	var path []string
+	cmds := commands
+	for _, name := range args {
+		c := findCommand(cmds, name)
+		if c == nil {
+			fmt.Fprintf(os.Stderr, "%s: unknown command %q\n", commandName(path), name)
+			return 2
+		}
+		if c.commands == nil {
+			// Leaf commands print their own usage with their flags
+			return c.run([]string{"-h"})
+		}
+		path, cmds = append(path, c.name), c.commands
+	}
+	printCommands(os.Stdout, path, cmds)
+	return 0
+}
+
+// runVersion implements "status version"
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s version\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Prints the version, commit and Go version the binary was built with.")
+	}
+	fs.Parse(args)
+
+	commit := ""
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+				commit = s.Value[:12]
+			}
+		}
+	}
+	if commit != "" {
+		fmt.Printf("status %s (%s, %s)\n", version, commit, runtime.Version())
+	} else {
+		fmt.Printf("status %s (%s)\n", version, runtime.Version())
+	}
+	return 0
+}
+
+func findCommand(cmds []command, name string) *command {
+	for i := range cmds {
+		if cmds[i].name == name {
+			return &cmds[i]
+		}
+	}
+	return nil
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// commandName is the program name followed by the command path, as in usage lines
+func commandName(path []string) string {
+	return strings.Join(append([]string{filepath.Base(os.Args[0])}, path...), " ")
+}
+
+// printCommands prints the usage of a command group
+func printCommands(w *os.File, path []string, cmds []command) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", commandName(path))
+	width := 12
+	for _, c := range cmds {
+		width = max(width, len(c.name))
+	}
+	for _, c := range cmds {
+		fmt.Fprintf(w, "  %-*s %s\n", width, c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun \"%s <command> -h\" for a command's flags.\n", commandName(path))
+}
+
+// storageFlags adds the -config and -data-dir flags of commands that open
+// the database and returns a function that opens it. The database is
+// locked while a server has it open, so these commands run offline; with
+// create false a missing database is an error rather than created empty.
+func storageFlags(fs *flag.FlagSet, create bool) func() (*storage.Storage, error) {
+	configPath := fs.String("config", "config.yaml", "Configuration file naming the data directory")
+	dataDir := fs.String("data-dir", os.Getenv("STATUS_DATA_DIR"), "Data directory, overriding the config file's storage.data_dir (env STATUS_DATA_DIR)")
+
+	return func() (*storage.Storage, error) {
+		dir := *dataDir
+		if dir == "" {
+			cfg, err := loadCommandConfig(*configPath)
+			if err != nil {
+				return nil, err
+			}
+			dir = cfg.Storage.DataDir
+		}
+		return openStorage(dir, create)
+	}
+}
+
+// loadCommandConfig loads the config file for a command other than serve.
+// As when serving, only a missing file falls back to the defaults.
+func loadCommandConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		if _, statErr := os.Stat(path); statErr == nil || config.IsRemote(path) {
+			return nil, err
+		}
+		cfg = config.DefaultConfig()
+	}
+	return cfg, nil
+}
+
+// openStorage opens the database in dir, or in "data" if dir is empty
+func openStorage(dir string, create bool) (*storage.Storage, error) {
+	if dir == "" {
+		dir = "data"
+	}
+	if !create {
+		if _, err := os.Stat(filepath.Join(dir, "status.db")); errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no database in %s", dir)
+		}
+	}
+	return storage.NewStorage(dir)
+}
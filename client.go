@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// apiTimeout bounds each request a command makes to a running server
+const apiTimeout = 30 * time.Second
+
+// apiClient calls a running server's API on behalf of a command
+type apiClient struct {
+	baseURL string
+	api     config.APIConfig // Credentials sent with each request
+	http    *http.Client
+}
+
+// connection is how a command reaches the data: a running server's API,
+// or the database when no server is running. Exactly one of client and
+// store is set.
+type connection struct {
+	cfg    *config.Config
+	client *apiClient
+	store  *storage.Storage
+}
+
+// Close closes the database, if the connection opened it
+func (c *connection) Close() {
+	if c.store != nil {
+		c.store.Close()
+	}
+}
+
+// serverFlags adds the flags of commands that work through a running
+// server's API, so it sends notifications and updates open pages, and
+// returns a function that connects. Without -server the server is looked
+// for on this host at the configured port; when nothing answers there, the
+// function opens the database instead, so the commands also work while the
+// server is down.
+func serverFlags(fs *flag.FlagSet) func() (*connection, error) {
+	configPath := fs.String("config", "config.yaml", "Configuration file naming the server's port, API credentials and data directory")
+	dataDir := fs.String("data-dir", os.Getenv("STATUS_DATA_DIR"), "Data directory when offline, overriding the config file's storage.data_dir (env STATUS_DATA_DIR)")
+	server := fs.String("server", os.Getenv("STATUS_SERVER"), "Server URL, such as https://status.example.com (env STATUS_SERVER; default localhost at the configured port)")
+	apiKey := fs.String("api-key", os.Getenv("STATUS_API_KEY"), "API key or token (env STATUS_API_KEY; default the config file's api credentials)")
+	offline := fs.Bool("offline", false, "Use the database directly rather than a server, which must be stopped")
+
+	return func() (*connection, error) {
+		cfg, err := loadCommandConfig(*configPath)
+		if err != nil {
+			return nil, err
+		}
+		dir := *dataDir
+		if dir == "" {
+			dir = cfg.Storage.DataDir
+		}
+		if dir == "" {
+			dir = "data"
+		}
+
+		if !*offline {
+			api := cfg.API
+			if *apiKey != "" {
+				api.Key = *apiKey
+			}
+			client := &apiClient{
+				baseURL: strings.TrimRight(*server, "/"),
+				api:     api,
+				http:    &http.Client{Timeout: apiTimeout},
+			}
+			if client.baseURL == "" {
+				client.baseURL = fmt.Sprintf("http://localhost:%d%s", cfg.Server.Port, cfg.BasePath)
+			}
+			err := client.ping()
+			if err == nil {
+				return &connection{cfg: cfg, client: client}, nil
+			}
+			// A server named with -server must answer; falling back to a
+			// local database could change another instance's data
+			if *server != "" || !isUnreachable(err) {
+				return nil, err
+			}
+			store, openErr := openStorage(dir, false)
+			if openErr != nil {
+				return nil, fmt.Errorf("no server at %s, and %w", client.baseURL, openErr)
+			}
+			fmt.Fprintf(os.Stderr, "No server at %s; using the database in %s directly\n", client.baseURL, dir)
+			return &connection{cfg: cfg, store: store}, nil
+		}
+
+		store, err := openStorage(dir, false)
+		if err != nil {
+			return nil, err
+		}
+		return &connection{cfg: cfg, store: store}, nil
+	}
+}
+
+// isUnreachable reports whether err is from failing to connect, as when
+// no server is listening
+func isUnreachable(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// ping checks that a server answers at the client's URL
+func (c *apiClient) ping() error {
+	resp, err := c.http.Get(c.baseURL + "/readyz")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// send sends a request to the API with the client's credentials, with
+// body encoded as JSON unless nil
+func (c *apiClient) send(method, path string, body interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.api.Key != "":
+		req.Header.Set("X-API-Key", c.api.Key)
+	case c.api.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.api.BearerToken)
+	case c.api.BasicAuth.Enabled:
+		req.SetBasicAuth(c.api.BasicAuth.Username, c.api.BasicAuth.Password)
+	}
+	return c.http.Do(req)
+}
+
+// do sends a request to the API and decodes the response's data into out
+// unless nil. An error response is returned as an error with the server's
+// message.
+func (c *apiClient) do(method, path string, body, out interface{}) error {
+	resp, err := c.send(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool            `json:"success"`
+		Data    json.RawMessage `json:"data"`
+		Error   string          `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if !result.Success {
+		if result.Error == "" {
+			result.Error = resp.Status
+		}
+		return fmt.Errorf("%s (%d)", result.Error, resp.StatusCode)
+	}
+	if out != nil {
+		return json.Unmarshal(result.Data, out)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// errNoAgeIdentity means no age identity could unwrap the file key
+var errNoAgeIdentity = errors.New("no age identity matches")
+
+// ageIdentities reads the identities sops uses: $SOPS_AGE_KEY, the file
+// named by $SOPS_AGE_KEY_FILE, or sops/age/keys.txt in the user's config
+// directory. Lines starting with # are comments.
+func ageIdentities() ([]age.Identity, error) {
+	text := os.Getenv("SOPS_AGE_KEY")
+	if text == "" {
+		path := os.Getenv("SOPS_AGE_KEY_FILE")
+		if path == "" {
+			dir, err := os.UserConfigDir()
+			if err != nil {
+				return nil, nil
+			}
+			path = filepath.Join(dir, "sops", "age", "keys.txt")
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) && os.Getenv("SOPS_AGE_KEY_FILE") == "" {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+
+	identities, err := age.ParseIdentities(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("malformed age identity: %w", err)
+	}
+	return identities, nil
+}
+
+// ageDecrypt decrypts an age file (https://age-encryption.org/v1),
+// ASCII-armored or binary, with the first identity that matches one of its
+// recipients
+func ageDecrypt(file []byte, identities []age.Identity) ([]byte, error) {
+	file = bytes.TrimSpace(file)
+	var r io.Reader = bytes.NewReader(file)
+	if bytes.HasPrefix(file, []byte(armor.Header)) {
+		r = armor.NewReader(r)
+	}
+
+	plaintext, err := age.Decrypt(r, identities...)
+	var noMatch *age.NoIdentityMatchError
+	if errors.As(err, &noMatch) {
+		return nil, errNoAgeIdentity
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(plaintext)
+}
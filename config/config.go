@@ -1,7 +1,9 @@
 package config
 
 import (
-	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -9,18 +11,253 @@ import (
 
 // Config holds the main configuration
 type Config struct {
+	Include     []string        `yaml:"include"` // Files merged into this one: globs or directories, relative to it
 	Title       string          `yaml:"title"`
 	Description string          `yaml:"description"`
 	Logo        string          `yaml:"logo"`
 	Favicon     string          `yaml:"favicon"`
 	BaseURL     string          `yaml:"base_url"`
+	BasePath    string          `yaml:"base_path"` // Path prefix when served from a sub-directory (e.g. /status)
+	Timezone    string          `yaml:"timezone"`  // IANA zone for displayed timestamps: page, feeds, notifications (default local)
 	Theme       ThemeConfig     `yaml:"theme"`
 	Server      ServerConfig    `yaml:"server"`
 	Services    []Service       `yaml:"services"`
+	Defaults    Service         `yaml:"defaults"`  // Settings every service starts from
+	Templates   map[string]Service `yaml:"templates"` // Named settings services inherit with extends
+	Groups      []GroupConfig   `yaml:"groups"`    // Service groups, in display order
 	Incidents   []Incident      `yaml:"incidents"`
+	Maintenance []MaintenanceWindow `yaml:"maintenance"` // Maintenance windows, once or recurring
+	Announcement AnnouncementConfig `yaml:"announcement"` // Site-wide banner; set here, the API cannot change it
+	Pages       []PageConfig    `yaml:"pages"`     // More status pages, each showing a subset of services
 	Webhooks    []WebhookConfig `yaml:"webhooks"`
 	Storage     StorageConfig   `yaml:"storage"`
 	API         APIConfig       `yaml:"api"`
+	Endpoints   EndpointsConfig `yaml:"endpoints"`
+	Integrations IntegrationsConfig `yaml:"integrations"`
+	Email       EmailConfig     `yaml:"email"`
+	Push        PushConfig      `yaml:"push"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	AutoIncidents AutoIncidentConfig  `yaml:"auto_incidents"`
+	Feeds         FeedsConfig         `yaml:"feeds"`
+	Secrets       SecretsConfig       `yaml:"secrets"`
+	Discovery     DiscoveryConfig     `yaml:"discovery"`
+	Logging       LoggingConfig       `yaml:"logging"`
+	HA            HAConfig            `yaml:"ha"`
+}
+
+// HAConfig runs several instances on a shared data directory. The one
+// holding the leader lease runs checks, sends notifications and writes;
+// the others serve reads from snapshots it publishes, forward writes to
+// it, and take over when its lease runs out.
+type HAConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	NodeID           string        `yaml:"node_id"`           // Unique name of this instance (default hostname and process ID)
+	AdvertiseURL     string        `yaml:"advertise_url"`     // Where other instances reach this one to forward writes while it leads
+	LeaseFile        string        `yaml:"lease_file"`        // Default leader.lease in the data directory
+	LeaseDuration    time.Duration `yaml:"lease_duration"`    // How long the lease lasts unless renewed (default 15s)
+	RenewInterval    time.Duration `yaml:"renew_interval"`    // How often the leader renews and followers poll (default 5s)
+	SnapshotInterval time.Duration `yaml:"snapshot_interval"` // How often the leader publishes data for followers (default 10s)
+}
+
+// LoggingConfig sets what the server logs and how. Level and format apply
+// again on reload.
+type LoggingConfig struct {
+	Level  string `yaml:"level" enum:"debug,info,warn,error"` // Least severe level logged (default info); debug logs every check and request
+	Format string `yaml:"format" enum:"text,json"`            // text (default) or json, one object per line
+}
+
+// DiscoveryConfig adds services found in other systems to those listed in
+// the config file. Discovered services start from defaults, group defaults
+// and templates like listed ones; a listed service with the same name wins.
+type DiscoveryConfig struct {
+	Interval   time.Duration       `yaml:"interval"` // How often each provider is polled (default 30s)
+	Kubernetes KubernetesDiscovery `yaml:"kubernetes"`
+	Docker     DockerDiscovery     `yaml:"docker"`
+	Consul     CatalogDiscovery    `yaml:"consul"`
+	Nomad      CatalogDiscovery    `yaml:"nomad"`
+}
+
+// KubernetesDiscovery monitors Services and Ingresses annotated with
+// status.monitor/enabled: "true"
+type KubernetesDiscovery struct {
+	Enabled       bool     `yaml:"enabled"`
+	APIServer     string   `yaml:"api_server"`      // e.g. https://k8s.example.com:6443 (default: in-cluster)
+	Token         string   `yaml:"token"`           // Also token_file (default: the pod's service account)
+	CAFile        string   `yaml:"ca_file"`         // CA bundle for the API server (default: the pod's service account)
+	SkipTLSVerify bool     `yaml:"skip_tls_verify"`
+	Namespaces    []string `yaml:"namespaces"`      // Namespaces to watch (default all)
+}
+
+// DockerDiscovery monitors running containers labelled
+// status.monitor.enabled=true
+type DockerDiscovery struct {
+	Enabled bool   `yaml:"enabled"`
+	Host    string `yaml:"host"`    // unix:///var/run/docker.sock or tcp://host:2375 (default $DOCKER_HOST, then the socket)
+	Network string `yaml:"network"` // Network whose container address is checked (default the first)
+}
+
+// CatalogDiscovery imports the healthy instances of services registered in
+// Consul or Nomad
+type CatalogDiscovery struct {
+	Enabled    bool   `yaml:"enabled"`
+	Address    string `yaml:"address"`    // HTTP API address (default $CONSUL_HTTP_ADDR or $NOMAD_ADDR, then the local agent)
+	Token      string `yaml:"token"`      // ACL token; also token_file (default $CONSUL_HTTP_TOKEN or $NOMAD_TOKEN)
+	Datacenter string `yaml:"datacenter"` // Consul datacenter (default the agent's)
+	Namespace  string `yaml:"namespace"`  // Nomad namespace, or * for all (default "default")
+	Tag        string `yaml:"tag"`        // Only instances with this tag (default all)
+}
+
+// FeedsConfig controls the RSS, Atom and JSON feeds
+type FeedsConfig struct {
+	StatusSummary bool              `yaml:"status_summary"` // Lead each feed with a current-status entry (default true)
+	WebSub        WebSubConfig      `yaml:"websub"`
+	Limit         int               `yaml:"limit"`        // Items per feed page unless ?limit= is given (default 50, at most 500)
+	Language      string            `yaml:"language"`     // Feed language and built-in translations: en, de, fr, es (default en)
+	Translations  map[string]string `yaml:"translations"` // Override feed strings by key, e.g. summary.title
+}
+
+// WebSubConfig announces feed updates through a WebSub (PubSubHubbub) hub
+type WebSubConfig struct {
+	Hub              string `yaml:"hub"`               // External hub URL, or "self" for the built-in hub at /websub
+	MaxSubscriptions int    `yaml:"max_subscriptions"` // Subscriptions the built-in hub keeps (default 1000)
+	MaxPerTopic      int    `yaml:"max_per_topic"`     // Subscriptions to one feed (default 100)
+}
+
+// AutoIncidentConfig controls incidents opened automatically from failing
+// health checks. Title and Message are Go text/template strings rendered
+// with the service's current check result.
+type AutoIncidentConfig struct {
+	Enabled          bool   `yaml:"enabled"`
+	Threshold        int    `yaml:"threshold"`         // Consecutive failing checks before opening (default 3)
+	Title            string `yaml:"title"`             // Default: "{{.Name}} is {{.Status}}"
+	Message          string `yaml:"message"`           // Initial update text
+	DownSeverity     string `yaml:"down_severity" enum:"minor,major,critical"`     // Severity when down (default major)
+	DegradedSeverity string `yaml:"degraded_severity" enum:"minor,major,critical"` // Severity when degraded (default minor)
+	AutoResolve      bool   `yaml:"auto_resolve"`      // Resolve on recovery; otherwise move to monitoring and wait for an operator (default true)
+}
+
+// NotificationsConfig holds delivery settings shared by all webhooks
+type NotificationsConfig struct {
+	Retry         RetryConfig        `yaml:"retry"`
+	Breaker       BreakerConfig      `yaml:"circuit_breaker"`
+	Heartbeat     HeartbeatConfig    `yaml:"heartbeat"`
+	StatusChanges StatusChangeConfig `yaml:"status_changes"`
+	Certificates  CertificateConfig  `yaml:"certificates"`
+	Routes        []RouteConfig      `yaml:"routes"`
+	Escalations   []EscalationConfig `yaml:"escalations"`
+	DedupWindow   time.Duration      `yaml:"dedup_window"` // Suppress identical notifications within this window (0 disables)
+	URLs          []string           `yaml:"urls"`         // Apprise-style notification URLs (slack://, discord://, mailto://, ...)
+	DryRun        bool               `yaml:"dry_run"`      // Log notifications on every channel instead of sending them
+}
+
+// NotificationURLID returns the webhook ID of the i-th (0-based) entry in
+// notifications.urls
+func NotificationURLID(i int) string {
+	return "url-" + strconv.Itoa(i+1)
+}
+
+// EscalationConfig notifies further webhooks while an incident remains
+// unacknowledged. The first policy matching an incident applies.
+type EscalationConfig struct {
+	Name       string           `yaml:"name"`
+	Severities []string         `yaml:"severities" enum:"minor,major,critical"` // Incident severities covered (default all)
+	Services   []string         `yaml:"services"`   // Affected services covered (default all)
+	Steps      []EscalationStep `yaml:"steps"`
+}
+
+// EscalationStep notifies webhooks once an incident has been open for After
+type EscalationStep struct {
+	After    time.Duration `yaml:"after"`
+	Webhooks []string      `yaml:"webhooks"` // Webhook IDs
+}
+
+// RouteConfig sends matching notifications to specific webhooks instead of
+// every webhook subscribed to the event. Routes are evaluated in order and
+// empty match fields match anything; unmatched events use each webhook's
+// own events list.
+type RouteConfig struct {
+	Name       string   `yaml:"name"`
+	Events     []string `yaml:"events"`     // Exact names, "incident.*" or "*"
+	Severities []string `yaml:"severities" enum:"minor,major,critical"`
+	Services   []string `yaml:"services"`
+	Groups     []string `yaml:"groups"`
+	Days       []string `yaml:"days"`     // mon, tue, ... (default every day)
+	From       string   `yaml:"from"`     // Time window start, HH:MM
+	To         string   `yaml:"to"`       // Time window end, HH:MM (may wrap past midnight)
+	Timezone   string   `yaml:"timezone"` // IANA zone for days and times (default local)
+	Webhooks   []string `yaml:"webhooks"` // Webhook IDs to notify
+	Continue   bool     `yaml:"continue"` // Keep evaluating later routes after a match
+}
+
+// StatusChangeConfig controls service.down / service.degraded /
+// service.recovered notifications driven by health checks
+type StatusChangeConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Threshold int           `yaml:"threshold"` // Consecutive checks before alerting (default 2)
+	Cooldown  time.Duration `yaml:"cooldown"`  // Minimum time between alerts per service (default 5m)
+	Degraded  bool          `yaml:"degraded"`  // Also alert on degraded (default true)
+}
+
+// CertificateConfig controls certificate.expiring notifications from TLS
+// checks
+type CertificateConfig struct {
+	Enabled    bool  `yaml:"enabled"`
+	Thresholds []int `yaml:"thresholds"` // Days remaining to alert again at, below tls_warn_days (default 14, 7, 3, 1)
+}
+
+// RetryConfig controls webhook redelivery with exponential backoff
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"`    // Total attempts including the first (default 5)
+	InitialBackoff time.Duration `yaml:"initial_backoff"` // Delay before the first retry (default 2s)
+	MaxBackoff     time.Duration `yaml:"max_backoff"`     // Cap on the delay between attempts (default 5m)
+}
+
+// BreakerConfig stops deliveries to webhooks that keep failing
+type BreakerConfig struct {
+	Threshold int           `yaml:"threshold"` // Consecutive failed deliveries before skipping the webhook (default 5, 0 disables)
+	Cooldown  time.Duration `yaml:"cooldown"`  // Time before a probe delivery is tried (default 5m)
+}
+
+// HeartbeatConfig periodically checks that each enabled webhook still works
+type HeartbeatConfig struct {
+	Interval time.Duration `yaml:"interval"` // Time between checks (0 disables, the default)
+	Mode     string        `yaml:"mode" enum:"probe,message"` // probe (default) or message
+}
+
+// EmailConfig configures SMTP delivery of notifications to subscribers
+type EmailConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	Host      string `yaml:"host"`
+	Port      int    `yaml:"port"`
+	Username  string `yaml:"username"`
+	Password  string `yaml:"password"`
+	From      string `yaml:"from"`       // Sender address
+	FromName  string `yaml:"from_name"`  // Sender display name (default: title)
+	TLS       string `yaml:"tls" enum:"starttls,tls,none"` // starttls (default), tls, none
+	BatchSize int    `yaml:"batch_size"` // Messages sent per SMTP connection (default 50)
+}
+
+// PushConfig configures Web Push browser notifications. The VAPID key pair
+// is generated and stored in the database when not set here.
+type PushConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Subject          string        `yaml:"subject"`           // Contact for push services (default mailto:<email.from> or base_url)
+	VAPIDPublicKey   string        `yaml:"vapid_public_key"`  // Base64url
+	VAPIDPrivateKey  string        `yaml:"vapid_private_key"` // Base64url
+	TTL              time.Duration `yaml:"ttl"`               // How long push services hold undelivered messages (default 24h)
+	EndpointHosts    []string      `yaml:"endpoint_hosts"`    // Push services browsers may subscribe through, with their subdomains (default those of Chrome, Firefox, Edge and Safari)
+	MaxSubscriptions int           `yaml:"max_subscriptions"` // Browsers that may subscribe (default 10000)
+}
+
+// IntegrationsConfig holds settings for inbound chat integrations
+type IntegrationsConfig struct {
+	Slack SlackIntegration `yaml:"slack"`
+}
+
+// SlackIntegration configures the Slack slash-command endpoint
+type SlackIntegration struct {
+	Enabled       bool   `yaml:"enabled"`
+	SigningSecret string `yaml:"signing_secret"` // From the Slack app's Basic Information page
 }
 
 // StorageConfig holds storage settings
@@ -38,6 +275,17 @@ type APIConfig struct {
 	RateLimit    int      `yaml:"rate_limit"`
 }
 
+// EndpointsConfig switches families of public endpoints on or off; all are
+// on by default. Disabled endpoints answer 404. The status page itself and
+// the authenticated admin API are always served.
+type EndpointsConfig struct {
+	PublicAPI     bool `yaml:"public_api"`    // Unauthenticated /api/* reads: status, summary, history, incidents, ...
+	Feeds         bool `yaml:"feeds"`         // /feed/* and the WebSub hub
+	WebSocket     bool `yaml:"websocket"`     // /ws live updates
+	Subscriptions bool `yaml:"subscriptions"` // Email and Web Push subscriptions
+	Metrics       bool `yaml:"metrics"`       // /metrics and /api/metrics
+}
+
 // BasicAuth holds basic auth credentials
 type BasicAuth struct {
 	Enabled  bool   `yaml:"enabled"`
@@ -50,10 +298,27 @@ type WebhookConfig struct {
 	ID      string            `yaml:"id"`
 	Name    string            `yaml:"name"`
 	URL     string            `yaml:"url"`
-	Type    string            `yaml:"type"` // generic, slack, discord, teams
+	Type    string            `yaml:"type"` // generic, slack, discord, teams, googlechat, mattermost, rocketchat, pagerduty, opsgenie, telegram, twilio, email, mastodon, x
 	Events  []string          `yaml:"events"`
 	Headers map[string]string `yaml:"headers"`
 	Enabled bool              `yaml:"enabled"`
+	Secret  string            `yaml:"secret"` // HMAC key for X-Status-Signature (generic webhooks)
+
+	QuietHours *QuietHoursConfig `yaml:"quiet_hours"`
+	Digest     time.Duration     `yaml:"digest"`   // Coalesce notifications into one summary per window
+	Template   string            `yaml:"template"` // Go text/template for the request body
+	DryRun     bool              `yaml:"dry_run"`  // Format and log notifications without sending them
+}
+
+// QuietHoursConfig holds back a webhook's lower-severity notifications
+// during a recurring window
+type QuietHoursConfig struct {
+	Days        []string `yaml:"days"`         // mon, tue, ... (default every day)
+	From        string   `yaml:"from"`         // HH:MM
+	To          string   `yaml:"to"`           // HH:MM, may wrap past midnight
+	Timezone    string   `yaml:"timezone"`     // IANA zone (default local)
+	Action      string   `yaml:"action" enum:"suppress,defer"`                   // suppress (default) or defer until the window ends
+	MinSeverity string   `yaml:"min_severity" enum:"minor,major,critical"` // Still delivered during quiet hours (default critical)
 }
 
 // ThemeConfig holds theme customization
@@ -63,11 +328,58 @@ type ThemeConfig struct {
 	DarkMode       bool   `yaml:"dark_mode"`
 }
 
+// PageConfig is an additional status page served by the same process,
+// selected by hostname or by path. It shows the listed services and groups,
+// or every service if neither is given; incidents and maintenance that
+// only affect other services are left out.
+type PageConfig struct {
+	Name        string       `yaml:"name"`        // Identifies the page; required
+	Hostname    string       `yaml:"hostname"`    // Served for requests to this host, e.g. status.example.com
+	Path        string       `yaml:"path"`        // Or under this path, e.g. /internal
+	Title       string       `yaml:"title"`       // Default the main page's
+	Description string       `yaml:"description"` // Default the main page's
+	Logo        string       `yaml:"logo"`        // Default the main page's
+	Theme       *ThemeConfig `yaml:"theme"`       // Default the main page's
+	Services    []string     `yaml:"services"`    // Services shown, by name
+	Groups      []string     `yaml:"groups"`      // Groups whose services are all shown
+}
+
+// Shows reports whether the page includes the named service of group. A
+// nil page is the main page, which shows every service.
+func (p *PageConfig) Shows(service, group string) bool {
+	if p == nil || (len(p.Services) == 0 && len(p.Groups) == 0) {
+		return true
+	}
+	for _, name := range p.Services {
+		if name == service {
+			return true
+		}
+	}
+	for _, name := range p.Groups {
+		if name == group {
+			return true
+		}
+	}
+	return false
+}
+
 // ServerConfig holds HTTP server settings
 type ServerConfig struct {
-	Port         int           `yaml:"port"`
-	ReadTimeout  time.Duration `yaml:"read_timeout"`
-	WriteTimeout time.Duration `yaml:"write_timeout"`
+	Port            int           `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	DrainDelay      time.Duration `yaml:"drain_delay"`      // Keep serving this long after /readyz fails on shutdown (default 0)
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // Deadline for shutdown, drain delay included (default 10s)
+}
+
+// GroupConfig defines a service group shown on the status page. Services
+// join it by naming it in their group setting.
+type GroupConfig struct {
+	Name        string  `yaml:"name"`
+	Description string  `yaml:"description"`
+	Collapsed   bool    `yaml:"collapsed"` // Shown collapsed until expanded
+	Order       int     `yaml:"order"`     // Position among groups: lower first, ties in listed order
+	Defaults    Service `yaml:"defaults"`  // Settings member services start from
 }
 
 // CheckType represents the type of health check
@@ -94,12 +406,15 @@ const (
 	CheckMongoDB   CheckType = "mongodb"
 	CheckMySQL     CheckType = "mysql"
 	CheckPostgres  CheckType = "postgres"
+	CheckStatusPage CheckType = "statuspage" // Another status page, with its components
 )
 
 // Service represents a monitored service
 type Service struct {
 	Name           string            `yaml:"name"`
+	Extends        string            `yaml:"extends"`        // Template to inherit settings from
 	Group          string            `yaml:"group"`
+	Order          int               `yaml:"order"`          // Position within its group: lower first, ties in listed order
 	Type           CheckType         `yaml:"type"`           // http, tcp, icmp, dns, websocket, grpc
 	URL            string            `yaml:"url"`            // For HTTP/WebSocket/gRPC
 	Host           string            `yaml:"host"`           // For TCP/ICMP/DNS
@@ -110,8 +425,9 @@ type Service struct {
 	Headers        map[string]string `yaml:"headers"`
 	ExpectedStatus int               `yaml:"expected_status"`
 	Description    string            `yaml:"description"`
+	SLO            float64           `yaml:"slo"` // Uptime objective in percent (default 99.9)
 	// DNS specific
-	DNSRecordType  string            `yaml:"dns_record_type"` // A, AAAA, CNAME, MX, TXT
+	DNSRecordType  string            `yaml:"dns_record_type" enum:"A,AAAA,CNAME,MX,TXT,NS"` // A, AAAA, CNAME, MX, TXT, NS
 	DNSResolver    string            `yaml:"dns_resolver"`    // Custom DNS resolver
 	// TLS options
 	SkipTLSVerify  bool              `yaml:"skip_tls_verify"`
@@ -129,6 +445,8 @@ type Service struct {
 	// SMTP/Email specific
 	SMTPStartTLS   bool              `yaml:"smtp_starttls"`   // Use STARTTLS
 	SMTPAuth       bool              `yaml:"smtp_auth"`       // Require auth response
+	// Status page aggregation
+	PageFormat     string            `yaml:"page_format" enum:"status,statuspage,cachet"` // API the page at url serves (default status)
 }
 
 // Incident represents a past or ongoing incident
@@ -136,8 +454,8 @@ type Incident struct {
 	ID          string    `yaml:"id"`
 	Title       string    `yaml:"title"`
 	Description string    `yaml:"description"`
-	Status      string    `yaml:"status"` // investigating, identified, monitoring, resolved
-	Severity    string    `yaml:"severity"` // minor, major, critical
+	Status      string    `yaml:"status" enum:"investigating,identified,monitoring,resolved"`
+	Severity    string    `yaml:"severity" enum:"minor,major,critical"`
 	CreatedAt   time.Time `yaml:"created_at"`
 	UpdatedAt   time.Time `yaml:"updated_at"`
 	ResolvedAt  *time.Time `yaml:"resolved_at"`
@@ -152,6 +470,37 @@ type IncidentUpdate struct {
 	Timestamp time.Time `yaml:"timestamp"`
 }
 
+// AnnouncementConfig is a site-wide banner shown at the top of the page and
+// in /api/summary, for notices that aren't incidents
+type AnnouncementConfig struct {
+	Text  string    `yaml:"text"`                        // Markdown; empty for no banner
+	Level string    `yaml:"level" enum:"info,warning"` // Banner style (default info)
+	Start time.Time `yaml:"start"`                       // Shown from this time (default immediately)
+	End   time.Time `yaml:"end"`                         // Hidden from this time (default never)
+}
+
+// MaintenanceWindow is a maintenance window declared in the config file,
+// once or repeating. It is stored under its id; occurrences of recurring
+// windows are stored up to 30 days ahead as <id>-<start>.
+type MaintenanceWindow struct {
+	ID               string        `yaml:"id"`
+	Title            string        `yaml:"title"`
+	Description      string        `yaml:"description"`
+	AffectedServices []string      `yaml:"affected_services"`
+	Start            time.Time     `yaml:"start"`      // Start of the (first) window
+	End              time.Time     `yaml:"end"`        // End of the (first) window; or set duration
+	Duration         time.Duration `yaml:"duration"`   // Length of each window; or set end
+	Recurrence       string        `yaml:"recurrence"` // RRULE-style rule, e.g. FREQ=WEEKLY;BYDAY=SU;COUNT=10
+}
+
+// Length returns how long each of the window's occurrences lasts
+func (m MaintenanceWindow) Length() time.Duration {
+	if m.Duration > 0 {
+		return m.Duration
+	}
+	return m.End.Sub(m.Start)
+}
+
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
@@ -165,8 +514,9 @@ func DefaultConfig() *Config {
 		},
 		Server: ServerConfig{
 			Port:         8080,
-			ReadTimeout:  15 * time.Second,
-			WriteTimeout: 15 * time.Second,
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
 		},
 		Storage: StorageConfig{
 			DataDir: "data",
@@ -175,49 +525,220 @@ func DefaultConfig() *Config {
 			Enabled:   true,
 			RateLimit: 100,
 		},
+		Endpoints: EndpointsConfig{
+			PublicAPI:     true,
+			Feeds:         true,
+			WebSocket:     true,
+			Subscriptions: true,
+			Metrics:       true,
+		},
+		Notifications: NotificationsConfig{
+			Retry: RetryConfig{
+				MaxAttempts:    5,
+				InitialBackoff: 2 * time.Second,
+				MaxBackoff:     5 * time.Minute,
+			},
+			Breaker: BreakerConfig{
+				Threshold: 5,
+				Cooldown:  5 * time.Minute,
+			},
+			DedupWindow: 5 * time.Minute,
+			StatusChanges: StatusChangeConfig{
+				Enabled:   true,
+				Threshold: 2,
+				Cooldown:  5 * time.Minute,
+				Degraded:  true,
+			},
+			Certificates: CertificateConfig{
+				Enabled: true,
+			},
+		},
+		AutoIncidents: AutoIncidentConfig{
+			Threshold:        3,
+			Title:            "{{.Name}} is {{.Status}}",
+			Message:          "Automated checks are failing for {{.Name}}.{{if .Error}} Error: {{.Error}}{{end}}",
+			DownSeverity:     "major",
+			DegradedSeverity: "minor",
+			AutoResolve:      true,
+		},
+		Feeds: FeedsConfig{
+			StatusSummary: true,
+			Limit:         50,
+		},
 		Services: []Service{},
 		Webhooks: []WebhookConfig{},
 	}
 }
 
-// Load reads configuration from a YAML file
+// Load reads configuration from a YAML file. Values may reference
+// environment variables as ${VAR} or ${VAR:-default}.
 func Load(path string) (*Config, error) {
-	cfg := DefaultConfig()
-
-	data, err := os.ReadFile(path)
+	f, err := ParseFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return f.Config, nil
+}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
+// ParseFile reads a YAML, JSON or TOML configuration file or remote source,
+// keeping its parsed tree so problems can be reported by line
+func ParseFile(path string) (*File, error) {
+	data, err := readSource(path)
+	if err != nil {
 		return nil, err
 	}
 
-	// Apply defaults for services
-	for i := range cfg.Services {
-		// Default check type is HTTP
-		if cfg.Services[i].Type == "" {
-			cfg.Services[i].Type = CheckHTTP
-		}
-		if cfg.Services[i].Method == "" {
-			cfg.Services[i].Method = "GET"
-		}
-		if cfg.Services[i].Interval == 0 {
-			cfg.Services[i].Interval = 30 * time.Second
-		}
-		if cfg.Services[i].Timeout == 0 {
-			cfg.Services[i].Timeout = 10 * time.Second
-		}
-		if cfg.Services[i].ExpectedStatus == 0 {
-			cfg.Services[i].ExpectedStatus = 200
+	doc, err := loadDocument(path, data)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{
+		Path:    path,
+		doc:     doc,
+		sources: map[string][]string{path: strings.Split(string(data), "\n")},
+		origins: make(map[*yaml.Node]string),
+	}
+	if err := f.include(doc); err != nil {
+		return nil, err
+	}
+	if err := f.applyTemplates(doc); err != nil {
+		return nil, err
+	}
+	if err := f.loadSecrets(doc); err != nil {
+		return nil, err
+	}
+	if root := documentRoot(doc); root != nil {
+		if problems := f.checkFields(root, reflect.TypeOf(Config{}), "", nil, ""); len(problems) > 0 {
+			return nil, &DecodeError{File: f, Problems: problems}
 		}
-		if cfg.Services[i].DNSRecordType == "" {
-			cfg.Services[i].DNSRecordType = "A"
+	}
+
+	cfg := DefaultConfig()
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(cfg); err != nil {
+			return nil, err
 		}
-		if cfg.Services[i].DNSResolver == "" {
-			cfg.Services[i].DNSResolver = "8.8.8.8:53"
+	}
+	cfg.applyDefaults()
+	f.Config = cfg
+
+	return f, nil
+}
+
+// applyDefaults fills in settings that are unset or derived from others
+func (cfg *Config) applyDefaults() {
+	// Normalize base path to "/prefix" form (empty means served from root)
+	cfg.BasePath = NormalizeBasePath(cfg.BasePath)
+	if cfg.BasePath != "" && !strings.HasSuffix(strings.TrimRight(cfg.BaseURL, "/"), cfg.BasePath) {
+		cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/") + cfg.BasePath
+	}
+
+	if cfg.Server.ShutdownTimeout == 0 {
+		cfg.Server.ShutdownTimeout = 10 * time.Second
+	}
+
+	// Email defaults
+	if cfg.Email.Port == 0 {
+		cfg.Email.Port = 587
+	}
+	if cfg.Email.TLS == "" {
+		cfg.Email.TLS = "starttls"
+	}
+	if cfg.Email.BatchSize == 0 {
+		cfg.Email.BatchSize = 50
+	}
+	if cfg.Email.FromName == "" {
+		cfg.Email.FromName = cfg.Title
+	}
+
+	// Push defaults
+	if cfg.Push.Subject == "" {
+		if cfg.Email.From != "" {
+			cfg.Push.Subject = "mailto:" + cfg.Email.From
+		} else {
+			cfg.Push.Subject = cfg.BaseURL
 		}
 	}
+	if cfg.Push.TTL == 0 {
+		cfg.Push.TTL = 24 * time.Hour
+	}
+	if cfg.Push.MaxSubscriptions == 0 {
+		cfg.Push.MaxSubscriptions = 10000
+	}
+	if cfg.Feeds.WebSub.MaxSubscriptions == 0 {
+		cfg.Feeds.WebSub.MaxSubscriptions = 1000
+	}
+	if cfg.Feeds.WebSub.MaxPerTopic == 0 {
+		cfg.Feeds.WebSub.MaxPerTopic = 100
+	}
+
+	if cfg.Discovery.Interval == 0 {
+		cfg.Discovery.Interval = 30 * time.Second
+	}
+
+	if cfg.HA.LeaseDuration == 0 {
+		cfg.HA.LeaseDuration = 15 * time.Second
+	}
+	if cfg.HA.RenewInterval == 0 {
+		cfg.HA.RenewInterval = 5 * time.Second
+	}
+	if cfg.HA.SnapshotInterval == 0 {
+		cfg.HA.SnapshotInterval = 10 * time.Second
+	}
+
+	// Apply defaults for services
+	for i := range cfg.Services {
+		cfg.Services[i].applyDefaults()
+	}
+}
 
-	return cfg, nil
+// applyDefaults fills in a service's unset check settings
+func (svc *Service) applyDefaults() {
+	// Default check type is HTTP
+	if svc.Type == "" {
+		svc.Type = CheckHTTP
+	}
+	if svc.Method == "" {
+		svc.Method = "GET"
+	}
+	if svc.Interval == 0 {
+		svc.Interval = 30 * time.Second
+	}
+	if svc.Timeout == 0 {
+		svc.Timeout = 10 * time.Second
+	}
+	if svc.ExpectedStatus == 0 {
+		svc.ExpectedStatus = 200
+	}
+	if svc.DNSRecordType == "" {
+		svc.DNSRecordType = "A"
+	}
+	if svc.DNSResolver == "" {
+		svc.DNSResolver = "8.8.8.8:53"
+	}
+	if svc.SLO == 0 {
+		svc.SLO = 99.9
+	}
+	if svc.Type == CheckStatusPage && svc.PageFormat == "" {
+		svc.PageFormat = "status"
+	}
+}
+
+// Location returns the zone timestamps are displayed in: timezone, or the
+// server's local zone when unset
+func (cfg *Config) Location() (*time.Location, error) {
+	if cfg.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(cfg.Timezone)
+}
+
+// NormalizeBasePath cleans a base path so it has a leading slash and no
+// trailing slash. Root ("" or "/") is returned as an empty string.
+func NormalizeBasePath(p string) string {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
 }
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envRef matches ${VAR}, ${VAR:-default} and ${VAR:?message}, and the $${
+// escape for a literal ${
+var envRef = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:-|:\?)([^}]*))?\}`)
+
+// expandEnv replaces environment variable references in a string value.
+// ${VAR:-default} uses default when VAR is unset or empty, and
+// ${VAR:?message} fails with message. A plain ${VAR} must be set.
+func expandEnv(s string) (string, error) {
+	var err error
+	out := envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		m := envRef.FindStringSubmatch(ref)
+		name, op, arg := m[1], m[2], m[3]
+
+		if value, ok := os.LookupEnv(name); ok && (value != "" || op == "") {
+			return value
+		}
+		switch op {
+		case ":-":
+			return arg
+		case ":?":
+			if arg == "" {
+				arg = "not set"
+			}
+			err = fmt.Errorf("environment variable %s: %s", name, arg)
+		default:
+			if err == nil {
+				err = fmt.Errorf("environment variable %s is not set (use ${%s:-} for an empty default)", name, name)
+			}
+		}
+		return ""
+	})
+	return out, err
+}
+
+// expandEnvNode expands environment variable references in every scalar of
+// a parsed YAML document. Keys and comments are left alone, and values are
+// substituted after parsing so they cannot change the document's structure.
+func expandEnvNode(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, "${") {
+			return nil
+		}
+		value, err := expandEnv(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = value
+		// Let unquoted values resolve again, so "port: ${PORT}" is a number
+		if node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle|yaml.TaggedStyle) == 0 {
+			node.Tag = ""
+		}
+		return nil
+	}
+
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue // key
+		}
+		if err := expandEnvNode(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
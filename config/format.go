@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// wholeEnvRef matches a value that is one environment variable reference
+var wholeEnvRef = regexp.MustCompile(`^\$\{[A-Za-z_][A-Za-z0-9_]*(?:(?::-|:\?)[^}]*)?\}$`)
+
+// parseDocument parses a configuration file in the format named by its
+// extension (or a remote source's path): .json, .toml, or YAML for anything
+// else. Every format yields the same node tree, so includes, decoding and
+// line numbers in problems work alike.
+func parseDocument(path string, data []byte) (*yaml.Node, error) {
+	if IsRemote(path) {
+		if u, err := url.Parse(path); err == nil {
+			path = u.Path
+		}
+	}
+
+	var doc yaml.Node
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		// JSON is YAML too, but its own parser gives clearer errors
+		if err := checkJSON(data); err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		unquoteEnvRefs(&doc)
+	case ".toml":
+		root, err := parseTOML(data)
+		if err != nil {
+			return nil, err
+		}
+		doc = *root
+		unquoteEnvRefs(&doc)
+	default:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return &doc, nil
+}
+
+// loadDocument parses a configuration file, decrypts its sops values and
+// expands environment variable references in the rest. The sops MAC covers
+// the file as written, so it is checked before anything is expanded.
+func loadDocument(path string, data []byte) (*yaml.Node, error) {
+	doc, err := parseDocument(path, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := decryptSops(doc); err != nil {
+		return nil, err
+	}
+	if err := expandEnvNode(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// checkJSON reports a JSON syntax error with its line
+func checkJSON(data []byte) error {
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		return fmt.Errorf("line %d: %w", 1+bytes.Count(data[:syntax.Offset], []byte("\n")), err)
+	}
+	return err
+}
+
+// unquoteEnvRefs marks strings holding just an environment variable
+// reference as unquoted, so "${PORT}" becomes a number once expanded. JSON
+// and TOML have no unquoted form to write such a value in.
+func unquoteEnvRefs(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && wholeEnvRef.MatchString(node.Value) {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		unquoteEnvRefs(child)
+	}
+}
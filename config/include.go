@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Files returns the configuration file at path followed by the files it
+// includes, in the order they are merged
+func Files(path string) ([]string, error) {
+	if IsRemote(path) {
+		return []string{path}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseDocument(path, data)
+	if err == nil {
+		err = expandEnvNode(doc)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var top struct {
+		Include []string `yaml:"include"`
+	}
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&top); err != nil {
+			return nil, err
+		}
+	}
+	included, err := resolveIncludes(filepath.Dir(path), top.Include)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{path}, included...), nil
+}
+
+// resolveIncludes expands include patterns relative to dir. A directory
+// stands for the .yaml, .yml, .json and .toml files in it. Files matched more than once
+// are included once, in sorted order within each pattern.
+func resolveIncludes(dir string, patterns []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		var matches []string
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			for _, ext := range []string{"*.yaml", "*.yml", "*.json", "*.toml"} {
+				m, _ := filepath.Glob(filepath.Join(pattern, ext))
+				matches = append(matches, m...)
+			}
+		} else {
+			m, err := filepath.Glob(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("include %q: %w", pattern, err)
+			}
+			if m == nil && !strings.ContainsAny(pattern, "*?[") {
+				return nil, fmt.Errorf("include %q: file not found", pattern)
+			}
+			matches = m
+		}
+		sort.Strings(matches)
+
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+	return files, nil
+}
+
+// include merges the files listed under the document's include key into
+// it. Lists are appended, mappings merged, and a setting given in more than
+// one file is an error. A file holding only a list adds to services.
+func (f *File) include(doc *yaml.Node) error {
+	root := documentRoot(doc)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var top struct {
+		Include []string `yaml:"include"`
+	}
+	if err := root.Decode(&top); err != nil {
+		return err
+	}
+	if len(top.Include) > 0 && IsRemote(f.Path) {
+		return fmt.Errorf("include is not supported in remote configs")
+	}
+	files, err := resolveIncludes(filepath.Dir(f.Path), top.Include)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		included, err := loadDocument(path, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		f.sources[path] = strings.Split(string(data), "\n")
+
+		node := documentRoot(included)
+		if node == nil {
+			continue // Empty file
+		}
+		if node.Kind == yaml.SequenceNode {
+			node = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "services"}, node,
+			}}
+		}
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s: expected a mapping or a list of services", path)
+		}
+
+		// Decode on its own first, so type errors name the file
+		if err := node.Decode(&Config{}); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "include" {
+				return fmt.Errorf("%s:%d: included files cannot include others", path, node.Content[i].Line)
+			}
+		}
+
+		f.markOrigin(node, path)
+		if err := f.merge(root, node, path, ""); err != nil {
+			return err
+		}
+	}
+	f.Included = files
+	return nil
+}
+
+// origin returns the file a node came from
+func (f *File) origin(node *yaml.Node) string {
+	if path, ok := f.origins[node]; ok {
+		return path
+	}
+	return f.Path
+}
+
+// markOrigin records the file that every node under node came from
+func (f *File) markOrigin(node *yaml.Node, path string) {
+	f.origins[node] = path
+	for _, child := range node.Content {
+		f.markOrigin(child, path)
+	}
+}
+
+// merge merges the mapping src, from the file at path, into dst
+func (f *File) merge(dst, src *yaml.Node, path, prefix string) error {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		name := prefix + key.Value
+
+		var existing *yaml.Node
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				existing = dst.Content[j+1]
+				break
+			}
+		}
+
+		switch {
+		case existing == nil || existing.Tag == "!!null":
+			if existing != nil {
+				*existing = *value
+				f.origins[existing] = path
+			} else {
+				dst.Content = append(dst.Content, key, value)
+			}
+		case existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			existing.Content = append(existing.Content, value.Content...)
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			if err := f.merge(existing, value, path, name+"."); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s:%d: %s is already set at %s:%d", path, key.Line, name, f.origin(existing), existing.Line)
+		}
+	}
+	return nil
+}
+
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		return doc.Content[0]
+	}
+	return doc
+}
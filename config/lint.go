@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icmpProbeTimeout bounds the loopback ping Lint runs for icmp checks
+const icmpProbeTimeout = 3 * time.Second
+
+// Lint returns warnings about settings that are valid but probably not
+// what was meant. Unlike problems from Check they don't stop the file from
+// loading: checks that overlap their interval, skipped TLS verification on
+// public hosts, an admin API without credentials, services checking the
+// same target and icmp checks the ping command cannot run.
+func (f *File) Lint() []Problem {
+	var warnings []Problem
+	cfg := f.Config
+
+	api := cfg.API
+	if api.Key == "" && api.BearerToken == "" && !api.BasicAuth.Enabled {
+		warnings = append(warnings, f.Problem("no key, bearer_token or basic_auth is set, so the admin API (incidents, maintenance, tokens, webhooks) is open to anyone until a token is created with \"status token create\"", "api"))
+	}
+
+	if cfg.HA.Enabled && cfg.HA.AdvertiseURL == "" {
+		warnings = append(warnings, f.Problem("no advertise_url is set, so while this instance leads the others refuse writes instead of forwarding them to it", "ha"))
+	}
+
+	targets := make(map[string]int)
+	icmp := -1
+	for i, svc := range cfg.Services {
+		if svc.Interval > 0 && svc.Timeout >= svc.Interval {
+			warnings = append(warnings, f.Problem(fmt.Sprintf("timeout %s is not shorter than interval %s, so a hanging check delays the next one", svc.Timeout, svc.Interval), "services", i, "timeout"))
+		}
+		if svc.SkipTLSVerify {
+			if host := serviceHost(svc); host != "" && isPublicHost(host) {
+				warnings = append(warnings, f.Problem(fmt.Sprintf("skip_tls_verify is set for public host %s, where certificate errors are worth fixing rather than hiding", host), "services", i, "skip_tls_verify"))
+			}
+		}
+		if target := serviceTarget(svc); target != "" {
+			if first, ok := targets[target]; ok {
+				warnings = append(warnings, f.Problem(fmt.Sprintf("checks the same %s as services[%d] (%s)", target, first, cfg.Services[first].Name), "services", i))
+			} else {
+				targets[target] = i
+			}
+		}
+		if svc.Type == CheckICMP && icmp < 0 {
+			icmp = i
+		}
+	}
+
+	if icmp >= 0 {
+		if err := probePing(); err != nil {
+			warnings = append(warnings, f.Problem(fmt.Sprintf("icmp checks run the ping command, which fails here (%v); install it or grant it privileges (setuid, cap_net_raw or net.ipv4.ping_group_range)", err), "services", icmp, "type"))
+		}
+	}
+
+	return warnings
+}
+
+// serviceHost returns the host a service connects to
+func serviceHost(svc Service) string {
+	if svc.Host != "" {
+		return svc.Host
+	}
+	if u, err := url.Parse(svc.URL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return ""
+}
+
+// serviceTarget identifies what a service checks: its type and URL, or
+// type, host and port. Empty when the service has neither.
+func serviceTarget(svc Service) string {
+	typ := svc.Type
+	if typ == "" {
+		typ = CheckHTTP
+	}
+	switch {
+	case svc.URL != "":
+		return fmt.Sprintf("%s target %s", typ, svc.URL)
+	case svc.Host != "" && svc.Port > 0:
+		return fmt.Sprintf("%s target %s", typ, net.JoinHostPort(svc.Host, strconv.Itoa(svc.Port)))
+	case svc.Host != "":
+		return fmt.Sprintf("%s target %s", typ, svc.Host)
+	}
+	return ""
+}
+
+// isPublicHost reports whether a host looks reachable from the internet:
+// a public IP address, or a name outside local and cluster-internal domains
+func isPublicHost(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsUnspecified()
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.Contains(host, ".") {
+		return false
+	}
+	for _, suffix := range []string{".local", ".localhost", ".internal", ".lan", ".home.arpa", ".localdomain", ".svc", ".cluster.local", ".consul"} {
+		if strings.HasSuffix(host, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// probePing runs the ping command icmp checks use against the loopback
+// address
+func probePing() error {
+	ctx, cancel := context.WithTimeout(context.Background(), icmpProbeTimeout)
+	defer cancel()
+
+	args := []string{"-c", "1", "127.0.0.1"}
+	if runtime.GOOS == "windows" {
+		args = []string{"-n", "1", "127.0.0.1"}
+	}
+	if out, err := exec.CommandContext(ctx, "ping", args...).CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, strings.SplitN(msg, "\n", 2)[0])
+		}
+		return err
+	}
+	return nil
+}
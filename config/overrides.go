@@ -0,0 +1,42 @@
+package config
+
+import "strings"
+
+// Overrides are settings given on the command line or in the environment,
+// which take precedence over the config file. Empty fields leave the file's
+// setting alone.
+type Overrides struct {
+	Port    int
+	DataDir string
+	BaseURL string
+	APIKey  string
+
+	LogLevel  string
+	LogFormat string
+}
+
+// Apply sets the overridden settings in cfg
+func (o Overrides) Apply(cfg *Config) {
+	if o.Port != 0 {
+		cfg.Server.Port = o.Port
+	}
+	if o.DataDir != "" {
+		cfg.Storage.DataDir = o.DataDir
+	}
+	if o.BaseURL != "" {
+		// As when loading, the base path is added to the base URL
+		cfg.BaseURL = strings.TrimRight(o.BaseURL, "/")
+		if cfg.BasePath != "" && !strings.HasSuffix(cfg.BaseURL, cfg.BasePath) {
+			cfg.BaseURL += cfg.BasePath
+		}
+	}
+	if o.APIKey != "" {
+		cfg.API.Key = o.APIKey
+	}
+	if o.LogLevel != "" {
+		cfg.Logging.Level = o.LogLevel
+	}
+	if o.LogFormat != "" {
+		cfg.Logging.Format = o.LogFormat
+	}
+}
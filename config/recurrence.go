@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrencePeriods bounds how far a rule is followed, so rules whose
+// days never occur (such as the 31st every other February) still end
+const maxRecurrencePeriods = 10000
+
+// Recurrence is a repetition rule in a subset of iCalendar RRULE syntax:
+// FREQ (DAILY, WEEKLY or MONTHLY), INTERVAL, BYDAY, COUNT and UNTIL, as in
+// "FREQ=WEEKLY;BYDAY=SA,SU;COUNT=10". Weeks start on Monday and monthly
+// rules repeat on the first occurrence's day of the month.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    time.Time
+}
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRecurrence parses a rule such as "FREQ=DAILY;INTERVAL=2". An
+// "RRULE:" prefix is allowed, so rules can be copied from calendars.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not KEY=VALUE", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+			if r.Freq != "DAILY" && r.Freq != "WEEKLY" && r.Freq != "MONTHLY" {
+				return nil, fmt.Errorf("unsupported FREQ %q (want DAILY, WEEKLY or MONTHLY)", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL must be a positive number, not %q", value)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT must be a positive number, not %q", value)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[strings.ToUpper(code)]
+				if !ok {
+					return nil, fmt.Errorf("unknown BYDAY day %q (want MO, TU, WE, TH, FR, SA or SU)", code)
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported rule part %s", key)
+		}
+	}
+	if r.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if r.Freq == "MONTHLY" && len(r.ByDay) > 0 {
+		return nil, fmt.Errorf("BYDAY is not supported with FREQ=MONTHLY")
+	}
+	return r, nil
+}
+
+// parseUntil accepts the iCalendar forms 20261231 and 20261231T235959Z as
+// well as RFC 3339 times
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// A date includes the whole day
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("UNTIL %q is not a date such as 20261231 or 20261231T235959Z", value)
+}
+
+// Each calls fn with the start of every occurrence in order, beginning
+// with first, until fn returns false or the rule ends. Occurrences keep
+// first's wall-clock time in loc, so they do not shift with daylight
+// saving time.
+func (r *Recurrence) Each(first time.Time, loc *time.Location, fn func(time.Time) bool) {
+	first = first.In(loc)
+	days := append([]time.Weekday(nil), r.ByDay...)
+	if r.Freq == "WEEKLY" && len(days) == 0 {
+		days = []time.Weekday{first.Weekday()}
+	}
+	// Order days within a week starting on Monday
+	sort.Slice(days, func(i, j int) bool { return (days[i]+6)%7 < (days[j]+6)%7 })
+
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, first.Hour(), first.Minute(), first.Second(), 0, loc)
+	}
+	matches := func(t time.Time) bool {
+		if len(days) == 0 {
+			return true
+		}
+		for _, d := range days {
+			if t.Weekday() == d {
+				return true
+			}
+		}
+		return false
+	}
+
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if t.Before(first) || !matches(t) {
+			return true
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		emitted++
+		return fn(t) && (r.Count == 0 || emitted < r.Count)
+	}
+
+	// Monday of the first occurrence's week
+	weekStart := first.AddDate(0, 0, -int((first.Weekday()+6)%7))
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		step := period * r.Interval
+		switch r.Freq {
+		case "DAILY":
+			if !emit(at(first.Year(), first.Month(), first.Day()+step)) {
+				return
+			}
+		case "WEEKLY":
+			monday := weekStart.AddDate(0, 0, 7*step)
+			for _, d := range days {
+				if !emit(at(monday.Year(), monday.Month(), monday.Day()+int((d+6)%7))) {
+					return
+				}
+			}
+		case "MONTHLY":
+			t := at(first.Year(), first.Month()+time.Month(step), first.Day())
+			// Months without the day are skipped, as in RFC 5545
+			if t.Day() == first.Day() && !emit(t) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// remoteTimeout bounds each request for a remote config
+const remoteTimeout = 30 * time.Second
+
+// maxRemoteSize limits the size of a remote config
+const maxRemoteSize = 10 << 20
+
+var remoteClient = &http.Client{Timeout: remoteTimeout}
+
+// IsRemote reports whether path names a remote config source (an http(s),
+// s3 or etcd URL) rather than a file
+func IsRemote(path string) bool {
+	for _, prefix := range []string{"http://", "https://", "s3://", "etcd://", "etcds://"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns path with any password in it replaced, for logging
+func Redact(path string) string {
+	if u, err := url.Parse(path); err == nil && IsRemote(path) {
+		return u.Redacted()
+	}
+	return path
+}
+
+// Fetch reads a config file or remote source, as ParseFile does, without
+// parsing it
+func Fetch(path string) ([]byte, error) {
+	return readSource(path)
+}
+
+// readSource reads a config file or remote source. A remote source ending
+// in #sha256=<hex> must have that checksum; one ending in #sha256 must
+// match the checksum published beside it, at the same URL plus ".sha256".
+func readSource(path string) ([]byte, error) {
+	if !IsRemote(path) {
+		return os.ReadFile(path)
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	fragment := u.Fragment
+	u.Fragment, u.RawFragment = "", ""
+
+	data, err := fetchRemote(u)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", u.Redacted(), err)
+	}
+	if fragment == "" {
+		return data, nil
+	}
+
+	want, pinned := strings.CutPrefix(fragment, "sha256=")
+	if !pinned {
+		if fragment != "sha256" {
+			return nil, fmt.Errorf("%s: unknown checksum %q (want #sha256=<hex> or #sha256)", u.Redacted(), fragment)
+		}
+		sidecar := *u
+		sidecar.Path += ".sha256"
+		sum, err := fetchRemote(&sidecar)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sidecar.Redacted(), err)
+		}
+		// sha256sum output: the digest, then the file name
+		if fields := strings.Fields(string(sum)); len(fields) > 0 {
+			want = fields[0]
+		}
+	}
+	got := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(got[:]), want) {
+		return nil, fmt.Errorf("%s: checksum mismatch: got sha256 %x, want %s", u.Redacted(), got, want)
+	}
+	return data, nil
+}
+
+// fetchRemote reads the content at a remote URL
+func fetchRemote(u *url.URL) ([]byte, error) {
+	switch u.Scheme {
+	case "s3":
+		return fetchS3(u)
+	case "etcd", "etcds":
+		return fetchEtcd(u)
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return doRemote(req)
+}
+
+// doRemote sends a request and returns the body of a 200 response
+func doRemote(req *http.Request) ([]byte, error) {
+	resp, err := remoteClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	if len(data) > maxRemoteSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxRemoteSize)
+	}
+	return data, nil
+}
+
+// fetchS3 reads s3://bucket/key. Requests are signed with the standard AWS
+// environment variables when AWS_ACCESS_KEY_ID is set, and anonymous
+// otherwise; AWS_ENDPOINT_URL_S3 selects an S3-compatible service such as
+// MinIO.
+func fetchS3(u *url.URL) ([]byte, error) {
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("want s3://bucket/key")
+	}
+	region := firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	var endpoint string
+	if custom := firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); custom != "" {
+		endpoint = strings.TrimRight(custom, "/") + "/" + bucket + "/" + s3Escape(key)
+	} else {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, s3Escape(key))
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accessKey := os.Getenv("AWS_ACCESS_KEY_ID"); accessKey != "" {
+		signS3(req, accessKey, os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN"), region, time.Now())
+	}
+	return doRemote(req)
+}
+
+// signS3 adds an AWS Signature Version 4 to a GET request without a body
+func signS3(req *http.Request, accessKey, secretKey, sessionToken, region string, now time.Time) {
+	const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	stamp := now.UTC().Format("20060102T150405Z")
+	date := stamp[:8]
+
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", emptyHash)
+	headers := []string{"host:" + req.URL.Host, "x-amz-content-sha256:" + emptyHash, "x-amz-date:" + stamp}
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+		headers = append(headers, "x-amz-security-token:"+sessionToken)
+		signed += ";x-amz-security-token"
+	}
+
+	canonical := strings.Join([]string{"GET", req.URL.EscapedPath(), req.URL.RawQuery, strings.Join(headers, "\n") + "\n", signed, emptyHash}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signed, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes an object key as S3 signatures expect: all but
+// unreserved characters and slashes
+func s3Escape(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-._~/", c) >= 0 {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// fetchEtcd reads a key through etcd's v3 JSON gateway: etcd://host:2379/key
+// over HTTP, or etcds:// over HTTPS. User and password in the URL
+// authenticate first.
+func fetchEtcd(u *url.URL) ([]byte, error) {
+	scheme := "http"
+	if u.Scheme == "etcds" {
+		scheme = "https"
+	}
+	base := scheme + "://" + u.Host
+	post := func(path string, body interface{}, token string, result interface{}) error {
+		data, _ := json.Marshal(body)
+		req, err := http.NewRequest("POST", base+path, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, err := doRemote(req)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(resp, result)
+	}
+
+	var token string
+	if u.User != nil {
+		password, _ := u.User.Password()
+		var auth struct {
+			Token string `json:"token"`
+		}
+		if err := post("/v3/auth/authenticate", map[string]string{"name": u.User.Username(), "password": password}, "", &auth); err != nil {
+			return nil, fmt.Errorf("authenticate: %w", err)
+		}
+		token = auth.Token
+	}
+
+	var result struct {
+		Kvs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := post("/v3/kv/range", map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(u.Path))}, token, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Kvs) == 0 {
+		return nil, fmt.Errorf("key %s not found", u.Path)
+	}
+	return base64.StdEncoding.DecodeString(result.Kvs[0].Value)
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
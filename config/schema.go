@@ -0,0 +1,177 @@
+package config
+
+import (
+	"embed"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// The settings' comments become their descriptions in the schema
+//
+//go:embed config.go secrets.go
+var sources embed.FS
+
+// durationPattern matches the durations time.ParseDuration accepts
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// Schema returns a JSON Schema (draft 2020-12) for the configuration file,
+// for editors to complete and check config.yaml. Unknown keys are rejected,
+// as they are when the file is loaded.
+func Schema() map[string]interface{} {
+	b := &schemaBuilder{docs: fieldDocs(), defs: make(map[string]interface{})}
+	b.defs["EnvRef"] = map[string]interface{}{
+		"description": "An environment variable reference, expanded when the file is loaded",
+		"type":        "string",
+		"pattern":     `^\$\{[A-Za-z_][A-Za-z0-9_]*((:-|:\?)[^}]*)?\}$`,
+	}
+
+	schema := b.structSchema(reflect.TypeOf(Config{}), "")
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		properties["sops"] = map[string]interface{}{
+			"description": "Written by sops in encrypted files; its ENC[...] values are decrypted when the file is loaded",
+			"type":        "object",
+		}
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Status page configuration"
+	schema["$defs"] = b.defs
+	return schema
+}
+
+type schemaBuilder struct {
+	docs map[string]string // Comments by "Type" and "Type.Field"
+	defs map[string]interface{}
+}
+
+// schemaFor returns the schema of a value of type t, at path (keys joined
+// by dots, with * for list and map elements)
+func (b *schemaBuilder) schemaFor(t reflect.Type, enum, path string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	envRef := map[string]interface{}{"$ref": "#/$defs/EnvRef"}
+
+	switch {
+	case t == durationType:
+		return map[string]interface{}{"anyOf": []interface{}{
+			map[string]interface{}{"type": "string", "pattern": durationPattern, "examples": []string{"30s", "5m", "1h"}},
+			map[string]interface{}{"const": 0},
+			envRef,
+		}}
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == checkTypeType:
+		return map[string]interface{}{"type": "string", "enum": checkTypeNames()}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := b.defs[t.Name()]; !ok {
+			b.defs[t.Name()] = true // Placeholder, for types that contain themselves
+			b.defs[t.Name()] = b.structSchema(t, path)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem(), "", path+".*")}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": b.schemaFor(t.Elem(), enum, path+".*")}
+	case reflect.Bool:
+		return map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "boolean"}, envRef}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "integer"}, envRef}}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"anyOf": []interface{}{map[string]interface{}{"type": "number"}, envRef}}
+	}
+
+	s := map[string]interface{}{"type": "string"}
+	if enum != "" {
+		s["enum"] = strings.Split(enum, ",")
+	}
+	return s
+}
+
+// structSchema returns the schema of a mapping decoded into the struct t
+func (b *schemaBuilder) structSchema(t reflect.Type, path string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		field := strings.TrimPrefix(path+"."+name, ".")
+
+		s := b.schemaFor(sf.Type, sf.Tag.Get("enum"), field)
+		if doc := b.docs[t.Name()+"."+sf.Name]; doc != "" {
+			s["description"] = doc
+		}
+		properties[name] = s
+
+		if contains(secretFields, field) || field == "secrets.vault.token" {
+			properties[name+"_file"] = map[string]interface{}{
+				"type":        "string",
+				"description": "File to read " + name + " from",
+			}
+		}
+	}
+
+	s := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if doc := b.docs[t.Name()]; doc != "" {
+		s["description"] = doc
+	}
+	return s
+}
+
+// fieldDocs reads the comments on the configuration types and their fields
+func fieldDocs() map[string]string {
+	docs := make(map[string]string)
+	text := func(groups ...*ast.CommentGroup) string {
+		for _, g := range groups {
+			if g != nil {
+				return strings.Join(strings.Fields(g.Text()), " ")
+			}
+		}
+		return ""
+	}
+
+	entries, _ := sources.ReadDir(".")
+	for _, entry := range entries {
+		data, err := sources.ReadFile(entry.Name())
+		if err != nil {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), entry.Name(), data, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				docs[ts.Name.Name] = text(ts.Doc, gen.Doc)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				for _, field := range st.Fields.List {
+					for _, name := range field.Names {
+						// Prefer the comment on the field's own line, as
+						// those above some fields head a section
+						docs[ts.Name.Name+"."+name.Name] = text(field.Comment, field.Doc)
+					}
+				}
+			}
+		}
+	}
+	return docs
+}
@@ -0,0 +1,267 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// vaultTimeout bounds each request to Vault
+const vaultTimeout = 10 * time.Second
+
+// secretFields are the settings that may be read from a file, by giving
+// <field>_file instead, or from Vault, with a "vault:path#key" value. A *
+// matches every list element or every key of a mapping.
+var secretFields = []string{
+	"api.key",
+	"api.bearer_token",
+	"api.basic_auth.password",
+	"email.username",
+	"email.password",
+	"push.vapid_private_key",
+	"integrations.slack.signing_secret",
+	"webhooks.*.url",
+	"webhooks.*.secret",
+	"webhooks.*.headers.*", // Channel options such as access_token
+	"notifications.urls.*",
+	"discovery.kubernetes.token",
+	"discovery.consul.token",
+	"discovery.nomad.token",
+}
+
+// SecretsConfig configures where "vault:" secret references are read from
+type SecretsConfig struct {
+	Vault VaultConfig `yaml:"vault"`
+}
+
+// VaultConfig reads secrets from HashiCorp Vault's HTTP API
+type VaultConfig struct {
+	Address   string `yaml:"address"`   // e.g. https://vault.example.com:8200 (default $VAULT_ADDR)
+	Token     string `yaml:"token"`     // Also token_file (default $VAULT_TOKEN)
+	Namespace string `yaml:"namespace"` // Vault Enterprise namespace
+}
+
+// loadSecrets replaces secret fields given as files or Vault references
+// with their values
+func (f *File) loadSecrets(doc *yaml.Node) error {
+	root := documentRoot(doc)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	// The Vault token can itself come from a file
+	if vault := lookup(lookup(root, "secrets"), "vault"); vault != nil {
+		if err := f.resolveSecrets(vault, "token", nil); err != nil {
+			return err
+		}
+	}
+	var top struct {
+		Secrets SecretsConfig `yaml:"secrets"`
+	}
+	if err := root.Decode(&top); err != nil {
+		return err
+	}
+	vault := newVaultClient(top.Secrets.Vault)
+
+	for _, field := range secretFields {
+		path := strings.Split(field, ".")
+		for _, node := range walkPath(root, path[:len(path)-1]) {
+			if err := f.resolveSecrets(node, path[len(path)-1], vault); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// walkPath returns the nodes at path below node
+func walkPath(node *yaml.Node, path []string) []*yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if len(path) == 0 {
+		return []*yaml.Node{node}
+	}
+
+	var children []*yaml.Node
+	switch {
+	case path[0] != "*":
+		children = []*yaml.Node{lookup(node, path[0])}
+	case node.Kind == yaml.SequenceNode:
+		children = node.Content
+	case node.Kind == yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			children = append(children, node.Content[i])
+		}
+	}
+
+	var nodes []*yaml.Node
+	for _, child := range children {
+		nodes = append(nodes, walkPath(child, path[1:])...)
+	}
+	return nodes
+}
+
+// resolveSecrets resolves the secret field named key (or every field, for
+// *) of a mapping or list. vault is nil when references are not allowed.
+func (f *File) resolveSecrets(node *yaml.Node, key string, vault *vaultClient) error {
+	var values []*yaml.Node
+	switch {
+	case node.Kind == yaml.SequenceNode && key == "*":
+		values = node.Content
+
+	case node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, value := node.Content[i], node.Content[i+1]
+			name := strings.TrimSuffix(k.Value, "_file")
+			if key != "*" && name != key {
+				continue
+			}
+			if name != k.Value {
+				if lookup(node, name) != nil {
+					return fmt.Errorf("%s:%d: set %s or %s, not both", f.origin(k), k.Line, name, k.Value)
+				}
+				secret, err := f.readSecretFile(value)
+				if err != nil {
+					return fmt.Errorf("%s:%d: %s: %w", f.origin(k), k.Line, k.Value, err)
+				}
+				k.Value = name
+				*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.DoubleQuotedStyle, Value: secret, Line: value.Line, Column: value.Column}
+			}
+			values = append(values, value)
+		}
+	}
+
+	for _, value := range values {
+		if vault == nil || value.Kind != yaml.ScalarNode || !strings.HasPrefix(value.Value, "vault:") {
+			continue
+		}
+		secret, err := vault.read(strings.TrimPrefix(value.Value, "vault:"))
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", f.origin(value), value.Line, err)
+		}
+		*value = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Style: yaml.DoubleQuotedStyle, Value: secret, Line: value.Line, Column: value.Column}
+	}
+	return nil
+}
+
+// readSecretFile reads the file named by a <field>_file value, relative to
+// the config file it is in (or the working directory, for a remote config).
+// A trailing newline is removed.
+func (f *File) readSecretFile(value *yaml.Node) (string, error) {
+	if value.Kind != yaml.ScalarNode || value.Value == "" {
+		return "", fmt.Errorf("expected a file path")
+	}
+	path := value.Value
+	if !filepath.IsAbs(path) && !IsRemote(f.origin(value)) {
+		path = filepath.Join(filepath.Dir(f.origin(value)), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// vaultClient reads secrets from Vault, fetching each path once
+type vaultClient struct {
+	config  VaultConfig
+	client  *http.Client
+	secrets map[string]map[string]interface{}
+}
+
+func newVaultClient(cfg VaultConfig) *vaultClient {
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	return &vaultClient{
+		config:  cfg,
+		client:  &http.Client{Timeout: vaultTimeout},
+		secrets: make(map[string]map[string]interface{}),
+	}
+}
+
+// read returns one key of a secret, given as "path#key", e.g.
+// "secret/data/status#api_key". Both KV version 1 and 2 are supported.
+func (v *vaultClient) read(ref string) (string, error) {
+	path, key, ok := strings.Cut(ref, "#")
+	if !ok || path == "" || key == "" {
+		return "", fmt.Errorf("vault reference %q must be vault:path#key", "vault:"+ref)
+	}
+	if v.config.Address == "" {
+		return "", fmt.Errorf("vault:%s: secrets.vault.address (or VAULT_ADDR) is not set", path)
+	}
+
+	data, ok := v.secrets[path]
+	if !ok {
+		var err error
+		if data, err = v.fetch(path); err != nil {
+			return "", fmt.Errorf("vault:%s: %w", path, err)
+		}
+		v.secrets[path] = data
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("vault:%s: secret has no key %q", path, key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// fetch reads the secret at path
+func (v *vaultClient) fetch(path string) (map[string]interface{}, error) {
+	req, err := http.NewRequest("GET", strings.TrimRight(v.config.Address, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.config.Token)
+	if v.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.config.Namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data   map[string]interface{} `json:"data"`
+		Errors []string               `json:"errors"`
+	}
+	json.Unmarshal(body, &result)
+	if resp.StatusCode != http.StatusOK {
+		if len(result.Errors) > 0 {
+			return nil, fmt.Errorf("%s: %s", resp.Status, strings.Join(result.Errors, "; "))
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	// KV version 2 nests the secret under data.data, beside its metadata
+	if inner, ok := result.Data["data"].(map[string]interface{}); ok {
+		if _, ok := result.Data["metadata"]; ok {
+			return inner, nil
+		}
+	}
+	return result.Data, nil
+}
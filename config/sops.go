@@ -0,0 +1,303 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sopsValue matches a value encrypted by sops
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// sopsMACOnlyEncrypted starts the MAC of files with mac_only_encrypted set,
+// so it never equals the MAC over all values
+var sopsMACOnlyEncrypted = []byte{0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0x0b, 0x0b, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69}
+
+// sopsMetadata is the part of a sops file's sops block needed to recover
+// the data key its values are encrypted with and check the file's MAC
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	PGP []struct {
+		Fingerprint string `yaml:"fp"`
+		Enc         string `yaml:"enc"`
+	} `yaml:"pgp"`
+
+	MAC              string `yaml:"mac"`
+	LastModified     string `yaml:"lastmodified"`
+	MACOnlyEncrypted bool   `yaml:"mac_only_encrypted"`
+}
+
+// decryptSops decrypts the ENC[...] values of a file encrypted with sops,
+// in place, and removes its sops block. Files without one are left alone.
+// The data key is unwrapped with an age identity (see ageIdentities) or by
+// gpg with the user's keyring, and the file's MAC must match before any
+// value is used. Decrypted values have ${ escaped, so environment variables
+// are only expanded in the file's plain values.
+func decryptSops(doc *yaml.Node) error {
+	root := documentRoot(doc)
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil
+	}
+	meta := lookup(root, "sops")
+	if meta == nil {
+		if value := findSopsValue(root); value != nil {
+			return fmt.Errorf("line %d: encrypted value in a file without sops metadata", value.Line)
+		}
+		return nil
+	}
+
+	var m sopsMetadata
+	if err := meta.Decode(&m); err != nil {
+		return fmt.Errorf("line %d: sops: %w", meta.Line, err)
+	}
+	key, err := sopsDataKey(m)
+	if err != nil {
+		return fmt.Errorf("line %d: sops: %w", meta.Line, err)
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "sops" {
+			root.Content = append(root.Content[:i], root.Content[i+2:]...)
+			break
+		}
+	}
+	if err := checkSopsMAC(root, key, m); err != nil {
+		return fmt.Errorf("line %d: sops: %w", meta.Line, err)
+	}
+	return decryptSopsNode(root, key, nil)
+}
+
+// checkSopsMAC compares the file's MAC with the one sops computes: a
+// SHA-512 over every value in document order, decrypted, so values cannot
+// be removed, added or swapped without the data key. With
+// mac_only_encrypted, plain values are left out.
+func checkSopsMAC(root *yaml.Node, key []byte, m sopsMetadata) error {
+	if m.MAC == "" {
+		return errors.New("the file has no MAC")
+	}
+	match := sopsValue.FindStringSubmatch(m.MAC)
+	lastModified, err := time.Parse(time.RFC3339, m.LastModified)
+	if match == nil || err != nil {
+		return errors.New("malformed mac or lastmodified")
+	}
+	want, err := sopsDecrypt(match, key, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("mac: %w", err)
+	}
+
+	h := sha512.New()
+	if m.MACOnlyEncrypted {
+		h.Write(sopsMACOnlyEncrypted)
+	}
+	if err := hashSopsValues(h, root, key, nil, m.MACOnlyEncrypted); err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(fmt.Sprintf("%X", h.Sum(nil))), []byte(want)) != 1 {
+		return errors.New("MAC does not match, so values were added, removed or changed after encryption")
+	}
+	return nil
+}
+
+// hashSopsValues writes the values under node to the MAC as sops does:
+// decrypted, and formatted as sops formats numbers and booleans. Encrypted
+// comments are not part of it.
+func hashSopsValues(w io.Writer, node *yaml.Node, key []byte, path []string, onlyEncrypted bool) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := hashSopsValues(w, node.Content[i+1], key, append(path, node.Content[i].Value), onlyEncrypted); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := hashSopsValues(w, item, key, path, onlyEncrypted); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		return hashSopsValues(w, node.Alias, key, path, onlyEncrypted)
+	case yaml.ScalarNode:
+		if match := sopsValue.FindStringSubmatch(node.Value); match != nil {
+			plaintext, err := sopsDecrypt(match, key, strings.Join(path, ":")+":")
+			if err != nil {
+				return fmt.Errorf("line %d: %s: %w", node.Line, strings.Join(path, "."), err)
+			}
+			if match[4] != "comment" {
+				io.WriteString(w, plaintext)
+			}
+			return nil
+		}
+		if onlyEncrypted {
+			return nil
+		}
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		switch v := value.(type) {
+		case nil:
+		case string:
+			io.WriteString(w, v)
+		case int:
+			io.WriteString(w, strconv.Itoa(v))
+		case float64:
+			io.WriteString(w, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			if v {
+				io.WriteString(w, "True")
+			} else {
+				io.WriteString(w, "False")
+			}
+		case time.Time:
+			text, _ := v.MarshalText()
+			w.Write(text)
+		default:
+			fmt.Fprint(w, v)
+		}
+	}
+	return nil
+}
+
+// findSopsValue returns the first encrypted value under node
+func findSopsValue(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.ScalarNode && strings.HasPrefix(node.Value, "ENC[") {
+		return node
+	}
+	for _, child := range node.Content {
+		if found := findSopsValue(child); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// sopsDataKey unwraps the data key with the first age or PGP key available
+func sopsDataKey(m sopsMetadata) ([]byte, error) {
+	var problems []string
+	if len(m.Age) > 0 {
+		identities, err := ageIdentities()
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("age: %v", err))
+		case len(identities) == 0:
+			problems = append(problems, "age: no identity (set SOPS_AGE_KEY_FILE or SOPS_AGE_KEY)")
+		default:
+			for _, entry := range m.Age {
+				key, err := ageDecrypt([]byte(entry.Enc), identities)
+				if err == nil {
+					return key, nil
+				}
+				if !errors.Is(err, errNoAgeIdentity) {
+					problems = append(problems, fmt.Sprintf("age %s: %v", entry.Recipient, err))
+				}
+			}
+			if len(problems) == 0 {
+				problems = append(problems, "age: no identity matches the file's recipients")
+			}
+		}
+	}
+
+	for _, entry := range m.PGP {
+		cmd := exec.Command("gpg", "--batch", "--quiet", "--no-tty", "--decrypt")
+		cmd.Stdin = strings.NewReader(entry.Enc)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		key, err := cmd.Output()
+		if err == nil {
+			return key, nil
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.New(strings.ReplaceAll(msg, "\n", "; "))
+		}
+		problems = append(problems, fmt.Sprintf("pgp %s: %v", entry.Fingerprint, err))
+	}
+
+	if len(problems) == 0 {
+		return nil, errors.New("no age or pgp key in the file (other key services are not supported)")
+	}
+	return nil, fmt.Errorf("cannot decrypt the data key: %s", strings.Join(problems, "; "))
+}
+
+// decryptSopsNode decrypts the values under node. sops authenticates each
+// value with the mapping keys leading to it, so values cannot be moved.
+func decryptSopsNode(node *yaml.Node, key []byte, path []string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := decryptSopsNode(node.Content[i+1], key, append(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := decryptSopsNode(item, key, path); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		match := sopsValue.FindStringSubmatch(node.Value)
+		if match == nil {
+			return nil
+		}
+		plaintext, err := sopsDecrypt(match, key, strings.Join(path, ":")+":")
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", node.Line, strings.Join(path, "."), err)
+		}
+		node.Value = strings.ReplaceAll(plaintext, "${", "$${")
+		switch match[4] {
+		case "int":
+			node.Tag, node.Style = "!!int", 0
+		case "float":
+			node.Tag, node.Style = "!!float", 0
+		case "bool":
+			node.Tag, node.Style = "!!bool", 0
+		default:
+			node.Tag, node.Style = "!!str", yaml.DoubleQuotedStyle
+		}
+	}
+	return nil
+}
+
+// sopsDecrypt decrypts one AES-256-GCM value
+func sopsDecrypt(match []string, key []byte, additionalData string) (string, error) {
+	var parts [3][]byte
+	for i := range parts {
+		b, err := base64.StdEncoding.DecodeString(match[i+1])
+		if err != nil {
+			return "", errors.New("malformed encrypted value")
+		}
+		parts[i] = b
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", errors.New("cannot decrypt (wrong key, or the value was moved or altered)")
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// The fixtures in testdata were encrypted by sops 3.13.3 from
+//
+//	title: Status
+//	server:
+//	  port: 8080
+//	api:
+//	  key: secret123
+//	services:
+//	  - name: API
+//	    url: https://api.example.com
+//	    password: hunter2
+//	    retries: 3
+//
+// for the key in testdata/age.key, with --encrypted-regex
+// '^(key|password|retries)$' so plain values are left for the MAC to cover.
+// sops-mac-only.yaml was also given --mac-only-encrypted.
+
+// decryptFixture decrypts the sops fixture name after applying edit to it
+func decryptFixture(t *testing.T, name string, edit func(string) string) (map[string]interface{}, error) {
+	t.Helper()
+	t.Setenv("SOPS_AGE_KEY", "")
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join("testdata", "age.key"))
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(data)
+	if edit != nil {
+		edited := edit(src)
+		if edited == src {
+			t.Fatalf("edit left %s unchanged", name)
+		}
+		src = edited
+	}
+	doc, err := parseDocument(name, []byte(src))
+	if err != nil {
+		t.Fatalf("parsing %s: %v", name, err)
+	}
+	if err := decryptSops(doc); err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := doc.Decode(&values); err != nil {
+		t.Fatalf("decoding %s: %v", name, err)
+	}
+	return values, nil
+}
+
+// encryptedValue matches the encrypted value of a key in a fixture
+func encryptedValue(key string) *regexp.Regexp {
+	return regexp.MustCompile(`(?m)^(\s*` + key + `: )(ENC\[[^\]]*\])$`)
+}
+
+// swapValues swaps the encrypted values of keys a and b
+func swapValues(a, b string) func(string) string {
+	return func(src string) string {
+		va := encryptedValue(a).FindStringSubmatch(src)
+		vb := encryptedValue(b).FindStringSubmatch(src)
+		if va == nil || vb == nil {
+			return src
+		}
+		src = strings.Replace(src, va[0], va[1]+"SWAPPED", 1)
+		src = strings.Replace(src, vb[0], vb[1]+va[2], 1)
+		return strings.Replace(src, va[1]+"SWAPPED", va[1]+vb[2], 1)
+	}
+}
+
+func TestSopsDecrypt(t *testing.T) {
+	for _, name := range []string{"sops.yaml", "sops-mac-only.yaml"} {
+		got, err := decryptFixture(t, name, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, ok := got["sops"]; ok {
+			t.Errorf("%s: sops block left in the document", name)
+		}
+		if key := got["api"].(map[string]interface{})["key"]; key != "secret123" {
+			t.Errorf("%s: api.key = %#v, want secret123", name, key)
+		}
+		service := got["services"].([]interface{})[0].(map[string]interface{})
+		if service["password"] != "hunter2" {
+			t.Errorf("%s: services[0].password = %#v, want hunter2", name, service["password"])
+		}
+		if service["retries"] != 3 {
+			t.Errorf("%s: services[0].retries = %#v, want the int 3", name, service["retries"])
+		}
+		if service["url"] != "https://api.example.com" {
+			t.Errorf("%s: services[0].url = %#v, want it unchanged", name, service["url"])
+		}
+	}
+}
+
+func TestSopsTamperedPlainValue(t *testing.T) {
+	edit := func(src string) string {
+		return strings.Replace(src, "https://api.example.com", "https://evil.example.com", 1)
+	}
+
+	_, err := decryptFixture(t, "sops.yaml", edit)
+	if err == nil || !strings.Contains(err.Error(), "MAC does not match") {
+		t.Errorf("changed plain value: got %v, want a MAC mismatch", err)
+	}
+
+	// With mac_only_encrypted plain values are not covered, as in sops
+	got, err := decryptFixture(t, "sops-mac-only.yaml", edit)
+	if err != nil {
+		t.Fatalf("mac_only_encrypted, changed plain value: %v", err)
+	}
+	if url := got["services"].([]interface{})[0].(map[string]interface{})["url"]; url != "https://evil.example.com" {
+		t.Errorf("services[0].url = %#v, want the changed value", url)
+	}
+}
+
+func TestSopsTamperedEncryptedValue(t *testing.T) {
+	flip := func(src string) string {
+		match := encryptedValue("key").FindStringSubmatch(src)
+		if match == nil {
+			return src
+		}
+		// Change the first character of the authentication tag
+		i := strings.Index(match[2], ",tag:") + len(",tag:")
+		c := byte('A')
+		if match[2][i] == c {
+			c = 'B'
+		}
+		return strings.Replace(src, match[2], match[2][:i]+string(c)+match[2][i+1:], 1)
+	}
+	for _, name := range []string{"sops.yaml", "sops-mac-only.yaml"} {
+		if _, err := decryptFixture(t, name, flip); err == nil || !strings.Contains(err.Error(), "cannot decrypt") {
+			t.Errorf("%s: altered value: got %v, want a decryption error", name, err)
+		}
+	}
+}
+
+func TestSopsMovedValue(t *testing.T) {
+	// Each value is authenticated with its path, so a value moved to
+	// another key cannot be decrypted there, even with a valid MAC
+	for _, name := range []string{"sops.yaml", "sops-mac-only.yaml"} {
+		_, err := decryptFixture(t, name, swapValues("key", "password"))
+		if err == nil || !strings.Contains(err.Error(), "cannot decrypt") {
+			t.Errorf("%s: swapped values: got %v, want a decryption error", name, err)
+		}
+	}
+}
+
+func TestSopsRemovedValue(t *testing.T) {
+	remove := func(src string) string {
+		return encryptedValue("retries").ReplaceAllString(src, "")
+	}
+	for _, name := range []string{"sops.yaml", "sops-mac-only.yaml"} {
+		_, err := decryptFixture(t, name, remove)
+		if err == nil || !strings.Contains(err.Error(), "MAC does not match") {
+			t.Errorf("%s: removed value: got %v, want a MAC mismatch", name, err)
+		}
+	}
+}
+
+func TestSopsMACOnlyEncryptedFlag(t *testing.T) {
+	// Dropping mac_only_encrypted would let plain values go unchecked if
+	// the MAC were the same either way
+	edit := func(src string) string {
+		return strings.Replace(src, "    mac_only_encrypted: true\n", "", 1)
+	}
+	if _, err := decryptFixture(t, "sops-mac-only.yaml", edit); err == nil || !strings.Contains(err.Error(), "MAC does not match") {
+		t.Errorf("mac_only_encrypted removed: got %v, want a MAC mismatch", err)
+	}
+}
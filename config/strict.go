@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	timeType      = reflect.TypeOf(time.Time{})
+	checkTypeType = reflect.TypeOf(CheckType(""))
+	bareNumber    = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// DecodeError is returned by ParseFile when settings do not fit the
+// configuration: unknown keys, values of the wrong type, durations without
+// a unit or values outside a fixed set
+type DecodeError struct {
+	File     *File
+	Problems []Problem
+}
+
+func (e *DecodeError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// checkFields compares the settings under node with the fields of t,
+// recursing into nested settings
+func (f *File) checkFields(node *yaml.Node, t reflect.Type, field string, key *yaml.Node, enum string) []Problem {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.ShortTag() == "!!null" {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	problem := func(format string, args ...interface{}) []Problem {
+		at := key
+		if at == nil {
+			at = node
+		}
+		return []Problem{{File: f.origin(at), Line: at.Line, Field: field, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	switch {
+	case t == durationType:
+		return f.checkDuration(node, problem)
+
+	case t == timeType:
+		return nil
+
+	case t.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return problem("expected a mapping of settings")
+		}
+		return f.checkStruct(node, t, field)
+
+	case t.Kind() == reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return problem("expected a mapping")
+		}
+		var problems []Problem
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, f.checkFields(node.Content[i+1], t.Elem(), field+"."+node.Content[i].Value, node.Content[i], "")...)
+		}
+		return problems
+
+	case t.Kind() == reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return problem("expected a list")
+		}
+		var problems []Problem
+		for i, item := range node.Content {
+			problems = append(problems, f.checkFields(item, t.Elem(), fmt.Sprintf("%s[%d]", field, i), nil, enum)...)
+		}
+		return problems
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return problem("expected a single value")
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(node.Value) {
+		case "true", "false", "yes", "no", "on", "off":
+		default:
+			return problem("%q is not true or false", node.Value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if node.ShortTag() != "!!int" {
+			return problem("%q is not a whole number", node.Value)
+		}
+	case reflect.Float32, reflect.Float64:
+		if tag := node.ShortTag(); tag != "!!int" && tag != "!!float" {
+			return problem("%q is not a number", node.Value)
+		}
+	case reflect.String:
+		allowed := strings.Split(enum, ",")
+		if t == checkTypeType {
+			allowed = checkTypeNames()
+		}
+		if (enum != "" || t == checkTypeType) && node.Value != "" && !contains(allowed, node.Value) {
+			if guess := closest(node.Value, allowed); guess != "" {
+				return problem("%q is not one of %s; did you mean %q?", node.Value, strings.Join(allowed, ", "), guess)
+			}
+			return problem("%q is not one of %s", node.Value, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// checkStruct checks each setting of a mapping decoded into the struct t
+func (f *File) checkStruct(node *yaml.Node, t reflect.Type, field string) []Problem {
+	fields := make(map[string]reflect.StructField)
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = sf
+		names = append(names, name)
+	}
+
+	var problems []Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := key.Value
+		if field != "" {
+			path = field + "." + key.Value
+		}
+		if key.Value == "<<" {
+			continue // YAML merge key
+		}
+
+		sf, ok := fields[key.Value]
+		if !ok {
+			message := "unknown setting"
+			if base := strings.TrimSuffix(key.Value, "_file"); base != key.Value && fields[base].Name != "" {
+				message = fmt.Sprintf("%s cannot be read from a file", base)
+			} else if guess := closest(key.Value, names); guess != "" {
+				message = fmt.Sprintf("unknown setting; did you mean %s?", guess)
+			}
+			problems = append(problems, Problem{File: f.origin(key), Line: key.Line, Field: path, Message: message})
+			continue
+		}
+		problems = append(problems, f.checkFields(value, sf.Type, path, key, sf.Tag.Get("enum"))...)
+	}
+	return problems
+}
+
+// checkDuration reports a duration without a unit, which would otherwise be
+// read as nanoseconds
+func (f *File) checkDuration(node *yaml.Node, problem func(string, ...interface{}) []Problem) []Problem {
+	if node.Kind != yaml.ScalarNode {
+		return problem("expected a duration such as 30s, 5m or 1h")
+	}
+	if bareNumber.MatchString(node.Value) {
+		if strings.Trim(node.Value, "0.") == "" {
+			return nil
+		}
+		return problem("%s has no unit; did you mean %q?", node.Value, node.Value+"s")
+	}
+	if _, err := time.ParseDuration(node.Value); err != nil {
+		return problem("%q is not a duration; use a number with a unit such as 30s, 5m or 1h", node.Value)
+	}
+	return nil
+}
+
+// closest returns the candidate most like s, if any is close enough to be a
+// likely typo
+func closest(s string, candidates []string) string {
+	best, bestDistance := "", max(2, len(s)/3)+1
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(s), strings.ToLower(c)); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyTemplates fills in each service from the defaults block, its
+// group's defaults and the template it extends, so services only list what
+// differs. Settings in the service win over its template, which wins over
+// group defaults and then defaults; mappings such as headers are merged key
+// by key, and lists are replaced.
+func (f *File) applyTemplates(doc *yaml.Node) error {
+	root := documentRoot(doc)
+	services := lookup(root, "services")
+	if services == nil || services.Kind != yaml.SequenceNode {
+		return nil
+	}
+	defaults := lookup(root, "defaults")
+	templates := lookup(root, "templates")
+	groups := lookup(root, "groups")
+
+	for _, svc := range services.Content {
+		if svc.Kind != yaml.MappingNode {
+			continue
+		}
+		expanded, err := f.extend(svc, templates, nil)
+		if err != nil {
+			return err
+		}
+		if group := lookup(expanded, "group"); group != nil {
+			if groupDefaults := lookup(groupNode(groups, group.Value), "defaults"); groupDefaults != nil && groupDefaults.Kind == yaml.MappingNode {
+				expanded = f.overlay(groupDefaults, expanded)
+			}
+		}
+		if defaults != nil && defaults.Kind == yaml.MappingNode {
+			expanded = f.overlay(defaults, expanded)
+		}
+		*svc = *expanded
+	}
+	return nil
+}
+
+// groupNode returns the definition of the named group
+func groupNode(groups *yaml.Node, name string) *yaml.Node {
+	if groups == nil || groups.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, group := range groups.Content {
+		if n := lookup(group, "name"); n != nil && n.Value == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// extend returns node overlaid on the template it extends, if any. chain
+// holds the templates already being expanded, to catch cycles.
+func (f *File) extend(node, templates *yaml.Node, chain []string) (*yaml.Node, error) {
+	ext := lookup(node, "extends")
+	if ext == nil || ext.Value == "" {
+		return node, nil
+	}
+	name := ext.Value
+	for _, seen := range chain {
+		if seen == name {
+			return nil, fmt.Errorf("%s:%d: templates extend each other: %s -> %s", f.origin(ext), ext.Line, strings.Join(chain, " -> "), name)
+		}
+	}
+	template := lookup(templates, name)
+	if template == nil || template.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s:%d: unknown template %q", f.origin(ext), ext.Line, name)
+	}
+
+	base, err := f.extend(template, templates, append(chain, name))
+	if err != nil {
+		return nil, err
+	}
+	return f.overlay(base, node), nil
+}
+
+// overlay returns a mapping with the settings of over added to copies of
+// those in base
+func (f *File) overlay(base, over *yaml.Node) *yaml.Node {
+	result := *over
+	result.Content = nil
+
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		key := base.Content[i].Value
+		if key == "extends" || lookup(over, key) != nil {
+			continue
+		}
+		result.Content = append(result.Content, f.copyNode(base.Content[i]), f.copyNode(base.Content[i+1]))
+	}
+	for i := 0; i+1 < len(over.Content); i += 2 {
+		key, value := over.Content[i], over.Content[i+1]
+		if inherited := lookup(base, key.Value); inherited != nil && inherited.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			value = f.overlay(inherited, value)
+		}
+		result.Content = append(result.Content, key, value)
+	}
+	return &result
+}
+
+// copyNode deep-copies a node, keeping the file each part came from, so a
+// template's settings are reported at their own lines
+func (f *File) copyNode(node *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		copied.Content[i] = f.copyNode(child)
+	}
+	if path, ok := f.origins[node]; ok {
+		f.origins[&copied] = path
+	}
+	return &copied
+}
+
+// CompleteService fills in a service defined outside the config file, such
+// as a discovered one, as applyTemplates and applyDefaults would had it been
+// listed: from its template, group defaults and defaults, then the built-in
+// defaults.
+func (cfg *Config) CompleteService(svc Service) (Service, error) {
+	var chain []string
+	for name := svc.Extends; name != ""; {
+		for _, seen := range chain {
+			if seen == name {
+				return svc, fmt.Errorf("templates extend each other: %s -> %s", strings.Join(chain, " -> "), name)
+			}
+		}
+		template, ok := cfg.Templates[name]
+		if !ok {
+			return svc, fmt.Errorf("unknown template %q", name)
+		}
+		chain = append(chain, name)
+		fillUnset(reflect.ValueOf(&svc).Elem(), reflect.ValueOf(template))
+		name = template.Extends
+	}
+	for _, group := range cfg.Groups {
+		if group.Name == svc.Group {
+			fillUnset(reflect.ValueOf(&svc).Elem(), reflect.ValueOf(group.Defaults))
+		}
+	}
+	fillUnset(reflect.ValueOf(&svc).Elem(), reflect.ValueOf(cfg.Defaults))
+	svc.applyDefaults()
+	return svc, nil
+}
+
+// fillUnset copies the fields of base into the unset fields of v, merging
+// maps key by key, as overlay does for nodes
+func fillUnset(v, base reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field, inherited := v.Field(i), base.Field(i)
+		switch {
+		case inherited.IsZero():
+		case field.IsZero():
+			field.Set(inherited)
+		case field.Kind() == reflect.Map:
+			merged := reflect.MakeMap(field.Type())
+			for _, key := range inherited.MapKeys() {
+				merged.SetMapIndex(key, inherited.MapIndex(key))
+			}
+			for _, key := range field.MapKeys() {
+				merged.SetMapIndex(key, field.MapIndex(key))
+			}
+			field.Set(merged)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Layouts for the local dates and times TOML allows, by the zone names
+// the decoder gives them
+var tomlLocalLayouts = map[string]string{
+	"datetime-local": "2006-01-02T15:04:05.999999999",
+	"date-local":     "2006-01-02",
+	"time-local":     "15:04:05.999999999",
+}
+
+// parseTOML parses a TOML document into the node tree the YAML parser
+// produces. Keys keep the order they were written in, and dates and times
+// become strings (RFC 3339 for those with an offset).
+func parseTOML(data []byte) (*yaml.Node, error) {
+	var values map[string]interface{}
+	md, err := toml.Decode(string(data), &values)
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			return nil, fmt.Errorf("line %d: %s", perr.Position.Line, perr.Message)
+		}
+		return nil, err
+	}
+
+	keys := locateTOMLKeys(string(data), md)
+	root := tomlNode(values, "", keys, 1)
+	return &yaml.Node{Kind: yaml.DocumentNode, Line: 1, Column: 1, Content: []*yaml.Node{root}}, nil
+}
+
+// tomlKey is where a key is first defined
+type tomlKey struct {
+	order int
+	line  int
+}
+
+// locateTOMLKeys maps each key's path, with the indexes of arrays of
+// tables as in services[1].name, to its order and line. The decoder lists
+// keys in order but without lines, so each is found in the source after
+// the one before it.
+func locateTOMLKeys(src string, md toml.MetaData) map[string]tomlKey {
+	lines := strings.Split(src, "\n")
+	keys := make(map[string]tomlKey)
+	elements := make(map[string]int) // Tables seen in each array of tables
+	line := 0
+
+	for _, key := range md.Keys() {
+		last := key[len(key)-1]
+		for i := line; i < len(lines); i++ {
+			if definesTOMLKey(lines[i], last) {
+				line = i
+				break
+			}
+		}
+
+		path := ""
+		for i, part := range key {
+			path = joinTOMLPath(path, part)
+			if i == len(key)-1 && md.Type(key[:i+1]...) == "ArrayHash" && isTOMLTableHeader(lines[line]) {
+				elements[path]++
+			}
+			if n := elements[path]; n > 0 {
+				path += "[" + strconv.Itoa(n-1) + "]"
+			}
+			if _, ok := keys[path]; !ok {
+				keys[path] = tomlKey{order: len(keys), line: line + 1}
+			}
+		}
+	}
+	return keys
+}
+
+// definesTOMLKey reports whether a line of TOML may define key: the key,
+// bare or quoted, followed by '=', '.' or ']'
+func definesTOMLKey(line, key string) bool {
+	for _, form := range []string{key, `"` + key + `"`, "'" + key + "'"} {
+		for rest := line; ; {
+			i := strings.Index(rest, form)
+			if i < 0 {
+				break
+			}
+			before := strings.TrimRight(rest[:i], " \t")
+			after := strings.TrimLeft(rest[i+len(form):], " \t")
+			if (before == "" || strings.ContainsAny(before[len(before)-1:], "[{,.")) &&
+				after != "" && strings.ContainsAny(after[:1], "=.]") {
+				return true
+			}
+			rest = rest[i+len(form):]
+		}
+	}
+	return false
+}
+
+// isTOMLTableHeader reports whether a line is an [[array of tables]] header
+func isTOMLTableHeader(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "[[")
+}
+
+func joinTOMLPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// tomlNode converts a decoded TOML value at path into a node, with line
+// numbers from keys; line is used where they have none
+func tomlNode(value interface{}, path string, keys map[string]tomlKey, line int) *yaml.Node {
+	if key, ok := keys[path]; ok {
+		line = key.line
+	}
+	node := &yaml.Node{Line: line, Column: 1}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		node.Kind, node.Tag = yaml.MappingNode, "!!map"
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			a, aok := keys[joinTOMLPath(path, names[i])]
+			b, bok := keys[joinTOMLPath(path, names[j])]
+			if aok != bok {
+				return aok
+			}
+			if a.order != b.order {
+				return a.order < b.order
+			}
+			return names[i] < names[j]
+		})
+		for _, name := range names {
+			child := tomlNode(v[name], joinTOMLPath(path, name), keys, line)
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name, Line: child.Line, Column: 1},
+				child)
+		}
+	case []map[string]interface{}:
+		node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+		for i, table := range v {
+			node.Content = append(node.Content, tomlNode(table, path+"["+strconv.Itoa(i)+"]", keys, line))
+		}
+	case []interface{}:
+		node.Kind, node.Tag = yaml.SequenceNode, "!!seq"
+		for i, item := range v {
+			node.Content = append(node.Content, tomlNode(item, path+"["+strconv.Itoa(i)+"]", keys, line))
+		}
+	case string:
+		node.Kind, node.Tag, node.Value, node.Style = yaml.ScalarNode, "!!str", v, yaml.DoubleQuotedStyle
+	case bool:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!bool", strconv.FormatBool(v)
+	case int64:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!int", strconv.FormatInt(v, 10)
+	case float64:
+		node.Kind, node.Tag = yaml.ScalarNode, "!!float"
+		switch {
+		case math.IsNaN(v):
+			node.Value = ".nan"
+		case math.IsInf(v, 1):
+			node.Value = ".inf"
+		case math.IsInf(v, -1):
+			node.Value = "-.inf"
+		default:
+			node.Value = strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	case time.Time:
+		layout, ok := tomlLocalLayouts[v.Location().String()]
+		if !ok {
+			layout = time.RFC3339Nano
+		}
+		node.Kind, node.Tag, node.Value, node.Style = yaml.ScalarNode, "!!str", v.Format(layout), yaml.DoubleQuotedStyle
+	default:
+		node.Kind, node.Tag, node.Value = yaml.ScalarNode, "!!str", fmt.Sprint(v)
+	}
+	return node
+}
+
+// lookup returns the value of key in a mapping node, or nil
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeDocument parses src as the file path and decodes it into plain values
+func decodeDocument(t *testing.T, path, src string) (*yaml.Node, map[string]interface{}) {
+	t.Helper()
+	doc, err := parseDocument(path, []byte(src))
+	if err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	var values map[string]interface{}
+	if err := doc.Decode(&values); err != nil {
+		t.Fatalf("decoding %s: %v", path, err)
+	}
+	return doc, values
+}
+
+// keyLine returns the line of the key at path, given as keys and indexes
+func keyLine(t *testing.T, doc *yaml.Node, path ...interface{}) int {
+	t.Helper()
+	f := &File{Path: "test", doc: doc}
+	_, line := f.locate(path)
+	return line
+}
+
+func TestTOMLTables(t *testing.T) {
+	src := `title = "Status"
+
+[server]
+port = 8080
+host = "0.0.0.0"
+
+[server.tls]
+enabled = true
+`
+	doc, got := decodeDocument(t, "status.toml", src)
+	want := map[string]interface{}{
+		"title": "Status",
+		"server": map[string]interface{}{
+			"port": 8080,
+			"host": "0.0.0.0",
+			"tls":  map[string]interface{}{"enabled": true},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+
+	root := doc.Content[0]
+	var keys []string
+	for i := 0; i < len(root.Content); i += 2 {
+		keys = append(keys, root.Content[i].Value)
+	}
+	if !reflect.DeepEqual(keys, []string{"title", "server"}) {
+		t.Errorf("keys in order %v, want [title server]", keys)
+	}
+	if line := keyLine(t, doc, "server", "host"); line != 5 {
+		t.Errorf("server.host on line %d, want 5", line)
+	}
+	if line := keyLine(t, doc, "server", "tls", "enabled"); line != 8 {
+		t.Errorf("server.tls.enabled on line %d, want 8", line)
+	}
+}
+
+func TestTOMLArraysOfTables(t *testing.T) {
+	src := `[[services]]
+name = "API"
+url = "https://api.example.com"
+
+[services.headers]
+Accept = "application/json"
+
+[[services]]
+name = "DB"
+type = "tcp"
+port = 5432
+`
+	doc, got := decodeDocument(t, "status.toml", src)
+	want := map[string]interface{}{
+		"services": []interface{}{
+			map[string]interface{}{
+				"name":    "API",
+				"url":     "https://api.example.com",
+				"headers": map[string]interface{}{"Accept": "application/json"},
+			},
+			map[string]interface{}{"name": "DB", "type": "tcp", "port": 5432},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if line := keyLine(t, doc, "services", 1, "port"); line != 11 {
+		t.Errorf("services[1].port on line %d, want 11", line)
+	}
+	if line := keyLine(t, doc, "services", 0, "headers", "Accept"); line != 6 {
+		t.Errorf("services[0].headers.Accept on line %d, want 6", line)
+	}
+}
+
+func TestTOMLInlineTables(t *testing.T) {
+	src := `server = { port = 8080, tls = { enabled = false } }
+checks = [{ name = "a", interval = "30s" }, { name = "b" }]
+`
+	_, got := decodeDocument(t, "status.toml", src)
+	want := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls":  map[string]interface{}{"enabled": false},
+		},
+		"checks": []interface{}{
+			map[string]interface{}{"name": "a", "interval": "30s"},
+			map[string]interface{}{"name": "b"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestTOMLMultilineStrings(t *testing.T) {
+	src := `basic = """
+first line
+second \
+  continued"""
+literal = '''
+C:\path\${NOT_EXPANDED}
+'''
+`
+	_, got := decodeDocument(t, "status.toml", src)
+	if want := "first line\nsecond continued"; got["basic"] != want {
+		t.Errorf("basic = %q, want %q", got["basic"], want)
+	}
+	if want := "C:\\path\\${NOT_EXPANDED}\n"; got["literal"] != want {
+		t.Errorf("literal = %q, want %q", got["literal"], want)
+	}
+}
+
+func TestTOMLDottedKeys(t *testing.T) {
+	src := `server.port = 8080
+server.tls.enabled = true
+"quoted.key" = 1
+
+[notifications]
+email.smtp.host = "smtp.example.com"
+`
+	doc, got := decodeDocument(t, "status.toml", src)
+	want := map[string]interface{}{
+		"server": map[string]interface{}{
+			"port": 8080,
+			"tls":  map[string]interface{}{"enabled": true},
+		},
+		"quoted.key": 1,
+		"notifications": map[string]interface{}{
+			"email": map[string]interface{}{
+				"smtp": map[string]interface{}{"host": "smtp.example.com"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if line := keyLine(t, doc, "notifications", "email", "smtp", "host"); line != 6 {
+		t.Errorf("notifications.email.smtp.host on line %d, want 6", line)
+	}
+}
+
+func TestTOMLValues(t *testing.T) {
+	src := `hex = 0xff
+big = 1_000_000
+ratio = 0.5
+exp = 1e3
+inf = -inf
+when = 1979-05-27T07:32:00Z
+day = 1979-05-27
+local = 1979-05-27T07:32:00
+at = 07:32:00
+port = "${PORT}"
+`
+	_, got := decodeDocument(t, "status.toml", src)
+	want := map[string]interface{}{
+		"hex":   255,
+		"big":   1000000,
+		"ratio": 0.5,
+		"exp":   1000.0,
+		"when":  "1979-05-27T07:32:00Z",
+		"day":   "1979-05-27",
+		"local": "1979-05-27T07:32:00",
+		"at":    "07:32:00",
+		"port":  "${PORT}",
+	}
+	inf := got["inf"]
+	delete(got, "inf")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if f, ok := inf.(float64); !ok || f > -1e308 {
+		t.Errorf("inf = %#v, want negative infinity", inf)
+	}
+}
+
+func TestTOMLEnvRefUnquoted(t *testing.T) {
+	doc, _ := decodeDocument(t, "status.toml", "port = \"${PORT}\"\nname = \"at ${HOST}\"\n")
+	root := doc.Content[0]
+	if style := root.Content[1].Style; style != 0 {
+		t.Errorf("whole reference has style %v, want unquoted", style)
+	}
+	if style := root.Content[3].Style; style != yaml.DoubleQuotedStyle {
+		t.Errorf("embedded reference has style %v, want double quoted", style)
+	}
+}
+
+func TestTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name, src, line string
+	}{
+		{"duplicate key", "a = 1\na = 2\n", "line 2"},
+		{"redefined table", "[a]\nx = 1\n[a]\ny = 2\n", "line 3"},
+		{"unquoted string", "a = 1\nb = hello\n", "line 2"},
+		{"invalid escape", "a = 1\n\nb = \"\\q\"\n", "line 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDocument("status.toml", []byte(tt.src))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), tt.line+":") {
+				t.Errorf("error %q does not start with %q", err, tt.line)
+			}
+		})
+	}
+}
+
+func TestTOMLMatchesYAML(t *testing.T) {
+	toml := `title = "Status"
+[server]
+port = 8080
+[[services]]
+name = "API"
+url = "https://api.example.com"
+interval = "30s"
+expected_status = [200, 204]
+`
+	yml := `title: Status
+server:
+  port: 8080
+services:
+  - name: API
+    url: https://api.example.com
+    interval: 30s
+    expected_status: [200, 204]
+`
+	_, fromTOML := decodeDocument(t, "status.toml", toml)
+	_, fromYAML := decodeDocument(t, "status.yaml", yml)
+	if !reflect.DeepEqual(fromTOML, fromYAML) {
+		t.Errorf("TOML gives %#v, YAML gives %#v", fromTOML, fromYAML)
+	}
+}
+
+func TestJSONDocument(t *testing.T) {
+	src := `{
+  "title": "Status",
+  "server": {"port": 8080},
+  "services": [{"name": "API", "port": "${PORT}"}]
+}
+`
+	doc, got := decodeDocument(t, "status.json", src)
+	want := map[string]interface{}{
+		"title":    "Status",
+		"server":   map[string]interface{}{"port": 8080},
+		"services": []interface{}{map[string]interface{}{"name": "API", "port": "${PORT}"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if line := keyLine(t, doc, "services", 0, "name"); line != 4 {
+		t.Errorf("services[0].name on line %d, want 4", line)
+	}
+	port := lookup(doc.Content[0].Content[5].Content[0], "port")
+	if port == nil || port.Style != 0 {
+		t.Errorf("whole reference %#v is not unquoted", port)
+	}
+
+	_, err := parseDocument("status.json", []byte("{\n  \"title\": \"Status\",\n  \"server\": {\n}}}\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 4:") {
+		t.Errorf("syntax error %v, want one on line 4", err)
+	}
+}
@@ -0,0 +1,390 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolverCheckTimeout bounds the lookup that checks a DNS resolver answers
+const resolverCheckTimeout = 3 * time.Second
+
+// File is a parsed configuration file, with the files it includes
+type File struct {
+	Path     string
+	Included []string // Included files, in the order they were merged
+	Config   *Config
+	doc      *yaml.Node
+	sources  map[string][]string   // Lines of each file
+	origins  map[*yaml.Node]string // File of each node merged from an included file
+}
+
+// Problem is an error found in a configuration file
+type Problem struct {
+	File    string // The file the setting is in
+	Line    int    // 1-based; 0 when the setting is not in the file
+	Field   string // e.g. services[2].url
+	Message string
+}
+
+func (p Problem) Error() string {
+	if p.Field == "" {
+		return p.Message
+	}
+	return p.Field + ": " + p.Message
+}
+
+// Problem returns a problem with the setting at path, given as keys and
+// list indexes, e.g. ("services", 2, "url"). If the setting is not in the
+// file, the line of its closest parent is used.
+func (f *File) Problem(message string, path ...interface{}) Problem {
+	var field strings.Builder
+	for _, key := range path {
+		switch k := key.(type) {
+		case int:
+			fmt.Fprintf(&field, "[%d]", k)
+		default:
+			if field.Len() > 0 {
+				field.WriteByte('.')
+			}
+			fmt.Fprint(&field, k)
+		}
+	}
+	file, line := f.locate(path)
+	return Problem{File: file, Line: line, Field: field.String(), Message: message}
+}
+
+// locate returns the file and line of the setting at path
+func (f *File) locate(path []interface{}) (string, int) {
+	node := f.doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	file, line := f.Path, node.Line
+
+	for _, key := range path {
+		var next *yaml.Node
+		switch k := key.(type) {
+		case int:
+			if node.Kind == yaml.SequenceNode && k < len(node.Content) {
+				next = node.Content[k]
+				file, line = f.origin(next), next.Line
+			}
+		case string:
+			if node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i].Value == k {
+						next = node.Content[i+1]
+						file, line = f.origin(node.Content[i]), node.Content[i].Line
+						break
+					}
+				}
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return file, line
+}
+
+// Source returns the text of a 1-based line of the file or one of its
+// includes, or "" if there is none
+func (f *File) Source(file string, line int) string {
+	source := f.sources[file]
+	if line < 1 || line > len(source) {
+		return ""
+	}
+	return source[line-1]
+}
+
+// Check validates the display timezone, status pages, declared incidents
+// and maintenance windows, and the service definitions: names are present
+// and unique, check types are known and each check has the URL or host it
+// needs. With network set, custom DNS resolvers must also answer a query.
+func (f *File) Check(network bool) []Problem {
+	var problems []Problem
+	seen := make(map[string]int)
+	resolvers := make(map[string]error)
+
+	if _, err := f.Config.Location(); err != nil {
+		problems = append(problems, f.Problem(fmt.Sprintf("unknown timezone %q (want an IANA zone such as Europe/Berlin)", f.Config.Timezone), "timezone"))
+	}
+
+	// The drain delay comes out of the shutdown deadline, so it must leave
+	// time for requests and checks to finish
+	if srv := f.Config.Server; srv.DrainDelay < 0 {
+		problems = append(problems, f.Problem("drain_delay must not be negative", "server", "drain_delay"))
+	} else if srv.DrainDelay > 0 && srv.DrainDelay >= srv.ShutdownTimeout {
+		problems = append(problems, f.Problem(fmt.Sprintf("drain_delay %s leaves no time to finish requests and checks within shutdown_timeout %s", srv.DrainDelay, srv.ShutdownTimeout), "server", "drain_delay"))
+	}
+
+	groups := make(map[string]bool)
+	for i, g := range f.Config.Groups {
+		if g.Name == "" {
+			problems = append(problems, f.Problem("name is required", "groups", i))
+		} else if groups[g.Name] {
+			problems = append(problems, f.Problem(fmt.Sprintf("duplicate group name %q", g.Name), "groups", i, "name"))
+		}
+		groups[g.Name] = true
+	}
+
+	incidents := make(map[string]bool)
+	for i, inc := range f.Config.Incidents {
+		switch {
+		case inc.ID == "":
+			problems = append(problems, f.Problem("id is required; it identifies the incident in storage", "incidents", i))
+		case incidents[inc.ID]:
+			problems = append(problems, f.Problem(fmt.Sprintf("duplicate incident id %q", inc.ID), "incidents", i, "id"))
+		}
+		incidents[inc.ID] = true
+		if inc.Title == "" {
+			problems = append(problems, f.Problem("title is required", "incidents", i))
+		}
+	}
+
+	pages := make(map[string]bool)
+	routes := make(map[string]bool)
+	for i, p := range f.Config.Pages {
+		switch {
+		case p.Name == "":
+			problems = append(problems, f.Problem("name is required", "pages", i))
+		case pages[p.Name]:
+			problems = append(problems, f.Problem(fmt.Sprintf("duplicate page name %q", p.Name), "pages", i, "name"))
+		}
+		pages[p.Name] = true
+
+		switch {
+		case p.Hostname == "" && p.Path == "":
+			problems = append(problems, f.Problem("hostname or path is required to select the page", "pages", i))
+		case p.Path != "" && (!strings.HasPrefix(p.Path, "/") || strings.HasSuffix(p.Path, "/")):
+			problems = append(problems, f.Problem(fmt.Sprintf("path %q must start with / and not end with one", p.Path), "pages", i, "path"))
+		case p.Path != "" && reservedPagePath(p.Path):
+			problems = append(problems, f.Problem(fmt.Sprintf("path %q is used by the server itself", p.Path), "pages", i, "path"))
+		}
+		route := strings.ToLower(p.Hostname) + p.Path
+		if routes[route] {
+			problems = append(problems, f.Problem(fmt.Sprintf("another page is already served at %s", route), "pages", i))
+		}
+		routes[route] = true
+	}
+
+	if ha := f.Config.HA; ha.Enabled {
+		if ha.RenewInterval*2 > ha.LeaseDuration {
+			problems = append(problems, f.Problem(fmt.Sprintf("renew_interval %s must be at most half of lease_duration %s, so one failed renewal does not lose the lease", ha.RenewInterval, ha.LeaseDuration), "ha", "renew_interval"))
+		}
+		if ha.AdvertiseURL != "" {
+			if u, err := url.Parse(ha.AdvertiseURL); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+				problems = append(problems, f.Problem(fmt.Sprintf("advertise_url %q must be an http or https URL", ha.AdvertiseURL), "ha", "advertise_url"))
+			}
+		}
+	}
+
+	windows := make(map[string]bool)
+	for i, m := range f.Config.Maintenance {
+		switch {
+		case m.ID == "":
+			problems = append(problems, f.Problem("id is required; it identifies the window in storage", "maintenance", i))
+		case windows[m.ID]:
+			problems = append(problems, f.Problem(fmt.Sprintf("duplicate maintenance id %q", m.ID), "maintenance", i, "id"))
+		}
+		windows[m.ID] = true
+		if m.Title == "" {
+			problems = append(problems, f.Problem("title is required", "maintenance", i))
+		}
+		switch {
+		case m.Start.IsZero():
+			problems = append(problems, f.Problem("start is required", "maintenance", i))
+		case !m.End.IsZero() && m.Duration > 0:
+			problems = append(problems, f.Problem("set either end or duration, not both", "maintenance", i, "duration"))
+		case m.Length() <= 0:
+			problems = append(problems, f.Problem("end after start or a positive duration is required", "maintenance", i))
+		}
+		if m.Recurrence != "" {
+			if _, err := ParseRecurrence(m.Recurrence); err != nil {
+				problems = append(problems, f.Problem(fmt.Sprintf("invalid recurrence: %v", err), "maintenance", i, "recurrence"))
+			}
+		}
+	}
+
+	for i, svc := range f.Config.Services {
+		if svc.Name == "" {
+			problems = append(problems, f.Problem("name is required", "services", i))
+		} else if first, ok := seen[svc.Name]; ok {
+			problems = append(problems, f.Problem(fmt.Sprintf("duplicate service name %q (also services[%d]); names identify services in history, incidents and feeds", svc.Name, first), "services", i, "name"))
+		} else {
+			seen[svc.Name] = i
+		}
+
+		for _, msg := range checkService(svc) {
+			problems = append(problems, f.Problem(msg.text, "services", i, msg.field))
+		}
+
+		if svc.Type == CheckDNS && svc.DNSResolver != "" && network {
+			err, checked := resolvers[svc.DNSResolver]
+			if !checked {
+				err = checkResolver(svc.DNSResolver)
+				resolvers[svc.DNSResolver] = err
+			}
+			if err != nil {
+				problems = append(problems, f.Problem(fmt.Sprintf("DNS resolver %s is unreachable: %v", svc.DNSResolver, err), "services", i, "dns_resolver"))
+			}
+		}
+	}
+
+	return problems
+}
+
+// CheckService validates a service defined outside the config file, as
+// Check does for listed ones
+func CheckService(svc Service) error {
+	if svc.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	var texts []string
+	for _, problem := range checkService(svc) {
+		texts = append(texts, problem.text)
+	}
+	if len(texts) > 0 {
+		return fmt.Errorf("%s", strings.Join(texts, "; "))
+	}
+	return nil
+}
+
+type serviceProblem struct {
+	field string
+	text  string
+}
+
+// checkService returns problems with a service's check settings
+func checkService(svc Service) []serviceProblem {
+	var problems []serviceProblem
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, serviceProblem{field, fmt.Sprintf(format, args...)})
+	}
+
+	switch svc.Type {
+	case CheckHTTP, CheckWebSocket, CheckQUIC, CheckStatusPage:
+		schemes := map[CheckType][]string{
+			CheckHTTP:       {"http", "https"},
+			CheckWebSocket:  {"ws", "wss"},
+			CheckQUIC:       {"https"},
+			CheckStatusPage: {"http", "https"},
+		}[svc.Type]
+		if svc.URL == "" {
+			add("url", "url is required for %s checks", svc.Type)
+		} else if u, err := url.Parse(svc.URL); err != nil || u.Host == "" || !contains(schemes, u.Scheme) {
+			add("url", "url must be an absolute %s URL", strings.Join(schemes, " or "))
+		}
+
+	case CheckTCP, CheckUDP:
+		if svc.Host == "" {
+			add("host", "host is required for %s checks", svc.Type)
+		} else if _, _, err := net.SplitHostPort(svc.Host); err != nil && svc.Port == 0 {
+			add("port", "port is required for %s checks (or give host as host:port)", svc.Type)
+		}
+
+	case CheckICMP, CheckDNS, CheckTLS, CheckGRPC:
+		if svc.Host == "" && svc.URL == "" {
+			add("host", "host (or url) is required for %s checks", svc.Type)
+		}
+
+	case CheckSMTP, CheckSSH, CheckPOP3, CheckIMAP, CheckFTP, CheckNTP, CheckLDAP,
+		CheckRedis, CheckMongoDB, CheckMySQL, CheckPostgres:
+		if svc.Host == "" {
+			add("host", "host is required for %s checks", svc.Type)
+		}
+
+	default:
+		add("type", "unknown check type %q (want one of %s)", svc.Type, strings.Join(checkTypeNames(), ", "))
+	}
+
+	if svc.Port < 0 || svc.Port > 65535 {
+		add("port", "port %d is out of range", svc.Port)
+	}
+	if svc.Type == CheckDNS && svc.DNSResolver != "" {
+		if _, port, err := net.SplitHostPort(svc.DNSResolver); err != nil {
+			add("dns_resolver", "dns_resolver must be host:port, e.g. 1.1.1.1:53")
+		} else if _, err := strconv.Atoi(port); err != nil {
+			add("dns_resolver", "dns_resolver port %q is not a number", port)
+		}
+	}
+	if svc.Interval <= 0 {
+		add("interval", "interval must be positive")
+	}
+	if svc.Timeout <= 0 {
+		add("timeout", "timeout must be positive")
+	}
+
+	return problems
+}
+
+// checkResolver sends a query for the root zone's name servers to a DNS
+// server and waits for any reply
+func checkResolver(address string) error {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil // Reported as a format problem
+	}
+	conn, err := net.DialTimeout("udp", address, resolverCheckTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(resolverCheckTimeout))
+
+	// Header: ID, recursion desired, one question; then ". IN NS"
+	query := []byte{0x5e, 0x17, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 1}
+	if _, err := conn.Write(query); err != nil {
+		return err
+	}
+	reply := make([]byte, 512)
+	for {
+		n, err := conn.Read(reply)
+		if err != nil {
+			return err
+		}
+		if n >= 12 && reply[0] == query[0] && reply[1] == query[1] {
+			return nil
+		}
+	}
+}
+
+// checkTypeNames lists the supported check types
+func checkTypeNames() []string {
+	types := []CheckType{
+		CheckHTTP, CheckTCP, CheckUDP, CheckICMP, CheckDNS, CheckWebSocket, CheckGRPC, CheckQUIC,
+		CheckSMTP, CheckSSH, CheckTLS, CheckPOP3, CheckIMAP, CheckFTP, CheckNTP, CheckLDAP,
+		CheckRedis, CheckMongoDB, CheckMySQL, CheckPostgres, CheckStatusPage,
+	}
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return names
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedPagePath reports whether a page path would hide one of the
+// server's own routes
+func reservedPagePath(path string) bool {
+	first := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	switch first {
+	case "api", "feed", "static", "ws", "websub", "sw.js", "metrics", "embed", "history", "incidents", "favicon.ico", "favicon.svg":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/status/config"
+	"github.com/status/discovery"
+	"github.com/status/monitor"
+	"github.com/status/notify"
+	"github.com/status/web"
+)
+
+// startDiscovery starts polling the enabled discovery providers. It returns
+// nil when none are enabled.
+func startDiscovery(cfg *config.Config) *discovery.Discoverer {
+	var providers []discovery.Provider
+	if cfg.Discovery.Kubernetes.Enabled {
+		k, err := discovery.NewKubernetes(cfg.Discovery.Kubernetes)
+		if err != nil {
+			fatal("Invalid Kubernetes discovery", "error", err)
+		}
+		providers = append(providers, k)
+	}
+	if cfg.Discovery.Docker.Enabled {
+		d, err := discovery.NewDocker(cfg.Discovery.Docker)
+		if err != nil {
+			fatal("Invalid Docker discovery", "error", err)
+		}
+		providers = append(providers, d)
+	}
+	if cfg.Discovery.Consul.Enabled {
+		providers = append(providers, discovery.NewConsul(cfg.Discovery.Consul))
+	}
+	if cfg.Discovery.Nomad.Enabled {
+		providers = append(providers, discovery.NewNomad(cfg.Discovery.Nomad))
+	}
+	if len(providers) == 0 {
+		return nil
+	}
+
+	d := discovery.New(cfg.Discovery.Interval, providers...)
+	d.Start()
+	for _, p := range providers {
+		slog.Info("Discovering services", "provider", p.Name(), "interval", cfg.Discovery.Interval)
+	}
+	return d
+}
+
+// withDiscovered returns cfg with the discovered services added after the
+// listed ones, completed from defaults and templates. A discovered service
+// that is invalid or named like an earlier one is left out.
+func withDiscovered(cfg *config.Config, discovered []config.Service) *config.Config {
+	if len(discovered) == 0 {
+		return cfg
+	}
+	merged := *cfg
+	merged.Services = append([]config.Service(nil), cfg.Services...)
+
+	names := make(map[string]bool)
+	for _, svc := range cfg.Services {
+		names[svc.Name] = true
+	}
+	for _, svc := range discovered {
+		svc, err := cfg.CompleteService(svc)
+		if err == nil {
+			err = config.CheckService(svc)
+		}
+		if err != nil {
+			slog.Warn("Discovery: skipping service", "service", svc.Name, "error", err)
+			continue
+		}
+		if names[svc.Name] {
+			continue // Listed in the config file, or found twice
+		}
+		names[svc.Name] = true
+		merged.Services = append(merged.Services, svc)
+	}
+	return &merged
+}
+
+// applyDiscovered updates the monitored services after discovery finds a
+// change
+func applyDiscovered(cfg *config.Config, discovered []config.Service, mon *monitor.Monitor, notifier *notify.Notifier, server *web.Server) {
+	effective := withDiscovered(cfg, discovered)
+	if err := notifier.SetRoutes(notificationRoutes(effective), serviceGroups(effective)); err != nil {
+		slog.Error("Discovery: invalid notification route", "error", err)
+	}
+	added, removed, changed := mon.SetServices(effective.Services)
+	server.Reload(effective)
+	if len(added)+len(removed)+len(changed) > 0 {
+		slog.Info("Discovered services changed", "services", len(effective.Services)-len(cfg.Services), "added", len(added), "removed", len(removed), "changed", len(changed))
+	}
+}
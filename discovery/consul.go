@@ -0,0 +1,218 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+)
+
+// CatalogTag prefixes the tags that configure the check of a Consul or
+// Nomad service: status.monitor.path=/health, status.monitor.group=Core, ...
+const CatalogTag = "status.monitor."
+
+// Consul imports the healthy instances of services in the Consul catalog
+type Consul struct {
+	address    string
+	token      string
+	datacenter string
+	tag        string
+	client     *http.Client
+	warn       warnings
+}
+
+// NewConsul creates a Consul provider for the configured agent,
+// $CONSUL_HTTP_ADDR or the local one
+func NewConsul(cfg config.CatalogDiscovery) *Consul {
+	return &Consul{
+		address:    apiAddress(cfg.Address, os.Getenv("CONSUL_HTTP_ADDR"), "http://127.0.0.1:8500"),
+		token:      firstNonEmpty(cfg.Token, os.Getenv("CONSUL_HTTP_TOKEN")),
+		datacenter: cfg.Datacenter,
+		tag:        cfg.Tag,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies the provider in logs
+func (c *Consul) Name() string { return "consul" }
+
+// Discover lists the instances passing their health checks of each service
+// with the configured tag
+func (c *Consul) Discover() ([]config.Service, error) {
+	var catalog map[string][]string // Service name to tags
+	if err := c.get("/v1/catalog/services", nil, &catalog); err != nil {
+		return nil, err
+	}
+
+	var instances []instance
+	for name, tags := range catalog {
+		if c.tag != "" && !contains(tags, c.tag) {
+			continue
+		}
+		query := url.Values{"passing": {"true"}}
+		if c.tag != "" {
+			query.Set("tag", c.tag)
+		}
+		var entries []struct {
+			Node struct {
+				Node    string `json:"Node"`
+				Address string `json:"Address"`
+			} `json:"Node"`
+			Service struct {
+				Address string   `json:"Address"`
+				Port    int      `json:"Port"`
+				Tags    []string `json:"Tags"`
+			} `json:"Service"`
+		}
+		if err := c.get("/v1/health/service/"+url.PathEscape(name), query, &entries); err != nil {
+			return nil, fmt.Errorf("service %s: %w", name, err)
+		}
+		for _, e := range entries {
+			// Services registered without an address use their node's
+			address := firstNonEmpty(e.Service.Address, e.Node.Address)
+			instances = append(instances, instance{service: name, node: e.Node.Node, address: address, port: e.Service.Port, tags: e.Service.Tags})
+		}
+	}
+	return instanceServices(instances, &c.warn), nil
+}
+
+// get reads a JSON response from the Consul HTTP API
+func (c *Consul) get(path string, query url.Values, result interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	if c.datacenter != "" {
+		query.Set("dc", c.datacenter)
+	}
+	req, err := http.NewRequest("GET", c.address+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	return getJSON(c.client, req, result)
+}
+
+// instance is one registration of a service in a catalog
+type instance struct {
+	service string
+	node    string // Node name, or empty where the catalog has none
+	address string
+	port    int
+	tags    []string
+}
+
+// instanceServices builds the checks for catalog instances. Each is named
+// after its service and node (or address), and grouped by service, unless
+// its status.monitor. tags say otherwise.
+func instanceServices(instances []instance, warn *warnings) []config.Service {
+	sort.Slice(instances, func(i, j int) bool {
+		a, b := instances[i], instances[j]
+		if a.service != b.service {
+			return a.service < b.service
+		}
+		if a.node != b.node {
+			return a.node < b.node
+		}
+		return a.port < b.port
+	})
+	// Instances sharing a node are told apart by port
+	perNode := make(map[string]int)
+	for _, inst := range instances {
+		perNode[inst.service+"\x00"+inst.node]++
+	}
+
+	var services []config.Service
+	for _, inst := range instances {
+		s := make(settings)
+		for _, tag := range inst.tags {
+			if key, value, ok := strings.Cut(strings.TrimPrefix(tag, CatalogTag), "="); ok && strings.HasPrefix(tag, CatalogTag) {
+				s[key] = value
+			}
+		}
+
+		where := firstNonEmpty(inst.node, inst.address)
+		if inst.node == "" || perNode[inst.service+"\x00"+inst.node] > 1 {
+			where += ":" + strconv.Itoa(inst.port)
+		}
+		svc, err := s.service(inst.service + " (" + where + ")")
+		if err != nil {
+			warn.printf("%s on %s: %v", inst.service, where, err)
+			continue
+		}
+		if svc.Group == "" {
+			svc.Group = inst.service
+		}
+		port := inst.port
+		if p := s["port"]; p != "" {
+			if port, err = strconv.Atoi(p); err != nil {
+				warn.printf("%s on %s: port %q is not a number", inst.service, where, p)
+				continue
+			}
+		}
+		scheme := "http"
+		if port == 443 {
+			scheme = "https"
+		}
+		setAddress(&svc, scheme, inst.address, port, s["path"])
+		services = append(services, svc)
+	}
+	return services
+}
+
+// getJSON sends a request and decodes a 200 response
+func getJSON(client *http.Client, req *http.Request, result interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if msg := strings.TrimSpace(string(body)); msg != "" && len(msg) < 200 {
+			return fmt.Errorf("%s: %s", resp.Status, msg)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return json.Unmarshal(body, result)
+}
+
+// apiAddress returns the configured API address, or the one from the
+// environment, or the fallback, with a scheme and no trailing slash
+func apiAddress(configured, env, fallback string) string {
+	address := firstNonEmpty(configured, env, fallback)
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return strings.TrimRight(address, "/")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+// Package discovery finds services to monitor in other systems, such as
+// Kubernetes, so the status page follows the infrastructure without editing
+// the config file.
+package discovery
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/status/config"
+)
+
+// Provider lists the services to monitor that one system currently has
+type Provider interface {
+	Name() string
+	Discover() ([]config.Service, error)
+}
+
+// Discoverer polls providers and keeps the services they found. When a
+// provider fails, the services it found last are kept until it recovers.
+type Discoverer struct {
+	providers []Provider
+	interval  time.Duration
+	changed   chan struct{}
+
+	mu     sync.RWMutex
+	found  map[string][]config.Service // By provider
+	failed map[string]string           // Last error by provider, logged once
+}
+
+// New creates a discoverer polling each provider every interval
+func New(interval time.Duration, providers ...Provider) *Discoverer {
+	return &Discoverer{
+		providers: providers,
+		interval:  interval,
+		changed:   make(chan struct{}, 1),
+		found:     make(map[string][]config.Service),
+		failed:    make(map[string]string),
+	}
+}
+
+// Start polls the providers now and then every interval
+func (d *Discoverer) Start() {
+	go func() {
+		d.poll()
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			d.poll()
+		}
+	}()
+}
+
+// Changed receives a value whenever the discovered services change. A nil
+// Discoverer's channel never does.
+func (d *Discoverer) Changed() <-chan struct{} {
+	if d == nil {
+		return nil
+	}
+	return d.changed
+}
+
+// Services returns the discovered services, by provider and then name
+func (d *Discoverer) Services() []config.Service {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var services []config.Service
+	for _, p := range d.providers {
+		services = append(services, d.found[p.Name()]...)
+	}
+	return services
+}
+
+// poll asks every provider for its services
+func (d *Discoverer) poll() {
+	changed := false
+	for _, p := range d.providers {
+		services, err := p.Discover()
+
+		d.mu.Lock()
+		if err != nil {
+			if d.failed[p.Name()] != err.Error() {
+				slog.Warn("Discovery failed, keeping the services found before", "provider", p.Name(), "services", len(d.found[p.Name()]), "error", err)
+				d.failed[p.Name()] = err.Error()
+			}
+			d.mu.Unlock()
+			continue
+		}
+		if _, ok := d.failed[p.Name()]; ok {
+			slog.Info("Discovery recovered", "provider", p.Name())
+			delete(d.failed, p.Name())
+		}
+		sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+		if !reflect.DeepEqual(services, d.found[p.Name()]) {
+			d.found[p.Name()] = services
+			changed = true
+		}
+		d.mu.Unlock()
+	}
+
+	if changed {
+		select {
+		case d.changed <- struct{}{}:
+		default: // A change is already pending
+		}
+	}
+}
+
+// warnings logs each distinct problem once, so a misconfigured object is
+// reported when found rather than on every poll
+type warnings struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+func (w *warnings) printf(format string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.seen == nil {
+		w.seen = make(map[string]bool)
+	}
+	msg := "Discovery: " + fmt.Sprintf(format, args...)
+	if !w.seen[msg] {
+		w.seen[msg] = true
+		slog.Warn(msg)
+	}
+}
+
+// settings are the check settings given on a discovered object, as
+// annotations, labels or metadata, with the provider's prefix removed:
+//
+//	name, group, description, extends, type, url, host, port, path,
+//	interval, timeout, expected-status
+type settings map[string]string
+
+// withPrefix returns the entries of m whose keys start with prefix
+func withPrefix(m map[string]string, prefix string) settings {
+	s := make(settings)
+	for k, v := range m {
+		if len(k) > len(prefix) && k[:len(prefix)] == prefix {
+			s[k[len(prefix):]] = v
+		}
+	}
+	return s
+}
+
+// enabled reports whether the object opted in to monitoring
+func (s settings) enabled() bool {
+	on, _ := strconv.ParseBool(s["enabled"])
+	return on
+}
+
+// service returns the service the settings describe. Host, port and URL are
+// left to the provider, which knows how to reach the object.
+func (s settings) service(name string) (config.Service, error) {
+	svc := config.Service{
+		Name:        name,
+		Group:       s["group"],
+		Description: s["description"],
+		Extends:     s["extends"],
+		Type:        config.CheckType(s["type"]),
+		URL:         s["url"],
+	}
+	if n := s["name"]; n != "" {
+		svc.Name = n
+	}
+	for key, d := range map[string]*time.Duration{"interval": &svc.Interval, "timeout": &svc.Timeout} {
+		if s[key] == "" {
+			continue
+		}
+		v, err := time.ParseDuration(s[key])
+		if err != nil {
+			return svc, fmt.Errorf("%s: %w", key, err)
+		}
+		*d = v
+	}
+	if v := s["expected-status"]; v != "" {
+		code, err := strconv.Atoi(v)
+		if err != nil {
+			return svc, fmt.Errorf("expected-status %q is not a number", v)
+		}
+		svc.ExpectedStatus = code
+	}
+	return svc, nil
+}
+
+// setAddress points a service without a URL at the address a provider
+// found: a URL for HTTP-like checks, otherwise the host and port
+func setAddress(svc *config.Service, scheme, host string, port int, path string) {
+	switch svc.Type {
+	case "", config.CheckHTTP, config.CheckWebSocket, config.CheckQUIC:
+		if svc.URL != "" {
+			return
+		}
+		if svc.Type == config.CheckWebSocket {
+			scheme = strings.Replace(scheme, "http", "ws", 1)
+		}
+		address := host
+		if port != 0 && !(scheme == "http" && port == 80) && !(scheme == "https" && port == 443) {
+			address = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		svc.URL = scheme + "://" + address + "/" + strings.TrimPrefix(path, "/")
+	default:
+		svc.Host, svc.Port = host, port
+	}
+}
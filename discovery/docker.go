@@ -0,0 +1,205 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+)
+
+// DockerLabel prefixes the labels that configure a check:
+// status.monitor.enabled, status.monitor.port, status.monitor.group, ...
+const DockerLabel = "status.monitor."
+
+// defaultDockerHost is the Docker Engine's local socket
+const defaultDockerHost = "unix:///var/run/docker.sock"
+
+// Docker finds labelled containers through the Docker Engine API. Only
+// running containers are listed, so stopped ones drop off the page.
+type Docker struct {
+	base    string // URL the API paths are added to
+	network string
+	client  *http.Client
+	warn    warnings
+}
+
+// NewDocker creates a Docker provider for the configured host, $DOCKER_HOST
+// or the local socket
+func NewDocker(cfg config.DockerDiscovery) (*Docker, error) {
+	host := cfg.Host
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = defaultDockerHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Docker{network: cfg.Network}
+	transport := &http.Transport{}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		d.base = "http://docker"
+	case "tcp", "http":
+		d.base = "http://" + u.Host
+	case "https":
+		d.base = "https://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported Docker host %q (want unix://, tcp:// or https://)", host)
+	}
+	d.client = &http.Client{Timeout: 30 * time.Second, Transport: transport}
+	return d, nil
+}
+
+// Name identifies the provider in logs
+func (d *Docker) Name() string { return "docker" }
+
+// dockerContainer holds the fields of a container listing discovery reads
+type dockerContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Labels map[string]string `json:"Labels"`
+	Ports  []struct {
+		PrivatePort int    `json:"PrivatePort"`
+		Type        string `json:"Type"`
+	} `json:"Ports"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string `json:"IPAddress"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// name returns the container's name, without Docker's leading slash
+func (c dockerContainer) name() string {
+	if len(c.Names) == 0 {
+		return c.ID
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// Discover lists the running containers labelled for monitoring
+func (d *Docker) Discover() ([]config.Service, error) {
+	filters, _ := json.Marshal(map[string][]string{"label": {DockerLabel + "enabled"}})
+	req, err := http.NewRequest("GET", d.base+"/containers/json?filters="+url.QueryEscape(string(filters)), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("%s: %s", resp.Status, apiErr.Message)
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	var containers []dockerContainer
+	if err := json.Unmarshal(body, &containers); err != nil {
+		return nil, err
+	}
+	var services []config.Service
+	for _, c := range containers {
+		s := withPrefix(c.Labels, DockerLabel)
+		if !s.enabled() {
+			continue
+		}
+		svc, err := d.service(c, s)
+		if err != nil {
+			d.warn.printf("container %s: %v", c.name(), err)
+			continue
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
+
+// service builds the check for a labelled container. Unless labelled with
+// a URL, it is checked at its address on the configured (or first) network
+// and its lowest exposed TCP port, or the labelled one.
+func (d *Docker) service(c dockerContainer, s settings) (config.Service, error) {
+	svc, err := s.service(c.name())
+	if err != nil {
+		return svc, err
+	}
+
+	var ports []int
+	for _, p := range c.Ports {
+		if p.Type == "tcp" && !containsInt(ports, p.PrivatePort) {
+			ports = append(ports, p.PrivatePort)
+		}
+	}
+	sort.Ints(ports)
+	var port int
+	if want := s["port"]; want != "" {
+		if port, err = strconv.Atoi(want); err != nil {
+			return svc, fmt.Errorf("port %q is not a number", want)
+		}
+	} else if len(ports) > 0 {
+		port = ports[0]
+	}
+
+	// Containers on the host network have no address of their own
+	host := "localhost"
+	networks := make([]string, 0, len(c.NetworkSettings.Networks))
+	for network := range c.NetworkSettings.Networks {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	if d.network != "" {
+		networks = []string{d.network}
+	}
+	for _, network := range networks {
+		if ip := c.NetworkSettings.Networks[network].IPAddress; ip != "" {
+			host = ip
+			break
+		}
+	}
+
+	if svc.URL == "" && port == 0 && svc.Type != config.CheckICMP {
+		return svc, fmt.Errorf("no exposed TCP port; label %sport or %surl", DockerLabel, DockerLabel)
+	}
+	scheme := "http"
+	if port == 443 {
+		scheme = "https"
+	}
+	setAddress(&svc, scheme, host, port, s["path"])
+	return svc, nil
+}
+
+func containsInt(list []int, n int) bool {
+	for _, item := range list {
+		if item == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+package discovery
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+)
+
+// KubernetesAnnotation prefixes the annotations that configure a check:
+// status.monitor/enabled, status.monitor/url, status.monitor/group, ...
+const KubernetesAnnotation = "status.monitor/"
+
+// The pod's service account, used when no API server is configured
+const (
+	serviceAccountToken = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCA    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// Kubernetes finds annotated Services and Ingresses through the API server
+type Kubernetes struct {
+	server     string
+	token      string // Read from serviceAccountToken on each poll when empty, as it rotates
+	namespaces []string
+	client     *http.Client
+	warn       warnings
+}
+
+// NewKubernetes creates a Kubernetes provider, in-cluster unless an API
+// server is configured
+func NewKubernetes(cfg config.KubernetesDiscovery) (*Kubernetes, error) {
+	k := &Kubernetes{
+		server:     strings.TrimRight(cfg.APIServer, "/"),
+		token:      cfg.Token,
+		namespaces: cfg.Namespaces,
+	}
+	caFile := cfg.CAFile
+	if k.server == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" {
+			return nil, fmt.Errorf("not running in a cluster; set discovery.kubernetes.api_server")
+		}
+		k.server = "https://" + net.JoinHostPort(host, port)
+		if caFile == "" {
+			caFile = serviceAccountCA
+		}
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.SkipTLSVerify}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found", caFile)
+		}
+	}
+	k.client = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+	return k, nil
+}
+
+// Name identifies the provider in logs
+func (k *Kubernetes) Name() string { return "kubernetes" }
+
+// k8sObject holds the fields of Services and Ingresses discovery reads
+type k8sObject struct {
+	Metadata struct {
+		Name        string            `json:"name"`
+		Namespace   string            `json:"namespace"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"metadata"`
+	Spec struct {
+		// Service
+		Ports []struct {
+			Name        string `json:"name"`
+			Port        int    `json:"port"`
+			AppProtocol string `json:"appProtocol"`
+		} `json:"ports"`
+		// Ingress
+		Rules []struct {
+			Host string `json:"host"`
+			HTTP *struct {
+				Paths []struct {
+					Path string `json:"path"`
+				} `json:"paths"`
+			} `json:"http"`
+		} `json:"rules"`
+		TLS []struct {
+			Hosts []string `json:"hosts"`
+		} `json:"tls"`
+	} `json:"spec"`
+}
+
+// Discover lists the annotated Services and Ingresses
+func (k *Kubernetes) Discover() ([]config.Service, error) {
+	namespaces := k.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var services []config.Service
+	for _, ns := range namespaces {
+		scope := ""
+		if ns != "" {
+			scope = "/namespaces/" + ns
+		}
+		for _, kind := range []struct{ name, path string }{
+			{"Service", "/api/v1" + scope + "/services"},
+			{"Ingress", "/apis/networking.k8s.io/v1" + scope + "/ingresses"},
+		} {
+			objects, err := k.list(kind.path)
+			if err != nil {
+				return nil, fmt.Errorf("list %ss: %w", strings.ToLower(kind.name), err)
+			}
+			for _, obj := range objects {
+				s := withPrefix(obj.Metadata.Annotations, KubernetesAnnotation)
+				if !s.enabled() {
+					continue
+				}
+				svc, err := k.service(kind.name, obj, s)
+				if err != nil {
+					k.warn.printf("%s %s/%s: %v", kind.name, obj.Metadata.Namespace, obj.Metadata.Name, err)
+					continue
+				}
+				services = append(services, svc)
+			}
+		}
+	}
+	return services, nil
+}
+
+// service builds the check for an annotated object. Unless annotated with
+// a URL, a Service is checked at its cluster DNS name and first (or
+// annotated) port, and an Ingress at its first host.
+func (k *Kubernetes) service(kind string, obj k8sObject, s settings) (config.Service, error) {
+	svc, err := s.service(obj.Metadata.Name)
+	if err != nil {
+		return svc, err
+	}
+
+	var host, scheme, path string
+	var port int
+	switch kind {
+	case "Service":
+		if len(obj.Spec.Ports) == 0 {
+			return svc, fmt.Errorf("no ports")
+		}
+		p := obj.Spec.Ports[0]
+		if want := s["port"]; want != "" {
+			found := false
+			for _, candidate := range obj.Spec.Ports {
+				if candidate.Name == want || strconv.Itoa(candidate.Port) == want {
+					p, found = candidate, true
+					break
+				}
+			}
+			if !found {
+				return svc, fmt.Errorf("no port %q", want)
+			}
+		}
+		host = obj.Metadata.Name + "." + obj.Metadata.Namespace + ".svc"
+		port = p.Port
+		scheme = "http"
+		if p.Port == 443 || p.Name == "https" || p.AppProtocol == "https" {
+			scheme = "https"
+		}
+
+	case "Ingress":
+		for _, rule := range obj.Spec.Rules {
+			if rule.Host == "" {
+				continue
+			}
+			host = rule.Host
+			if rule.HTTP != nil && len(rule.HTTP.Paths) > 0 {
+				path = rule.HTTP.Paths[0].Path
+			}
+			break
+		}
+		if host == "" {
+			return svc, fmt.Errorf("no rule with a host")
+		}
+		scheme = "http"
+		for _, t := range obj.Spec.TLS {
+			for _, h := range t.Hosts {
+				if h == host {
+					scheme = "https"
+				}
+			}
+		}
+	}
+	if p := s["path"]; p != "" {
+		path = p
+	}
+	setAddress(&svc, scheme, host, port, path)
+	return svc, nil
+}
+
+// list returns the items of a list request
+func (k *Kubernetes) list(path string) ([]k8sObject, error) {
+	req, err := http.NewRequest("GET", k.server+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	token := k.token
+	if token == "" {
+		if data, err := os.ReadFile(serviceAccountToken); err == nil {
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &status) == nil && status.Message != "" {
+			return nil, fmt.Errorf("%s: %s", resp.Status, status.Message)
+		}
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	var list struct {
+		Items []k8sObject `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
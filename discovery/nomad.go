@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/status/config"
+)
+
+// Nomad imports the instances of services registered with Nomad's built-in
+// service discovery. Services Nomad registers in Consul are found by the
+// Consul provider instead.
+type Nomad struct {
+	address   string
+	token     string
+	namespace string
+	tag       string
+	client    *http.Client
+	warn      warnings
+}
+
+// NewNomad creates a Nomad provider for the configured agent, $NOMAD_ADDR
+// or the local one
+func NewNomad(cfg config.CatalogDiscovery) *Nomad {
+	return &Nomad{
+		address:   apiAddress(cfg.Address, os.Getenv("NOMAD_ADDR"), "http://127.0.0.1:4646"),
+		token:     firstNonEmpty(cfg.Token, os.Getenv("NOMAD_TOKEN")),
+		namespace: firstNonEmpty(cfg.Namespace, "default"),
+		tag:       cfg.Tag,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies the provider in logs
+func (n *Nomad) Name() string { return "nomad" }
+
+// Discover lists the registered instances of each service with the
+// configured tag. Nomad removes an instance when its allocation stops.
+func (n *Nomad) Discover() ([]config.Service, error) {
+	var namespaces []struct {
+		Namespace string `json:"Namespace"`
+		Services  []struct {
+			ServiceName string   `json:"ServiceName"`
+			Tags        []string `json:"Tags"`
+		} `json:"Services"`
+	}
+	if err := n.get("/v1/services", n.namespace, &namespaces); err != nil {
+		return nil, err
+	}
+
+	var instances []instance
+	for _, ns := range namespaces {
+		for _, svc := range ns.Services {
+			if n.tag != "" && !contains(svc.Tags, n.tag) {
+				continue
+			}
+			var registrations []struct {
+				Address string   `json:"Address"`
+				Port    int      `json:"Port"`
+				Tags    []string `json:"Tags"`
+			}
+			if err := n.get("/v1/service/"+url.PathEscape(svc.ServiceName), ns.Namespace, &registrations); err != nil {
+				return nil, fmt.Errorf("service %s: %w", svc.ServiceName, err)
+			}
+			for _, r := range registrations {
+				if n.tag != "" && !contains(r.Tags, n.tag) {
+					continue
+				}
+				instances = append(instances, instance{service: svc.ServiceName, address: r.Address, port: r.Port, tags: r.Tags})
+			}
+		}
+	}
+	return instanceServices(instances, &n.warn), nil
+}
+
+// get reads a JSON response from the Nomad HTTP API
+func (n *Nomad) get(path, namespace string, result interface{}) error {
+	req, err := http.NewRequest("GET", n.address+path+"?namespace="+url.QueryEscape(namespace), nil)
+	if err != nil {
+		return err
+	}
+	if n.token != "" {
+		req.Header.Set("X-Nomad-Token", n.token)
+	}
+	return getJSON(n.client, req, result)
+}
@@ -0,0 +1,97 @@
+package feeds
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// cacheMaxItems bounds the rendered feeds a generator keeps in memory
+const cacheMaxItems = 1000
+
+// Feed is a rendered feed with the validators for conditional requests
+type Feed struct {
+	Body         []byte
+	ETag         string
+	LastModified time.Time
+	version      string
+	key          string
+}
+
+// feedCache holds rendered feeds by variant. It is shared by the copies
+// Scoped, WithHub and Paged return, so it spans every scope, filter and
+// page of a generator. When full, the least recently served feed is
+// dropped, so one-off since= and page variants cannot push out the feeds
+// readers poll.
+type feedCache struct {
+	mu    sync.Mutex
+	feeds map[string]*list.Element // Of *Feed, in lru
+	lru   *list.List               // Most recently served first
+}
+
+func newFeedCache() *feedCache {
+	return &feedCache{feeds: make(map[string]*list.Element), lru: list.New()}
+}
+
+// Cached returns the feed in format ("rss", "atom", "json" or
+// "incident/<id>") for the generator's scope, filter and page, rendering it
+// with generate unless the one cached was rendered for the same version.
+// version identifies the content (latest updates, status summary);
+// lastModified is reported for conditional requests.
+func (fg *FeedGenerator) Cached(format, version string, lastModified time.Time, generate func() ([]byte, error)) (*Feed, error) {
+	key := fmt.Sprintf("%s|%s|%s|%s|%d", format, fg.scope, fg.scopePath, fg.hub, fg.page)
+
+	cache := fg.cache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	elem, cached := cache.feeds[key]
+	if cached {
+		cache.lru.MoveToFront(elem)
+		if feed := elem.Value.(*Feed); feed.version == version {
+			return feed, nil
+		}
+	}
+
+	body, err := generate()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(body)
+	feed := &Feed{
+		Body:         body,
+		ETag:         `"` + hex.EncodeToString(sum[:8]) + `"`,
+		LastModified: lastModified,
+		version:      version,
+		key:          key,
+	}
+	if cached {
+		elem.Value = feed
+		return feed, nil
+	}
+	if cache.lru.Len() >= cacheMaxItems {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.feeds, oldest.Value.(*Feed).key)
+	}
+	cache.feeds[key] = cache.lru.PushFront(feed)
+	return feed, nil
+}
+
+// ResetCache drops the rendered feeds, for when the data they were rendered
+// from changed without a new version, such as a follower's new snapshot
+func (fg *FeedGenerator) ResetCache() {
+	fg.cache.mu.Lock()
+	fg.cache.feeds = make(map[string]*list.Element)
+	fg.cache.lru.Init()
+	fg.cache.mu.Unlock()
+}
+
+// CachedFeeds returns the number of rendered feeds kept in memory
+func (fg *FeedGenerator) CachedFeeds() int {
+	fg.cache.mu.Lock()
+	defer fg.cache.mu.Unlock()
+	return len(fg.cache.feeds)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/status/markdown"
 	"github.com/status/storage"
 )
 
@@ -36,7 +37,7 @@ type RSSChannel struct {
 	Docs           string      `xml:"docs"`
 	TTL            int         `xml:"ttl"`
 	Image          *RSSImage   `xml:"image,omitempty"`
-	AtomLink       *RSSAtomLink `xml:"atom:link,omitempty"`
+	AtomLinks      []RSSAtomLink `xml:"atom:link"`
 	Items          []RSSItem   `xml:"item"`
 }
 
@@ -53,13 +54,20 @@ type RSSItem struct {
 	Author         string `xml:"author,omitempty"`
 	Category       string `xml:"category,omitempty"`
 	Comments       string `xml:"comments,omitempty"`
-	Enclosure      string `xml:"enclosure,omitempty"`
+	Enclosure      *RSSEnclosure `xml:"enclosure,omitempty"`
 	GUID           RSSGUID `xml:"guid"`
 	PubDate        string `xml:"pubDate"`
 	Source         string `xml:"source,omitempty"`
 	ContentEncoded string `xml:"content:encoded,omitempty"`
 }
 
+// RSSEnclosure attaches a file to an item. RSS allows one per item.
+type RSSEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
 type RSSGUID struct {
 	Value       string `xml:",chardata"`
 	IsPermaLink bool   `xml:"isPermaLink,attr"`
@@ -69,6 +77,7 @@ type RSSGUID struct {
 type AtomFeed struct {
 	XMLName   xml.Name    `xml:"feed"`
 	Xmlns     string      `xml:"xmlns,attr"`
+	Lang      string      `xml:"xml:lang,attr,omitempty"`
 	Title     string      `xml:"title"`
 	Subtitle  string      `xml:"subtitle,omitempty"`
 	Link      []AtomLink  `xml:"link"`
@@ -91,10 +100,11 @@ type RSSAtomLink struct {
 
 // AtomLink for Atom feeds
 type AtomLink struct {
-	Href  string `xml:"href,attr"`
-	Rel   string `xml:"rel,attr,omitempty"`
-	Type  string `xml:"type,attr,omitempty"`
-	Title string `xml:"title,attr,omitempty"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Title  string `xml:"title,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
 }
 
 type AtomAuthor struct {
@@ -196,6 +206,18 @@ type StatusSummary struct {
 	Total       int
 }
 
+// IncidentFilter selects the incidents included in a feed
+type IncidentFilter struct {
+	Severities []string  // Only these severities; empty means all
+	ActiveOnly bool      // Leave out resolved incidents
+	Since      time.Time // Leave out incidents last updated before this; zero means all
+}
+
+// IsZero reports whether the filter lets every incident through
+func (f IncidentFilter) IsZero() bool {
+	return len(f.Severities) == 0 && !f.ActiveOnly && f.Since.IsZero()
+}
+
 // FeedGenerator generates various feed formats
 type FeedGenerator struct {
 	title       string
@@ -204,6 +226,17 @@ type FeedGenerator struct {
 	copyright   string
 	author      string
 	email       string
+	scope       string // Service or group the feed is limited to
+	scopePath   string // Appended to /feed/<format> in the feed's own links
+	hub         string // WebSub hub declared in the feed
+	page        int    // Page of a paged feed (RFC 5005); 0 or 1 is the first
+	lastPage    int
+	maintenance []storage.Maintenance // Maintenance windows listed in the feed
+	language    string                // Language tag; empty is English
+	translations Catalog              // Overrides of the built-in catalog
+	descriptionSet bool               // Description set explicitly, not translated
+	location    *time.Location        // Zone of displayed times; nil is local
+	cache       *feedCache            // Rendered feeds, shared with copies
 }
 
 // NewFeedGenerator creates a new feed generator
@@ -211,16 +244,125 @@ func NewFeedGenerator(title, baseURL string) *FeedGenerator {
 	return &FeedGenerator{
 		title:       title,
 		baseURL:     baseURL,
-		description: "System status updates, incidents, and maintenance notifications",
+		description: catalogs["en"]["feed.description"],
 		copyright:   fmt.Sprintf("© %d %s. All rights reserved.", time.Now().Year(), title),
 		author:      "Status Monitor",
 		email:       "status@example.com",
+		cache:       newFeedCache(),
+	}
+}
+
+// Scoped returns a copy of the generator for a feed limited to one service
+// or group, or filtered (name empty). path is appended to /feed/<format> in
+// the feed's own links, e.g. "/api" or "?group=Backend&active=true".
+func (fg *FeedGenerator) Scoped(name, path string) *FeedGenerator {
+	scoped := *fg
+	scoped.scope = name
+	scoped.scopePath = path
+	return &scoped
+}
+
+// WithHub returns a copy of the generator declaring a WebSub hub, or none
+// when hub is empty
+func (fg *FeedGenerator) WithHub(hub string) *FeedGenerator {
+	withHub := *fg
+	withHub.hub = hub
+	return &withHub
+}
+
+// Paged returns a copy of the generator for page of a feed split into
+// lastPage pages, newest first. Pages link to each other (first, previous,
+// next, last) so readers can walk the whole incident history.
+func (fg *FeedGenerator) Paged(page, lastPage int) *FeedGenerator {
+	paged := *fg
+	paged.page = page
+	paged.lastPage = lastPage
+	return &paged
+}
+
+// FilterIncidents returns the incidents matching filter, in order
+func (fg *FeedGenerator) FilterIncidents(incidents []storage.Incident, filter IncidentFilter) []storage.Incident {
+	if filter.IsZero() {
+		return incidents
+	}
+
+	filtered := make([]storage.Incident, 0, len(incidents))
+	for _, inc := range incidents {
+		if filter.ActiveOnly && inc.Status == "resolved" {
+			continue
+		}
+		if len(filter.Severities) > 0 && !containsString(filter.Severities, inc.Severity) {
+			continue
+		}
+		if inc.UpdatedAt.Before(filter.Since) {
+			continue
+		}
+		filtered = append(filtered, inc)
 	}
+	return filtered
+}
+
+// feedTitle returns the feed's title, naming its scope if any
+func (fg *FeedGenerator) feedTitle() string {
+	if fg.scope != "" {
+		return fg.t("feed.title_scoped", fg.title, fg.scope)
+	}
+	return fg.t("feed.title", fg.title)
+}
+
+// feedURL returns the URL of this feed in the given format
+func (fg *FeedGenerator) feedURL(format string) string {
+	return fg.pageURL(format, fg.page)
+}
+
+// pageURL returns the URL of a page of this feed in the given format
+func (fg *FeedGenerator) pageURL(format string, page int) string {
+	u := fg.baseURL + "/feed/" + format + fg.scopePath
+	if page <= 1 {
+		return u
+	}
+	if strings.Contains(fg.scopePath, "?") {
+		return fmt.Sprintf("%s&page=%d", u, page)
+	}
+	return fmt.Sprintf("%s?page=%d", u, page)
+}
+
+// pageLinks returns the RFC 5005 paging links of this feed as rel -> URL,
+// or nil for a feed that fits on one page
+func (fg *FeedGenerator) pageLinks(format string) [][2]string {
+	if fg.lastPage <= 1 {
+		return nil
+	}
+	page := max(fg.page, 1)
+	links := [][2]string{{"first", fg.pageURL(format, 1)}}
+	if page > 1 {
+		links = append(links, [2]string{"previous", fg.pageURL(format, page-1)})
+	}
+	if page < fg.lastPage {
+		links = append(links, [2]string{"next", fg.pageURL(format, page+1)})
+	}
+	return append(links, [2]string{"last", fg.pageURL(format, fg.lastPage)})
+}
+
+// feedID returns the Atom feed ID: the site for the full feed, the feed
+// URL for a scoped or filtered one. Every page shares the first page's ID.
+func (fg *FeedGenerator) feedID() string {
+	if fg.scopePath != "" {
+		return fg.pageURL("atom", 1)
+	}
+	return fg.baseURL
 }
 
 // SetDescription sets custom feed description
 func (fg *FeedGenerator) SetDescription(desc string) {
 	fg.description = desc
+	fg.descriptionSet = true
+}
+
+// SetLocation sets the zone times in item text are shown in. Machine-read
+// dates (pubDate, updated) keep their own format.
+func (fg *FeedGenerator) SetLocation(loc *time.Location) {
+	fg.location = loc
 }
 
 // SetCopyright sets custom copyright notice
@@ -258,6 +400,19 @@ func (fg *FeedGenerator) GenerateRSSWithStatus(incidents []storage.Incident, sta
 		items = append(items, statusItem)
 	}
 
+	// Add maintenance windows
+	for _, m := range fg.maintenance {
+		items = append(items, RSSItem{
+			Title:          fg.formatMaintenanceTitle(m),
+			Link:           fg.baseURL,
+			Description:    fg.formatMaintenanceDescription(m),
+			GUID:           RSSGUID{Value: fmt.Sprintf("urn:maintenance:%s", m.ID), IsPermaLink: false},
+			PubDate:        m.CreatedAt.Format(time.RFC1123Z),
+			Category:       "maintenance",
+			ContentEncoded: fg.formatMaintenanceHTML(m),
+		})
+	}
+
 	// Add incidents
 	for _, inc := range incidents {
 		item := RSSItem{
@@ -269,6 +424,10 @@ func (fg *FeedGenerator) GenerateRSSWithStatus(incidents []storage.Incident, sta
 			Category:    fg.mapSeverityToCategory(inc.Severity),
 			ContentEncoded: fg.formatIncidentHTML(inc),
 		}
+		if len(inc.Attachments) > 0 {
+			att := inc.Attachments[0]
+			item.Enclosure = &RSSEnclosure{URL: att.URL, Length: att.Size, Type: att.MimeType}
+		}
 		items = append(items, item)
 	}
 
@@ -285,10 +444,10 @@ func (fg *FeedGenerator) GenerateRSSWithStatus(incidents []storage.Incident, sta
 		ContentNS: "http://purl.org/rss/1.0/modules/content/",
 		DcNS:      "http://purl.org/dc/elements/1.1/",
 		Channel: RSSChannel{
-			Title:         fg.title + " - Status Updates",
+			Title:         fg.feedTitle(),
 			Link:          fg.baseURL,
 			Description:   fg.description,
-			Language:      "en-us",
+			Language:      fg.lang(),
 			Copyright:     fg.copyright,
 			PubDate:       pubDate,
 			LastBuildDate: now.Format(time.RFC1123Z),
@@ -300,14 +459,18 @@ func (fg *FeedGenerator) GenerateRSSWithStatus(incidents []storage.Incident, sta
 				Title: fg.title,
 				Link:  fg.baseURL,
 			},
-			AtomLink: &RSSAtomLink{
-				Href: fg.baseURL + "/feed/rss",
-				Rel:  "self",
-				Type: "application/rss+xml",
+			AtomLinks: []RSSAtomLink{
+				{Href: fg.feedURL("rss"), Rel: "self", Type: "application/rss+xml"},
 			},
 			Items: items,
 		},
 	}
+	for _, link := range fg.pageLinks("rss") {
+		feed.Channel.AtomLinks = append(feed.Channel.AtomLinks, RSSAtomLink{Href: link[1], Rel: link[0], Type: "application/rss+xml"})
+	}
+	if fg.hub != "" {
+		feed.Channel.AtomLinks = append(feed.Channel.AtomLinks, RSSAtomLink{Href: fg.hub, Rel: "hub"})
+	}
 
 	output, err := xml.MarshalIndent(feed, "", "  ")
 	if err != nil {
@@ -340,12 +503,32 @@ func (fg *FeedGenerator) GenerateAtomWithStatus(incidents []storage.Incident, st
 			Summary:   &AtomContent{Type: "text", Value: fg.formatStatusDescription(status)},
 			Content:   &AtomContent{Type: "html", Value: fg.formatStatusHTML(status)},
 			Category: []AtomCategory{
-				{Term: "status", Label: "System Status"},
+				{Term: "status", Label: fg.t("category.status")},
 			},
 		}
 		entries = append(entries, statusEntry)
 	}
 
+	// Add maintenance windows
+	for _, m := range fg.maintenance {
+		entries = append(entries, AtomEntry{
+			Title: fg.formatMaintenanceTitle(m),
+			Link: []AtomLink{
+				{Href: fg.baseURL, Rel: "alternate", Type: "text/html"},
+			},
+			ID:        fmt.Sprintf("tag:%s,%s:maintenance:%s", extractDomain(fg.baseURL), m.CreatedAt.Format("2006-01-02"), m.ID),
+			Updated:   m.UpdatedAt.Format(time.RFC3339),
+			Published: m.CreatedAt.Format(time.RFC3339),
+			Author:    &AtomAuthor{Name: fg.author},
+			Summary:   &AtomContent{Type: "text", Value: fg.formatMaintenanceDescription(m)},
+			Content:   &AtomContent{Type: "html", Value: fg.formatMaintenanceHTML(m)},
+			Category: []AtomCategory{
+				{Term: "maintenance", Label: fg.t("category.maint")},
+				{Term: m.Status, Label: fg.mapMaintenanceStatusToLabel(m.Status)},
+			},
+		})
+	}
+
 	// Add incidents
 	for _, inc := range incidents {
 		entry := AtomEntry{
@@ -364,6 +547,9 @@ func (fg *FeedGenerator) GenerateAtomWithStatus(incidents []storage.Incident, st
 				{Term: inc.Status, Label: fg.mapStatusToLabel(inc.Status)},
 			},
 		}
+		for _, att := range inc.Attachments {
+			entry.Link = append(entry.Link, AtomLink{Href: att.URL, Rel: "enclosure", Type: att.MimeType, Title: att.Title, Length: att.Size})
+		}
 		entries = append(entries, entry)
 	}
 
@@ -376,16 +562,17 @@ func (fg *FeedGenerator) GenerateAtomWithStatus(incidents []storage.Incident, st
 
 	feed := AtomFeed{
 		Xmlns:    "http://www.w3.org/2005/Atom",
-		Title:    fg.title + " - Status Updates",
+		Lang:     fg.lang(),
+		Title:    fg.feedTitle(),
 		Subtitle: fg.description,
 		Link: []AtomLink{
 			{Href: fg.baseURL, Rel: "alternate", Type: "text/html"},
-			{Href: fg.baseURL + "/feed/atom", Rel: "self", Type: "application/atom+xml"},
-			{Href: fg.baseURL + "/feed/rss", Rel: "alternate", Type: "application/rss+xml", Title: "RSS Feed"},
-			{Href: fg.baseURL + "/feed/json", Rel: "alternate", Type: "application/feed+json", Title: "JSON Feed"},
+			{Href: fg.feedURL("atom"), Rel: "self", Type: "application/atom+xml"},
+			{Href: fg.feedURL("rss"), Rel: "alternate", Type: "application/rss+xml", Title: "RSS Feed"},
+			{Href: fg.feedURL("json"), Rel: "alternate", Type: "application/feed+json", Title: "JSON Feed"},
 		},
 		Updated: updated,
-		ID:      fg.baseURL,
+		ID:      fg.feedID(),
 		Author:  &AtomAuthor{Name: fg.author, URI: fg.baseURL},
 		Rights:  fg.copyright,
 		Generator: &AtomGenerator{
@@ -397,6 +584,12 @@ func (fg *FeedGenerator) GenerateAtomWithStatus(incidents []storage.Incident, st
 		Logo:    fg.baseURL + "/static/logo.svg",
 		Entries: entries,
 	}
+	for _, link := range fg.pageLinks("atom") {
+		feed.Link = append(feed.Link, AtomLink{Href: link[1], Rel: link[0], Type: "application/atom+xml"})
+	}
+	if fg.hub != "" {
+		feed.Link = append(feed.Link, AtomLink{Href: fg.hub, Rel: "hub"})
+	}
 
 	output, err := xml.MarshalIndent(feed, "", "  ")
 	if err != nil {
@@ -428,11 +621,33 @@ func (fg *FeedGenerator) GenerateJSONWithStatus(incidents []storage.Incident, st
 			DatePublished: now.Format(time.RFC3339),
 			DateModified:  now.Format(time.RFC3339),
 			Tags:          []string{"status", status.Overall},
-			Language:      "en",
+			Language:      fg.lang(),
 		}
 		items = append(items, statusItem)
 	}
 
+	// Add maintenance windows
+	for _, m := range fg.maintenance {
+		tags := []string{"maintenance", m.Status}
+		tags = append(tags, m.AffectedServices...)
+
+		items = append(items, JSONFeedItem{
+			ID:            fmt.Sprintf("urn:maintenance:%s", m.ID),
+			URL:           fg.baseURL,
+			Title:         fg.formatMaintenanceTitle(m),
+			ContentHTML:   fg.formatMaintenanceHTML(m),
+			ContentText:   fg.formatMaintenanceDescription(m),
+			Summary:       m.Description,
+			DatePublished: m.CreatedAt.Format(time.RFC3339),
+			DateModified:  m.UpdatedAt.Format(time.RFC3339),
+			Authors: []JSONAuthor{
+				{Name: fg.author, URL: fg.baseURL},
+			},
+			Tags:     tags,
+			Language: fg.lang(),
+		})
+	}
+
 	// Add incidents
 	for _, inc := range incidents {
 		tags := []string{inc.Severity, inc.Status}
@@ -451,26 +666,35 @@ func (fg *FeedGenerator) GenerateJSONWithStatus(incidents []storage.Incident, st
 				{Name: fg.author, URL: fg.baseURL},
 			},
 			Tags:     tags,
-			Language: "en",
+			Language: fg.lang(),
+		}
+		for _, att := range inc.Attachments {
+			item.Attachments = append(item.Attachments, JSONAttachment{URL: att.URL, MimeType: att.MimeType, Title: att.Title, Size: att.Size})
 		}
 		items = append(items, item)
 	}
 
 	feed := JSONFeed{
 		Version:     "https://jsonfeed.org/version/1.1",
-		Title:       fg.title + " - Status Updates",
+		Title:       fg.feedTitle(),
 		HomePageURL: fg.baseURL,
-		FeedURL:     fg.baseURL + "/feed/json",
+		FeedURL:     fg.feedURL("json"),
 		Description: fg.description,
-		UserComment: "This feed provides real-time status updates for " + fg.title + ". Subscribe to stay informed about incidents and maintenance.",
+		UserComment: fg.t("feed.comment", fg.title),
 		Icon:        fg.baseURL + "/static/logo.svg",
 		Favicon:     fg.baseURL + "/favicon.svg",
 		Authors: []JSONAuthor{
 			{Name: fg.author, URL: fg.baseURL},
 		},
-		Language: "en",
+		Language: fg.lang(),
 		Items:    items,
 	}
+	if fg.page < fg.lastPage {
+		feed.NextURL = fg.pageURL("json", max(fg.page, 1)+1)
+	}
+	if fg.hub != "" {
+		feed.Hubs = []JSONHub{{Type: "WebSub", URL: fg.hub}}
+	}
 
 	return json.MarshalIndent(feed, "", "  ")
 }
@@ -492,7 +716,7 @@ func (fg *FeedGenerator) formatIncidentTitle(inc storage.Incident) string {
 
 	statusText := ""
 	if inc.Status == "resolved" {
-		statusText = " [Resolved]"
+		statusText = " " + fg.t("incident.resolved")
 	}
 
 	return fmt.Sprintf("%s %s%s", icon, inc.Title, statusText)
@@ -501,29 +725,27 @@ func (fg *FeedGenerator) formatIncidentTitle(inc storage.Incident) string {
 func (fg *FeedGenerator) formatIncidentDescription(inc storage.Incident) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("Status: %s | Severity: %s\n",
-		fg.mapStatusToLabel(inc.Status),
-		fg.mapSeverityToLabel(inc.Severity)))
+	sb.WriteString(fg.t("incident.header", fg.mapStatusToLabel(inc.Status), fg.mapSeverityToLabel(inc.Severity)) + "\n")
 
 	if len(inc.AffectedServices) > 0 {
-		sb.WriteString(fmt.Sprintf("Affected Services: %s\n", strings.Join(inc.AffectedServices, ", ")))
+		sb.WriteString(fmt.Sprintf("%s: %s\n", fg.t("incident.services"), strings.Join(inc.AffectedServices, ", ")))
 	}
 
 	sb.WriteString(fmt.Sprintf("\n%s\n", inc.Message))
 
 	if len(inc.Updates) > 0 {
-		sb.WriteString("\n--- Timeline ---\n")
+		sb.WriteString(fmt.Sprintf("\n--- %s ---\n", fg.t("incident.timeline")))
 		for i := len(inc.Updates) - 1; i >= 0; i-- {
 			u := inc.Updates[i]
 			sb.WriteString(fmt.Sprintf("[%s] %s: %s\n",
-				u.CreatedAt.Format("Jan 02, 15:04 MST"),
+				fg.formatTime(u.CreatedAt, "time.short"),
 				fg.mapStatusToLabel(u.Status),
 				u.Message))
 		}
 	}
 
 	if inc.ResolvedAt != nil {
-		sb.WriteString(fmt.Sprintf("\nResolved at: %s", inc.ResolvedAt.Format("Jan 02, 2006 15:04 MST")))
+		sb.WriteString("\n" + fg.t("incident.resolved_at", fg.formatTime(*inc.ResolvedAt, "time.datetime")))
 	}
 
 	return sb.String()
@@ -548,7 +770,7 @@ func (fg *FeedGenerator) formatIncidentHTML(inc storage.Incident) string {
 
 	// Affected services
 	if len(inc.AffectedServices) > 0 {
-		sb.WriteString(`<div style="margin-bottom: 16px;"><strong>Affected Services:</strong> `)
+		sb.WriteString(fmt.Sprintf(`<div style="margin-bottom: 16px;"><strong>%s:</strong> `, html.EscapeString(fg.t("incident.services"))))
 		for i, svc := range inc.AffectedServices {
 			if i > 0 {
 				sb.WriteString(", ")
@@ -560,11 +782,11 @@ func (fg *FeedGenerator) formatIncidentHTML(inc storage.Incident) string {
 
 	// Message
 	sb.WriteString(fmt.Sprintf(`<div style="margin-bottom: 16px; padding: 16px; background: #f8fafc; border-radius: 8px; border-left: 4px solid %s;">%s</div>`,
-		badgeColor, html.EscapeString(inc.Message)))
+		badgeColor, markdown.ToHTML(inc.Message)))
 
 	// Timeline
 	if len(inc.Updates) > 0 {
-		sb.WriteString(`<div style="margin-top: 24px;"><h4 style="margin: 0 0 12px 0; font-size: 14px; text-transform: uppercase; letter-spacing: 0.5px; color: #64748b;">Timeline</h4>`)
+		sb.WriteString(`<div style="margin-top: 24px;"><h4 style="margin: 0 0 12px 0; font-size: 14px; text-transform: uppercase; letter-spacing: 0.5px; color: #64748b;">` + html.EscapeString(fg.t("incident.timeline")) + `</h4>`)
 		sb.WriteString(`<div style="border-left: 2px solid #e2e8f0; padding-left: 16px;">`)
 
 		for i := len(inc.Updates) - 1; i >= 0; i-- {
@@ -576,9 +798,9 @@ func (fg *FeedGenerator) formatIncidentHTML(inc storage.Incident) string {
 				<div style="color: #334155;">%s</div>
 			</div>`,
 				fg.getStatusBadge(u.Status),
-				u.CreatedAt.Format("Jan 02, 2006 • 15:04 MST"),
+				fg.formatTime(u.CreatedAt, "time.timeline"),
 				html.EscapeString(fg.mapStatusToLabel(u.Status)),
-				html.EscapeString(u.Message)))
+				markdown.ToHTML(u.Message)))
 		}
 		sb.WriteString(`</div></div>`)
 	}
@@ -586,8 +808,8 @@ func (fg *FeedGenerator) formatIncidentHTML(inc storage.Incident) string {
 	// Resolution info
 	if inc.ResolvedAt != nil {
 		sb.WriteString(fmt.Sprintf(`<div style="margin-top: 16px; padding: 12px; background: #dcfce7; border-radius: 8px; color: #166534;">
-			<strong>✓ Resolved:</strong> %s
-		</div>`, inc.ResolvedAt.Format("January 02, 2006 at 15:04 MST")))
+			<strong>✓ %s</strong> %s
+		</div>`, html.EscapeString(fg.t("incident.resolved_on")), fg.formatTime(*inc.ResolvedAt, "time.long")))
 	}
 
 	sb.WriteString(`</div>`)
@@ -607,11 +829,11 @@ func (fg *FeedGenerator) formatStatusTitle(status *StatusSummary) string {
 		icon = "ℹ️"
 	}
 
-	return fmt.Sprintf("%s Current Status: %s", icon, fg.mapOverallToLabel(status.Overall))
+	return icon + " " + fg.t("summary.title", fg.mapOverallToLabel(status.Overall))
 }
 
 func (fg *FeedGenerator) formatStatusDescription(status *StatusSummary) string {
-	return fmt.Sprintf("%s - %d/%d services operational, %d degraded, %d down",
+	return fg.t("summary.description",
 		fg.mapOverallToLabel(status.Overall),
 		status.Operational,
 		status.Total,
@@ -643,9 +865,9 @@ func (fg *FeedGenerator) formatStatusHTML(status *StatusSummary) string {
 	// Status banner
 	sb.WriteString(fmt.Sprintf(`<div style="padding: 20px; background: %s; border-radius: 12px; text-align: center; margin-bottom: 20px;">
 		<div style="font-size: 24px; font-weight: 700; color: %s; margin-bottom: 4px;">%s</div>
-		<div style="font-size: 14px; color: %s; opacity: 0.8;">Last updated: %s</div>
+		<div style="font-size: 14px; color: %s; opacity: 0.8;">%s</div>
 	</div>`,
-		bgColor, textColor, fg.mapOverallToLabel(status.Overall), textColor, time.Now().Format("Jan 02, 2006 15:04 MST")))
+		bgColor, textColor, html.EscapeString(fg.mapOverallToLabel(status.Overall)), textColor, html.EscapeString(fg.t("summary.updated", fg.formatTime(time.Now(), "time.datetime")))))
 
 	// Service stats
 	sb.WriteString(`<div style="display: grid; grid-template-columns: repeat(3, 1fr); gap: 12px; margin-bottom: 20px;">`)
@@ -653,20 +875,20 @@ func (fg *FeedGenerator) formatStatusHTML(status *StatusSummary) string {
 	// Operational
 	sb.WriteString(fmt.Sprintf(`<div style="text-align: center; padding: 16px; background: #f0fdf4; border-radius: 8px;">
 		<div style="font-size: 28px; font-weight: 700; color: #166534;">%d</div>
-		<div style="font-size: 12px; color: #166534; text-transform: uppercase;">Operational</div>
-	</div>`, status.Operational))
+		<div style="font-size: 12px; color: #166534; text-transform: uppercase;">%s</div>
+	</div>`, status.Operational, html.EscapeString(fg.t("summary.operational"))))
 
 	// Degraded
 	sb.WriteString(fmt.Sprintf(`<div style="text-align: center; padding: 16px; background: #fffbeb; border-radius: 8px;">
 		<div style="font-size: 28px; font-weight: 700; color: #92400e;">%d</div>
-		<div style="font-size: 12px; color: #92400e; text-transform: uppercase;">Degraded</div>
-	</div>`, status.Degraded))
+		<div style="font-size: 12px; color: #92400e; text-transform: uppercase;">%s</div>
+	</div>`, status.Degraded, html.EscapeString(fg.t("summary.degraded"))))
 
 	// Down
 	sb.WriteString(fmt.Sprintf(`<div style="text-align: center; padding: 16px; background: #fef2f2; border-radius: 8px;">
 		<div style="font-size: 28px; font-weight: 700; color: #991b1b;">%d</div>
-		<div style="font-size: 12px; color: #991b1b; text-transform: uppercase;">Down</div>
-	</div>`, status.Down))
+		<div style="font-size: 12px; color: #991b1b; text-transform: uppercase;">%s</div>
+	</div>`, status.Down, html.EscapeString(fg.t("summary.down"))))
 
 	sb.WriteString(`</div>`)
 
@@ -677,8 +899,8 @@ func (fg *FeedGenerator) formatStatusHTML(status *StatusSummary) string {
 			<div style="background: %s; height: 100%%; width: %.1f%%; transition: width 0.3s;"></div>
 		</div>
 		<div style="text-align: center; font-size: 13px; color: #64748b; margin-top: 8px;">
-			%.1f%% of services operational
-		</div>`, barColor, operationalPct, operationalPct))
+			%s
+		</div>`, barColor, operationalPct, html.EscapeString(fg.t("summary.percent", operationalPct))))
 	}
 
 	sb.WriteString(`</div>`)
@@ -689,25 +911,17 @@ func (fg *FeedGenerator) formatStatusHTML(status *StatusSummary) string {
 
 func (fg *FeedGenerator) mapSeverityToCategory(severity string) string {
 	switch severity {
-	case "critical":
-		return "Critical Incident"
-	case "major":
-		return "Major Incident"
-	case "minor":
-		return "Minor Incident"
+	case "critical", "major", "minor":
+		return fg.t("category." + severity)
 	default:
-		return "Incident"
+		return fg.t("category.incident")
 	}
 }
 
 func (fg *FeedGenerator) mapSeverityToLabel(severity string) string {
 	switch severity {
-	case "critical":
-		return "Critical"
-	case "major":
-		return "Major"
-	case "minor":
-		return "Minor"
+	case "critical", "major", "minor":
+		return fg.t("severity." + severity)
 	default:
 		return severity
 	}
@@ -715,14 +929,8 @@ func (fg *FeedGenerator) mapSeverityToLabel(severity string) string {
 
 func (fg *FeedGenerator) mapStatusToLabel(status string) string {
 	switch status {
-	case "investigating":
-		return "Investigating"
-	case "identified":
-		return "Identified"
-	case "monitoring":
-		return "Monitoring"
-	case "resolved":
-		return "Resolved"
+	case "investigating", "identified", "monitoring", "resolved":
+		return fg.t("status." + status)
 	default:
 		return status
 	}
@@ -730,14 +938,10 @@ func (fg *FeedGenerator) mapStatusToLabel(status string) string {
 
 func (fg *FeedGenerator) mapOverallToLabel(overall string) string {
 	switch overall {
-	case "operational":
-		return "All Systems Operational"
-	case "degraded":
-		return "Partial System Outage"
-	case "down":
-		return "Major System Outage"
+	case "operational", "degraded", "down":
+		return fg.t("overall." + overall)
 	default:
-		return "Status Unknown"
+		return fg.t("overall.unknown")
 	}
 }
 
@@ -769,6 +973,15 @@ func (fg *FeedGenerator) getStatusBadge(status string) string {
 	}
 }
 
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // extractDomain extracts domain from URL for Atom tag URIs
 func extractDomain(url string) string {
 	url = strings.TrimPrefix(url, "https://")
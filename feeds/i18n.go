@@ -0,0 +1,269 @@
+package feeds
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Catalog maps message keys to translated text. Values are fmt format
+// strings and keep the verbs of the English text.
+type Catalog map[string]string
+
+// catalogs holds the built-in translations, by base language
+var catalogs = map[string]Catalog{
+	"en": {
+		"feed.title":           "%s - Status Updates",
+		"feed.title_scoped":    "%s - %s Status Updates",
+		"feed.description":     "System status updates, incidents, and maintenance notifications",
+		"feed.comment":         "This feed provides real-time status updates for %s. Subscribe to stay informed about incidents and maintenance.",
+		"time.datetime":        "Jan 02, 2006 15:04 MST",
+		"time.short":           "Jan 02, 15:04 MST",
+		"time.timeline":        "Jan 02, 2006 • 15:04 MST",
+		"time.long":            "January 02, 2006 at 15:04 MST",
+		"severity.critical":    "Critical",
+		"severity.major":       "Major",
+		"severity.minor":       "Minor",
+		"category.critical":    "Critical Incident",
+		"category.major":       "Major Incident",
+		"category.minor":       "Minor Incident",
+		"category.incident":    "Incident",
+		"category.status":      "System Status",
+		"category.maint":       "Maintenance",
+		"status.investigating": "Investigating",
+		"status.identified":    "Identified",
+		"status.monitoring":    "Monitoring",
+		"status.resolved":      "Resolved",
+		"maint.scheduled":      "Scheduled",
+		"maint.in_progress":    "In Progress",
+		"maint.completed":      "Completed",
+		"overall.operational":  "All Systems Operational",
+		"overall.degraded":     "Partial System Outage",
+		"overall.down":         "Major System Outage",
+		"overall.unknown":      "Status Unknown",
+		"summary.title":        "Current Status: %s",
+		"summary.description":  "%s - %d/%d services operational, %d degraded, %d down",
+		"summary.updated":      "Last updated: %s",
+		"summary.operational":  "Operational",
+		"summary.degraded":     "Degraded",
+		"summary.down":         "Down",
+		"summary.percent":      "%.1f%% of services operational",
+		"incident.resolved":    "[Resolved]",
+		"incident.header":      "Status: %s | Severity: %s",
+		"incident.services":    "Affected Services",
+		"incident.timeline":    "Timeline",
+		"incident.resolved_at": "Resolved at: %s",
+		"incident.resolved_on": "Resolved:",
+		"maint.header":         "Status: %s | Maintenance",
+		"maint.starts":         "Starts: %s",
+		"maint.ends":           "Ends: %s (%s)",
+		"maint.when":           "When: %s",
+		"maint.scheduled_on":   "Scheduled:",
+	},
+	"de": {
+		"feed.title":           "%s - Statusmeldungen",
+		"feed.title_scoped":    "%s - Statusmeldungen für %s",
+		"feed.description":     "Systemstatus, Störungen und Wartungsarbeiten",
+		"feed.comment":         "Dieser Feed liefert aktuelle Statusmeldungen für %s. Abonnieren Sie ihn, um über Störungen und Wartungsarbeiten informiert zu bleiben.",
+		"time.datetime":        "02.01.2006 15:04 MST",
+		"time.short":           "02.01. 15:04 MST",
+		"time.timeline":        "02.01.2006 • 15:04 MST",
+		"time.long":            "02.01.2006 um 15:04 MST",
+		"severity.critical":    "Kritisch",
+		"severity.major":       "Schwer",
+		"severity.minor":       "Gering",
+		"category.critical":    "Kritische Störung",
+		"category.major":       "Schwere Störung",
+		"category.minor":       "Geringe Störung",
+		"category.incident":    "Störung",
+		"category.status":      "Systemstatus",
+		"category.maint":       "Wartung",
+		"status.investigating": "Wird untersucht",
+		"status.identified":    "Ursache erkannt",
+		"status.monitoring":    "Wird beobachtet",
+		"status.resolved":      "Behoben",
+		"maint.scheduled":      "Geplant",
+		"maint.in_progress":    "Läuft",
+		"maint.completed":      "Abgeschlossen",
+		"overall.operational":  "Alle Systeme betriebsbereit",
+		"overall.degraded":     "Teilweise Störung",
+		"overall.down":         "Schwere Störung",
+		"overall.unknown":      "Status unbekannt",
+		"summary.title":        "Aktueller Status: %s",
+		"summary.description":  "%s - %d/%d Dienste betriebsbereit, %d eingeschränkt, %d ausgefallen",
+		"summary.updated":      "Zuletzt aktualisiert: %s",
+		"summary.operational":  "Betriebsbereit",
+		"summary.degraded":     "Eingeschränkt",
+		"summary.down":         "Ausgefallen",
+		"summary.percent":      "%.1f%% der Dienste betriebsbereit",
+		"incident.resolved":    "[Behoben]",
+		"incident.header":      "Status: %s | Schweregrad: %s",
+		"incident.services":    "Betroffene Dienste",
+		"incident.timeline":    "Verlauf",
+		"incident.resolved_at": "Behoben am: %s",
+		"incident.resolved_on": "Behoben:",
+		"maint.header":         "Status: %s | Wartung",
+		"maint.starts":         "Beginn: %s",
+		"maint.ends":           "Ende: %s (%s)",
+		"maint.when":           "Zeitraum: %s",
+		"maint.scheduled_on":   "Geplant:",
+	},
+	"fr": {
+		"feed.title":           "%s - Mises à jour du statut",
+		"feed.title_scoped":    "%s - Mises à jour du statut de %s",
+		"feed.description":     "État des systèmes, incidents et opérations de maintenance",
+		"feed.comment":         "Ce flux fournit en temps réel l'état de %s. Abonnez-vous pour être informé des incidents et des maintenances.",
+		"time.datetime":        "02/01/2006 15:04 MST",
+		"time.short":           "02/01 15:04 MST",
+		"time.timeline":        "02/01/2006 • 15:04 MST",
+		"time.long":            "02/01/2006 à 15:04 MST",
+		"severity.critical":    "Critique",
+		"severity.major":       "Majeur",
+		"severity.minor":       "Mineur",
+		"category.critical":    "Incident critique",
+		"category.major":       "Incident majeur",
+		"category.minor":       "Incident mineur",
+		"category.incident":    "Incident",
+		"category.status":      "État des systèmes",
+		"category.maint":       "Maintenance",
+		"status.investigating": "En cours d'analyse",
+		"status.identified":    "Identifié",
+		"status.monitoring":    "Sous surveillance",
+		"status.resolved":      "Résolu",
+		"maint.scheduled":      "Planifiée",
+		"maint.in_progress":    "En cours",
+		"maint.completed":      "Terminée",
+		"overall.operational":  "Tous les systèmes sont opérationnels",
+		"overall.degraded":     "Panne partielle",
+		"overall.down":         "Panne majeure",
+		"overall.unknown":      "État inconnu",
+		"summary.title":        "État actuel : %s",
+		"summary.description":  "%s - %d/%d services opérationnels, %d dégradés, %d en panne",
+		"summary.updated":      "Dernière mise à jour : %s",
+		"summary.operational":  "Opérationnels",
+		"summary.degraded":     "Dégradés",
+		"summary.down":         "En panne",
+		"summary.percent":      "%.1f%% des services opérationnels",
+		"incident.resolved":    "[Résolu]",
+		"incident.header":      "Statut : %s | Gravité : %s",
+		"incident.services":    "Services concernés",
+		"incident.timeline":    "Chronologie",
+		"incident.resolved_at": "Résolu le : %s",
+		"incident.resolved_on": "Résolu :",
+		"maint.header":         "Statut : %s | Maintenance",
+		"maint.starts":         "Début : %s",
+		"maint.ends":           "Fin : %s (%s)",
+		"maint.when":           "Période : %s",
+		"maint.scheduled_on":   "Planifiée :",
+	},
+	"es": {
+		"feed.title":           "%s - Actualizaciones de estado",
+		"feed.title_scoped":    "%s - Actualizaciones de estado de %s",
+		"feed.description":     "Estado del sistema, incidentes y mantenimientos",
+		"feed.comment":         "Este feed ofrece el estado de %s en tiempo real. Suscríbete para estar al tanto de incidentes y mantenimientos.",
+		"time.datetime":        "02/01/2006 15:04 MST",
+		"time.short":           "02/01 15:04 MST",
+		"time.timeline":        "02/01/2006 • 15:04 MST",
+		"time.long":            "02/01/2006 a las 15:04 MST",
+		"severity.critical":    "Crítico",
+		"severity.major":       "Grave",
+		"severity.minor":       "Leve",
+		"category.critical":    "Incidente crítico",
+		"category.major":       "Incidente grave",
+		"category.minor":       "Incidente leve",
+		"category.incident":    "Incidente",
+		"category.status":      "Estado del sistema",
+		"category.maint":       "Mantenimiento",
+		"status.investigating": "Investigando",
+		"status.identified":    "Identificado",
+		"status.monitoring":    "En observación",
+		"status.resolved":      "Resuelto",
+		"maint.scheduled":      "Programado",
+		"maint.in_progress":    "En curso",
+		"maint.completed":      "Completado",
+		"overall.operational":  "Todos los sistemas operativos",
+		"overall.degraded":     "Interrupción parcial",
+		"overall.down":         "Interrupción grave",
+		"overall.unknown":      "Estado desconocido",
+		"summary.title":        "Estado actual: %s",
+		"summary.description":  "%s - %d/%d servicios operativos, %d degradados, %d caídos",
+		"summary.updated":      "Última actualización: %s",
+		"summary.operational":  "Operativos",
+		"summary.degraded":     "Degradados",
+		"summary.down":         "Caídos",
+		"summary.percent":      "%.1f%% de los servicios operativos",
+		"incident.resolved":    "[Resuelto]",
+		"incident.header":      "Estado: %s | Gravedad: %s",
+		"incident.services":    "Servicios afectados",
+		"incident.timeline":    "Cronología",
+		"incident.resolved_at": "Resuelto el: %s",
+		"incident.resolved_on": "Resuelto:",
+		"maint.header":         "Estado: %s | Mantenimiento",
+		"maint.starts":         "Inicio: %s",
+		"maint.ends":           "Fin: %s (%s)",
+		"maint.when":           "Periodo: %s",
+		"maint.scheduled_on":   "Programado:",
+	},
+}
+
+// HasCatalog reports whether feeds have built-in translations for lang, a
+// language tag such as "de" or "pt-BR"
+func HasCatalog(lang string) bool {
+	_, ok := catalogs[baseLanguage(lang)]
+	return ok
+}
+
+// SetLanguage sets the feed language (e.g. "de", "fr-CA") and optional
+// translations overriding the built-in catalog by key. Keys missing from
+// both fall back to English.
+func (fg *FeedGenerator) SetLanguage(lang string, overrides Catalog) {
+	if lang == "" {
+		lang = "en"
+	}
+	fg.language = lang
+	fg.translations = overrides
+	if !fg.descriptionSet {
+		fg.description = fg.t("feed.description")
+	}
+}
+
+// lang returns the feed language tag, in lowercase as RSS expects
+func (fg *FeedGenerator) lang() string {
+	if fg.language == "" {
+		return "en"
+	}
+	return strings.ToLower(fg.language)
+}
+
+// t returns the translation of key, formatted with args
+func (fg *FeedGenerator) t(key string, args ...interface{}) string {
+	format, ok := fg.translations[key]
+	if !ok {
+		format, ok = catalogs[baseLanguage(fg.language)][key]
+	}
+	if !ok {
+		format = catalogs["en"][key]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// formatTime formats t in the feed's zone with the layout stored under key,
+// e.g. "time.short"
+func (fg *FeedGenerator) formatTime(t time.Time, key string) string {
+	if fg.location != nil {
+		t = t.In(fg.location)
+	}
+	return t.Format(fg.t(key))
+}
+
+// baseLanguage returns the primary subtag of a language tag: "pt" for "pt-BR"
+func baseLanguage(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
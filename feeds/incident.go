@@ -0,0 +1,87 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"time"
+
+	"github.com/status/markdown"
+	"github.com/status/storage"
+)
+
+// IncidentFeedURL returns the URL of an incident's update feed
+func (fg *FeedGenerator) IncidentFeedURL(id string) string {
+	return fmt.Sprintf("%s/feed/incidents/%s.atom", fg.baseURL, id)
+}
+
+// GenerateIncidentAtom generates an Atom feed of one incident's timeline,
+// with an entry per update, newest first
+func (fg *FeedGenerator) GenerateIncidentAtom(inc storage.Incident) ([]byte, error) {
+	domain := extractDomain(fg.baseURL)
+	incidentURL := fmt.Sprintf("%s/incidents/%s", fg.baseURL, inc.ID)
+
+	entries := make([]AtomEntry, 0, len(inc.Updates))
+	for i := len(inc.Updates) - 1; i >= 0; i-- {
+		u := inc.Updates[i]
+		entries = append(entries, AtomEntry{
+			Title: fmt.Sprintf("%s: %s", fg.mapStatusToLabel(u.Status), inc.Title),
+			Link: []AtomLink{
+				{Href: incidentURL, Rel: "alternate", Type: "text/html"},
+			},
+			ID:        fmt.Sprintf("tag:%s,%s:incident:%s:update:%s", domain, inc.CreatedAt.Format("2006-01-02"), inc.ID, u.ID),
+			Updated:   u.CreatedAt.Format(time.RFC3339),
+			Published: u.CreatedAt.Format(time.RFC3339),
+			Author:    &AtomAuthor{Name: fg.author},
+			Summary:   &AtomContent{Type: "text", Value: u.Message},
+			Content:   &AtomContent{Type: "html", Value: fg.formatUpdateHTML(u)},
+			Category: []AtomCategory{
+				{Term: u.Status, Label: fg.mapStatusToLabel(u.Status)},
+			},
+		})
+	}
+
+	feed := AtomFeed{
+		Xmlns:    "http://www.w3.org/2005/Atom",
+		Lang:     fg.lang(),
+		Title:    fmt.Sprintf("%s - %s", inc.Title, fg.title),
+		Subtitle: fmt.Sprintf("%s | %s", fg.mapSeverityToCategory(inc.Severity), fg.mapStatusToLabel(inc.Status)),
+		Link: []AtomLink{
+			{Href: incidentURL, Rel: "alternate", Type: "text/html"},
+			{Href: fg.IncidentFeedURL(inc.ID), Rel: "self", Type: "application/atom+xml"},
+		},
+		Updated: inc.UpdatedAt.Format(time.RFC3339),
+		ID:      fmt.Sprintf("tag:%s,%s:incident:%s", domain, inc.CreatedAt.Format("2006-01-02"), inc.ID),
+		Author:  &AtomAuthor{Name: fg.author, URI: fg.baseURL},
+		Rights:  fg.copyright,
+		Generator: &AtomGenerator{
+			Value:   "Status Monitor",
+			URI:     "https://github.com/status",
+			Version: "1.0",
+		},
+		Icon:    fg.baseURL + "/favicon.svg",
+		Logo:    fg.baseURL + "/static/logo.svg",
+		Entries: entries,
+	}
+	if fg.hub != "" {
+		feed.Link = append(feed.Link, AtomLink{Href: fg.hub, Rel: "hub"})
+	}
+
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), output...), nil
+}
+
+func (fg *FeedGenerator) formatUpdateHTML(u storage.IncidentUpdate) string {
+	return fmt.Sprintf(`<div style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 600px;">
+		<div style="margin-bottom: 12px;"><span style="display: inline-block; padding: 4px 12px; border-radius: 4px; font-size: 12px; font-weight: 600; text-transform: uppercase; background-color: %s; color: white;">%s</span>
+		<span style="font-size: 12px; color: #64748b; margin-left: 8px;">%s</span></div>
+		<div style="color: #334155;">%s</div>
+	</div>`,
+		fg.getStatusBadge(u.Status),
+		html.EscapeString(fg.mapStatusToLabel(u.Status)),
+		fg.formatTime(u.CreatedAt, "time.timeline"),
+		markdown.ToHTML(u.Message))
+}
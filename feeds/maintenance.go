@@ -0,0 +1,134 @@
+package feeds
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// WithMaintenance returns a copy of the generator that lists the given
+// maintenance windows after the status summary
+func (fg *FeedGenerator) WithMaintenance(windows []storage.Maintenance) *FeedGenerator {
+	withMaintenance := *fg
+	withMaintenance.maintenance = windows
+	return &withMaintenance
+}
+
+func (fg *FeedGenerator) formatMaintenanceTitle(m storage.Maintenance) string {
+	return fmt.Sprintf("🔧 %s [%s]", m.Title, fg.mapMaintenanceStatusToLabel(m.Status))
+}
+
+// formatMaintenanceDescription renders a maintenance window as text. The
+// window is also given as an ISO 8601 interval, as in an iCal event.
+func (fg *FeedGenerator) formatMaintenanceDescription(m storage.Maintenance) string {
+	var sb strings.Builder
+
+	sb.WriteString(fg.t("maint.header", fg.mapMaintenanceStatusToLabel(m.Status)) + "\n")
+	if len(m.AffectedServices) > 0 {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", fg.t("incident.services"), strings.Join(m.AffectedServices, ", ")))
+	}
+
+	sb.WriteString("\n" + fg.t("maint.starts", fg.formatTime(m.ScheduledStart, "time.datetime")) + "\n")
+	if !m.ScheduledEnd.IsZero() {
+		sb.WriteString(fg.t("maint.ends", fg.formatTime(m.ScheduledEnd, "time.datetime"), formatWindow(m)) + "\n")
+	}
+	sb.WriteString(fg.t("maint.when", maintenanceInterval(m)) + "\n")
+
+	if m.Description != "" {
+		sb.WriteString(fmt.Sprintf("\n%s\n", m.Description))
+	}
+
+	return sb.String()
+}
+
+func (fg *FeedGenerator) formatMaintenanceHTML(m storage.Maintenance) string {
+	var sb strings.Builder
+	color := fg.getMaintenanceColor(m.Status)
+
+	sb.WriteString(`<div style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 600px;">`)
+
+	sb.WriteString(`<div style="margin-bottom: 16px;">`)
+	sb.WriteString(`<span style="display: inline-block; padding: 4px 12px; border-radius: 4px; font-size: 12px; font-weight: 600; text-transform: uppercase; background-color: #6366f1; color: white; margin-right: 8px;">` + html.EscapeString(fg.t("category.maint")) + `</span>`)
+	sb.WriteString(fmt.Sprintf(`<span style="display: inline-block; padding: 4px 12px; border-radius: 4px; font-size: 12px; font-weight: 600; text-transform: uppercase; background-color: %s; color: white;">%s</span>`,
+		color, html.EscapeString(fg.mapMaintenanceStatusToLabel(m.Status))))
+	sb.WriteString(`</div>`)
+
+	if len(m.AffectedServices) > 0 {
+		sb.WriteString(fmt.Sprintf(`<div style="margin-bottom: 16px;"><strong>%s:</strong> `, html.EscapeString(fg.t("incident.services"))))
+		for i, svc := range m.AffectedServices {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(fmt.Sprintf(`<span style="background: #f1f5f9; padding: 2px 8px; border-radius: 4px; font-size: 13px;">%s</span>`, html.EscapeString(svc)))
+		}
+		sb.WriteString(`</div>`)
+	}
+
+	// Schedule, machine-readable as well
+	sb.WriteString(fmt.Sprintf(`<div style="margin-bottom: 16px;"><strong>%s</strong> `, html.EscapeString(fg.t("maint.scheduled_on"))))
+	sb.WriteString(fmt.Sprintf(`<time datetime="%s">%s</time>`,
+		m.ScheduledStart.UTC().Format(time.RFC3339), fg.formatTime(m.ScheduledStart, "time.datetime")))
+	if !m.ScheduledEnd.IsZero() {
+		sb.WriteString(fmt.Sprintf(` – <time datetime="%s">%s</time> (%s)`,
+			m.ScheduledEnd.UTC().Format(time.RFC3339), fg.formatTime(m.ScheduledEnd, "time.datetime"), formatWindow(m)))
+	}
+	sb.WriteString(`</div>`)
+
+	if m.Description != "" {
+		sb.WriteString(fmt.Sprintf(`<div style="margin-bottom: 16px; padding: 16px; background: #f8fafc; border-radius: 8px; border-left: 4px solid %s;">%s</div>`,
+			color, html.EscapeString(m.Description)))
+	}
+
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
+
+func (fg *FeedGenerator) mapMaintenanceStatusToLabel(status string) string {
+	switch status {
+	case "scheduled", "in_progress", "completed":
+		return fg.t("maint." + status)
+	default:
+		return status
+	}
+}
+
+func (fg *FeedGenerator) getMaintenanceColor(status string) string {
+	switch status {
+	case "scheduled":
+		return "#3b82f6"
+	case "in_progress":
+		return "#f97316"
+	case "completed":
+		return "#22c55e"
+	default:
+		return "#64748b"
+	}
+}
+
+// maintenanceInterval returns the window as an ISO 8601 interval in UTC,
+// e.g. 20261016T020000Z/20261016T040000Z
+func maintenanceInterval(m storage.Maintenance) string {
+	const basic = "20060102T150405Z"
+	start := m.ScheduledStart.UTC().Format(basic)
+	if m.ScheduledEnd.IsZero() {
+		return start
+	}
+	return start + "/" + m.ScheduledEnd.UTC().Format(basic)
+}
+
+// formatWindow returns the length of a maintenance window, e.g. "2h30m"
+func formatWindow(m storage.Maintenance) string {
+	d := m.ScheduledEnd.Sub(m.ScheduledStart).Round(time.Minute)
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case hours == 0:
+		return fmt.Sprintf("%dm", minutes)
+	case minutes == 0:
+		return fmt.Sprintf("%dh", hours)
+	default:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
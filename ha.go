@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/ha"
+	"github.com/status/monitor"
+	"github.com/status/storage"
+	"github.com/status/web"
+)
+
+// haNode is this instance's part in an HA deployment: the leader, which
+// holds the lease and the database, or a follower serving the leader's
+// snapshots
+type haNode struct {
+	cfg      config.HAConfig
+	elector  *ha.Elector
+	store    *storage.Storage
+	lease    ha.Lease      // Held while leading, last read while following
+	leading  bool          // Owned by run once it starts
+	promoted chan struct{} // Receives when a follower has taken over
+	lost     chan error    // Receives when the leader cannot keep its lease
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// joinCluster opens storage for an instance in HA mode: the database
+// itself when the lease is free, taking it, or otherwise the leader's
+// latest snapshot, waiting for the first one to be published
+func joinCluster(cfg *config.Config) (*haNode, error) {
+	id := cfg.HA.NodeID
+	if id == "" {
+		host, _ := os.Hostname()
+		id = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	path := cfg.HA.LeaseFile
+	if path == "" {
+		path = filepath.Join(cfg.Storage.DataDir, "leader.lease")
+	}
+	for _, dir := range []string{cfg.Storage.DataDir, filepath.Dir(path)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	n := &haNode{
+		cfg:      cfg.HA,
+		elector:  ha.NewElector(path, id, cfg.HA.AdvertiseURL, cfg.HA.LeaseDuration),
+		promoted: make(chan struct{}, 1),
+		lost:     make(chan error, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	waiting := ""
+	wait := func(reason string, args ...any) {
+		if reason != waiting {
+			slog.Info(reason, args...)
+			waiting = reason
+		}
+		time.Sleep(cfg.HA.RenewInterval)
+	}
+	for {
+		lease, held, err := n.elector.Acquire(time.Now())
+		if errors.Is(err, ha.ErrBusy) {
+			wait("Waiting for the lease file lock", "lease_file", path)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("lease %s: %w", path, err)
+		}
+		n.lease = lease
+
+		if held {
+			store, err := storage.NewStorage(cfg.Storage.DataDir)
+			if errors.Is(err, storage.ErrDatabaseLocked) {
+				wait("Waiting for the previous leader to close the database")
+				continue
+			}
+			if err != nil {
+				n.elector.Release()
+				return nil, err
+			}
+			n.store, n.leading = store, true
+			slog.Info("Leading", "node", id, "term", lease.Term)
+			return n, nil
+		}
+
+		store, err := storage.OpenReplica(cfg.Storage.DataDir)
+		if errors.Is(err, storage.ErrNoSnapshot) {
+			wait("Waiting for the leader's first snapshot", "leader", lease.Holder)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		n.store = store
+		slog.Info("Following", "node", id, "leader", lease.Holder, "term", lease.Term)
+		return n, nil
+	}
+}
+
+// run keeps the instance's role until stopped. A leader renews its lease
+// and publishes snapshots, and reports on lost when it cannot renew before
+// the lease runs out. A follower moves to newer snapshots, updating mon and
+// server, and takes over once the lease runs out, reporting on promoted.
+func (n *haNode) run(mon *monitor.Monitor, server *web.Server) {
+	defer close(n.done)
+
+	renew := time.NewTicker(n.cfg.RenewInterval)
+	defer renew.Stop()
+	snapshot := time.NewTicker(n.cfg.SnapshotInterval)
+	defer snapshot.Stop()
+
+	if n.leading {
+		n.publish()
+	}
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-snapshot.C:
+			if n.leading {
+				n.publish()
+			}
+		case now := <-renew.C:
+			if !n.leading {
+				n.follow(now, mon, server)
+				continue
+			}
+			if err := n.renew(now); err != nil {
+				n.lost <- err
+				return
+			}
+		}
+	}
+}
+
+// renew extends the leader's lease. A failed renewal is retried while the
+// lease has time left for another attempt.
+func (n *haNode) renew(now time.Time) error {
+	lease, held, err := n.elector.Acquire(now)
+	switch {
+	case err != nil && now.Add(n.cfg.RenewInterval).Before(n.lease.ExpiresAt):
+		slog.Warn("Lease renewal failed, retrying", "error", err)
+		return nil
+	case err != nil:
+		return fmt.Errorf("lease renewal failed: %w", err)
+	case !held:
+		return fmt.Errorf("%s holds the lease (term %d)", lease.Holder, lease.Term)
+	case lease.Term != n.lease.Term:
+		// The lease ran out before this renewal, so another instance may
+		// have led in between
+		return fmt.Errorf("lease ran out (term %d, now %d)", n.lease.Term, lease.Term)
+	}
+	n.lease = lease
+	return nil
+}
+
+// follow tracks the leader: it takes over when the lease has run out, and
+// otherwise moves to the leader's latest snapshot
+func (n *haNode) follow(now time.Time, mon *monitor.Monitor, server *web.Server) {
+	lease, held, err := n.elector.Acquire(now)
+	if err != nil {
+		slog.Warn("Leader lease unavailable", "error", err)
+		return
+	}
+
+	if held {
+		err := n.store.Promote()
+		if errors.Is(err, storage.ErrDatabaseLocked) {
+			slog.Info("Waiting for the previous leader to close the database")
+			return
+		}
+		if err != nil {
+			// Let another follower try
+			slog.Error("Taking over failed", "error", err)
+			n.elector.Release()
+			return
+		}
+		slog.Warn("Took over as leader", "node", n.elector.ID(), "previous", n.lease.Holder, "term", lease.Term)
+		n.lease, n.leading = lease, true
+		n.publish()
+		n.promoted <- struct{}{}
+		return
+	}
+
+	if lease.Holder != n.lease.Holder {
+		slog.Info("Following a new leader", "leader", lease.Holder, "term", lease.Term)
+	}
+	n.lease = lease
+	server.SetFollower(lease.URL)
+
+	changed, err := n.store.Refresh()
+	if err != nil {
+		slog.Warn("Snapshot not refreshed", "error", err)
+		return
+	}
+	if changed {
+		mon.Sync()
+		server.DataChanged()
+	}
+}
+
+// publish writes a snapshot for followers
+func (n *haNode) publish() {
+	if err := n.store.WriteSnapshot(); err != nil {
+		slog.Error("Snapshot not published", "error", err)
+	}
+}
+
+// Promoted receives when this follower has taken over as leader
+func (n *haNode) Promoted() <-chan struct{} {
+	return n.promoted
+}
+
+// Lost receives when this leader could not keep its lease
+func (n *haNode) Lost() <-chan error {
+	return n.lost
+}
+
+// halt stops run and waits for it to return
+func (n *haNode) halt() {
+	close(n.stop)
+	<-n.done
+}
+
+// leave gives up the lease if this instance leads, so a follower takes
+// over at once. Call it after closing storage, so the follower can open
+// the database.
+func (n *haNode) leave() {
+	if !n.leading {
+		return
+	}
+	if err := n.elector.Release(); err != nil {
+		slog.Warn("Lease not released; a follower takes over when it runs out", "error", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Package ha elects one leader among instances sharing a data directory.
+// The leader holds a lease, recorded in a file there, which it renews
+// while it is healthy; when it stops renewing, another instance takes the
+// lease once it runs out.
+package ha
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Lease names the instance that leads until ExpiresAt
+type Lease struct {
+	Holder     string    `json:"holder"`
+	URL        string    `json:"url,omitempty"` // Where the holder takes writes
+	Term       uint64    `json:"term"`          // Incremented each time the lease changes hands
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the lease has run out at now. The zero lease,
+// before any instance has led, is expired.
+func (l Lease) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// ErrBusy is returned when another instance held the lease file's lock
+// for the whole time an operation waited for it
+var ErrBusy = errors.New("lease file is locked by another instance")
+
+// lockAttempts and lockRetry bound how long an operation waits for the
+// lock another instance holds while it reads and writes the lease
+const (
+	lockAttempts = 20
+	lockRetry    = 50 * time.Millisecond
+)
+
+// Elector takes and renews the lease for one instance
+type Elector struct {
+	path     string
+	id       string
+	url      string
+	duration time.Duration
+}
+
+// NewElector returns an elector for the instance id, which takes writes
+// at url, using the lease file at path. Leases it takes last for duration
+// unless renewed.
+func NewElector(path, id, url string, duration time.Duration) *Elector {
+	return &Elector{path: path, id: id, url: url, duration: duration}
+}
+
+// ID returns the instance's name in the lease
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// Acquire takes the lease if it is expired or already held by this
+// instance, and extends it by the lease duration. It returns the lease as
+// it now stands and whether this instance holds it.
+func (e *Elector) Acquire(now time.Time) (Lease, bool, error) {
+	unlock, err := e.lock()
+	if err != nil {
+		return Lease{}, false, err
+	}
+	defer unlock()
+
+	current, err := e.Read()
+	if err != nil {
+		return Lease{}, false, err
+	}
+	if current.Holder != e.id && !current.Expired(now) {
+		return current, false, nil
+	}
+
+	next := current
+	if current.Holder != e.id || current.Expired(now) {
+		next = Lease{Holder: e.id, Term: current.Term + 1, AcquiredAt: now}
+	}
+	next.URL = e.url
+	next.ExpiresAt = now.Add(e.duration)
+	if err := e.write(next); err != nil {
+		return current, false, err
+	}
+	return next, true, nil
+}
+
+// Release ends the lease if this instance holds it, so another takes over
+// without waiting for it to run out
+func (e *Elector) Release() error {
+	now := time.Now()
+	unlock, err := e.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := e.Read()
+	if err != nil || current.Holder != e.id || current.Expired(now) {
+		return err
+	}
+	current.ExpiresAt = now
+	return e.write(current)
+}
+
+// Read returns the lease in the file, or the zero lease if there is none
+func (e *Elector) Read() (Lease, error) {
+	var lease Lease
+	data, err := os.ReadFile(e.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lease, nil
+	}
+	if err != nil {
+		return lease, err
+	}
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return lease, fmt.Errorf("%s: %w", e.path, err)
+	}
+	return lease, nil
+}
+
+// write replaces the lease file in one step, so readers never see a
+// partial lease
+func (e *Elector) write(lease Lease) error {
+	data, err := json.MarshalIndent(lease, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(e.path), filepath.Base(e.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), e.path)
+}
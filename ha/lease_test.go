@@ -0,0 +1,219 @@
+package ha
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testDuration = 10 * time.Second
+
+// electors returns electors for the named instances sharing a lease file
+func electors(t *testing.T, ids ...string) []*Elector {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "leader.json")
+	var es []*Elector
+	for _, id := range ids {
+		es = append(es, NewElector(path, id, "http://"+id, testDuration))
+	}
+	return es
+}
+
+// acquire calls Acquire and fails the test on an error or if the lease is
+// not held as expected
+func acquire(t *testing.T, e *Elector, now time.Time, held bool) Lease {
+	t.Helper()
+	lease, ok, err := e.Acquire(now)
+	if err != nil {
+		t.Fatalf("%s: %v", e.ID(), err)
+	}
+	if ok != held {
+		t.Fatalf("%s holds the lease: %v, want %v (lease %+v)", e.ID(), ok, held, lease)
+	}
+	return lease
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	es := electors(t, "a", "b")
+	a, b := es[0], es[1]
+	now := time.Now()
+
+	lease := acquire(t, a, now, true)
+	if lease.Holder != "a" || lease.URL != "http://a" || lease.Term != 1 {
+		t.Errorf("first lease %+v, want a's in term 1", lease)
+	}
+	if !lease.ExpiresAt.Equal(now.Add(testDuration)) {
+		t.Errorf("lease expires at %v, want %v", lease.ExpiresAt, now.Add(testDuration))
+	}
+
+	if lease := acquire(t, b, now, false); lease.Holder != "a" {
+		t.Errorf("b sees holder %q, want a", lease.Holder)
+	}
+
+	if err := a.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if lease, err := b.Read(); err != nil || !lease.Expired(time.Now()) {
+		t.Fatalf("released lease %+v (%v), want expired", lease, err)
+	}
+	if lease := acquire(t, b, time.Now(), true); lease.Holder != "b" || lease.Term != 2 {
+		t.Errorf("lease after release %+v, want b's in term 2", lease)
+	}
+
+	// Releasing a lease held by another instance leaves it alone
+	if err := a.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if lease, _ := a.Read(); lease.Holder != "b" || lease.Expired(time.Now()) {
+		t.Errorf("a released b's lease: %+v", lease)
+	}
+}
+
+func TestRenewKeepsTerm(t *testing.T) {
+	a := electors(t, "a")[0]
+	now := time.Now()
+
+	first := acquire(t, a, now, true)
+	renewed := acquire(t, a, now.Add(testDuration/2), true)
+	if renewed.Term != first.Term || !renewed.AcquiredAt.Equal(first.AcquiredAt) {
+		t.Errorf("renewal %+v changed the term or acquisition of %+v", renewed, first)
+	}
+	if want := now.Add(testDuration / 2).Add(testDuration); !renewed.ExpiresAt.Equal(want) {
+		t.Errorf("renewed lease expires at %v, want %v", renewed.ExpiresAt, want)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	es := electors(t, "a", "b")
+	a, b := es[0], es[1]
+	now := time.Now()
+
+	acquire(t, a, now, true)
+	acquire(t, b, now.Add(testDuration-time.Nanosecond), false)
+
+	expired := now.Add(testDuration)
+	if lease := acquire(t, b, expired, true); lease.Holder != "b" || lease.Term != 2 {
+		t.Errorf("lease after expiry %+v, want b's in term 2", lease)
+	}
+	acquire(t, a, expired.Add(time.Second), false)
+
+	// Taking back its own expired lease starts a new term, since another
+	// instance may have led in between
+	later := expired.Add(2 * testDuration)
+	if lease := acquire(t, b, later, true); lease.Term != 3 || !lease.AcquiredAt.Equal(later) {
+		t.Errorf("lease retaken after expiry %+v, want term 3 acquired at %v", lease, later)
+	}
+}
+
+func TestReadNoLease(t *testing.T) {
+	a := electors(t, "a")[0]
+	lease, err := a.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease != (Lease{}) || !lease.Expired(time.Now()) {
+		t.Errorf("lease without a file %+v, want the zero lease, expired", lease)
+	}
+
+	if err := os.WriteFile(a.path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Read(); err == nil {
+		t.Error("expected an error for a corrupt lease file")
+	}
+}
+
+func TestLockBusy(t *testing.T) {
+	es := electors(t, "a", "b")
+	a, b := es[0], es[1]
+
+	unlock, err := a.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := b.Acquire(time.Now()); !errors.Is(err, ErrBusy) {
+		t.Errorf("acquiring while the lock is held: %v, want ErrBusy", err)
+	}
+	unlock()
+	acquire(t, b, time.Now(), true)
+}
+
+func TestStaleLockBroken(t *testing.T) {
+	a := electors(t, "a")[0]
+	lock := a.path + ".lock"
+	if err := os.WriteFile(lock, []byte("dead\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * testDuration)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	acquire(t, a, time.Now(), true)
+}
+
+// TestLockExcludes has many instances race for a stale lock and the lease
+// it guards: only one may take the lock at a time, so only one leads.
+func TestLockExcludes(t *testing.T) {
+	var ids []string
+	for i := 0; i < 16; i++ {
+		ids = append(ids, fmt.Sprintf("i%d", i))
+	}
+	es := electors(t, ids...)
+	lock := es[0].path + ".lock"
+	if err := os.WriteFile(lock, []byte("dead\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * testDuration)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu      sync.Mutex
+		holding int
+		overlap bool
+		leaders []string
+		wg      sync.WaitGroup
+	)
+	now := time.Now()
+	for _, e := range es {
+		wg.Add(1)
+		go func(e *Elector) {
+			defer wg.Done()
+			unlock, err := e.lock()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			holding++
+			overlap = overlap || holding > 1
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			holding--
+			mu.Unlock()
+			unlock()
+
+			if _, ok, err := e.Acquire(now); err != nil {
+				t.Error(err)
+			} else if ok {
+				mu.Lock()
+				leaders = append(leaders, e.ID())
+				mu.Unlock()
+			}
+		}(e)
+	}
+	wg.Wait()
+
+	if overlap {
+		t.Error("two instances held the lock at once")
+	}
+	if len(leaders) != 1 {
+		t.Errorf("leaders %v, want exactly one", leaders)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build unix && !aix && !solaris
+
+package ha
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+)
+
+// lock takes the lock guarding a read and write of the lease: an exclusive
+// flock on a file next to it, which Linux also takes on NFS. The kernel
+// drops the lock when the instance holding it exits, so one left by an
+// instance that died never needs breaking, and the file itself stays.
+func (e *Elector) lock() (unlock func(), err error) {
+	f, err := os.OpenFile(e.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; attempt < lockAttempts; attempt++ {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			return func() { f.Close() }, nil
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			f.Close()
+			return nil, err
+		}
+		time.Sleep(lockRetry)
+	}
+	f.Close()
+	return nil, ErrBusy
+}
@@ -0,0 +1,57 @@
+//go:build !unix || aix || solaris
+
+package ha
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// lock takes the lock guarding a read and write of the lease: a file
+// created with O_EXCL, which is atomic on local filesystems and NFS. A lock
+// older than the lease duration was left by an instance that died holding
+// it, and is broken.
+func (e *Elector) lock() (unlock func(), err error) {
+	path := e.path + ".lock"
+	for attempt := 0; attempt < lockAttempts; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintln(f, e.id)
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > e.duration {
+			e.breakLock(path, info)
+			continue
+		}
+		time.Sleep(lockRetry)
+	}
+	return nil, ErrBusy
+}
+
+// breakLock removes the stale lock found at path. Removing it by name would
+// race with another instance breaking it too: that one may already have
+// taken a new lock there, which a remove would delete. Instead the lock is
+// renamed to a name of this instance's own, which succeeds for only one of
+// them, and removed if it is still the stale file. If another instance
+// replaced it in the meantime, its lock is put back.
+func (e *Elector) breakLock(path string, stale fs.FileInfo) {
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	broken := path + "." + hex.EncodeToString(nonce)
+	if err := os.Rename(path, broken); err != nil {
+		return
+	}
+	defer os.Remove(broken)
+	if info, err := os.Stat(broken); err == nil && !os.SameFile(info, stale) {
+		os.Link(broken, path) // Fails if yet another lock was taken
+	}
+}
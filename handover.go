@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/status/monitor"
+	"github.com/status/web"
+)
+
+// handoverEnv is set for a process started to take over from a running
+// server in a zero-downtime restart
+const handoverEnv = "STATUS_HANDOVER"
+
+// Descriptors passed to the new process, after stdin, stdout and stderr
+const (
+	handoverListenerFD = 3 // The listening socket
+	handoverStateFD    = 4 // The monitor's state, written once storage is closed
+	handoverReadyFD    = 5 // Written to when the new process is ready to take over
+)
+
+// handoverTimeout bounds how long a server waits for its replacement to
+// load the configuration and get ready to take over
+const handoverTimeout = 30 * time.Second
+
+// upgrade is a replacement process ready to take over from this one
+type upgrade struct {
+	cmd   *exec.Cmd
+	state *os.File // Where the monitor's state is written for it
+}
+
+// startUpgrade starts the executable at this process's path, which may be
+// a new version, with the same arguments, and passes it the listening
+// socket. It returns once the new process has loaded its configuration
+// and is waiting for this one to close storage, or an error if it exited
+// or timed out first; this process then keeps serving.
+func startUpgrade(server *web.Server) (*upgrade, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := server.ListenerFile()
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+	stateR, stateW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer stateR.Close()
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		stateW.Close()
+		return nil, err
+	}
+	defer readyR.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = []*os.File{listener, stateR, readyW}
+	cmd.Env = append(handoverEnviron(), handoverEnv+"=1")
+	err = cmd.Start()
+	readyW.Close()
+	if err != nil {
+		stateW.Close()
+		return nil, err
+	}
+
+	// The new process writes once it is ready, and the pipe closes without
+	// a write if it exits first
+	readyR.SetReadDeadline(time.Now().Add(handoverTimeout))
+	if _, err := readyR.Read(make([]byte, 1)); err != nil {
+		stateW.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("new process exited before taking over: %s", cmd.ProcessState)
+		}
+		return nil, fmt.Errorf("new process not ready to take over: %w", err)
+	}
+	return &upgrade{cmd: cmd, state: stateW}, nil
+}
+
+// handoverEnviron returns this process's environment for the new one.
+// WATCHDOG_PID names this process, so it is left out for the new one to
+// keep the watchdog fed once it is systemd's main process.
+func handoverEnviron() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "WATCHDOG_PID=") && !strings.HasPrefix(kv, handoverEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
+
+// PID returns the new process's ID
+func (u *upgrade) PID() int {
+	return u.cmd.Process.Pid
+}
+
+// complete hands the monitor's state to the new process, which then opens
+// storage and starts serving. Call it once checks have stopped and storage
+// is closed.
+func (u *upgrade) complete(mon *monitor.Monitor) error {
+	defer u.state.Close()
+	return mon.WriteHandoff(u.state)
+}
+
+// inheritance is what a process started by startUpgrade receives from the
+// process it replaces
+type inheritance struct {
+	listener net.Listener
+	state    *os.File
+	ready    *os.File
+}
+
+// inherit returns what the process being replaced passed to this one, or
+// nil if it was started normally
+func inherit() (*inheritance, error) {
+	if os.Getenv(handoverEnv) == "" {
+		return nil, nil
+	}
+	os.Unsetenv(handoverEnv)
+
+	f := os.NewFile(handoverListenerFD, "listener")
+	listener, err := net.FileListener(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("inherited listener: %w", err)
+	}
+	return &inheritance{
+		listener: listener,
+		state:    os.NewFile(handoverStateFD, "handover state"),
+		ready:    os.NewFile(handoverReadyFD, "handover ready"),
+	}, nil
+}
+
+// takeOver tells the process being replaced that this one is ready, and
+// waits for it to stop checks and close storage. It returns the monitor
+// state it handed over, which is empty if it exited without writing any.
+func (in *inheritance) takeOver() []byte {
+	in.ready.Write([]byte{1})
+	in.ready.Close()
+
+	var state bytes.Buffer
+	io.Copy(&state, in.state)
+	in.state.Close()
+	return state.Bytes()
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// notifyHandover does nothing: there is no SIGUSR2 to ask for a
+// zero-downtime restart on this platform
+func notifyHandover(c chan<- os.Signal) {}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyHandover relays SIGUSR2, which asks for a zero-downtime restart, to c
+func notifyHandover(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGUSR2)
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// incidentSourceConfig marks incidents declared in the config file
+const incidentSourceConfig = "config"
+
+// syncIncidents writes the incidents declared in the config file to
+// storage, keyed by their IDs, so they appear with those created through
+// the API. Declared incidents are records, not events: no notifications
+// are sent, and removing one from the file leaves it in storage.
+func syncIncidents(cfg *config.Config, store *storage.Storage) error {
+	changed := 0
+	for i, declared := range cfg.Incidents {
+		if declared.ID == "" {
+			slog.Warn("Config incident has no id and is not stored", "index", i, "title", declared.Title)
+			continue
+		}
+		// Without created_at an incident keeps the time it was first stored
+		created := time.Now()
+		if existing := store.GetIncident(declared.ID); existing != nil {
+			created = existing.CreatedAt
+		}
+		_, ok, err := store.PutIncident(configIncident(declared, created))
+		if err != nil {
+			return fmt.Errorf("incident %s: %w", declared.ID, err)
+		}
+		if ok {
+			changed++
+		}
+	}
+	if changed > 0 {
+		slog.Info("Incidents from config stored", "changed", changed, "declared", len(cfg.Incidents))
+	}
+	return nil
+}
+
+// configIncident converts a declared incident. Missing times are derived
+// from its updates, falling back to created; the status defaults to
+// resolved when a resolution time is given and investigating otherwise.
+func configIncident(declared config.Incident, created time.Time) storage.Incident {
+	inc := storage.Incident{
+		ID:               declared.ID,
+		Title:            declared.Title,
+		Status:           declared.Status,
+		Severity:         declared.Severity,
+		Message:          declared.Description,
+		AffectedServices: declared.AffectedServices,
+		Source:           incidentSourceConfig,
+		CreatedAt:        declared.CreatedAt,
+		UpdatedAt:        declared.UpdatedAt,
+		ResolvedAt:       declared.ResolvedAt,
+		Updates:          []storage.IncidentUpdate{},
+	}
+	if inc.AffectedServices == nil {
+		inc.AffectedServices = []string{}
+	}
+	if inc.Severity == "" {
+		inc.Severity = "minor"
+	}
+	if inc.Status == "" {
+		inc.Status = "investigating"
+		if inc.ResolvedAt != nil {
+			inc.Status = "resolved"
+		}
+	}
+
+	var first, last time.Time
+	for i, u := range declared.Updates {
+		status := u.Status
+		if status == "" {
+			status = inc.Status
+		}
+		// Stable IDs keep feed entries for updates from changing on reload
+		inc.Updates = append(inc.Updates, storage.IncidentUpdate{
+			ID:        fmt.Sprintf("%s-%d", declared.ID, i+1),
+			Status:    status,
+			Message:   u.Message,
+			CreatedAt: u.Timestamp,
+		})
+		if !u.Timestamp.IsZero() && (first.IsZero() || u.Timestamp.Before(first)) {
+			first = u.Timestamp
+		}
+		if u.Timestamp.After(last) {
+			last = u.Timestamp
+		}
+	}
+
+	if inc.CreatedAt.IsZero() {
+		inc.CreatedAt = first
+	}
+	if inc.CreatedAt.IsZero() {
+		inc.CreatedAt = created
+	}
+	if inc.UpdatedAt.IsZero() {
+		inc.UpdatedAt = inc.CreatedAt
+		if last.After(inc.UpdatedAt) {
+			inc.UpdatedAt = last
+		}
+		if inc.ResolvedAt != nil && inc.ResolvedAt.After(inc.UpdatedAt) {
+			inc.UpdatedAt = *inc.ResolvedAt
+		}
+	}
+	if inc.Status == "resolved" && inc.ResolvedAt == nil {
+		resolved := inc.UpdatedAt
+		inc.ResolvedAt = &resolved
+	}
+	for i := range inc.Updates {
+		if inc.Updates[i].CreatedAt.IsZero() {
+			inc.Updates[i].CreatedAt = inc.CreatedAt
+		}
+	}
+	return inc
+}
+
+// Incident statuses and severities, as the API and the config file use them
+var (
+	incidentStatuses   = []string{"investigating", "identified", "monitoring", "resolved"}
+	incidentSeverities = []string{"minor", "major", "critical"}
+)
+
+// runIncidentList implements "status incident list": it prints the
+// incidents, newest first
+func runIncidentList(args []string) int {
+	fs := flag.NewFlagSet("incident list", flag.ExitOnError)
+	connect := serverFlags(fs)
+	active := fs.Bool("active", false, "Only list unresolved incidents")
+	limit := fs.Int("n", 0, "List at most this many incidents (0 for all)")
+	asJSON := fs.Bool("json", false, "Print the incidents as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s incident list [-active] [-n count] [-json] [flags]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Lists incidents, newest first, from the running server or the database.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	conn, err := connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "incident list: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+	var incidents []storage.Incident
+	if client := conn.client; client != nil {
+		query := url.Values{"limit": {strconv.Itoa(*limit)}}
+		if *active {
+			query.Set("active", "true")
+		}
+		if err := client.do(http.MethodGet, "/api/incidents?"+query.Encode(), nil, &incidents); err != nil {
+			fmt.Fprintf(os.Stderr, "incident list: %v\n", err)
+			return 1
+		}
+	} else {
+		incidents = conn.store.GetIncidents(*limit, *active)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(incidents)
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tSEVERITY\tCREATED\tTITLE")
+	for _, inc := range incidents {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", inc.ID, inc.Status, inc.Severity, inc.CreatedAt.Local().Format("2006-01-02 15:04"), inc.Title)
+	}
+	tw.Flush()
+	return 0
+}
+
+// runIncidentCreate implements "status incident create": it declares an
+// incident through the running server, or in the database when offline
+func runIncidentCreate(args []string) int {
+	fs := flag.NewFlagSet("incident create", flag.ExitOnError)
+	connect := serverFlags(fs)
+	severity := fs.String("severity", "minor", "minor, major or critical")
+	status := fs.String("status", "investigating", "investigating, identified, monitoring or resolved")
+	services := fs.String("services", "", "Comma-separated names of the affected services")
+	message := fs.String("m", "", "First update, in Markdown")
+	asJSON := fs.Bool("json", false, "Print the incident as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s incident create [-severity level] [-services a,b] [-m message] [flags] <title>\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Declares an incident and prints its ID. Through a server, notifications are")
+		fmt.Fprintln(fs.Output(), "sent and open pages update; offline, it is only saved.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	title := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if title == "" {
+		fs.Usage()
+		return 2
+	}
+	if !slices.Contains(incidentSeverities, *severity) {
+		fmt.Fprintf(os.Stderr, "incident create: severity must be one of %s\n", strings.Join(incidentSeverities, ", "))
+		return 2
+	}
+	if !slices.Contains(incidentStatuses, *status) {
+		fmt.Fprintf(os.Stderr, "incident create: status must be one of %s\n", strings.Join(incidentStatuses, ", "))
+		return 2
+	}
+	affected := []string{}
+	for _, name := range strings.Split(*services, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			affected = append(affected, name)
+		}
+	}
+
+	conn, err := connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "incident create: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+	incident := storage.Incident{
+		Title:            title,
+		Status:           *status,
+		Severity:         *severity,
+		Message:          *message,
+		AffectedServices: affected,
+	}
+	var created *storage.Incident
+	if conn.client != nil {
+		err = conn.client.do(http.MethodPost, "/api/incidents", incident, &created)
+	} else {
+		created, err = conn.store.CreateIncident(incident)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "incident create: %v\n", err)
+		return 1
+	}
+	printIncident(*created, *asJSON)
+	return 0
+}
+
+// runIncidentUpdate implements "status incident update": it posts an
+// update to an incident, changing its status or keeping it
+func runIncidentUpdate(args []string) int {
+	return updateIncident("update", args)
+}
+
+// runIncidentResolve implements "status incident resolve"
+func runIncidentResolve(args []string) int {
+	return updateIncident("resolve", args)
+}
+
+// updateIncident runs "status incident update" and "resolve", which only
+// differ in the status they default to
+func updateIncident(name string, args []string) int {
+	fs := flag.NewFlagSet("incident "+name, flag.ExitOnError)
+	connect := serverFlags(fs)
+	message := fs.String("m", "", "Update message, in Markdown")
+	status := "resolved"
+	if name == "update" {
+		fs.StringVar(&status, "status", "", "investigating, identified, monitoring or resolved (default the current status)")
+	}
+	asJSON := fs.Bool("json", false, "Print the incident as JSON")
+	fs.Usage = func() {
+		if name == "resolve" {
+			fmt.Fprintf(fs.Output(), "Usage: %s incident resolve [-m message] [flags] <id>\n\n", os.Args[0])
+			fmt.Fprintln(fs.Output(), "Resolves an incident, with an optional closing message.")
+		} else {
+			fmt.Fprintf(fs.Output(), "Usage: %s incident update [-status status] [-m message] [flags] <id>\n\n", os.Args[0])
+			fmt.Fprintln(fs.Output(), "Posts an update to an incident and changes its status.")
+		}
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	id := fs.Arg(0)
+	if status == "" && *message == "" {
+		fmt.Fprintf(os.Stderr, "incident %s: give a -status, a -m message or both\n", name)
+		return 2
+	}
+	if status != "" && !slices.Contains(incidentStatuses, status) {
+		fmt.Fprintf(os.Stderr, "incident %s: status must be one of %s\n", name, strings.Join(incidentStatuses, ", "))
+		return 2
+	}
+
+	conn, err := connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "incident %s: %v\n", name, err)
+		return 1
+	}
+	defer conn.Close()
+
+	// Updating always sets the status, so keeping it takes the current one
+	path := "/api/incidents/" + url.PathEscape(id)
+	if status == "" {
+		var current *storage.Incident
+		if conn.client != nil {
+			err = conn.client.do(http.MethodGet, path, nil, &current)
+		} else if current = conn.store.GetIncident(id); current == nil {
+			err = fmt.Errorf("no incident %s", id)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "incident %s: %v\n", name, err)
+			return 1
+		}
+		status = current.Status
+	}
+
+	var updated *storage.Incident
+	if conn.client != nil {
+		update := map[string]string{"status": status, "message": *message}
+		err = conn.client.do(http.MethodPatch, path, update, &updated)
+	} else if updated, err = conn.store.UpdateIncident(id, status, *message); err == nil && updated == nil {
+		err = fmt.Errorf("no incident %s", id)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "incident %s: %v\n", name, err)
+		return 1
+	}
+	printIncident(*updated, *asJSON)
+	return 0
+}
+
+// printIncident prints an incident a command created or changed
+func printIncident(inc storage.Incident, asJSON bool) {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(inc)
+		return
+	}
+	fmt.Printf("%s\t%s\t%s\t%s\n", inc.ID, inc.Status, inc.Severity, inc.Title)
+}
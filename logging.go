@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/status/config"
+)
+
+// logLevel is the level of the default logger, changed in place on reload
+var logLevel = new(slog.LevelVar)
+
+// configureLogging makes the default logger, which the log package also
+// writes to, log at the configured level in the configured format on
+// standard error
+func configureLogging(cfg config.LoggingConfig) error {
+	var level slog.Level
+	switch strings.ToLower(cfg.Level) {
+	case "debug":
+		level = slog.LevelDebug
+	case "", "info":
+		level = slog.LevelInfo
+	case "warn", "warning":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		return fmt.Errorf("unknown log level %q (want debug, info, warn or error)", cfg.Level)
+	}
+
+	opts := &slog.HandlerOptions{Level: logLevel}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q (want text or json)", cfg.Format)
+	}
+
+	logLevel.Set(level)
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// fatal logs an error that stops the server from starting, and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
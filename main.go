@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/status/config"
+	"github.com/status/feeds"
 	"github.com/status/monitor"
 	"github.com/status/notify"
 	"github.com/status/storage"
@@ -17,15 +24,50 @@ import (
 )
 
 func main() {
-	// Parse command line flags
-	configPath := flag.String("config", "config.yaml", "Path to configuration file")
-	flag.Parse()
+	os.Exit(runCLI(os.Args[1:]))
+}
+
+// runServe implements "status serve": it runs the status page until
+// interrupted, returning the process exit code
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	watch := fs.Bool("watch", false, "Reload the configuration when the file changes")
+	refresh := fs.Duration("refresh", time.Minute, "How often to fetch a remote configuration (-config URL) for changes (0 disables)")
+
+	// Overrides for per-environment settings; each flag defaults to its
+	// environment variable
+	var overrides config.Overrides
+	fs.IntVar(&overrides.Port, "port", envInt("STATUS_PORT"), "HTTP port, overriding server.port (env STATUS_PORT)")
+	fs.StringVar(&overrides.DataDir, "data-dir", os.Getenv("STATUS_DATA_DIR"), "Data directory, overriding storage.data_dir (env STATUS_DATA_DIR)")
+	fs.StringVar(&overrides.BaseURL, "base-url", os.Getenv("STATUS_BASE_URL"), "Public URL, overriding base_url (env STATUS_BASE_URL)")
+	fs.StringVar(&overrides.APIKey, "api-key", os.Getenv("STATUS_API_KEY"), "Admin API key, overriding api.key (env STATUS_API_KEY)")
+	fs.StringVar(&overrides.LogLevel, "log-level", os.Getenv("STATUS_LOG_LEVEL"), "debug, info, warn or error, overriding logging.level (env STATUS_LOG_LEVEL)")
+	fs.StringVar(&overrides.LogFormat, "log-format", os.Getenv("STATUS_LOG_FORMAT"), "text or json, overriding logging.format (env STATUS_LOG_FORMAT)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [serve] [flags]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Runs the status page server.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Log as the flags ask until the config file is read
+	if err := configureLogging(config.LoggingConfig{Level: overrides.LogLevel, Format: overrides.LogFormat}); err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return 2
+	}
 
 	// Load configuration
-	cfg, err := config.Load(*configPath)
-	if err != nil {
-		log.Printf("Warning: Could not load config file: %v", err)
-		log.Println("Using default configuration with sample services...")
+	var cfg *config.Config
+	f, err := config.ParseFile(*configPath)
+	if err == nil {
+		cfg = f.Config
+	} else {
+		// Only a missing file falls back to the demo; a broken one is fatal
+		if _, statErr := os.Stat(*configPath); statErr == nil || config.IsRemote(*configPath) {
+			fatal("Invalid config file", "path", config.Redact(*configPath), "error", err)
+		}
+		slog.Warn("Could not load config file, using the sample services", "error", err)
 		cfg = config.DefaultConfig()
 		// Add sample services for demo
 		cfg.Services = []config.Service{
@@ -105,97 +147,402 @@ func main() {
 		}
 	}
 
-	// Print startup banner
-	printBanner()
+	overrides.Apply(cfg)
+	if err := configureLogging(cfg.Logging); err != nil {
+		fatal("Invalid logging configuration", "error", err)
+	}
+
+	// Settings that are valid but look like mistakes are logged, not fatal
+	if f != nil {
+		for _, w := range f.Lint() {
+			slog.Warn(w.Message, "file", config.Redact(w.File), "line", w.Line, "field", w.Field)
+		}
+	}
 
-	// Initialize storage
-	store, err := storage.NewStorage(cfg.Storage.DataDir)
+	// The banner is for people reading the terminal, not log collectors
+	if cfg.Logging.Format != "json" {
+		printBanner()
+	}
+
+	// A process started to take over in a zero-downtime restart waits for
+	// the one it replaces to stop checks and close storage
+	inherited, err := inherit()
 	if err != nil {
-		log.Fatalf("Failed to initialize storage: %v", err)
+		fatal("Failed to take over from the previous process", "error", err)
+	}
+	var handoff []byte
+	if inherited != nil {
+		slog.Info("Taking over from the previous process")
+		handoff = inherited.takeOver()
+	}
+
+	// Initialize storage. In HA mode only the leader opens the database;
+	// followers read the snapshots it publishes.
+	var store *storage.Storage
+	var node *haNode
+	if cfg.HA.Enabled {
+		node, err = joinCluster(cfg)
+		if err != nil {
+			fatal("Failed to join the HA cluster", "data_dir", cfg.Storage.DataDir, "error", err)
+		}
+		store = node.store
+	} else {
+		store, err = storage.NewStorage(cfg.Storage.DataDir)
+		if err != nil {
+			// Keep the page up from the checks alone rather than take it down
+			slog.Error("Failed to initialize storage, serving live checks without incidents, maintenance or history", "data_dir", cfg.Storage.DataDir, "error", err)
+			store = storage.Unavailable(cfg.Storage.DataDir, err)
+		}
+	}
+	if store.Outage() == nil {
+		slog.Info("Storage initialized", "data_dir", cfg.Storage.DataDir)
 	}
-	log.Printf("Storage initialized at: %s", cfg.Storage.DataDir)
 
 	// Initialize notifier with webhooks
-	var webhookConfigs []notify.WebhookConfig
-	for _, wh := range cfg.Webhooks {
-		webhookConfigs = append(webhookConfigs, notify.WebhookConfig{
-			ID:      wh.ID,
-			Name:    wh.Name,
-			URL:     wh.URL,
-			Type:    wh.Type,
-			Events:  wh.Events,
-			Headers: wh.Headers,
-			Enabled: wh.Enabled,
-		})
+	webhookConfigs, err := configWebhooks(cfg)
+	if err != nil {
+		fatal("Invalid webhook configuration", "error", err)
 	}
 	notifier := notify.NewNotifier(webhookConfigs)
-	log.Printf("Webhooks configured: %d", len(webhookConfigs))
+	notifier.SetRetryPolicy(notify.RetryPolicy{
+		MaxAttempts:    cfg.Notifications.Retry.MaxAttempts,
+		InitialBackoff: cfg.Notifications.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Notifications.Retry.MaxBackoff,
+	})
+	notifier.SetBreakerPolicy(notify.BreakerPolicy{
+		Threshold: cfg.Notifications.Breaker.Threshold,
+		Cooldown:  cfg.Notifications.Breaker.Cooldown,
+	})
+	notifier.SetDeadLetterStore(store)
+	if hb := cfg.Notifications.Heartbeat; hb.Interval > 0 && hb.Mode != "" && hb.Mode != notify.HeartbeatProbe && hb.Mode != notify.HeartbeatMessage {
+		fatal("Invalid heartbeat mode (want probe or message)", "mode", hb.Mode)
+	}
+	notifier.SetDedupWindow(cfg.Notifications.DedupWindow)
+	loc, err := cfg.Location()
+	if err != nil {
+		fatal("Invalid timezone", "timezone", cfg.Timezone, "error", err)
+	}
+	notifier.SetLocation(loc)
+	if cfg.Notifications.DryRun {
+		notifier.SetDryRun(true)
+		slog.Info("Notifications in dry-run mode: payloads are logged, not sent")
+	}
+
+	// Routing rules map events to specific webhooks
+	routes := notificationRoutes(cfg)
+	if err := notifier.SetRoutes(routes, serviceGroups(cfg)); err != nil {
+		fatal("Invalid notification route", "error", err)
+	}
+	if len(routes) > 0 {
+		slog.Info("Notification routes configured", "routes", len(routes))
+	}
+
+	// Add webhooks created at runtime via the admin API
+	stored := 0
+	for _, wh := range store.GetWebhooks() {
+		notifier.AddWebhook(notify.WebhookFromStorage(wh))
+		stored++
+	}
+	slog.Info("Webhooks configured", "webhooks", len(webhookConfigs)+stored, "from_storage", stored)
+
+	// Email verified subscribers when SMTP is configured
+	if cfg.Email.Enabled {
+		notifier.EnableEmail(emailConfig(cfg), store)
+		slog.Info("Email notifications enabled", "host", cfg.Email.Host, "port", cfg.Email.Port)
+	}
+
+	// Push to subscribed browsers via Web Push
+	if cfg.Push.Enabled {
+		enablePush(cfg, store, notifier)
+	}
 
 	// Create monitor with storage for persistence
 	mon := monitor.NewMonitor(cfg.Services, store)
+	if len(handoff) > 0 {
+		if err := mon.ReadHandoff(bytes.NewReader(handoff)); err != nil {
+			slog.Warn("Ignoring the check state handed over", "error", err)
+		}
+	}
+
+	// Add services found in Kubernetes and other systems as they appear
+	disc := startDiscovery(cfg)
 
-	// Start monitoring
-	log.Printf("Starting health monitors for %d services...", len(cfg.Services))
-	mon.Start()
+	if lang := cfg.Feeds.Language; lang != "" && !feeds.HasCatalog(lang) {
+		slog.Warn("No feed translations for the language, using English labels", "language", lang)
+	}
 
 	// Create and start web server
 	server := web.NewServer(cfg, mon, store, notifier)
+	if inherited != nil {
+		server.SetListener(inherited.listener)
+		if addr, ok := inherited.listener.Addr().(*net.TCPAddr); ok && addr.Port != cfg.Server.Port {
+			slog.Warn("Keeping the handed over socket; restart to listen on the configured port", "listening", addr.Port, "port", cfg.Server.Port)
+		}
+	}
+
+	// lead starts what only the leader does: storing config incidents and
+	// maintenance, checks, notifications and the server's background tasks.
+	// Without HA this instance always leads.
+	lead := func() {
+		if store.Outage() != nil {
+			slog.Warn("Config incidents and maintenance are not stored while storage is unavailable")
+		} else {
+			if err := syncIncidents(cfg, store); err != nil {
+				fatal("Failed to store config incidents", "error", err)
+			}
+			if err := syncMaintenance(cfg, store, time.Now()); err != nil {
+				fatal("Failed to store config maintenance", "error", err)
+			}
+			if cfg.Push.Enabled && notifier.VAPIDPublicKey() == "" {
+				enablePush(cfg, store, notifier)
+			}
+		}
+		if hb := cfg.Notifications.Heartbeat; hb.Interval > 0 {
+			notifier.StartHeartbeat(notify.HeartbeatConfig{Interval: hb.Interval, Mode: hb.Mode}, cfg.BaseURL)
+		}
+
+		// Notify on service status changes
+		if sc := cfg.Notifications.StatusChanges; sc.Enabled {
+			notifier.WatchMonitor(mon, notify.StatusAlertConfig{
+				Threshold: sc.Threshold,
+				Cooldown:  sc.Cooldown,
+				Degraded:  sc.Degraded,
+			}, cfg.BaseURL)
+		}
+
+		// Notify when TLS certificates approach expiry
+		if cc := cfg.Notifications.Certificates; cc.Enabled {
+			notifier.WatchCertificates(mon, cc.Thresholds, cfg.BaseURL)
+		}
+
+		// Start monitoring
+		slog.Info("Starting health monitors", "services", len(cfg.Services))
+		mon.Start()
+		server.Lead()
+	}
+
+	var promoted <-chan struct{}
+	var lost <-chan error
+	if node == nil || node.leading {
+		lead()
+	} else {
+		server.SetFollower(node.lease.URL)
+		slog.Info("Serving reads from the leader's snapshots", "leader", node.lease.Holder)
+	}
+	if node != nil {
+		promoted, lost = node.Promoted(), node.Lost()
+		go node.run(mon, server)
+	}
 
 	// Handle graceful shutdown
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		if err := server.Start(); err != nil {
-			log.Printf("Server error: %v", err)
+		if err := server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server error", "error", err)
 			done <- syscall.SIGTERM
 		}
 	}()
 
-	log.Printf("Status page is running at %s", cfg.BaseURL)
-	log.Println("")
-	log.Println("Available endpoints:")
-	log.Println("  GET  /                    - Status page")
-	log.Println("  GET  /api/summary         - Summary (Cloudflare-style)")
-	log.Println("  GET  /api/status          - All service statuses")
-	log.Println("  GET  /api/components      - Component list")
-	log.Println("  GET  /api/incidents       - Incident list")
-	log.Println("  POST /api/incidents       - Create incident (requires API key)")
-	log.Println("  GET  /api/maintenance     - Scheduled maintenance")
-	log.Println("  GET  /api/history         - 90-day history")
-	log.Println("  GET  /api/metrics         - System metrics")
-	log.Println("  GET  /feed/rss            - RSS feed")
-	log.Println("  GET  /feed/atom           - Atom feed")
-	log.Println("  GET  /feed/json           - JSON feed")
-	log.Println("  WS   /ws                  - WebSocket updates")
-	log.Println("")
+	slog.Info("Status page is running", "url", cfg.BaseURL)
+	if cfg.Logging.Format != "json" {
+		printEndpoints()
+	}
 	if cfg.API.Key != "" {
-		log.Printf("API Key configured for admin endpoints")
-	} else {
-		log.Printf("WARNING: No API key configured. Admin endpoints are open.")
+		slog.Info("API key configured for admin endpoints")
+	}
+
+	// Reload the configuration on SIGHUP, or when the file changes with -watch
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	// Hand over to a new process, such as an upgraded binary, on SIGUSR2
+	usr2 := make(chan os.Signal, 1)
+	notifyHandover(usr2)
+	var next *upgrade
+	changed := make(chan struct{}, 1)
+	reload := func() {
+		next, err := reloadConfig(*configPath, overrides, disc.Services(), cfg, mon, notifier, server, store)
+		if err != nil {
+			slog.Error("Config reload failed, keeping the current configuration", "error", err)
+		}
+		cfg = next
 	}
-	log.Println("")
-	log.Println("Press Ctrl+C to stop")
+	switch {
+	case config.IsRemote(*configPath) && *refresh > 0:
+		go watchConfig(*configPath, *refresh, changed)
+		slog.Info("Fetching the config for changes", "source", config.Redact(*configPath), "interval", *refresh)
+	case *watch && !config.IsRemote(*configPath):
+		go watchConfig(*configPath, configWatchInterval, changed)
+		slog.Info("Watching the config for changes", "path", *configPath)
+	}
+
+	// Tell systemd once the server is listening, and keep its watchdog fed
+	// while checks and the maintenance scheduler are making progress
+	ready := server.Ready()
+	stopWatchdog := make(chan struct{})
+	defer close(stopWatchdog)
+
+	// Roll recurring maintenance windows forward
+	maintenanceTicker := time.NewTicker(maintenanceSyncInterval)
+	defer maintenanceTicker.Stop()
 
 	// Wait for shutdown signal
-	<-done
-	log.Println("Shutting down...")
+	exitCode := 0
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		case <-ready:
+			ready = nil
+			notifySystemd(fmt.Sprintf("READY=1\nSTATUS=Monitoring %d services", len(cfg.Services)))
+			if timeout := watchdogInterval(); timeout > 0 {
+				go runWatchdog(timeout, healthCheck(mon, server), stopWatchdog)
+				slog.Info("systemd watchdog enabled", "timeout", timeout)
+			}
+		case now := <-maintenanceTicker.C:
+			if store.IsReplica() || store.Outage() != nil {
+				continue
+			}
+			if err := syncMaintenance(cfg, store, now); err != nil {
+				slog.Error("Config maintenance not stored", "error", err)
+			}
+		case <-promoted:
+			lead()
+		case err := <-lost:
+			// Exit rather than keep writing; a restart rejoins as a follower
+			slog.Error("Lost the leader lease, stopping", "error", err)
+			exitCode = 1
+			running = false
+		case <-usr2:
+			if node != nil {
+				slog.Warn("Zero-downtime restarts are not supported in HA mode; restart one instance at a time")
+				continue
+			}
+			slog.Info("Starting a new process to take over")
+			u, err := startUpgrade(server)
+			if err != nil {
+				slog.Error("Handover failed, still serving", "error", err)
+				continue
+			}
+			next = u
+			running = false
+		case <-hup:
+			reload()
+		case <-changed:
+			reload()
+		case <-disc.Changed():
+			applyDiscovered(cfg, disc.Services(), mon, notifier, server)
+		}
+	}
+	if next != nil {
+		// The new process is systemd's main process from here on
+		slog.Info("Handing over to the new process", "pid", next.PID())
+		notifySystemd(fmt.Sprintf("RELOADING=1\nMAINPID=%d", next.PID()))
+	}
 
-	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Graceful shutdown: stop accepting connections and let requests and
+	// checks in progress finish and save their results, then close storage.
+	// The drain delay comes out of the same deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
+	if next == nil {
+		slog.Info("Shutting down")
+		server.Drain()
+		notifySystemd("STOPPING=1")
+		waitDrain(ctx, cfg.Server.DrainDelay, done)
+	}
 
-	mon.Stop()
+	checksStopped := make(chan error, 1)
+	go func() {
+		checksStopped <- mon.Stop(ctx)
+	}()
 	if err := server.Stop(ctx); err != nil {
-		log.Printf("Server shutdown error: %v", err)
+		slog.Error("Server shutdown error", "error", err)
+	}
+	if err := <-checksStopped; err != nil {
+		slog.Warn("Abandoned checks still running at the shutdown deadline", "error", err)
+	}
+	if node != nil {
+		node.halt()
 	}
 
 	// Close storage
 	if err := store.Close(); err != nil {
-		log.Printf("Storage close error: %v", err)
+		slog.Error("Storage close error", "error", err)
+	}
+	if node != nil {
+		node.leave()
 	}
+	if next != nil {
+		if err := next.complete(mon); err != nil {
+			slog.Error("Check state not handed over", "error", err)
+		}
+	}
+
+	slog.Info("Server stopped")
+	return exitCode
+}
+
+// enablePush turns on Web Push with the configured VAPID keys, or those in
+// storage, generating and storing a pair if there are none. A follower in
+// HA mode cannot store keys, and leaves Web Push off until the leader has
+// generated them; so does a server whose storage is unavailable.
+func enablePush(cfg *config.Config, store *storage.Storage, notifier *notify.Notifier) {
+	publicKey, privateKey := cfg.Push.VAPIDPublicKey, cfg.Push.VAPIDPrivateKey
+	if privateKey == "" {
+		publicKey, privateKey = store.GetSetting("vapid_public_key"), store.GetSetting("vapid_private_key")
+	}
+	if privateKey == "" && store.IsReplica() {
+		slog.Warn("Web Push is off until the leader has generated VAPID keys; set push.vapid_private_key to share them")
+		return
+	}
+	if privateKey == "" && store.Outage() != nil {
+		slog.Warn("Web Push is off while storage is unavailable; set push.vapid_private_key to keep it on")
+		return
+	}
+	if privateKey == "" {
+		var err error
+		publicKey, privateKey, err = notify.GenerateVAPIDKeys()
+		if err != nil {
+			fatal("Failed to generate VAPID keys", "error", err)
+		}
+		if err := store.SetSetting("vapid_public_key", publicKey); err != nil {
+			fatal("Failed to store VAPID keys", "error", err)
+		}
+		if err := store.SetSetting("vapid_private_key", privateKey); err != nil {
+			fatal("Failed to store VAPID keys", "error", err)
+		}
+		slog.Info("Generated VAPID keys for Web Push")
+	}
+	if err := notifier.EnablePush(notify.PushConfig{
+		Subject:       cfg.Push.Subject,
+		PublicKey:     publicKey,
+		PrivateKey:    privateKey,
+		TTL:           cfg.Push.TTL,
+		EndpointHosts: cfg.Push.EndpointHosts,
+	}, store); err != nil {
+		fatal("Invalid Web Push configuration", "error", err)
+	}
+	slog.Info("Web Push notifications enabled")
+}
 
-	log.Println("Server stopped")
+// waitDrain keeps serving for delay after /readyz starts failing, so load
+// balancers and orchestrators notice before connections are refused. A
+// second signal or the shutdown deadline cuts it short.
+func waitDrain(ctx context.Context, delay time.Duration, done <-chan os.Signal) {
+	if delay <= 0 {
+		return
+	}
+	slog.Info("Draining before closing connections", "delay", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-done:
+	case <-ctx.Done():
+	}
 }
 
 func printBanner() {
@@ -214,5 +561,43 @@ func printBanner() {
 ║                                                                               ║
 ╚═══════════════════════════════════════════════════════════════════════════════╝
 `
-	log.Println(banner)
+	fmt.Fprintln(os.Stderr, banner)
+}
+
+// printEndpoints lists the main endpoints for whoever started the server
+func printEndpoints() {
+	fmt.Fprint(os.Stderr, `
+Available endpoints:
+  GET  /                    - Status page
+  GET  /api/summary         - Summary (Cloudflare-style)
+  GET  /api/status          - All service statuses
+  GET  /api/components      - Component list
+  GET  /api/incidents       - Incident list
+  POST /api/incidents       - Create incident (requires API key)
+  GET  /api/maintenance     - Scheduled maintenance
+  GET  /api/history         - 90-day history
+  GET  /api/metrics         - System metrics
+  GET  /metrics             - Prometheus metrics
+  GET  /feed/rss            - RSS feed
+  GET  /feed/atom           - Atom feed
+  GET  /feed/json           - JSON feed
+  WS   /ws                  - WebSocket updates
+  GET  /readyz              - Readiness (503 while shutting down)
+
+Press Ctrl+C to stop
+`)
+}
+
+// envInt reads an integer environment variable, exiting if it is malformed
+func envInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid %s %q: %v\n", name, value, err)
+		os.Exit(2)
+	}
+	return n
 }
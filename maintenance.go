@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// maintenanceSourceConfig marks maintenance windows declared in the config file
+const maintenanceSourceConfig = "config"
+
+// maintenanceHorizon is how far ahead occurrences of recurring windows are
+// stored; the next occurrence is always stored, however far away
+const maintenanceHorizon = 30 * 24 * time.Hour
+
+// maintenanceSyncInterval is how often recurring windows are rolled forward
+const maintenanceSyncInterval = time.Hour
+
+// syncMaintenance writes the maintenance windows declared in the config
+// file to storage, where the scheduler starts and completes them like
+// those created through the API. Upcoming windows that are no longer
+// declared are removed; past ones are kept as history.
+func syncMaintenance(cfg *config.Config, store *storage.Storage, now time.Time) error {
+	loc, err := cfg.Location()
+	if err != nil {
+		return err
+	}
+
+	declared := make(map[string]bool)
+	changed := 0
+	for i, window := range cfg.Maintenance {
+		if window.ID == "" || window.Start.IsZero() || window.Length() <= 0 {
+			slog.Warn("Config maintenance window needs an id, a start and an end or duration, and is not stored", "index", i, "title", window.Title)
+			continue
+		}
+		occurrences, err := maintenanceOccurrences(window, loc, now)
+		if err != nil {
+			return fmt.Errorf("maintenance %s: %w", window.ID, err)
+		}
+		for _, m := range occurrences {
+			declared[m.ID] = true
+			ok, err := store.PutMaintenance(m)
+			if err != nil {
+				return fmt.Errorf("maintenance %s: %w", m.ID, err)
+			}
+			if ok {
+				changed++
+			}
+		}
+	}
+
+	removed := 0
+	for _, m := range store.GetMaintenance(true) {
+		if m.Source == maintenanceSourceConfig && m.Status == "scheduled" && !declared[m.ID] && m.ScheduledStart.After(now) {
+			if store.DeleteMaintenance(m.ID) {
+				removed++
+			}
+		}
+	}
+
+	if changed > 0 || removed > 0 {
+		slog.Info("Maintenance from config stored", "changed", changed, "removed", removed, "declared", len(cfg.Maintenance))
+	}
+	return nil
+}
+
+// maintenanceOccurrences returns the stored form of a declared window: the
+// window itself, or for a recurring one each occurrence that has not ended
+// and starts within maintenanceHorizon of now.
+func maintenanceOccurrences(window config.MaintenanceWindow, loc *time.Location, now time.Time) ([]storage.Maintenance, error) {
+	length := window.Length()
+	occurrence := func(id string, start time.Time) storage.Maintenance {
+		affected := window.AffectedServices
+		if affected == nil {
+			affected = []string{}
+		}
+		return storage.Maintenance{
+			ID:               id,
+			Title:            window.Title,
+			Description:      window.Description,
+			AffectedServices: affected,
+			ScheduledStart:   start,
+			ScheduledEnd:     start.Add(length),
+			Source:           maintenanceSourceConfig,
+		}
+	}
+
+	if window.Recurrence == "" {
+		return []storage.Maintenance{occurrence(window.ID, window.Start)}, nil
+	}
+
+	rule, err := config.ParseRecurrence(window.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	var occurrences []storage.Maintenance
+	horizon := now.Add(maintenanceHorizon)
+	rule.Each(window.Start, loc, func(start time.Time) bool {
+		if !start.Add(length).After(now) {
+			return true
+		}
+		if start.After(horizon) && len(occurrences) > 0 {
+			return false
+		}
+		id := fmt.Sprintf("%s-%s", window.ID, start.Format("20060102T1504"))
+		occurrences = append(occurrences, occurrence(id, start))
+		return true
+	})
+	return occurrences, nil
+}
+
+// runMaintenanceList implements "status maintenance list": it prints the
+// maintenance windows in storage
+func runMaintenanceList(args []string) int {
+	fs := flag.NewFlagSet("maintenance list", flag.ExitOnError)
+	open := storageFlags(fs, false)
+	upcoming := fs.Bool("upcoming", false, "Only list windows that have not ended")
+	asJSON := fs.Bool("json", false, "Print the windows as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s maintenance list [-upcoming] [-json] [-config path | -data-dir dir]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Lists the maintenance windows in the database.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	store, err := open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "maintenance list: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	windows := store.GetMaintenance(*upcoming)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(windows)
+		return 0
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tSTATUS\tSTART\tEND\tTITLE")
+	for _, m := range windows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", m.ID, m.Status, m.ScheduledStart.Local().Format("2006-01-02 15:04"), m.ScheduledEnd.Local().Format("2006-01-02 15:04"), m.Title)
+	}
+	tw.Flush()
+	return 0
+}
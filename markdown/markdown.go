@@ -0,0 +1,174 @@
+// Package markdown renders the Markdown subset used in incident messages to
+// HTML. Output is safe to embed: all text is escaped, only a fixed set of
+// tags is produced and links are limited to http, https, mailto and
+// site-relative URLs.
+//
+// Supported: paragraphs (single newlines become <br>), # headings, - and 1.
+// lists, > quotes, ``` fenced code, `code`, **bold**, *italic*, _italic_,
+// [links](https://example.com) and backslash escapes.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedRe = regexp.MustCompile(`^\s*\d{1,9}[.)]\s+(.*)$`)
+	quoteRe   = regexp.MustCompile(`^\s*>\s?(.*)$`)
+)
+
+// ToHTML renders Markdown source to sanitized HTML
+func ToHTML(src string) string {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	var sb strings.Builder
+	var para []string
+
+	flush := func() {
+		if len(para) > 0 {
+			sb.WriteString("<p>" + strings.Join(para, "<br>\n") + "</p>\n")
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			sb.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case headingRe.MatchString(trimmed):
+			flush()
+			m := headingRe.FindStringSubmatch(trimmed)
+			tag := "h" + string(rune('0'+len(m[1])))
+			sb.WriteString("<" + tag + ">" + inline(m[2]) + "</" + tag + ">\n")
+
+		case bulletRe.MatchString(line), orderedRe.MatchString(line):
+			flush()
+			re, tag := bulletRe, "ul"
+			if !bulletRe.MatchString(line) {
+				re, tag = orderedRe, "ol"
+			}
+			sb.WriteString("<" + tag + ">\n")
+			for ; i < len(lines) && re.MatchString(lines[i]); i++ {
+				sb.WriteString("<li>" + inline(re.FindStringSubmatch(lines[i])[1]) + "</li>\n")
+			}
+			i--
+			sb.WriteString("</" + tag + ">\n")
+
+		case quoteRe.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && quoteRe.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quoteRe.FindStringSubmatch(lines[i])[1])
+			}
+			i--
+			sb.WriteString("<blockquote>\n" + ToHTML(strings.Join(quoted, "\n")) + "</blockquote>\n")
+
+		default:
+			para = append(para, inline(trimmed))
+		}
+	}
+	flush()
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// inline renders emphasis, code spans, links and escapes within one line
+func inline(s string) string {
+	var sb strings.Builder
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_[]()#+-.!>", s[i+1]) >= 0:
+			sb.WriteString(html.EscapeString(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				sb.WriteString("<code>" + html.EscapeString(s[i+1:i+1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+
+		case c == '[':
+			if text, href, n, ok := link(s[i:]); ok {
+				sb.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener">` + inline(text) + "</a>")
+				i += n
+				continue
+			}
+
+		case (c == '*' || c == '_') && i+1 < len(s) && s[i+1] == c:
+			delim := s[i : i+2]
+			if end := strings.Index(s[i+2:], delim); end > 0 {
+				sb.WriteString("<strong>" + inline(s[i+2:i+2+end]) + "</strong>")
+				i += end + 4
+				continue
+			}
+
+		case (c == '*' || c == '_') && i+1 < len(s) && s[i+1] != ' ' && (c == '*' || i == 0 || !isWordByte(s[i-1])):
+			if end := strings.IndexByte(s[i+1:], c); end > 0 && s[i+end] != ' ' {
+				after := i + end + 2
+				if c == '*' || after >= len(s) || !isWordByte(s[after]) {
+					sb.WriteString("<em>" + inline(s[i+1:i+1+end]) + "</em>")
+					i = after
+					continue
+				}
+			}
+		}
+
+		sb.WriteString(html.EscapeString(s[i : i+1]))
+		i++
+	}
+
+	return sb.String()
+}
+
+// link parses [text](href) at the start of s, returning its length. ok is
+// false if s does not start with a link to an allowed URL.
+func link(s string) (text, href string, n int, ok bool) {
+	mid := strings.Index(s, "](")
+	if mid < 0 {
+		return "", "", 0, false
+	}
+	end := strings.IndexByte(s[mid+2:], ')')
+	if end < 0 {
+		return "", "", 0, false
+	}
+	text, href = s[1:mid], strings.TrimSpace(s[mid+2:mid+2+end])
+	if text == "" || !safeURL(href) {
+		return "", "", 0, false
+	}
+	return text, href, mid + 3 + end, true
+}
+
+// safeURL allows http(s) and mailto links and site-relative paths
+func safeURL(href string) bool {
+	lower := strings.ToLower(href)
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"), strings.HasPrefix(lower, "mailto:"):
+		return true
+	case strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//"):
+		return true
+	}
+	return false
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// handoffStatus is a service's state as passed to a process taking over
+// from this one, with the fields ServiceStatus leaves out of its JSON
+type handoffStatus struct {
+	ServiceStatus
+	CertWarnDays int `json:"cert_warn_days,omitempty"`
+}
+
+// WriteHandoff writes every service's current state for a process taking
+// over from this one in a zero-downtime restart. Storage has the check
+// history too, but not certificate expiry or another page's components,
+// and the latest checks may not have reached it.
+func (m *Monitor) WriteHandoff(w io.Writer) error {
+	statuses := m.GetAllStatuses()
+	handoff := make([]handoffStatus, len(statuses))
+	for i, status := range statuses {
+		handoff[i] = handoffStatus{ServiceStatus: *status, CertWarnDays: status.CertWarnDays}
+	}
+	return json.NewEncoder(w).Encode(handoff)
+}
+
+// ReadHandoff takes over the state written by WriteHandoff for services
+// that are still configured. Check results replace what storage had where
+// they are newer. The configuration's name, group and description are
+// kept. Call it before Start.
+func (m *Monitor) ReadHandoff(r io.Reader) error {
+	var handoff []handoffStatus
+	if err := json.NewDecoder(r).Decode(&handoff); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, h := range handoff {
+		status, ok := m.statuses[h.Name]
+		if !ok {
+			continue
+		}
+		// Certificate expiry and components are only known in memory
+		status.CertExpiry = h.CertExpiry
+		status.CertWarnDays = h.CertWarnDays
+		status.Components = h.Components
+		if !h.LastCheck.After(status.LastCheck) {
+			continue
+		}
+		status.Status = h.Status
+		status.ResponseTime = h.ResponseTime
+		status.ResponseTimeMs = h.ResponseTimeMs
+		status.StatusCode = h.StatusCode
+		status.LastCheck = h.LastCheck
+		status.Uptime = h.Uptime
+		status.ErrorMessage = h.ErrorMessage
+		status.History = h.History
+		if len(status.History) > m.maxHistory {
+			status.History = status.History[len(status.History)-m.maxHistory:]
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"os/exec"
@@ -31,6 +32,7 @@ const (
 type ServiceStatus struct {
 	Name           string        `json:"name"`
 	Group          string        `json:"group"`
+	Order          int           `json:"order"`
 	URL            string        `json:"url"`
 	Description    string        `json:"description"`
 	Status         Status        `json:"status"`
@@ -41,6 +43,9 @@ type ServiceStatus struct {
 	Uptime         float64       `json:"uptime"` // percentage
 	ErrorMessage   string        `json:"error_message,omitempty"`
 	History        []HistoryPoint `json:"history"`
+	CertExpiry     *time.Time    `json:"cert_expiry,omitempty"` // TLS checks: leaf certificate NotAfter
+	CertWarnDays   int           `json:"-"`                     // TLS checks: warning window in days
+	Components     []Component   `json:"components,omitempty"`  // Statuspage checks: the other page's components
 }
 
 // HistoryPoint represents a single check result
@@ -63,8 +68,16 @@ type Monitor struct {
 	cancel      context.CancelFunc
 	maxHistory  int
 	storage     *storage.Storage
+	checks      map[string]context.CancelFunc // Running check loops by service
+	started     map[string]time.Time          // When each running check loop started
+	running     bool                          // Start has been called
+	loops       sync.WaitGroup                // Running check loops, waited for by Stop
 }
 
+// stallMargin is how much longer than two intervals plus its timeout a
+// check loop may go without completing a check before Stalled reports it
+const stallMargin = 30 * time.Second
+
 // NewMonitor creates a new monitor instance
 func NewMonitor(services []config.Service, store *storage.Storage) *Monitor {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -97,6 +110,8 @@ func NewMonitor(services []config.Service, store *storage.Storage) *Monitor {
 		cancel:     cancel,
 		maxHistory: 90, // Keep 90 data points (e.g., 90 checks)
 		storage:    store,
+		checks:     make(map[string]context.CancelFunc),
+		started:    make(map[string]time.Time),
 	}
 
 	// Load persisted check history if available
@@ -107,40 +122,7 @@ func NewMonitor(services []config.Service, store *storage.Storage) *Monitor {
 
 	// Initialize statuses
 	for _, svc := range services {
-		status := &ServiceStatus{
-			Name:        svc.Name,
-			Group:       svc.Group,
-			URL:         svc.URL,
-			Description: svc.Description,
-			Status:      StatusUnknown,
-			LastCheck:   time.Time{},
-			Uptime:      100.0,
-			History:     make([]HistoryPoint, 0, m.maxHistory),
-		}
-
-		// Restore persisted history if available
-		if persisted, ok := persistedHistory[svc.Name]; ok && persisted != nil {
-			for _, cp := range persisted.History {
-				status.History = append(status.History, HistoryPoint{
-					Timestamp:      cp.Timestamp,
-					ResponseTimeMs: cp.ResponseTimeMs,
-					Status:         Status(cp.Status),
-					StatusCode:     cp.StatusCode,
-				})
-			}
-			status.Uptime = persisted.Uptime
-			status.LastCheck = persisted.LastCheck
-			status.ErrorMessage = persisted.ErrorMessage
-			if len(status.History) > 0 {
-				lastPoint := status.History[len(status.History)-1]
-				status.Status = lastPoint.Status
-				status.ResponseTimeMs = lastPoint.ResponseTimeMs
-				status.ResponseTime = time.Duration(lastPoint.ResponseTimeMs) * time.Millisecond
-				status.StatusCode = lastPoint.StatusCode
-			}
-		}
-
-		m.statuses[svc.Name] = status
+		m.statuses[svc.Name] = m.newServiceStatus(svc, persistedHistory[svc.Name])
 	}
 
 	return m
@@ -148,14 +130,94 @@ func NewMonitor(services []config.Service, store *storage.Storage) *Monitor {
 
 // Start begins monitoring all services
 func (m *Monitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return
+	}
+	m.running = true
 	for _, svc := range m.services {
-		go m.monitorService(svc)
+		m.startService(svc)
 	}
 }
 
-// Stop stops all monitoring goroutines
-func (m *Monitor) Stop() {
-	m.cancel()
+// startService starts a service's check loop. m.mu must be held.
+func (m *Monitor) startService(svc config.Service) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.checks[svc.Name] = cancel
+	m.started[svc.Name] = time.Now()
+	m.loops.Add(1)
+	go m.monitorService(ctx, svc)
+}
+
+// Stalled returns the services whose check loop has not completed a check
+// in two intervals plus the timeout (and stallMargin): a check is hanging
+// or the loop has died
+func (m *Monitor) Stalled(now time.Time) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var stalled []string
+	for _, svc := range m.services {
+		started, running := m.started[svc.Name]
+		status, ok := m.statuses[svc.Name]
+		if !running || !ok {
+			continue
+		}
+		last := status.LastCheck
+		if last.Before(started) {
+			last = started
+		}
+		if now.Sub(last) > 2*svc.Interval+svc.Timeout+stallMargin {
+			stalled = append(stalled, svc.Name)
+		}
+	}
+	return stalled
+}
+
+// Stop stops the check loops and waits for checks in progress to finish
+// and record their results. Checks still running when ctx is done are
+// abandoned and their results discarded, and Stop returns ctx's error.
+func (m *Monitor) Stop(ctx context.Context) error {
+	defer m.cancel()
+
+	m.mu.Lock()
+	m.running = false
+	for name := range m.checks {
+		m.stopService(name)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.loops.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CheckOnce runs every service's check once, concurrently, without starting
+// the check loops, and returns the resulting statuses in config order
+func (m *Monitor) CheckOnce() []*ServiceStatus {
+	m.mu.RLock()
+	services := append([]config.Service(nil), m.services...)
+	m.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, svc := range services {
+		wg.Add(1)
+		go func(svc config.Service) {
+			defer wg.Done()
+			m.checkService(svc)
+		}(svc)
+	}
+	wg.Wait()
+	return m.GetAllStatuses()
 }
 
 // Subscribe returns a channel that receives status updates
@@ -185,8 +247,13 @@ func (m *Monitor) GetAllStatuses() []*ServiceStatus {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	// Iterate in config order so callers get a stable ordering
 	statuses := make([]*ServiceStatus, 0, len(m.statuses))
-	for _, status := range m.statuses {
+	for _, svc := range m.services {
+		status, ok := m.statuses[svc.Name]
+		if !ok {
+			continue
+		}
 		// Create a copy
 		s := *status
 		s.History = make([]HistoryPoint, len(status.History))
@@ -216,7 +283,17 @@ func (m *Monitor) GetOverallStatus() Status {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	total := len(m.statuses)
+	statuses := make([]*ServiceStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		statuses = append(statuses, status)
+	}
+	return OverallStatus(statuses)
+}
+
+// OverallStatus summarizes the statuses of a set of services, as
+// GetOverallStatus does for all of them
+func OverallStatus(statuses []*ServiceStatus) Status {
+	total := len(statuses)
 	if total == 0 {
 		return StatusOperational
 	}
@@ -224,7 +301,7 @@ func (m *Monitor) GetOverallStatus() Status {
 	downCount := 0
 	degradedCount := 0
 
-	for _, status := range m.statuses {
+	for _, status := range statuses {
 		switch status.Status {
 		case StatusDown:
 			downCount++
@@ -246,8 +323,10 @@ func (m *Monitor) GetOverallStatus() Status {
 	return StatusOperational
 }
 
-// monitorService continuously checks a single service
-func (m *Monitor) monitorService(svc config.Service) {
+// monitorService continuously checks a single service until ctx is done
+func (m *Monitor) monitorService(ctx context.Context, svc config.Service) {
+	defer m.loops.Done()
+
 	// Initial check
 	m.checkService(svc)
 
@@ -256,7 +335,7 @@ func (m *Monitor) monitorService(svc config.Service) {
 
 	for {
 		select {
-		case <-m.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
 			m.checkService(svc)
@@ -307,9 +386,32 @@ func (m *Monitor) checkService(svc config.Service) {
 		m.checkMySQL(svc)
 	case config.CheckPostgres:
 		m.checkPostgres(svc)
+	case config.CheckStatusPage:
+		m.checkStatusPage(svc)
 	default:
 		m.checkHTTP(svc) // Default to HTTP
 	}
+	m.logCheck(svc)
+}
+
+// logCheck logs the result of a service's latest check at debug level
+func (m *Monitor) logCheck(svc config.Service) {
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	status := m.GetStatus(svc.Name)
+	if status == nil {
+		return
+	}
+	checkType := svc.Type
+	if checkType == "" {
+		checkType = config.CheckHTTP
+	}
+	args := []any{"service", svc.Name, "check_type", checkType, "status", status.Status, "response_ms", status.ResponseTimeMs}
+	if status.ErrorMessage != "" {
+		args = append(args, "error", status.ErrorMessage)
+	}
+	slog.Debug("Check completed", args...)
 }
 
 // checkHTTP performs an HTTP/HTTPS health check
@@ -803,6 +905,12 @@ func (m *Monitor) checkQUIC(svc config.Service) {
 
 // updateStatus updates the status of a service and notifies subscribers
 func (m *Monitor) updateStatus(name string, status Status, responseTime time.Duration, statusCode int, errMsg string) {
+	// A check abandoned by Stop failed because it was cancelled, not
+	// because the service did
+	if m.ctx.Err() != nil {
+		return
+	}
+
 	m.mu.Lock()
 
 	svcStatus, ok := m.statuses[name]
@@ -856,6 +964,7 @@ func (m *Monitor) updateStatus(name string, status Status, responseTime time.Dur
 			}
 		}
 		m.storage.SaveServiceCheckHistory(name, checkPoints, svcStatus.Uptime, svcStatus.LastCheck, svcStatus.ErrorMessage)
+		m.storage.RecordCheck(name, checkPoints[len(checkPoints)-1])
 	}
 
 	// Create copy for notification
@@ -1036,6 +1145,7 @@ func (m *Monitor) checkTLS(svc config.Service) {
 	var status Status
 	var errMsg string
 
+	m.setCertificate(svc.Name, cert.NotAfter, warnDays)
 	if daysUntilExpiry <= 0 {
 		status = StatusDown
 		errMsg = "certificate expired"
@@ -1052,6 +1162,17 @@ func (m *Monitor) checkTLS(svc config.Service) {
 	m.updateStatus(svc.Name, status, responseTime, daysUntilExpiry, errMsg)
 }
 
+// setCertificate records the certificate expiry seen by a TLS check
+func (m *Monitor) setCertificate(name string, expiry time.Time, warnDays int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if svcStatus, ok := m.statuses[name]; ok {
+		svcStatus.CertExpiry = &expiry
+		svcStatus.CertWarnDays = warnDays
+	}
+}
+
 // checkPOP3 performs a POP3 server check
 func (m *Monitor) checkPOP3(svc config.Service) {
 	host := svc.Host
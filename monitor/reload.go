@@ -0,0 +1,149 @@
+package monitor
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// newServiceStatus creates the status of a service, restoring its check
+// history if one was persisted
+func (m *Monitor) newServiceStatus(svc config.Service, persisted *storage.ServiceCheckHistory) *ServiceStatus {
+	status := &ServiceStatus{
+		Name:        svc.Name,
+		Group:       svc.Group,
+		Order:       svc.Order,
+		URL:         svc.URL,
+		Description: svc.Description,
+		Status:      StatusUnknown,
+		LastCheck:   time.Time{},
+		Uptime:      100.0,
+		History:     make([]HistoryPoint, 0, m.maxHistory),
+	}
+	if persisted == nil {
+		return status
+	}
+
+	for _, cp := range persisted.History {
+		status.History = append(status.History, HistoryPoint{
+			Timestamp:      cp.Timestamp,
+			ResponseTimeMs: cp.ResponseTimeMs,
+			Status:         Status(cp.Status),
+			StatusCode:     cp.StatusCode,
+		})
+	}
+	status.Uptime = persisted.Uptime
+	status.LastCheck = persisted.LastCheck
+	status.ErrorMessage = persisted.ErrorMessage
+	if len(status.History) > 0 {
+		lastPoint := status.History[len(status.History)-1]
+		status.Status = lastPoint.Status
+		status.ResponseTimeMs = lastPoint.ResponseTimeMs
+		status.ResponseTime = time.Duration(lastPoint.ResponseTimeMs) * time.Millisecond
+		status.StatusCode = lastPoint.StatusCode
+	}
+	return status
+}
+
+// Sync updates statuses from the check history in storage, for a follower
+// in HA mode whose checks run on the leader. Services checked since the
+// last sync are sent to subscribers.
+func (m *Monitor) Sync() {
+	if m.storage == nil {
+		return
+	}
+	persisted := m.storage.GetAllServiceCheckHistory()
+
+	var updated []*ServiceStatus
+	m.mu.Lock()
+	for _, svc := range m.services {
+		current, ok := m.statuses[svc.Name]
+		history := persisted[svc.Name]
+		if !ok || history == nil || !history.LastCheck.After(current.LastCheck) {
+			continue
+		}
+		status := m.newServiceStatus(svc, history)
+		// Certificate expiry and components are only known where the check ran
+		status.CertExpiry = current.CertExpiry
+		status.CertWarnDays = current.CertWarnDays
+		status.Components = current.Components
+		m.statuses[svc.Name] = status
+
+		statusCopy := *status
+		statusCopy.History = make([]HistoryPoint, len(status.History))
+		copy(statusCopy.History, status.History)
+		updated = append(updated, &statusCopy)
+	}
+	m.mu.Unlock()
+
+	for _, status := range updated {
+		m.notifySubscribers(status)
+	}
+}
+
+// SetServices replaces the monitored services on a running monitor.
+// Services are matched by name: new ones start checking, removed ones stop
+// (their history stays in storage), and changed ones restart with the new
+// settings but keep their status and history. It returns the names of the
+// services added, removed and changed.
+func (m *Monitor) SetServices(services []config.Service) (added, removed, changed []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := make(map[string]config.Service, len(m.services))
+	for _, svc := range m.services {
+		current[svc.Name] = svc
+	}
+	next := make(map[string]bool, len(services))
+	for _, svc := range services {
+		next[svc.Name] = true
+	}
+
+	for _, svc := range m.services {
+		if !next[svc.Name] {
+			m.stopService(svc.Name)
+			delete(m.statuses, svc.Name)
+			removed = append(removed, svc.Name)
+		}
+	}
+
+	var persistedHistory map[string]*storage.ServiceCheckHistory
+	for _, svc := range services {
+		old, exists := current[svc.Name]
+		switch {
+		case !exists:
+			if persistedHistory == nil && m.storage != nil {
+				persistedHistory = m.storage.GetAllServiceCheckHistory()
+			}
+			m.statuses[svc.Name] = m.newServiceStatus(svc, persistedHistory[svc.Name])
+			added = append(added, svc.Name)
+		case !reflect.DeepEqual(old, svc):
+			m.stopService(svc.Name)
+			status := m.statuses[svc.Name]
+			status.Group = svc.Group
+			status.Order = svc.Order
+			status.URL = svc.URL
+			status.Description = svc.Description
+			changed = append(changed, svc.Name)
+		default:
+			continue
+		}
+		if m.running {
+			m.startService(svc)
+		}
+	}
+
+	m.services = services
+	return added, removed, changed
+}
+
+// stopService stops a service's check loop. m.mu must be held.
+func (m *Monitor) stopService(name string) {
+	if cancel, ok := m.checks[name]; ok {
+		cancel()
+		delete(m.checks, name)
+		delete(m.started, name)
+	}
+}
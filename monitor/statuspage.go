@@ -0,0 +1,313 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+)
+
+// Component is one component of another status page, as a statuspage
+// check last saw it
+type Component struct {
+	Name   string `json:"name"`
+	Group  string `json:"group,omitempty"`
+	Status Status `json:"status"`
+	Detail string `json:"detail,omitempty"` // The page's own word for the status, when it says more than Status
+}
+
+// maxSummarySize bounds how much of another page's API response is read
+const maxSummarySize = 4 * 1024 * 1024
+
+// pageSummary is what a statuspage check reads from another page: its
+// components, and the page's own description of its state
+type pageSummary struct {
+	Components  []Component
+	Indicator   string   // Statuspage indicator: none, minor, major or critical; empty if the page has none
+	Description string   // e.g. "Partial System Outage"
+	Incidents   []string // Names of unresolved incidents
+}
+
+// checkStatusPage reads another status page's API and reports its overall
+// state, with each of its components. page_format says which API the page
+// at url serves: this one's /api/summary, Atlassian Statuspage's
+// /api/v2/summary.json or Cachet's /api/v1/components.
+func (m *Monitor) checkStatusPage(svc config.Service) {
+	ctx, cancel := context.WithTimeout(m.ctx, svc.Timeout)
+	defer cancel()
+
+	endpoint, err := summaryURL(svc)
+	if err != nil {
+		m.updateStatus(svc.Name, StatusDown, 0, 0, err.Error())
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		m.updateStatus(svc.Name, StatusDown, 0, 0, err.Error())
+		return
+	}
+	for key, value := range svc.Headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "StatusMonitor/1.0")
+
+	client := m.client
+	if svc.SkipTLSVerify {
+		transport := &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+		client = &http.Client{Transport: transport, Timeout: svc.Timeout}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		m.updateStatus(svc.Name, StatusDown, time.Since(start), 0, err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSummarySize))
+	responseTime := time.Since(start)
+	if err != nil {
+		m.updateStatus(svc.Name, StatusDown, responseTime, resp.StatusCode, err.Error())
+		return
+	}
+	if resp.StatusCode != svc.ExpectedStatus {
+		m.updateStatus(svc.Name, StatusDown, responseTime, resp.StatusCode, fmt.Sprintf("unexpected status code: %d", resp.StatusCode))
+		return
+	}
+
+	var summary *pageSummary
+	switch svc.PageFormat {
+	case "cachet":
+		summary, err = parseCachetComponents(body)
+	default:
+		summary, err = parseSummary(body)
+	}
+	if err != nil {
+		m.updateStatus(svc.Name, StatusDown, responseTime, resp.StatusCode, "invalid summary: "+err.Error())
+		return
+	}
+
+	status, errMsg := summary.overall()
+	m.setComponents(svc.Name, summary.Components)
+	m.updateStatus(svc.Name, status, responseTime, resp.StatusCode, errMsg)
+}
+
+// summaryURL returns the API endpoint of the page at a service's url
+func summaryURL(svc config.Service) (string, error) {
+	u, err := url.Parse(svc.URL)
+	if err != nil {
+		return "", err
+	}
+	switch svc.PageFormat {
+	case "statuspage":
+		u = u.JoinPath("api", "v2", "summary.json")
+	case "cachet":
+		u = u.JoinPath("api", "v1", "components")
+		// Cachet pages its lists, 20 to a page by default
+		q := u.Query()
+		q.Set("per_page", "100")
+		u.RawQuery = q.Encode()
+	default:
+		u = u.JoinPath("api", "summary")
+	}
+	return u.String(), nil
+}
+
+// parseSummary reads a Statuspage summary, or this page's /api/summary,
+// which has the same shape inside the API's success envelope
+func parseSummary(body []byte) (*pageSummary, error) {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	if len(envelope.Data) > 0 {
+		body = envelope.Data
+	}
+
+	var doc struct {
+		Status *struct {
+			Indicator   string `json:"indicator"`
+			Description string `json:"description"`
+		} `json:"status"`
+		Components []struct {
+			ID      string          `json:"id"`
+			Name    string          `json:"name"`
+			Status  string          `json:"status"`
+			Group   json.RawMessage `json:"group"`    // Statuspage: whether it's a group; here: the group's name
+			GroupID string          `json:"group_id"` // Statuspage: the group it's in
+		} `json:"components"`
+		Incidents []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+		} `json:"incidents"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Status == nil && doc.Components == nil {
+		return nil, fmt.Errorf("no status or components")
+	}
+
+	summary := &pageSummary{}
+	if doc.Status != nil {
+		summary.Indicator = doc.Status.Indicator
+		summary.Description = doc.Status.Description
+	}
+
+	// Statuspage lists groups as components that the others point to
+	groups := make(map[string]string)
+	for _, c := range doc.Components {
+		var isGroup bool
+		if json.Unmarshal(c.Group, &isGroup) == nil && isGroup {
+			groups[c.ID] = c.Name
+		}
+	}
+	for _, c := range doc.Components {
+		if _, isGroup := groups[c.ID]; isGroup {
+			continue
+		}
+		var group string
+		if json.Unmarshal(c.Group, &group) != nil {
+			group = groups[c.GroupID]
+		}
+		status, detail := componentStatus(c.Status)
+		summary.Components = append(summary.Components, Component{Name: c.Name, Group: group, Status: status, Detail: detail})
+	}
+
+	for _, inc := range doc.Incidents {
+		if inc.Status != "resolved" && inc.Status != "postmortem" {
+			summary.Incidents = append(summary.Incidents, inc.Name)
+		}
+	}
+	return summary, nil
+}
+
+// componentStatus maps a component status, in this page's words or
+// Statuspage's, to a Status and the page's own wording when it says more
+func componentStatus(s string) (Status, string) {
+	switch s {
+	case "operational":
+		return StatusOperational, ""
+	case "degraded", "down":
+		return Status(s), ""
+	case "degraded_performance", "partial_outage", "under_maintenance":
+		return StatusDegraded, strings.ReplaceAll(s, "_", " ")
+	case "major_outage":
+		return StatusDown, "major outage"
+	}
+	return StatusUnknown, ""
+}
+
+// cachetStatuses maps Cachet's numbered component statuses
+var cachetStatuses = map[int]Status{
+	1: StatusOperational, // Operational
+	2: StatusDegraded,    // Performance issues
+	3: StatusDegraded,    // Partial outage
+	4: StatusDown,        // Major outage
+}
+
+// parseCachetComponents reads Cachet's component list. Cachet has no
+// summary, so the overall state comes from the components alone.
+func parseCachetComponents(body []byte) (*pageSummary, error) {
+	var doc struct {
+		Data []struct {
+			Name       string `json:"name"`
+			Status     int    `json:"status"`
+			StatusName string `json:"status_name"`
+			Enabled    *bool  `json:"enabled"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Data == nil {
+		return nil, fmt.Errorf("no components")
+	}
+
+	summary := &pageSummary{}
+	for _, c := range doc.Data {
+		if c.Enabled != nil && !*c.Enabled {
+			continue
+		}
+		status, ok := cachetStatuses[c.Status]
+		if !ok {
+			status = StatusUnknown
+		}
+		var detail string
+		if status == StatusDegraded {
+			detail = strings.ToLower(c.StatusName)
+		}
+		summary.Components = append(summary.Components, Component{Name: c.Name, Status: status, Detail: detail})
+	}
+	return summary, nil
+}
+
+// overall summarizes a page the way this one summarizes its own services,
+// falling back to the page's indicator when it lists no components, and
+// explains anything short of operational
+func (p *pageSummary) overall() (Status, string) {
+	var status Status
+	if len(p.Components) > 0 {
+		statuses := make([]*ServiceStatus, len(p.Components))
+		for i, c := range p.Components {
+			statuses[i] = &ServiceStatus{Status: c.Status}
+		}
+		status = OverallStatus(statuses)
+	} else {
+		switch p.Indicator {
+		case "none":
+			status = StatusOperational
+		case "minor":
+			status = StatusDegraded
+		case "major", "critical":
+			status = StatusDown
+		default:
+			status = StatusUnknown
+		}
+	}
+
+	switch {
+	case len(p.Incidents) == 1:
+		return status, "incident: " + p.Incidents[0]
+	case len(p.Incidents) > 1:
+		return status, fmt.Sprintf("incident: %s (and %d more)", p.Incidents[0], len(p.Incidents)-1)
+	case status == StatusOperational:
+		return status, ""
+	case p.Indicator != "" && p.Indicator != "none" && p.Description != "":
+		return status, p.Description
+	}
+
+	var affected []string
+	for _, c := range p.Components {
+		if c.Status != StatusOperational {
+			affected = append(affected, c.Name)
+		}
+	}
+	if len(affected) == 0 {
+		return status, ""
+	}
+	return status, fmt.Sprintf("%d of %d components affected: %s", len(affected), len(p.Components), strings.Join(affected, ", "))
+}
+
+// setComponents records the components seen by a statuspage check
+func (m *Monitor) setComponents(name string, components []Component) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if svcStatus, ok := m.statuses[name]; ok {
+		svcStatus.Components = components
+	}
+}
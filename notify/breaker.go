@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// errCircuitOpen is recorded for deliveries skipped by an open circuit
+var errCircuitOpen = errors.New("circuit open: webhook failing persistently")
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // Deliveries flow normally
+	CircuitOpen     = "open"      // Deliveries are skipped until the cooldown passes
+	CircuitHalfOpen = "half_open" // One probe delivery is in flight
+)
+
+// BreakerPolicy controls when deliveries to a failing webhook are skipped
+type BreakerPolicy struct {
+	Threshold int           // Consecutive failed deliveries that open the circuit (0 disables)
+	Cooldown  time.Duration // How long the circuit stays open before a probe
+}
+
+// DefaultBreakerPolicy opens after five failed deliveries and probes every
+// five minutes
+var DefaultBreakerPolicy = BreakerPolicy{
+	Threshold: 5,
+	Cooldown:  5 * time.Minute,
+}
+
+// CircuitStatus is a webhook's circuit breaker state
+type CircuitStatus struct {
+	State    string     `json:"state"`
+	Failures int        `json:"consecutive_failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+	RetryAt  *time.Time `json:"retry_at,omitempty"` // When the next probe is allowed
+}
+
+// circuit tracks consecutive failures for one webhook
+type circuit struct {
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// SetBreakerPolicy replaces the circuit breaker policy
+func (n *Notifier) SetBreakerPolicy(policy BreakerPolicy) {
+	n.breakerMu.Lock()
+	defer n.breakerMu.Unlock()
+	n.breaker = policy
+}
+
+// CircuitStatus returns the circuit breaker state of a webhook
+func (n *Notifier) CircuitStatus(id string) CircuitStatus {
+	n.breakerMu.Lock()
+	defer n.breakerMu.Unlock()
+
+	c := n.circuits[id]
+	if c == nil {
+		return CircuitStatus{State: CircuitClosed}
+	}
+	status := CircuitStatus{State: CircuitClosed, Failures: c.failures}
+	if !c.openedAt.IsZero() {
+		opened := c.openedAt
+		retry := opened.Add(n.breaker.Cooldown)
+		status.State = CircuitOpen
+		status.OpenedAt, status.RetryAt = &opened, &retry
+		if c.probing {
+			status.State = CircuitHalfOpen
+		}
+	}
+	return status
+}
+
+// allowDelivery reports whether a delivery to the webhook may be attempted.
+// Once an open circuit's cooldown has passed, one caller is let through as
+// a probe.
+func (n *Notifier) allowDelivery(id string, now time.Time) bool {
+	n.breakerMu.Lock()
+	defer n.breakerMu.Unlock()
+
+	c := n.circuits[id]
+	if n.breaker.Threshold <= 0 || c == nil || c.openedAt.IsZero() {
+		return true
+	}
+	if c.probing || now.Sub(c.openedAt) < n.breaker.Cooldown {
+		return false
+	}
+	c.probing = true
+	return true
+}
+
+// recordDelivery updates the webhook's circuit with a delivery outcome
+func (n *Notifier) recordDelivery(webhook WebhookConfig, ok bool, now time.Time) {
+	n.breakerMu.Lock()
+	defer n.breakerMu.Unlock()
+
+	c := n.circuits[webhook.ID]
+	if ok {
+		if c != nil && !c.openedAt.IsZero() {
+			slog.Info("Webhook recovered, circuit closed", "webhook", webhook.Name)
+		}
+		delete(n.circuits, webhook.ID)
+		return
+	}
+
+	if c == nil {
+		if n.circuits == nil {
+			n.circuits = make(map[string]*circuit)
+		}
+		c = &circuit{}
+		n.circuits[webhook.ID] = c
+	}
+	c.failures++
+	c.probing = false
+	if n.breaker.Threshold > 0 && c.failures >= n.breaker.Threshold {
+		if c.openedAt.IsZero() {
+			slog.Warn("Webhook failing, circuit open", "webhook", webhook.Name, "failures", c.failures, "cooldown", n.breaker.Cooldown)
+		}
+		c.openedAt = now
+	}
+}
+
+// resetCircuit closes a webhook's circuit, e.g. after it was reconfigured
+func (n *Notifier) resetCircuit(id string) {
+	n.breakerMu.Lock()
+	defer n.breakerMu.Unlock()
+	delete(n.circuits, id)
+}
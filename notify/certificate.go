@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/status/monitor"
+)
+
+// EventCertificateExpiring is sent when a TLS check finds a certificate
+// inside its warning window, once per threshold band
+const EventCertificateExpiring = "certificate.expiring"
+
+// DefaultCertificateThresholds are the days remaining, below a service's
+// tls_warn_days, at which certificate.expiring is sent again
+var DefaultCertificateThresholds = []int{14, 7, 3, 1}
+
+// CertificateInfo describes the certificate behind a certificate.expiring
+// event
+type CertificateInfo struct {
+	ExpiresAt     time.Time `json:"expires_at"`
+	DaysRemaining int       `json:"days_remaining"`
+	Threshold     int       `json:"threshold"` // Band entered: at most this many days remain
+}
+
+// certTracker remembers the band last announced for each service
+type certTracker struct {
+	thresholds []int
+	mu         sync.Mutex
+	bands      map[string]int
+}
+
+// WatchCertificates subscribes to monitor updates and emits
+// certificate.expiring when a TLS check's certificate enters the service's
+// warning window (tls_warn_days) and again at each smaller threshold. A
+// renewed certificate resets the service.
+func (n *Notifier) WatchCertificates(mon *monitor.Monitor, thresholds []int, baseURL string) {
+	if thresholds == nil {
+		thresholds = DefaultCertificateThresholds
+	}
+	tracker := &certTracker{thresholds: thresholds, bands: make(map[string]int)}
+
+	ch := mon.Subscribe()
+	go func() {
+		defer mon.Unsubscribe(ch)
+		for status := range ch {
+			if event, ok := tracker.observe(status); ok {
+				slog.Warn("Certificate expiring", "service", event.Name, "days_remaining", event.Certificate.DaysRemaining)
+				n.NotifyCertificateExpiring(event, baseURL)
+			}
+		}
+	}()
+}
+
+// NotifyCertificateExpiring notifies that a service's certificate expires soon
+func (n *Notifier) NotifyCertificateExpiring(event ServiceEvent, baseURL string) {
+	n.notify(EventCertificateExpiring, event, baseURL)
+}
+
+// observe feeds one check result and returns an event when the certificate
+// has moved into a band not yet announced
+func (t *certTracker) observe(status *monitor.ServiceStatus) (ServiceEvent, bool) {
+	if status.CertExpiry == nil {
+		return ServiceEvent{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	days := int(status.CertExpiry.Sub(status.LastCheck).Hours() / 24)
+	if days > status.CertWarnDays {
+		delete(t.bands, status.Name)
+		return ServiceEvent{}, false
+	}
+
+	band := certificateBand(days, status.CertWarnDays, t.thresholds)
+	if announced, ok := t.bands[status.Name]; ok && band >= announced {
+		return ServiceEvent{}, false
+	}
+	t.bands[status.Name] = band
+
+	return ServiceEvent{
+		Name:           status.Name,
+		Group:          status.Group,
+		Status:         string(status.Status),
+		PreviousStatus: string(status.Status),
+		Error:          status.ErrorMessage,
+		Timestamp:      status.LastCheck,
+		Certificate: &CertificateInfo{
+			ExpiresAt:     *status.CertExpiry,
+			DaysRemaining: days,
+			Threshold:     band,
+		},
+	}, true
+}
+
+// certificateBand returns the smallest threshold (the warning window
+// included) that days falls within; expired certificates are band 0
+func certificateBand(days, warnDays int, thresholds []int) int {
+	if days <= 0 {
+		return 0
+	}
+	bands := []int{warnDays}
+	for _, t := range thresholds {
+		if t > 0 && t < warnDays {
+			bands = append(bands, t)
+		}
+	}
+	sort.Ints(bands)
+	for _, b := range bands {
+		if days <= b {
+			return b
+		}
+	}
+	return warnDays
+}
+
+// certificateTitle summarizes a certificate.expiring event
+func certificateTitle(name string, cert *CertificateInfo) string {
+	switch {
+	case cert.DaysRemaining <= 0:
+		return fmt.Sprintf("Certificate for %s has expired", name)
+	case cert.DaysRemaining == 1:
+		return fmt.Sprintf("Certificate for %s expires in 1 day", name)
+	}
+	return fmt.Sprintf("Certificate for %s expires in %d days", name, cert.DaysRemaining)
+}
@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Message is one notification being delivered to a webhook
+type Message struct {
+	ID      string // Delivery ID, stable across retries
+	Webhook WebhookConfig
+	Event   string
+	Data    interface{} // storage.Incident, storage.Maintenance, ServiceEvent or Digest
+	BaseURL string
+
+	// Reached holds the recipients earlier attempts of this delivery got
+	// through to, so channels sending to several retry only the others.
+	// Nil when the delivery is not retried.
+	Reached map[string]bool
+}
+
+// Channel formats and delivers notifications for one webhook type. Format
+// builds the payload; Send delivers it and returns the target's response.
+type Channel interface {
+	Format(msg Message) ([]byte, error)
+	Send(msg Message, payload []byte) (*DeliveryResult, error)
+}
+
+// ChannelFactory creates a channel bound to a notifier
+type ChannelFactory func(n *Notifier) Channel
+
+var (
+	channelMu        sync.RWMutex
+	channelFactories = map[string]ChannelFactory{
+		"generic":    httpChannel(formatGeneric),
+		"slack":      httpChannel(withWebhook((*Notifier).formatSlackPayload)),
+		"discord":    httpChannel(withoutWebhook((*Notifier).formatDiscordPayload)),
+		"teams":      httpChannel(formatTeams),
+		"msteams":    httpChannel(formatTeams),
+		"googlechat": httpChannel(withoutWebhook((*Notifier).formatGoogleChatPayload)),
+		"mattermost": httpChannel(withWebhook((*Notifier).formatMattermostPayload)),
+		"rocketchat": httpChannel(withWebhook((*Notifier).formatRocketChatPayload)),
+		"telegram":   httpChannel(withWebhook((*Notifier).formatTelegramPayload)),
+		"ntfy":       httpChannel(withWebhook((*Notifier).formatNtfyPayload)),
+		"gotify":     httpChannel(withWebhook((*Notifier).formatGotifyPayload)),
+		"pushover":   httpChannel(withWebhook((*Notifier).formatPushoverPayload)),
+		"pagerduty":  func(n *Notifier) Channel { return pagerDutyChannel{n} },
+		"opsgenie":   func(n *Notifier) Channel { return opsgenieChannel{n} },
+		"twilio":     func(n *Notifier) Channel { return twilioChannel{n} },
+		"email":      func(n *Notifier) Channel { return emailChannel{n} },
+		"mastodon":   func(n *Notifier) Channel { return mastodonChannel{n} },
+		"x":          func(n *Notifier) Channel { return xChannel{n} },
+	}
+)
+
+// RegisterChannel adds a webhook type, typically from an init function in
+// the package providing it. Registering an existing type replaces it.
+// options lists the headers keys that configure the channel rather than
+// being sent as HTTP headers.
+func RegisterChannel(webhookType string, factory ChannelFactory, options ...WebhookOption) {
+	channelMu.Lock()
+	defer channelMu.Unlock()
+	channelFactories[webhookType] = factory
+	if len(options) > 0 {
+		webhookOptions[webhookType] = options
+	}
+}
+
+// ChannelTypes returns the available webhook types, sorted
+func ChannelTypes() []string {
+	channelMu.RLock()
+	defer channelMu.RUnlock()
+
+	types := make([]string, 0, len(channelFactories))
+	for t := range channelFactories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// IsChannelType reports whether a webhook type is available
+func IsChannelType(webhookType string) bool {
+	channelMu.RLock()
+	defer channelMu.RUnlock()
+	_, ok := channelFactories[webhookType]
+	return ok
+}
+
+// channel returns the channel delivering to a webhook. Webhooks with a
+// payload template post the rendered template; unknown types are generic.
+func (n *Notifier) channel(webhook WebhookConfig) Channel {
+	if payloadFormat(webhook) == "template" {
+		return httpChannel(formatTemplate)(n)
+	}
+
+	channelMu.RLock()
+	factory, ok := channelFactories[webhook.Type]
+	if !ok {
+		factory = channelFactories["generic"]
+	}
+	channelMu.RUnlock()
+	return factory(n)
+}
+
+// PostJSON posts payload to the webhook's endpoint with its custom headers,
+// honoring dry-run mode. Channels registered outside this package can use
+// it as their Send.
+func (n *Notifier) PostJSON(webhook WebhookConfig, payload []byte) (*DeliveryResult, error) {
+	return n.post(webhook, webhook.Endpoint(), "application/json", payload, authorize(webhook))
+}
+
+// webhookChannel posts a formatted JSON payload to the webhook's endpoint
+type webhookChannel struct {
+	n      *Notifier
+	format formatFunc
+}
+
+// httpChannel returns a factory for a JSON webhook channel using format
+func httpChannel(format formatFunc) ChannelFactory {
+	return func(n *Notifier) Channel { return webhookChannel{n: n, format: format} }
+}
+
+func (c webhookChannel) Format(m Message) ([]byte, error) {
+	return c.format(c.n, m)
+}
+
+func (c webhookChannel) Send(m Message, payload []byte) (*DeliveryResult, error) {
+	prepare := authorize(m.Webhook)
+	if m.Webhook.Secret != "" && (m.Webhook.Type == "" || m.Webhook.Type == "generic") {
+		prepare = signRequest(m.ID, m.Webhook.Secret, payload)
+	}
+	return c.n.post(m.Webhook, m.Webhook.Endpoint(), "application/json", payload, prepare)
+}
+
+// formatFunc formats a message for a webhookChannel
+type formatFunc func(n *Notifier, m Message) ([]byte, error)
+
+// withWebhook adapts a formatter that reads the webhook's options
+func withWebhook(format func(n *Notifier, event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error)) formatFunc {
+	return func(n *Notifier, m Message) ([]byte, error) { return format(n, m.Event, m.Data, m.Webhook, m.BaseURL) }
+}
+
+// withoutWebhook adapts a formatter that needs only the event
+func withoutWebhook(format func(n *Notifier, event string, data interface{}, baseURL string) ([]byte, error)) formatFunc {
+	return func(n *Notifier, m Message) ([]byte, error) { return format(n, m.Event, m.Data, m.BaseURL) }
+}
+
+// formatGeneric formats the generic JSON envelope
+func formatGeneric(n *Notifier, m Message) ([]byte, error) {
+	return json.Marshal(WebhookPayload{
+		ID:        m.ID,
+		Event:     m.Event,
+		Timestamp: time.Now(),
+		Data:      m.Data,
+	})
+}
+
+func formatTeams(n *Notifier, m Message) ([]byte, error) {
+	if teamsMessageCard(m.Webhook) {
+		return n.formatMSTeamsPayload(m.Event, m.Data, m.BaseURL)
+	}
+	return n.formatAdaptiveCardPayload(m.Event, m.Data, m.BaseURL)
+}
+
+func formatTemplate(n *Notifier, m Message) ([]byte, error) {
+	return renderTemplate(m.ID, m.Webhook, m.Event, m.Data, m.BaseURL)
+}
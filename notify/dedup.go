@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// NotificationStats counts notifications passed to and suppressed by the
+// deduplication filter, and webhook deliveries by channel and event
+type NotificationStats struct {
+	Sent              int64            `json:"sent"`
+	Suppressed        int64            `json:"suppressed"`
+	SuppressedByEvent map[string]int64 `json:"suppressed_by_event"`
+
+	Deliveries map[string]map[string]DeliveryCounts `json:"deliveries"`
+}
+
+// SetDedupWindow suppresses repeats of an identical notification within
+// window. Zero disables deduplication.
+func (n *Notifier) SetDedupWindow(window time.Duration) {
+	n.dedupMu.Lock()
+	defer n.dedupMu.Unlock()
+	n.dedupWindow = window
+}
+
+// Stats returns notification counters
+func (n *Notifier) Stats() NotificationStats {
+	n.dedupMu.Lock()
+	defer n.dedupMu.Unlock()
+
+	stats := n.stats
+	stats.SuppressedByEvent = make(map[string]int64, len(n.stats.SuppressedByEvent))
+	for event, count := range n.stats.SuppressedByEvent {
+		stats.SuppressedByEvent[event] = count
+	}
+	stats.Deliveries = n.deliveries.snapshot()
+	return stats
+}
+
+// isDuplicate reports whether an identical notification was sent within
+// the dedup window, and records this one otherwise
+func (n *Notifier) isDuplicate(event string, data interface{}, now time.Time) bool {
+	n.dedupMu.Lock()
+	defer n.dedupMu.Unlock()
+
+	key := dedupKey(event, data)
+	if n.dedupWindow <= 0 || key == "" {
+		n.stats.Sent++
+		return false
+	}
+
+	if n.recent == nil {
+		n.recent = make(map[string]time.Time)
+	}
+	for k, sent := range n.recent {
+		if now.Sub(sent) >= n.dedupWindow {
+			delete(n.recent, k)
+		}
+	}
+
+	if _, ok := n.recent[key]; ok {
+		n.stats.Suppressed++
+		if n.stats.SuppressedByEvent == nil {
+			n.stats.SuppressedByEvent = make(map[string]int64)
+		}
+		n.stats.SuppressedByEvent[event]++
+		slog.Info("Suppressed duplicate notification", "event", event, "key", key)
+		return true
+	}
+
+	n.recent[key] = now
+	n.stats.Sent++
+	return false
+}
+
+// dedupKey identifies notifications that would produce the same message:
+// the event, the services involved and the severity. Incidents also
+// include their ID, title, status and latest update so distinct incidents
+// and updates are never dropped. Digests are never deduplicated.
+func dedupKey(event string, data interface{}) string {
+	switch v := data.(type) {
+	case storage.Incident:
+		var latest string
+		if len(v.Updates) > 0 {
+			latest = v.Updates[len(v.Updates)-1].Message
+		}
+		return fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s", event, v.ID, joinSorted(v.AffectedServices), v.Severity, v.Title, v.Status, latest)
+	case storage.Maintenance:
+		return fmt.Sprintf("%s|%s|%s", event, v.ID, v.Status)
+	case ServiceEvent:
+		return fmt.Sprintf("%s|%s|%s", event, v.Name, eventSeverity(v))
+	}
+	return ""
+}
+
+func joinSorted(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// deliveryLogSize is how many recent deliveries the log keeps
+const deliveryLogSize = 200
+
+// Delivery is an entry in the log of recent deliveries: one webhook
+// notification, or one event emailed or pushed to subscribers
+type Delivery struct {
+	ID          string    `json:"id"`
+	Channel     string    `json:"channel"` // Webhook type, "subscriber_email" or "webpush"
+	WebhookID   string    `json:"webhook_id,omitempty"`
+	WebhookName string    `json:"webhook_name,omitempty"`
+	Event       string    `json:"event"`
+	Sent        bool      `json:"sent"`                 // Accepted, by the webhook or for some subscribers
+	DryRun      bool      `json:"dry_run"`              // Formatted but not sent
+	Recipients  int       `json:"recipients,omitempty"` // Subscribers emailed or browsers pushed to
+	Attempts    int       `json:"attempts,omitempty"`   // Webhook attempts made
+	Error       string    `json:"error,omitempty"`      // Why the delivery failed
+	Payload     string    `json:"payload,omitempty"`    // What a dry run would have sent
+	Time        time.Time `json:"time"`
+}
+
+// deliveryLog keeps the most recent deliveries, oldest first
+type deliveryLog struct {
+	mu      sync.Mutex
+	entries []Delivery
+}
+
+func (l *deliveryLog) add(d Delivery) {
+	if d.ID == "" {
+		d.ID = newDeliveryID()
+	}
+	d.Time = time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) >= deliveryLogSize {
+		l.entries = append(l.entries[:0], l.entries[1:]...)
+	}
+	l.entries = append(l.entries, d)
+}
+
+// Deliveries returns the recent deliveries, newest first, including the
+// dry runs that were only logged
+func (n *Notifier) Deliveries() []Delivery {
+	n.deliveryLog.mu.Lock()
+	defer n.deliveryLog.mu.Unlock()
+
+	out := make([]Delivery, len(n.deliveryLog.entries))
+	for i, d := range n.deliveryLog.entries {
+		out[len(out)-1-i] = d
+	}
+	return out
+}
+
+// logWebhookDelivery adds a webhook delivery's outcome to the log. result
+// is the last attempt's, if there was one.
+func (n *Notifier) logWebhookDelivery(id string, webhook WebhookConfig, event string, attempts int, result *DeliveryResult, err error) {
+	d := Delivery{
+		ID:          id,
+		Channel:     channelName(webhook),
+		WebhookID:   webhook.ID,
+		WebhookName: webhook.Name,
+		Event:       event,
+		Sent:        err == nil,
+		Attempts:    attempts,
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	if result != nil && result.DryRun {
+		d.Sent, d.DryRun = false, true
+		d.Payload = result.Body
+	}
+	n.deliveryLog.add(d)
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// EventDigest is the event name of a coalesced summary notification
+const EventDigest = "digest"
+
+// Digest summarizes the notifications a webhook received during its digest
+// window
+type Digest struct {
+	Start   time.Time     `json:"start"`
+	End     time.Time     `json:"end"`
+	Entries []DigestEntry `json:"entries"`
+}
+
+// DigestEntry is one notification within a digest
+type DigestEntry struct {
+	Event     string    `json:"event"`
+	Title     string    `json:"title"`
+	Severity  string    `json:"severity,omitempty"`
+	Link      string    `json:"link,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// pendingDigest buffers notifications until the digest window closes
+type pendingDigest struct {
+	start   time.Time
+	event   string // First notification, sent as-is if nothing else arrives
+	data    interface{}
+	entries []DigestEntry
+}
+
+// ValidateDigest checks a webhook's digest window
+func ValidateDigest(webhookType, window string) error {
+	if window == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid digest window %q", window)
+	}
+	if webhookType == "pagerduty" || webhookType == "opsgenie" || webhookType == "email" {
+		return fmt.Errorf("digest is not supported for %s webhooks", webhookType)
+	}
+	return nil
+}
+
+// addToDigest buffers a notification for a webhook with a digest window.
+// The first notification starts the window; when it closes, everything
+// buffered is sent as a single digest. It reports whether the notification
+// was buffered.
+func (n *Notifier) addToDigest(webhook WebhookConfig, event string, data interface{}, baseURL string, now time.Time) bool {
+	if webhook.Digest == "" {
+		return false
+	}
+	window, err := time.ParseDuration(webhook.Digest)
+	if err != nil || window <= 0 {
+		return false
+	}
+
+	entry := newDigestEntry(event, data, baseURL, now)
+
+	n.digestMu.Lock()
+	defer n.digestMu.Unlock()
+
+	if n.digests == nil {
+		n.digests = make(map[string]*pendingDigest)
+	}
+	if pending, ok := n.digests[webhook.ID]; ok {
+		pending.entries = append(pending.entries, entry)
+		return true
+	}
+
+	n.digests[webhook.ID] = &pendingDigest{
+		start:   now,
+		event:   event,
+		data:    data,
+		entries: []DigestEntry{entry},
+	}
+	time.AfterFunc(window, func() { n.flushDigest(webhook.ID, baseURL) })
+	return true
+}
+
+// flushDigest sends a webhook's buffered notifications. A window holding a
+// single notification sends it unchanged.
+func (n *Notifier) flushDigest(webhookID, baseURL string) {
+	n.digestMu.Lock()
+	pending := n.digests[webhookID]
+	delete(n.digests, webhookID)
+	n.digestMu.Unlock()
+
+	if pending == nil {
+		return
+	}
+	webhook := n.findWebhook(webhookID)
+	if webhook == nil || !webhook.Enabled {
+		return
+	}
+
+	if len(pending.entries) == 1 {
+		n.sendWebhook(*webhook, pending.event, pending.data, baseURL)
+		return
+	}
+
+	slog.Info("Sending digest", "webhook", webhook.Name, "notifications", len(pending.entries))
+	n.sendWebhook(*webhook, EventDigest, Digest{
+		Start:   pending.start,
+		End:     time.Now(),
+		Entries: pending.entries,
+	}, baseURL)
+}
+
+func newDigestEntry(event string, data interface{}, baseURL string, now time.Time) DigestEntry {
+	entry := DigestEntry{Event: event, Severity: eventSeverity(data), Timestamp: now}
+	switch v := data.(type) {
+	case storage.Incident:
+		entry.Title = fmt.Sprintf("[%s] %s", v.Status, v.Title)
+		entry.Link = fmt.Sprintf("%s/incidents/%s", baseURL, v.ID)
+	case storage.Maintenance:
+		entry.Title = maintenanceHeading(v) + ": " + v.Title
+		entry.Link = fmt.Sprintf("%s/maintenance/%s", baseURL, v.ID)
+	case ServiceEvent:
+		entry.Title = v.title()
+		entry.Link = baseURL
+	default:
+		entry.Title = event
+	}
+	return entry
+}
+
+// title returns a one-line summary of the digest
+func (d Digest) title() string {
+	return fmt.Sprintf("%d status updates", len(d.Entries))
+}
+
+// severity returns the worst severity among the digest's entries
+func (d Digest) severity() string {
+	worst := ""
+	for _, e := range d.Entries {
+		if severityRank[e.Severity] > severityRank[worst] {
+			worst = e.Severity
+		}
+	}
+	return worst
+}
+
+// lines returns one line per entry, prefixed with its time
+func (d Digest) lines() []string {
+	lines := make([]string, 0, len(d.Entries))
+	for _, e := range d.Entries {
+		lines = append(lines, fmt.Sprintf("%s %s", e.Timestamp.Format("15:04"), e.Title))
+	}
+	return lines
+}
@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// SetDryRun makes every channel format and log its notifications without
+// sending them. Webhooks can also be put in dry-run mode individually.
+func (n *Notifier) SetDryRun(enabled bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.dryRun = enabled
+}
+
+// DryRun reports whether the global dry-run mode is on
+func (n *Notifier) DryRun() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.dryRun
+}
+
+// isDryRun reports whether deliveries to webhook should only be logged
+func (n *Notifier) isDryRun(webhook WebhookConfig) bool {
+	return webhook.DryRun || n.DryRun()
+}
+
+// dryRunResult logs a delivery that was formatted but not sent and returns
+// it as a successful result carrying the payload
+func dryRunResult(webhook WebhookConfig, target string, payload []byte) *DeliveryResult {
+	slog.Info("Dry run: webhook not sent", "webhook", webhook.Name, "type", webhook.Type, "target", target, "payload", string(payload))
+	return &DeliveryResult{
+		StatusCode: http.StatusOK,
+		Body:       string(payload),
+		DryRun:     true,
+	}
+}
@@ -0,0 +1,502 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/status/storage"
+)
+
+//go:embed templates/*
+var templateFiles embed.FS
+
+var (
+	emailHTML = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/email.html"))
+	emailText = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/email.txt"))
+)
+
+// EmailConfig holds SMTP settings for subscriber notifications
+type EmailConfig struct {
+	Host      string `json:"host" yaml:"host"`
+	Port      int    `json:"port" yaml:"port"`
+	Username  string `json:"username" yaml:"username"`
+	Password  string `json:"-" yaml:"password"`
+	From      string `json:"from" yaml:"from"`
+	FromName  string `json:"from_name" yaml:"from_name"`
+	TLS       string `json:"tls" yaml:"tls"` // starttls, tls, none
+	BatchSize int    `json:"batch_size" yaml:"batch_size"`
+}
+
+// SubscriberSource provides the subscribers to email
+type SubscriberSource interface {
+	GetSubscribers(verifiedOnly bool) []storage.Subscriber
+}
+
+// EmailMessage is the data passed to the email templates
+type EmailMessage struct {
+	SiteName       string
+	Subject        string
+	Event          string
+	Incident       *storage.Incident
+	Maintenance    *storage.Maintenance
+	Service        *ServiceEvent
+	URL            string
+	VerifyURL      string
+	ManageURL      string // Sent instead of a verification to a verified subscriber
+	PreferencesURL string
+	UnsubscribeURL string
+}
+
+// EnableEmail turns on email delivery to verified subscribers
+func (n *Notifier) EnableEmail(cfg EmailConfig, subscribers SubscriberSource) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	n.email = &cfg
+	n.subscribers = subscribers
+}
+
+// EmailEnabled reports whether email delivery is configured
+func (n *Notifier) EmailEnabled() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.email != nil
+}
+
+// SendVerification emails a subscriber the link that confirms their address
+func (n *Notifier) SendVerification(sub storage.Subscriber, baseURL string) error {
+	n.mu.RLock()
+	cfg := n.email
+	n.mu.RUnlock()
+
+	if cfg == nil {
+		return fmt.Errorf("email is not configured")
+	}
+
+	msg := EmailMessage{
+		SiteName:       cfg.FromName,
+		Subject:        fmt.Sprintf("Confirm your subscription to %s", cfg.FromName),
+		Event:          "subscriber.verify",
+		URL:            baseURL,
+		VerifyURL:      fmt.Sprintf("%s/api/subscribe/verify?token=%s", baseURL, url.QueryEscape(sub.Token)),
+		PreferencesURL: preferencesURL(baseURL, sub),
+		UnsubscribeURL: unsubscribeURL(baseURL, sub),
+	}
+
+	_, err := n.sendBatch(*cfg, []storage.Subscriber{sub}, func(storage.Subscriber) EmailMessage { return msg })
+	return err
+}
+
+// SendManageLink emails a verified subscriber the link to their
+// preferences, when their address is submitted again. Their subscription is
+// only changed there, as whoever submitted it may not own the address.
+func (n *Notifier) SendManageLink(sub storage.Subscriber, baseURL string) error {
+	n.mu.RLock()
+	cfg := n.email
+	n.mu.RUnlock()
+
+	if cfg == nil {
+		return fmt.Errorf("email is not configured")
+	}
+
+	msg := EmailMessage{
+		SiteName:       cfg.FromName,
+		Subject:        fmt.Sprintf("Manage your subscription to %s", cfg.FromName),
+		Event:          "subscriber.manage",
+		URL:            baseURL,
+		ManageURL:      preferencesURL(baseURL, sub),
+		PreferencesURL: preferencesURL(baseURL, sub),
+		UnsubscribeURL: unsubscribeURL(baseURL, sub),
+	}
+
+	_, err := n.sendBatch(*cfg, []storage.Subscriber{sub}, func(storage.Subscriber) EmailMessage { return msg })
+	return err
+}
+
+// sendSubscriberEmails emails an event to every verified subscriber whose
+// service selection overlaps the affected services
+func (n *Notifier) sendSubscriberEmails(event string, data interface{}, baseURL string) {
+	n.mu.RLock()
+	cfg := n.email
+	source := n.subscribers
+	dryRun := n.dryRun
+	n.mu.RUnlock()
+
+	if cfg == nil || source == nil {
+		return
+	}
+
+	base, ok := newEmailMessage(cfg.FromName, event, n.inLocation(data), baseURL)
+	if !ok {
+		return
+	}
+	affected := eventServices(data)
+
+	var recipients []storage.Subscriber
+	for _, sub := range source.GetSubscribers(true) {
+		if subscribedToServices(sub.Services, affected) {
+			recipients = append(recipients, sub)
+		}
+	}
+	if len(recipients) == 0 {
+		return
+	}
+	if dryRun {
+		slog.Info("Dry run: email not sent", "event", event, "subject", base.Subject, "recipients", len(recipients))
+		n.deliveryLog.add(Delivery{Channel: subscriberEmailChannel, Event: event, DryRun: true, Recipients: len(recipients), Payload: base.Subject})
+		return
+	}
+
+	compose := func(sub storage.Subscriber) EmailMessage {
+		msg := base
+		msg.PreferencesURL = preferencesURL(baseURL, sub)
+		msg.UnsubscribeURL = unsubscribeURL(baseURL, sub)
+		return msg
+	}
+
+	delivery := Delivery{Channel: subscriberEmailChannel, Event: event}
+	for start := 0; start < len(recipients); start += cfg.BatchSize {
+		end := start + cfg.BatchSize
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+		batch := recipients[start:end]
+		sent, err := n.sendBatch(*cfg, batch, compose)
+		n.deliveries.add(subscriberEmailChannel, event, sent, len(batch)-sent)
+		delivery.Recipients += sent
+		if err != nil {
+			slog.Error("Error sending emails", "event", event, "error", err)
+			delivery.Error = err.Error()
+		}
+	}
+	slog.Info("Sent email", "event", event, "recipients", delivery.Recipients)
+	delivery.Sent = delivery.Recipients > 0
+	n.deliveryLog.add(delivery)
+}
+
+// newEmailMessage builds the email for an event. ok is false for data
+// that has no email form.
+func newEmailMessage(siteName, event string, data interface{}, baseURL string) (msg EmailMessage, ok bool) {
+	msg = EmailMessage{
+		SiteName: siteName,
+		Event:    event,
+	}
+
+	switch v := data.(type) {
+	case storage.Incident:
+		msg.Incident = &v
+		msg.URL = fmt.Sprintf("%s/incidents/%s", baseURL, v.ID)
+		switch event {
+		case "incident.created":
+			msg.Subject = fmt.Sprintf("[%s] %s", capitalize(v.Severity), v.Title)
+		case "incident.resolved":
+			msg.Subject = fmt.Sprintf("[Resolved] %s", v.Title)
+		default:
+			msg.Subject = fmt.Sprintf("[%s] %s", capitalize(v.Status), v.Title)
+		}
+	case storage.Maintenance:
+		msg.Maintenance = &v
+		msg.URL = baseURL
+		msg.Subject = fmt.Sprintf("[%s] %s", maintenanceHeading(v), v.Title)
+	case ServiceEvent:
+		msg.Service = &v
+		msg.URL = baseURL
+		msg.Subject = v.title()
+	default:
+		return msg, false
+	}
+	return msg, true
+}
+
+// emailChannel sends an event to the fixed recipients of an "email"
+// webhook over the SMTP server in its options
+type emailChannel struct {
+	n *Notifier
+}
+
+// Format renders the email as sent, addressed to the whole "to" list
+func (c emailChannel) Format(m Message) ([]byte, error) {
+	cfg, _, err := emailWebhookConfig(m.Webhook)
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := newEmailMessage(cfg.FromName, m.Event, m.Data, m.BaseURL)
+	if !ok {
+		return nil, fmt.Errorf("%s events cannot be emailed", m.Event)
+	}
+	return buildEmail(cfg, m.Webhook.Headers["to"], msg)
+}
+
+func (c emailChannel) Send(m Message, payload []byte) (*DeliveryResult, error) {
+	cfg, recipients, err := emailWebhookConfig(m.Webhook)
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := newEmailMessage(cfg.FromName, m.Event, m.Data, m.BaseURL)
+	if !ok {
+		return nil, fmt.Errorf("%s events cannot be emailed", m.Event)
+	}
+	if c.n.isDryRun(m.Webhook) {
+		return dryRunResult(m.Webhook, cfg.Host, []byte(fmt.Sprintf("%q to %s", msg.Subject, m.Webhook.Headers["to"]))), nil
+	}
+
+	start := time.Now()
+	if _, err := c.n.sendBatch(cfg, recipients, func(storage.Subscriber) EmailMessage { return msg }); err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+	return &DeliveryResult{
+		StatusCode: http.StatusOK,
+		Body:       fmt.Sprintf("sent to %d recipient(s)", len(recipients)),
+		Duration:   elapsed,
+		DurationMs: elapsed.Milliseconds(),
+	}, nil
+}
+
+// Probe connects and authenticates to the SMTP server without sending
+func (c emailChannel) Probe(webhook WebhookConfig) error {
+	cfg, _, err := emailWebhookConfig(webhook)
+	if err != nil {
+		return err
+	}
+	client, err := dialSMTP(cfg)
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// emailWebhookConfig reads the SMTP settings and recipients from an email
+// webhook's options
+func emailWebhookConfig(webhook WebhookConfig) (EmailConfig, []storage.Subscriber, error) {
+	port, _ := strconv.Atoi(webhook.Headers["port"])
+	cfg := EmailConfig{
+		Host:     webhook.Headers["host"],
+		Port:     port,
+		Username: webhook.Headers["username"],
+		Password: webhook.Headers["password"],
+		From:     webhook.Headers["from"],
+		FromName: webhook.Headers["from_name"],
+		TLS:      webhook.Headers["tls"],
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.TLS == "" {
+		cfg.TLS = "starttls"
+	}
+	if cfg.FromName == "" {
+		cfg.FromName = "Status Monitor"
+	}
+
+	var recipients []storage.Subscriber
+	for _, to := range strings.Split(webhook.Headers["to"], ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			recipients = append(recipients, storage.Subscriber{Email: to})
+		}
+	}
+	if cfg.Host == "" || cfg.From == "" || len(recipients) == 0 {
+		return cfg, nil, fmt.Errorf("email webhook requires host, from and to")
+	}
+	return cfg, recipients, nil
+}
+
+// subscribedToServices reports whether a subscriber selecting services
+// should hear about an event affecting the given services. An empty list
+// on either side matches everything.
+func subscribedToServices(services, affected []string) bool {
+	if len(services) == 0 || len(affected) == 0 {
+		return true
+	}
+	for _, s := range services {
+		for _, a := range affected {
+			if s == a {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func preferencesURL(baseURL string, sub storage.Subscriber) string {
+	return fmt.Sprintf("%s/api/subscribe/preferences?token=%s", baseURL, url.QueryEscape(sub.Token))
+}
+
+func unsubscribeURL(baseURL string, sub storage.Subscriber) string {
+	return fmt.Sprintf("%s/api/subscribe/unsubscribe?token=%s", baseURL, url.QueryEscape(sub.Token))
+}
+
+// sendBatch delivers one message per recipient over a single SMTP
+// connection, returning how many were accepted
+func (n *Notifier) sendBatch(cfg EmailConfig, recipients []storage.Subscriber, compose func(storage.Subscriber) EmailMessage) (sent int, err error) {
+	client, err := dialSMTP(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	for _, sub := range recipients {
+		body, err := buildEmail(cfg, sub.Email, compose(sub))
+		if err != nil {
+			return sent, fmt.Errorf("rendering email: %w", err)
+		}
+
+		if err := sendMessage(client, cfg.From, sub.Email, body); err != nil {
+			slog.Error("Error emailing subscriber", "email", sub.Email, "error", err)
+			client.Reset()
+			continue
+		}
+		sent++
+	}
+
+	client.Quit()
+	if failed := len(recipients) - sent; failed > 0 {
+		return sent, fmt.Errorf("%d of %d messages failed", failed, len(recipients))
+	}
+	return sent, nil
+}
+
+func dialSMTP(cfg EmailConfig) (*smtp.Client, error) {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if cfg.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("%s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("starting TLS: %w", err)
+		}
+	}
+
+	if cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func sendMessage(client *smtp.Client, from, to string, body []byte) error {
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// buildEmail renders a multipart/alternative message with text and HTML parts
+func buildEmail(cfg EmailConfig, to string, msg EmailMessage) ([]byte, error) {
+	var text, html bytes.Buffer
+	if err := emailText.Execute(&text, msg); err != nil {
+		return nil, err
+	}
+	if err := emailHTML.Execute(&html, msg); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	from := cfg.From
+	if cfg.FromName != "" {
+		from = fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("utf-8", cfg.FromName), cfg.From)
+	}
+
+	headers := []string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	if msg.UnsubscribeURL != "" {
+		headers = append(headers,
+			"List-Unsubscribe: <"+msg.UnsubscribeURL+">",
+			"List-Unsubscribe-Post: List-Unsubscribe=One-Click",
+		)
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		qp.Write(part.body)
+		qp.Close()
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/status/storage"
+)
+
+// GoogleChatPayload for Google Chat incoming webhooks (Cards v2)
+type GoogleChatPayload struct {
+	Text    string           `json:"text,omitempty"`
+	CardsV2 []GoogleChatCard `json:"cardsV2"`
+}
+
+type GoogleChatCard struct {
+	CardID string             `json:"cardId"`
+	Card   GoogleChatCardBody `json:"card"`
+}
+
+type GoogleChatCardBody struct {
+	Header   GoogleChatHeader    `json:"header"`
+	Sections []GoogleChatSection `json:"sections"`
+}
+
+type GoogleChatHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+type GoogleChatSection struct {
+	Header  string             `json:"header,omitempty"`
+	Widgets []GoogleChatWidget `json:"widgets"`
+}
+
+// GoogleChatWidget holds exactly one widget kind
+type GoogleChatWidget struct {
+	DecoratedText *GoogleChatDecoratedText `json:"decoratedText,omitempty"`
+	TextParagraph *GoogleChatText          `json:"textParagraph,omitempty"`
+	ButtonList    *GoogleChatButtonList    `json:"buttonList,omitempty"`
+}
+
+type GoogleChatDecoratedText struct {
+	TopLabel string `json:"topLabel"`
+	Text     string `json:"text"`
+}
+
+type GoogleChatText struct {
+	Text string `json:"text"`
+}
+
+type GoogleChatButtonList struct {
+	Buttons []GoogleChatButton `json:"buttons"`
+}
+
+type GoogleChatButton struct {
+	Text    string            `json:"text"`
+	OnClick GoogleChatOnClick `json:"onClick"`
+}
+
+type GoogleChatOnClick struct {
+	OpenLink GoogleChatLink `json:"openLink"`
+}
+
+type GoogleChatLink struct {
+	URL string `json:"url"`
+}
+
+// formatGoogleChatPayload formats a Cards v2 message for Google Chat
+func (n *Notifier) formatGoogleChatPayload(event string, data interface{}, baseURL string) ([]byte, error) {
+	var header GoogleChatHeader
+	var widgets []GoogleChatWidget
+	var text, link, linkText string
+
+	switch v := data.(type) {
+	case storage.Incident:
+		header = GoogleChatHeader{Title: v.Title, Subtitle: "Incident " + v.Status}
+		widgets = append(widgets,
+			googleChatField("Status", googleChatColored(v.Status, n.severityToColor(v.Severity))),
+			googleChatField("Severity", html.EscapeString(v.Severity)),
+		)
+		if len(v.AffectedServices) > 0 {
+			widgets = append(widgets, googleChatField("Affected Services", html.EscapeString(strings.Join(v.AffectedServices, ", "))))
+		}
+		if v.Message != "" {
+			widgets = append(widgets, googleChatParagraph(v.Message))
+		}
+		text = fmt.Sprintf("[%s] %s", v.Status, v.Title)
+		link, linkText = fmt.Sprintf("%s/incidents/%s", baseURL, v.ID), "View incident"
+
+	case storage.Maintenance:
+		header = GoogleChatHeader{Title: v.Title, Subtitle: maintenanceHeading(v)}
+		widgets = append(widgets,
+			googleChatField("Start", v.ScheduledStart.Format("Jan 02, 2006 15:04 MST")),
+			googleChatField("End", v.ScheduledEnd.Format("Jan 02, 2006 15:04 MST")),
+		)
+		if len(v.AffectedServices) > 0 {
+			widgets = append(widgets, googleChatField("Affected Services", html.EscapeString(strings.Join(v.AffectedServices, ", "))))
+		}
+		if v.Description != "" {
+			widgets = append(widgets, googleChatParagraph(v.Description))
+		}
+		text = maintenanceHeading(v) + ": " + v.Title
+		link, linkText = fmt.Sprintf("%s/maintenance/%s", baseURL, v.ID), "View maintenance"
+
+	case ServiceEvent:
+		header = GoogleChatHeader{Title: v.title(), Subtitle: v.Group}
+		widgets = append(widgets,
+			googleChatField("Status", googleChatColored(v.Status, n.serviceStatusToColor(v.Status))),
+			googleChatField("Previous", html.EscapeString(v.PreviousStatus)),
+			googleChatParagraph(v.detail()),
+		)
+		text = v.title()
+		link, linkText = baseURL, "View status page"
+
+	case Digest:
+		header = GoogleChatHeader{Title: v.title()}
+		widgets = append(widgets, googleChatParagraph(strings.Join(v.lines(), "\n")))
+		text = v.title()
+		link, linkText = baseURL, "View status page"
+
+	default:
+		header = GoogleChatHeader{Title: "Status Update"}
+		text = "Status Update"
+		link, linkText = baseURL, "View status page"
+	}
+
+	widgets = append(widgets, GoogleChatWidget{ButtonList: &GoogleChatButtonList{
+		Buttons: []GoogleChatButton{{Text: linkText, OnClick: GoogleChatOnClick{OpenLink: GoogleChatLink{URL: link}}}},
+	}})
+
+	return json.Marshal(GoogleChatPayload{
+		Text: text,
+		CardsV2: []GoogleChatCard{{
+			CardID: "status-" + event,
+			Card: GoogleChatCardBody{
+				Header:   header,
+				Sections: []GoogleChatSection{{Widgets: widgets}},
+			},
+		}},
+	})
+}
+
+func googleChatField(label, value string) GoogleChatWidget {
+	return GoogleChatWidget{DecoratedText: &GoogleChatDecoratedText{TopLabel: label, Text: value}}
+}
+
+func googleChatParagraph(text string) GoogleChatWidget {
+	return GoogleChatWidget{TextParagraph: &GoogleChatText{Text: html.EscapeString(text)}}
+}
+
+// googleChatColored wraps text in a font color tag (Chat's HTML subset)
+func googleChatColored(text, color string) string {
+	return fmt.Sprintf(`<font color="%s">%s</font>`, color, html.EscapeString(text))
+}
@@ -0,0 +1,223 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// EventHeartbeat is delivered by heartbeats in message mode
+const EventHeartbeat = "heartbeat"
+
+// Heartbeat modes
+const (
+	HeartbeatProbe   = "probe"   // Check the target is reachable and accepts the credentials
+	HeartbeatMessage = "message" // Deliver a heartbeat notification
+)
+
+// Channel health states
+const (
+	ChannelHealthy = "healthy"
+	ChannelFailing = "failing"
+)
+
+// HeartbeatConfig controls periodic checks of every enabled webhook
+type HeartbeatConfig struct {
+	Interval time.Duration // Time between checks (0 disables)
+	Mode     string        // HeartbeatProbe or HeartbeatMessage
+}
+
+// ChannelHealth is the outcome of a webhook's latest heartbeat
+type ChannelHealth struct {
+	Status      string     `json:"status"`
+	Mode        string     `json:"mode"`
+	CheckedAt   time.Time  `json:"checked_at"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Prober is implemented by channels that can check their target without
+// sending a notification. Other channels are probed with an HTTP HEAD
+// request to the webhook's endpoint.
+type Prober interface {
+	Probe(webhook WebhookConfig) error
+}
+
+// pagingChannels page someone on every message, so heartbeats only probe them
+var pagingChannels = map[string]bool{
+	"pagerduty": true,
+	"opsgenie":  true,
+	"twilio":    true,
+}
+
+// StartHeartbeat checks every enabled webhook each interval, so a revoked
+// or unreachable channel shows up in its health before an incident needs
+// it. Probes also run at startup.
+func (n *Notifier) StartHeartbeat(cfg HeartbeatConfig, baseURL string) {
+	if cfg.Interval <= 0 {
+		return
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = HeartbeatProbe
+	}
+
+	go func() {
+		if cfg.Mode == HeartbeatProbe {
+			n.checkChannels(cfg.Mode, baseURL)
+		}
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n.checkChannels(cfg.Mode, baseURL)
+		}
+	}()
+}
+
+// ChannelHealth returns the latest heartbeat outcome for a webhook, or nil
+// if it has not been checked
+func (n *Notifier) ChannelHealth(id string) *ChannelHealth {
+	n.healthMu.Lock()
+	defer n.healthMu.Unlock()
+
+	h, ok := n.health[id]
+	if !ok {
+		return nil
+	}
+	return &h
+}
+
+// ProbeWebhook probes the webhook with the given ID now, whether or not it
+// is enabled, and returns its updated health
+func (n *Notifier) ProbeWebhook(id string) (*ChannelHealth, error) {
+	webhook := n.findWebhook(id)
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	n.recordHealth(*webhook, HeartbeatProbe, n.probe(*webhook), time.Now())
+	return n.ChannelHealth(id), nil
+}
+
+// checkChannels runs one heartbeat for each enabled webhook
+func (n *Notifier) checkChannels(mode, baseURL string) {
+	for _, webhook := range n.Webhooks() {
+		if !webhook.Enabled {
+			continue
+		}
+		n.checkChannel(webhook, mode, baseURL)
+	}
+}
+
+// checkChannel runs a heartbeat for one webhook and records its health
+func (n *Notifier) checkChannel(webhook WebhookConfig, mode, baseURL string) {
+	if pagingChannels[webhook.Type] {
+		mode = HeartbeatProbe
+	}
+
+	var err error
+	if mode == HeartbeatMessage {
+		err = n.sendHeartbeat(webhook, baseURL)
+	} else {
+		err = n.probe(webhook)
+	}
+	n.recordHealth(webhook, mode, err, time.Now())
+}
+
+// sendHeartbeat delivers a heartbeat notification through the webhook's
+// channel
+func (n *Notifier) sendHeartbeat(webhook WebhookConfig, baseURL string) error {
+	now := time.Now()
+	event := ServiceEvent{
+		Name:           "Notification heartbeat",
+		Status:         "operational",
+		PreviousStatus: "operational",
+		Timestamp:      now,
+		Heartbeat:      true,
+	}
+	result, err := n.deliver(newDeliveryID(), webhook, EventHeartbeat, event, baseURL, nil)
+	if err != nil {
+		return err
+	}
+	if result.StatusCode >= 400 {
+		return fmt.Errorf("status %d: %s", result.StatusCode, truncate(result.Body, 200))
+	}
+	return nil
+}
+
+// probe checks a webhook's target with its channel's Prober, or with an
+// HTTP HEAD request
+func (n *Notifier) probe(webhook WebhookConfig) error {
+	if p, ok := n.channel(webhook).(Prober); ok {
+		return p.Probe(webhook)
+	}
+	return n.probeHTTP(webhook, authorize(webhook), true)
+}
+
+// probeHTTP sends a HEAD request to the webhook's endpoint. Any response
+// counts as reachable except rejected credentials and, when strict, a
+// missing endpoint (404, 410), which is how revoked incoming webhooks
+// answer.
+func (n *Notifier) probeHTTP(webhook WebhookConfig, prepare func(*http.Request), strict bool) error {
+	req, err := http.NewRequest(http.MethodHead, webhook.Endpoint(), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", redactURLError(err))
+	}
+	for key, value := range webhook.Headers {
+		if isWebhookOption(webhook.Type, key) {
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+	if prepare != nil {
+		prepare(req)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return redactURLError(err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("credentials rejected (status %d)", resp.StatusCode)
+	case http.StatusNotFound, http.StatusGone:
+		if strict {
+			return fmt.Errorf("endpoint not found (status %d)", resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+// recordHealth stores a heartbeat outcome, logging changes between healthy
+// and failing
+func (n *Notifier) recordHealth(webhook WebhookConfig, mode string, err error, now time.Time) {
+	n.healthMu.Lock()
+	defer n.healthMu.Unlock()
+
+	if n.health == nil {
+		n.health = make(map[string]ChannelHealth)
+	}
+	prev, seen := n.health[webhook.ID]
+	h := ChannelHealth{Status: ChannelHealthy, Mode: mode, CheckedAt: now, LastSuccess: prev.LastSuccess}
+	if err != nil {
+		h.Status = ChannelFailing
+		h.Error = err.Error()
+		if !seen || prev.Status != ChannelFailing {
+			slog.Warn("Webhook heartbeat failed", "webhook", webhook.Name, "error", err)
+		}
+	} else {
+		h.LastSuccess = &now
+		if seen && prev.Status == ChannelFailing {
+			slog.Info("Webhook heartbeat recovered", "webhook", webhook.Name)
+		}
+	}
+	n.health[webhook.ID] = h
+}
+
+// clearHealth forgets a webhook's heartbeat, e.g. after it was reconfigured
+func (n *Notifier) clearHealth(id string) {
+	n.healthMu.Lock()
+	defer n.healthMu.Unlock()
+	delete(n.health, id)
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// MattermostPayload for Mattermost incoming webhooks
+type MattermostPayload struct {
+	Text        string                 `json:"text,omitempty"`
+	Channel     string                 `json:"channel,omitempty"`
+	Username    string                 `json:"username,omitempty"`
+	IconURL     string                 `json:"icon_url,omitempty"`
+	Attachments []MattermostAttachment `json:"attachments"`
+}
+
+type MattermostAttachment struct {
+	Fallback  string      `json:"fallback"`
+	Color     string      `json:"color"`
+	Title     string      `json:"title"`
+	TitleLink string      `json:"title_link,omitempty"`
+	Text      string      `json:"text,omitempty"`
+	Fields    []ChatField `json:"fields,omitempty"`
+	Footer    string      `json:"footer,omitempty"`
+}
+
+// RocketChatPayload for Rocket.Chat incoming webhooks
+type RocketChatPayload struct {
+	Text        string                 `json:"text"`
+	Channel     string                 `json:"channel,omitempty"`
+	Alias       string                 `json:"alias,omitempty"`
+	Emoji       string                 `json:"emoji,omitempty"`
+	Avatar      string                 `json:"avatar,omitempty"`
+	Attachments []RocketChatAttachment `json:"attachments"`
+}
+
+type RocketChatAttachment struct {
+	Color     string      `json:"color"`
+	Title     string      `json:"title"`
+	TitleLink string      `json:"title_link,omitempty"`
+	Text      string      `json:"text,omitempty"`
+	Fields    []ChatField `json:"fields,omitempty"`
+	Ts        string      `json:"ts,omitempty"` // ISO 8601; Rocket.Chat ignores Unix seconds
+}
+
+// ChatField is an attachment field, shared by Mattermost and Rocket.Chat
+type ChatField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// chatMessage holds the fields shared by the Mattermost, Rocket.Chat and
+// Teams Adaptive Card formats
+type chatMessage struct {
+	color    string
+	title    string
+	link     string
+	linkText string
+	text     string
+	fields   []ChatField
+	ts       time.Time
+}
+
+func (n *Notifier) buildChatMessage(data interface{}, baseURL string) chatMessage {
+	switch v := data.(type) {
+	case storage.Incident:
+		msg := chatMessage{
+			color:    n.severityToColor(v.Severity),
+			title:    fmt.Sprintf("[%s] %s", v.Status, v.Title),
+			link:     fmt.Sprintf("%s/incidents/%s", baseURL, v.ID),
+			text:     v.Message,
+			linkText: "View incident",
+			fields: []ChatField{
+				{Title: "Status", Value: v.Status, Short: true},
+				{Title: "Severity", Value: v.Severity, Short: true},
+			},
+			ts: v.UpdatedAt,
+		}
+		if v.Status == "resolved" {
+			msg.color = n.serviceStatusToColor("operational")
+		}
+		if len(v.AffectedServices) > 0 {
+			msg.fields = append(msg.fields, ChatField{Title: "Affected Services", Value: strings.Join(v.AffectedServices, ", ")})
+		}
+		return msg
+
+	case storage.Maintenance:
+		msg := chatMessage{
+			color:    "#3498db",
+			title:    maintenanceHeading(v) + ": " + v.Title,
+			link:     fmt.Sprintf("%s/maintenance/%s", baseURL, v.ID),
+			text:     v.Description,
+			linkText: "View maintenance",
+			fields: []ChatField{
+				{Title: "Start", Value: v.ScheduledStart.Format("Jan 02, 2006 15:04 MST"), Short: true},
+				{Title: "End", Value: v.ScheduledEnd.Format("Jan 02, 2006 15:04 MST"), Short: true},
+			},
+			ts: v.CreatedAt,
+		}
+		if len(v.AffectedServices) > 0 {
+			msg.fields = append(msg.fields, ChatField{Title: "Affected Services", Value: strings.Join(v.AffectedServices, ", ")})
+		}
+		return msg
+
+	case ServiceEvent:
+		return chatMessage{
+			color:    n.serviceStatusToColor(v.Status),
+			title:    v.title(),
+			link:     baseURL,
+			text:     v.detail(),
+			linkText: "View status page",
+			fields: []ChatField{
+				{Title: "Status", Value: v.Status, Short: true},
+				{Title: "Previous", Value: v.PreviousStatus, Short: true},
+			},
+			ts: v.Timestamp,
+		}
+
+	case Digest:
+		// One markdown list item per entry, linked where possible
+		lines := make([]string, 0, len(v.Entries))
+		for _, e := range v.Entries {
+			title := e.Title
+			if e.Link != "" {
+				title = fmt.Sprintf("[%s](%s)", e.Title, e.Link)
+			}
+			lines = append(lines, fmt.Sprintf("- %s %s", e.Timestamp.Format("15:04"), title))
+		}
+		return chatMessage{
+			color:    n.severityToColor(v.severity()),
+			title:    v.title(),
+			link:     baseURL,
+			text:     strings.Join(lines, "\n"),
+			linkText: "View status page",
+			ts:       v.End,
+		}
+	}
+
+	return chatMessage{color: "#95a5a6", title: "Status Update", link: baseURL, linkText: "View status page", ts: time.Now()}
+}
+
+// formatMattermostPayload formats an attachment message for Mattermost.
+// The optional channel, username and icon_url options override the
+// webhook's defaults when the Mattermost server allows it.
+func (n *Notifier) formatMattermostPayload(event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	msg := n.buildChatMessage(data, baseURL)
+
+	return json.Marshal(MattermostPayload{
+		Channel:  webhook.Headers["channel"],
+		Username: webhook.Headers["username"],
+		IconURL:  webhook.Headers["icon_url"],
+		Attachments: []MattermostAttachment{{
+			Fallback:  msg.title,
+			Color:     msg.color,
+			Title:     msg.title,
+			TitleLink: msg.link,
+			Text:      msg.text,
+			Fields:    msg.fields,
+			Footer:    "Status Monitor",
+		}},
+	})
+}
+
+// formatRocketChatPayload formats an attachment message for Rocket.Chat.
+// Rocket.Chat shows text as the notification preview, so the title is
+// repeated there.
+func (n *Notifier) formatRocketChatPayload(event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	msg := n.buildChatMessage(data, baseURL)
+
+	return json.Marshal(RocketChatPayload{
+		Text:    msg.title,
+		Channel: webhook.Headers["channel"],
+		Alias:   webhook.Headers["alias"],
+		Emoji:   webhook.Headers["emoji"],
+		Avatar:  webhook.Headers["avatar"],
+		Attachments: []RocketChatAttachment{{
+			Color:     msg.color,
+			Title:     msg.title,
+			TitleLink: msg.link,
+			Text:      msg.text,
+			Fields:    msg.fields,
+			Ts:        msg.ts.UTC().Format(time.RFC3339),
+		}},
+	})
+}
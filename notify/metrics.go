@@ -0,0 +1,89 @@
+package notify
+
+import "sync"
+
+// DeliveryCounts counts deliveries for one channel and event. Sent and
+// Failed count notifications, or messages to subscribers; Retried counts
+// extra attempts.
+type DeliveryCounts struct {
+	Sent    int64 `json:"sent"`
+	Failed  int64 `json:"failed"`
+	Retried int64 `json:"retried"`
+}
+
+// deliveryMetrics holds delivery counters keyed by channel, then event
+type deliveryMetrics struct {
+	mu     sync.Mutex
+	counts map[string]map[string]*DeliveryCounts
+}
+
+// Channels subscriber deliveries are counted under, apart from webhooks
+const (
+	subscriberEmailChannel = "subscriber_email"
+	webPushChannel         = "webpush"
+)
+
+// channelName is the channel a webhook's deliveries are counted under
+func channelName(webhook WebhookConfig) string {
+	if webhook.Type == "" {
+		return "generic"
+	}
+	return webhook.Type
+}
+
+// record applies update to the counters of a channel and event
+func (m *deliveryMetrics) record(channel, event string, update func(*DeliveryCounts)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counts == nil {
+		m.counts = make(map[string]map[string]*DeliveryCounts)
+	}
+	if m.counts[channel] == nil {
+		m.counts[channel] = make(map[string]*DeliveryCounts)
+	}
+	c := m.counts[channel][event]
+	if c == nil {
+		c = &DeliveryCounts{}
+		m.counts[channel][event] = c
+	}
+	update(c)
+}
+
+func (m *deliveryMetrics) sent(webhook WebhookConfig, event string) {
+	m.record(channelName(webhook), event, func(c *DeliveryCounts) { c.Sent++ })
+}
+
+func (m *deliveryMetrics) failed(webhook WebhookConfig, event string) {
+	m.record(channelName(webhook), event, func(c *DeliveryCounts) { c.Failed++ })
+}
+
+func (m *deliveryMetrics) retried(webhook WebhookConfig, event string) {
+	m.record(channelName(webhook), event, func(c *DeliveryCounts) { c.Retried++ })
+}
+
+// add counts messages sent and failed on a channel other than a webhook's
+func (m *deliveryMetrics) add(channel, event string, sent, failed int) {
+	if sent == 0 && failed == 0 {
+		return
+	}
+	m.record(channel, event, func(c *DeliveryCounts) {
+		c.Sent += int64(sent)
+		c.Failed += int64(failed)
+	})
+}
+
+// snapshot copies the counters
+func (m *deliveryMetrics) snapshot() map[string]map[string]DeliveryCounts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make(map[string]map[string]DeliveryCounts, len(m.counts))
+	for channel, events := range m.counts {
+		out[channel] = make(map[string]DeliveryCounts, len(events))
+		for event, c := range events {
+			out[channel][event] = *c
+		}
+	}
+	return out
+}
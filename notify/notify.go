@@ -3,9 +3,13 @@ package notify
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,10 +18,34 @@ import (
 
 // Notifier handles sending notifications via webhooks
 type Notifier struct {
-	webhooks    []WebhookConfig
-	subscribers []Subscriber
-	mu          sync.RWMutex
-	client      *http.Client
+	webhooks      []WebhookConfig
+	email         *EmailConfig
+	subscribers   SubscriberSource
+	push          *webPush
+	breaker       BreakerPolicy
+	circuits      map[string]*circuit
+	breakerMu     sync.Mutex
+	health        map[string]ChannelHealth
+	healthMu      sync.Mutex
+	dryRun        bool
+	retry         RetryPolicy
+	deadLetters   DeadLetterStore
+	routes        []Route
+	serviceGroups map[string]string
+	deferred      []deferredNotification
+	deferMu       sync.Mutex
+	deferOnce     sync.Once
+	digests       map[string]*pendingDigest
+	digestMu      sync.Mutex
+	dedupWindow   time.Duration
+	recent        map[string]time.Time
+	stats         NotificationStats
+	dedupMu       sync.Mutex
+	deliveries    deliveryMetrics
+	deliveryLog   deliveryLog
+	location      *time.Location // Zone of displayed timestamps; nil is local
+	mu            sync.RWMutex
+	client        *http.Client
 }
 
 // WebhookConfig represents a webhook configuration
@@ -25,49 +53,53 @@ type WebhookConfig struct {
 	ID      string            `json:"id" yaml:"id"`
 	Name    string            `json:"name" yaml:"name"`
 	URL     string            `json:"url" yaml:"url"`
-	Type    string            `json:"type" yaml:"type"` // generic, slack, discord, teams, pagerduty
-	Events  []string          `json:"events" yaml:"events"` // incident.created, incident.updated, incident.resolved, maintenance.scheduled
+	Type    string            `json:"type" yaml:"type"` // generic, slack, discord, teams, googlechat, mattermost, rocketchat, pagerduty, opsgenie, telegram, twilio, email, mastodon, x
+	Events  []string          `json:"events" yaml:"events"` // incident.*, maintenance.*, service.down, service.degraded, service.recovered
 	Headers map[string]string `json:"headers" yaml:"headers"`
 	Enabled bool              `json:"enabled" yaml:"enabled"`
-}
+	Secret  string            `json:"-" yaml:"secret"` // Signs generic payloads (X-Status-Signature)
 
-// Subscriber represents an email subscriber
-type Subscriber struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	Verified  bool      `json:"verified"`
-	Token     string    `json:"token"`
-	CreatedAt time.Time `json:"created_at"`
-	Services  []string  `json:"services"` // Empty means all services
+	QuietHours *storage.QuietHours `json:"quiet_hours,omitempty" yaml:"quiet_hours"`
+	Digest     string              `json:"digest,omitempty" yaml:"digest"`     // Coalesce notifications over this window, e.g. "15m"
+	Template   string              `json:"template,omitempty" yaml:"template"` // Go text/template replacing the built-in payload
+	DryRun     bool                `json:"dry_run,omitempty" yaml:"dry_run"`   // Format and log notifications without sending them
 }
 
 // WebhookPayload is the generic webhook payload
 type WebhookPayload struct {
+	ID        string      `json:"id"` // Delivery ID, stable across retries
 	Event     string      `json:"event"`
 	Timestamp time.Time   `json:"timestamp"`
 	Data      interface{} `json:"data"`
 }
 
-// SlackPayload for Slack webhooks
+// SlackPayload for Slack webhooks (Block Kit). Text is the fallback shown
+// in notifications.
 type SlackPayload struct {
-	Text        string            `json:"text,omitempty"`
-	Attachments []SlackAttachment `json:"attachments,omitempty"`
+	Text    string       `json:"text"`
+	Channel string       `json:"channel,omitempty"`
+	Blocks  []SlackBlock `json:"blocks"`
 }
 
-type SlackAttachment struct {
-	Color      string       `json:"color"`
-	Title      string       `json:"title"`
-	TitleLink  string       `json:"title_link,omitempty"`
-	Text       string       `json:"text"`
-	Fields     []SlackField `json:"fields,omitempty"`
-	Footer     string       `json:"footer,omitempty"`
-	Ts         int64        `json:"ts,omitempty"`
+// SlackBlock is a Block Kit layout block
+type SlackBlock struct {
+	Type     string        `json:"type"` // header, section, context, actions, divider
+	Text     *SlackText    `json:"text,omitempty"`
+	Fields   []SlackText   `json:"fields,omitempty"`
+	Elements []interface{} `json:"elements,omitempty"` // SlackText (context) or SlackButton (actions)
 }
 
-type SlackField struct {
-	Title string `json:"title"`
-	Value string `json:"value"`
-	Short bool   `json:"short"`
+// SlackText is a Block Kit text object
+type SlackText struct {
+	Type string `json:"type"` // mrkdwn or plain_text
+	Text string `json:"text"`
+}
+
+// SlackButton is a Block Kit link button
+type SlackButton struct {
+	Type string    `json:"type"`
+	Text SlackText `json:"text"`
+	URL  string    `json:"url"`
 }
 
 // DiscordPayload for Discord webhooks
@@ -96,7 +128,7 @@ type DiscordEmbedFooter struct {
 	Text string `json:"text"`
 }
 
-// MSTeamsPayload for Microsoft Teams webhooks
+// MSTeamsPayload for legacy Microsoft Teams connector webhooks (MessageCard)
 type MSTeamsPayload struct {
 	Type       string           `json:"@type"`
 	Context    string           `json:"@context"`
@@ -132,32 +164,266 @@ type PagerDutyDetails struct {
 	Timestamp string `json:"timestamp,omitempty"`
 }
 
+// TelegramPayload for the Telegram Bot API sendMessage method
+type TelegramPayload struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
 // OpsgeniePayload for Opsgenie alerts
 type OpsgeniePayload struct {
 	Message     string   `json:"message"`
+	Alias       string   `json:"alias,omitempty"`
 	Description string   `json:"description,omitempty"`
 	Priority    string   `json:"priority,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
+	Entity      string   `json:"entity,omitempty"`
+	Source      string   `json:"source,omitempty"`
 }
 
 // NewNotifier creates a new notifier
 func NewNotifier(webhooks []WebhookConfig) *Notifier {
 	return &Notifier{
-		webhooks:    webhooks,
-		subscribers: []Subscriber{},
+		webhooks: webhooks,
+		retry:    DefaultRetryPolicy,
+		breaker:  DefaultBreakerPolicy,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 	}
 }
 
-// AddWebhook adds a webhook
+// AddWebhook adds a webhook, replacing any with the same ID so concurrent
+// adds never register it twice
 func (n *Notifier) AddWebhook(webhook WebhookConfig) {
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	for i := range n.webhooks {
+		if n.webhooks[i].ID == webhook.ID {
+			n.webhooks[i] = webhook
+			n.resetCircuit(webhook.ID)
+			n.clearHealth(webhook.ID)
+			return
+		}
+	}
 	n.webhooks = append(n.webhooks, webhook)
 }
 
+// Webhooks returns a copy of the configured webhooks
+func (n *Notifier) Webhooks() []WebhookConfig {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	webhooks := make([]WebhookConfig, len(n.webhooks))
+	copy(webhooks, n.webhooks)
+	return webhooks
+}
+
+// UpdateWebhook replaces the webhook with the same ID
+func (n *Notifier) UpdateWebhook(webhook WebhookConfig) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i := range n.webhooks {
+		if n.webhooks[i].ID == webhook.ID {
+			n.webhooks[i] = webhook
+			n.resetCircuit(webhook.ID)
+			n.clearHealth(webhook.ID)
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveWebhook removes the webhook with the given ID
+func (n *Notifier) RemoveWebhook(id string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i := range n.webhooks {
+		if n.webhooks[i].ID == id {
+			n.webhooks = append(n.webhooks[:i], n.webhooks[i+1:]...)
+			n.resetCircuit(id)
+			n.clearHealth(id)
+			return true
+		}
+	}
+	return false
+}
+
+// SetWebhooks replaces all webhooks, as when the configuration is reloaded.
+// Circuits and health are kept for webhooks that did not change.
+func (n *Notifier) SetWebhooks(webhooks []WebhookConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	next := make(map[string]WebhookConfig, len(webhooks))
+	for _, wh := range webhooks {
+		next[wh.ID] = wh
+	}
+	for _, old := range n.webhooks {
+		if wh, ok := next[old.ID]; !ok || !reflect.DeepEqual(wh, old) {
+			n.resetCircuit(old.ID)
+			n.clearHealth(old.ID)
+		}
+	}
+	n.webhooks = webhooks
+}
+
+// WebhookFromStorage converts a stored webhook into a notifier webhook
+func WebhookFromStorage(wh storage.Webhook) WebhookConfig {
+	return WebhookConfig{
+		ID:      wh.ID,
+		Name:    wh.Name,
+		URL:     wh.URL,
+		Type:    wh.Type,
+		Events:  wh.Events,
+		Headers: wh.Headers,
+		Enabled: wh.Enabled,
+		Secret:  wh.Secret,
+
+		QuietHours: wh.QuietHours,
+		Digest:     wh.Digest,
+		Template:   wh.Template,
+		DryRun:     wh.DryRun,
+	}
+}
+
+// WebhookOption is a Headers key that configures a webhook type rather
+// than being sent as an HTTP header. Secret options hold credentials, which
+// the admin API accepts but never returns.
+type WebhookOption struct {
+	Key    string
+	Secret bool
+}
+
+// webhookOptions lists the options of each webhook type. Keys ending in
+// "." name a family of keys.
+var webhookOptions = map[string][]WebhookOption{
+	"slack":      {{"channel", false}},
+	"telegram":   {{"bot_token", true}, {"chat_id", false}},
+	"twilio":     {{"account_sid", true}, {"auth_token", true}, {"from", false}, {"to", false}, {"min_severity", false}},
+	"ntfy":       {{"topic", false}, {"priority", false}, {"tags", false}, {"token", true}},
+	"gotify":     {{"app_token", true}, {"priority", false}},
+	"pushover":   {{"app_token", true}, {"user_key", true}, {"device", false}, {"sound", false}, {"priority", false}, {"retry", false}, {"expire", false}},
+	"opsgenie":   {{"api_key", true}, {"region", false}},
+	"pagerduty":  {{"routing_key", true}, {"integration_key", true}, {"routing_key.", true}},
+	"email":      {{"host", false}, {"port", false}, {"username", true}, {"password", true}, {"from", false}, {"from_name", false}, {"to", false}, {"tls", false}},
+	"teams":      {{"format", false}},
+	"msteams":    {{"format", false}},
+	"mattermost": {{"channel", false}, {"username", false}, {"icon_url", false}},
+	"rocketchat": {{"channel", false}, {"alias", false}, {"emoji", false}, {"avatar", false}},
+	"mastodon":   {{"access_token", true}, {"visibility", false}, {"language", false}, {"min_severity", false}},
+	"x":          {{"api_key", true}, {"api_secret", true}, {"access_token", true}, {"access_token_secret", true}, {"min_severity", false}},
+}
+
+// webhookOption returns the option of a webhook type matching key
+func webhookOption(webhookType, key string) (WebhookOption, bool) {
+	for _, opt := range webhookOptions[webhookType] {
+		if opt.Key == key || (strings.HasSuffix(opt.Key, ".") && strings.HasPrefix(key, opt.Key)) {
+			return opt, true
+		}
+	}
+	return WebhookOption{}, false
+}
+
+func isWebhookOption(webhookType, key string) bool {
+	_, ok := webhookOption(webhookType, key)
+	return ok
+}
+
+// IsSecretHeader reports whether a Headers key of a webhook type holds a
+// credential: a secret option, or an HTTP header whose name suggests one
+// such as Authorization or X-Api-Key.
+func IsSecretHeader(webhookType, key string) bool {
+	if opt, ok := webhookOption(webhookType, key); ok {
+		return opt.Secret
+	}
+	name := strings.ToLower(key)
+	for _, word := range []string{"auth", "token", "secret", "key", "password", "cookie", "signature"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactURL reduces a delivery URL to its scheme and host, as user info,
+// the path and the query may carry credentials: Telegram's bot<token>
+// path, a Slack or Discord webhook path or an API key parameter.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "[redacted]"
+	}
+	redacted := u.Scheme + "://" + u.Host
+	if u.User != nil || u.RawQuery != "" || strings.Trim(u.Path, "/") != "" {
+		redacted += "/..."
+	}
+	return redacted
+}
+
+// redactURLError removes credentials from the URL in a transport error,
+// which is logged, dead-lettered and returned by the admin API
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return &url.Error{Op: urlErr.Op, URL: redactURL(urlErr.URL), Err: urlErr.Err}
+	}
+	return err
+}
+
+// Endpoint returns the URL deliveries are posted to. Types with a
+// well-known API derive it from their options when URL is empty.
+func (w WebhookConfig) Endpoint() string {
+	switch w.Type {
+	case "telegram":
+		if token := w.Headers["bot_token"]; w.URL == "" && token != "" {
+			return "https://api.telegram.org/bot" + token + "/sendMessage"
+		}
+	case "twilio":
+		if sid := w.Headers["account_sid"]; w.URL == "" && sid != "" {
+			return "https://api.twilio.com/2010-04-01/Accounts/" + sid + "/Messages.json"
+		}
+	case "ntfy":
+		if w.URL == "" {
+			return "https://ntfy.sh"
+		}
+	case "pushover":
+		if w.URL == "" {
+			return "https://api.pushover.net/1/messages.json"
+		}
+	case "pagerduty":
+		if w.URL == "" {
+			return pagerDutyEventsURL
+		}
+	case "opsgenie":
+		if w.URL == "" {
+			if endpoint, ok := opsgenieRegions[strings.ToLower(w.Headers["region"])]; ok {
+				return endpoint
+			}
+			return opsgenieRegions["us"]
+		}
+	case "mastodon":
+		// URL is the instance; statuses are posted to /api/v1/statuses
+		if w.URL != "" {
+			return mastodonInstance(w) + "/api/v1/statuses"
+		}
+	case "x":
+		if w.URL == "" {
+			return xTweetsURL
+		}
+	case "gotify":
+		// URL is the Gotify server; messages are posted to /message
+		if w.URL != "" && !strings.HasSuffix(w.URL, "/message") {
+			return strings.TrimRight(w.URL, "/") + "/message"
+		}
+	}
+	return w.URL
+}
+
 // NotifyIncidentCreated notifies about a new incident
 func (n *Notifier) NotifyIncidentCreated(incident storage.Incident, baseURL string) {
 	n.notify("incident.created", incident, baseURL)
@@ -173,34 +439,126 @@ func (n *Notifier) NotifyIncidentResolved(incident storage.Incident, baseURL str
 	n.notify("incident.resolved", incident, baseURL)
 }
 
+// NotifyIncidentEscalated notifies the given webhooks that an incident is
+// still unacknowledged
+func (n *Notifier) NotifyIncidentEscalated(incident storage.Incident, webhookIDs []string, baseURL string) {
+	n.NotifyWebhooks(webhookIDs, "incident.escalated", incident, baseURL)
+}
+
 // NotifyMaintenanceScheduled notifies about scheduled maintenance
 func (n *Notifier) NotifyMaintenanceScheduled(maintenance storage.Maintenance, baseURL string) {
 	n.notify("maintenance.scheduled", maintenance, baseURL)
 }
 
+// NotifyMaintenanceStarted notifies that a maintenance window is in progress
+func (n *Notifier) NotifyMaintenanceStarted(maintenance storage.Maintenance, baseURL string) {
+	n.notify("maintenance.started", maintenance, baseURL)
+}
+
+// NotifyMaintenanceCompleted notifies that a maintenance window has ended
+func (n *Notifier) NotifyMaintenanceCompleted(maintenance storage.Maintenance, baseURL string) {
+	n.notify("maintenance.completed", maintenance, baseURL)
+}
+
+// maintenanceHeading describes a maintenance window by its lifecycle stage
+func maintenanceHeading(m storage.Maintenance) string {
+	switch m.Status {
+	case "in_progress":
+		return "Maintenance In Progress"
+	case "completed":
+		return "Maintenance Completed"
+	}
+	return "Scheduled Maintenance"
+}
+
 func (n *Notifier) notify(event string, data interface{}, baseURL string) {
+	now := time.Now()
+	if n.isDuplicate(event, data, now) {
+		return
+	}
+
 	n.mu.RLock()
 	defer n.mu.RUnlock()
 
+	targets, routed := n.route(event, data, now)
+
 	for _, webhook := range n.webhooks {
 		if !webhook.Enabled {
 			continue
 		}
 
-		// Check if webhook is subscribed to this event
-		if !n.isSubscribedToEvent(webhook, event) {
+		// Routing rules take precedence over per-webhook event lists
+		if routed {
+			if !targets[webhook.ID] {
+				continue
+			}
+		} else if !n.isSubscribedToEvent(webhook, event) {
+			continue
+		}
+
+		if !meetsMinSeverity(webhook, data) {
+			continue
+		}
+
+		if n.holdForQuietHours(webhook, event, data, baseURL, now) {
+			continue
+		}
+
+		if n.addToDigest(webhook, event, data, baseURL, now) {
 			continue
 		}
 
 		go n.sendWebhook(webhook, event, data, baseURL)
 	}
+
+	if n.email != nil {
+		go n.sendSubscriberEmails(event, data, baseURL)
+	}
+	if n.push != nil {
+		go n.sendPushNotifications(event, data, baseURL)
+	}
+}
+
+// NotifyWebhooks sends an event to the listed webhooks, bypassing event
+// lists and routing rules. Disabled webhooks are skipped.
+func (n *Notifier) NotifyWebhooks(ids []string, event string, data interface{}, baseURL string) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	for _, webhook := range n.webhooks {
+		if webhook.Enabled && contains(ids, webhook.ID) {
+			go n.sendWebhook(webhook, event, data, baseURL)
+		}
+	}
+}
+
+// severityRank orders incident severities from least to most severe
+var severityRank = map[string]int{"minor": 1, "major": 2, "critical": 3}
+
+// meetsMinSeverity applies a webhook's min_severity option. Incidents and
+// service events carry a severity; other events pass only when no minimum
+// is set.
+func meetsMinSeverity(webhook WebhookConfig, data interface{}) bool {
+	if !isWebhookOption(webhook.Type, "min_severity") {
+		return true
+	}
+	min := webhook.Headers["min_severity"]
+	if min == "" {
+		return true
+	}
+
+	severity := eventSeverity(data)
+	return severity != "" && severityRank[severity] >= severityRank[min]
 }
 
 func (n *Notifier) isSubscribedToEvent(webhook WebhookConfig, event string) bool {
-	if len(webhook.Events) == 0 {
-		return true // Subscribe to all events by default
+	events := webhook.Events
+	if len(events) == 0 {
+		if events = socialEvents[webhook.Type]; events == nil {
+			return true // Subscribe to all events by default
+		}
 	}
-	for _, e := range webhook.Events {
+	for _, e := range events {
 		if e == event || e == "*" {
 			return true
 		}
@@ -208,103 +566,280 @@ func (n *Notifier) isSubscribedToEvent(webhook WebhookConfig, event string) bool
 	return false
 }
 
-func (n *Notifier) sendWebhook(webhook WebhookConfig, event string, data interface{}, baseURL string) {
-	var payload []byte
-	var err error
 
-	switch webhook.Type {
-	case "slack":
-		payload, err = n.formatSlackPayload(event, data, baseURL)
-	case "discord":
-		payload, err = n.formatDiscordPayload(event, data, baseURL)
-	case "teams", "msteams":
-		payload, err = n.formatMSTeamsPayload(event, data, baseURL)
-	case "pagerduty":
-		payload, err = n.formatPagerDutyPayload(event, data, webhook)
-	case "opsgenie":
-		payload, err = n.formatOpsgeniePayload(event, data)
-	default:
-		payload, err = json.Marshal(WebhookPayload{
-			Event:     event,
-			Timestamp: time.Now(),
-			Data:      data,
-		})
+// DeliveryResult describes the target's response to a webhook delivery
+type DeliveryResult struct {
+	StatusCode int           `json:"status_code"`
+	Body       string        `json:"body,omitempty"`
+	Duration   time.Duration `json:"-"`
+	DurationMs int64         `json:"duration_ms"`
+	RetryAfter time.Duration `json:"-"` // From the Retry-After header, if any
+	DryRun     bool          `json:"dry_run,omitempty"` // Formatted and logged but not sent; Body is the payload
+}
+
+// ErrWebhookNotFound is returned when a webhook ID does not exist
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// TestWebhook sends a synthetic incident to the webhook with the given ID
+// using the regular formatting and delivery path, ignoring event filters
+// and the enabled flag, and returns the target's response.
+func (n *Notifier) TestWebhook(id string, baseURL string) (*DeliveryResult, error) {
+	webhook := n.findWebhook(id)
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	now := time.Now()
+	incident := storage.Incident{
+		ID:               "test-" + now.Format("20060102150405"),
+		Title:            "Test notification",
+		Status:           "investigating",
+		Severity:         "minor",
+		Message:          "This is a test notification sent from the status page. No action is required.",
+		AffectedServices: []string{"Example Service"},
+		CreatedAt:        now,
+		UpdatedAt:        now,
 	}
 
+	result, err := n.deliver(newDeliveryID(), *webhook, "incident.created", incident, baseURL, nil)
+	if err == nil && result.StatusCode < 400 {
+		// A successful test closes an open circuit
+		n.recordDelivery(*webhook, true, now)
+	}
+	return result, err
+}
+
+// findWebhook returns a copy of the webhook with the given ID
+func (n *Notifier) findWebhook(id string) *WebhookConfig {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	for i := range n.webhooks {
+		if n.webhooks[i].ID == id {
+			wh := n.webhooks[i]
+			return &wh
+		}
+	}
+	return nil
+}
+
+// deliver formats the payload with the webhook type's channel and sends
+// it. The delivery ID identifies the notification to receivers across
+// retries.
+func (n *Notifier) deliver(id string, webhook WebhookConfig, event string, data interface{}, baseURL string, reached map[string]bool) (*DeliveryResult, error) {
+	msg := Message{ID: id, Webhook: webhook, Event: event, Data: n.inLocation(data), BaseURL: baseURL, Reached: reached}
+	ch := n.channel(webhook)
+
+	payload, err := ch.Format(msg)
 	if err != nil {
-		log.Printf("Error formatting webhook payload: %v", err)
-		return
+		return nil, fmt.Errorf("formatting payload: %w", err)
+	}
+	return ch.Send(msg, payload)
+}
+
+// payloadFormat returns the formatter for a webhook: its type, or
+// "template" when a custom payload template is set
+func payloadFormat(webhook WebhookConfig) string {
+	if webhook.Template != "" {
+		return "template"
+	}
+	return webhook.Type
+}
+
+// authorize returns a request hook adding the credentials configured in a
+// webhook's options, or nil when the type needs none
+func authorize(webhook WebhookConfig) func(*http.Request) {
+	switch webhook.Type {
+	case "ntfy":
+		if token := webhook.Headers["token"]; token != "" {
+			return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+		}
+	case "gotify":
+		if token := webhook.Headers["app_token"]; token != "" {
+			return func(req *http.Request) { req.Header.Set("X-Gotify-Key", token) }
+		}
+	case "opsgenie":
+		if key := webhook.Headers["api_key"]; key != "" {
+			return opsgenieAuth(key)
+		}
 	}
+	return nil
+}
 
-	req, err := http.NewRequest("POST", webhook.URL, bytes.NewBuffer(payload))
+// post sends body to url with the webhook's custom headers and returns
+// the target's response. prepare, if set, can adjust the request first.
+func (n *Notifier) post(webhook WebhookConfig, url, contentType string, body []byte, prepare func(*http.Request)) (*DeliveryResult, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
 	if err != nil {
-		log.Printf("Error creating webhook request: %v", err)
-		return
+		return nil, fmt.Errorf("creating request: %w", redactURLError(err))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	for key, value := range webhook.Headers {
+		if isWebhookOption(webhook.Type, key) {
+			continue
+		}
 		req.Header.Set(key, value)
 	}
+	if prepare != nil {
+		prepare(req)
+	}
+	if n.isDryRun(webhook) {
+		return dryRunResult(webhook, req.URL.Host, body), nil
+	}
 
+	start := time.Now()
 	resp, err := n.client.Do(req)
 	if err != nil {
-		log.Printf("Error sending webhook to %s: %v", webhook.Name, err)
-		return
+		return nil, redactURLError(err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		log.Printf("Webhook %s returned status %d", webhook.Name, resp.StatusCode)
-	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	elapsed := time.Since(start)
+
+	return &DeliveryResult{
+		StatusCode: resp.StatusCode,
+		Body:       string(respBody),
+		Duration:   elapsed,
+		DurationMs: elapsed.Milliseconds(),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}, nil
 }
 
-func (n *Notifier) formatSlackPayload(event string, data interface{}, baseURL string) ([]byte, error) {
-	var attachment SlackAttachment
+// formatSlackPayload formats a Block Kit message: a header, fields, the
+// message body, a context line and a button linking to the status page.
+// The optional channel option overrides a legacy webhook's channel.
+func (n *Notifier) formatSlackPayload(event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	var header, body, link, linkText string
+	var fields []SlackText
+	var ts time.Time
 
 	switch v := data.(type) {
 	case storage.Incident:
-		color := n.severityToColor(v.Severity)
-		attachment = SlackAttachment{
-			Color:     color,
-			Title:     fmt.Sprintf("[%s] %s", v.Status, v.Title),
-			TitleLink: fmt.Sprintf("%s/incidents/%s", baseURL, v.ID),
-			Text:      v.Message,
-			Fields: []SlackField{
-				{Title: "Status", Value: v.Status, Short: true},
-				{Title: "Severity", Value: v.Severity, Short: true},
-			},
-			Footer: "Status Monitor",
-			Ts:     v.UpdatedAt.Unix(),
+		header = fmt.Sprintf("%s [%s] %s", slackIncidentEmoji(v), v.Status, v.Title)
+		body = slackEscape(v.Message)
+		fields = []SlackText{
+			slackField("Status", v.Status),
+			slackField("Severity", v.Severity),
 		}
 		if len(v.AffectedServices) > 0 {
-			attachment.Fields = append(attachment.Fields, SlackField{
-				Title: "Affected Services",
-				Value: fmt.Sprintf("%v", v.AffectedServices),
-				Short: false,
-			})
+			fields = append(fields, slackField("Affected Services", strings.Join(v.AffectedServices, ", ")))
 		}
+		link, linkText = fmt.Sprintf("%s/incidents/%s", baseURL, v.ID), "View incident"
+		ts = v.UpdatedAt
 
 	case storage.Maintenance:
-		attachment = SlackAttachment{
-			Color:     "#3498db",
-			Title:     fmt.Sprintf("Scheduled Maintenance: %s", v.Title),
-			TitleLink: fmt.Sprintf("%s/maintenance/%s", baseURL, v.ID),
-			Text:      v.Description,
-			Fields: []SlackField{
-				{Title: "Start", Value: v.ScheduledStart.Format("Jan 02, 2006 15:04 MST"), Short: true},
-				{Title: "End", Value: v.ScheduledEnd.Format("Jan 02, 2006 15:04 MST"), Short: true},
-			},
-			Footer: "Status Monitor",
-			Ts:     v.CreatedAt.Unix(),
+		header = ":wrench: " + maintenanceHeading(v) + ": " + v.Title
+		body = slackEscape(v.Description)
+		fields = []SlackText{
+			slackField("Start", v.ScheduledStart.Format("Jan 02, 2006 15:04 MST")),
+			slackField("End", v.ScheduledEnd.Format("Jan 02, 2006 15:04 MST")),
+		}
+		if len(v.AffectedServices) > 0 {
+			fields = append(fields, slackField("Affected Services", strings.Join(v.AffectedServices, ", ")))
 		}
+		link, linkText = fmt.Sprintf("%s/maintenance/%s", baseURL, v.ID), "View maintenance"
+		ts = v.CreatedAt
+
+	case ServiceEvent:
+		header = slackStatusEmoji(v.Status) + " " + v.title()
+		body = slackEscape(v.detail())
+		fields = []SlackText{
+			slackField("Status", v.Status),
+			slackField("Previous", v.PreviousStatus),
+		}
+		link, linkText = baseURL, "View status page"
+		ts = v.Timestamp
+
+	case Digest:
+		header = ":bell: " + v.title()
+		lines := make([]string, 0, len(v.Entries))
+		for _, e := range v.Entries {
+			title := slackEscape(e.Title)
+			if e.Link != "" {
+				title = fmt.Sprintf("<%s|%s>", e.Link, title)
+			}
+			lines = append(lines, fmt.Sprintf("• %s %s", e.Timestamp.Format("15:04"), title))
+		}
+		body = strings.Join(lines, "\n")
+		link, linkText = baseURL, "View status page"
+		ts = v.End
+
+	default:
+		header = "Status Update"
+		link, linkText = baseURL, "View status page"
+		ts = time.Now()
 	}
 
+	blocks := []SlackBlock{
+		{Type: "header", Text: &SlackText{Type: "plain_text", Text: truncate(header, 150)}},
+	}
+	if len(fields) > 0 {
+		blocks = append(blocks, SlackBlock{Type: "section", Fields: fields})
+	}
+	if body != "" {
+		blocks = append(blocks, SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: truncate(body, 3000)}})
+	}
+	blocks = append(blocks,
+		SlackBlock{Type: "context", Elements: []interface{}{
+			SlackText{Type: "mrkdwn", Text: fmt.Sprintf("Status Monitor · <!date^%d^{date_short_pretty} {time}|%s>",
+				ts.Unix(), ts.Format("Jan 02, 2006 15:04 MST"))},
+		}},
+		SlackBlock{Type: "actions", Elements: []interface{}{
+			SlackButton{Type: "button", Text: SlackText{Type: "plain_text", Text: linkText}, URL: link},
+		}},
+	)
+
 	return json.Marshal(SlackPayload{
-		Attachments: []SlackAttachment{attachment},
+		Text:    slackEscape(header),
+		Channel: webhook.Headers["channel"],
+		Blocks:  blocks,
 	})
 }
 
+func slackField(label, value string) SlackText {
+	return SlackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", label, slackEscape(value))}
+}
+
+// slackEscape escapes the characters Slack treats as control sequences
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// truncate shortens s to at most max runes, ending with an ellipsis
+func truncate(s string, max int) string {
+	if runes := []rune(s); len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+	return s
+}
+
+func slackIncidentEmoji(incident storage.Incident) string {
+	if incident.Status == "resolved" {
+		return ":white_check_mark:"
+	}
+	switch incident.Severity {
+	case "critical":
+		return ":red_circle:"
+	case "major":
+		return ":large_orange_circle:"
+	default:
+		return ":large_yellow_circle:"
+	}
+}
+
+func slackStatusEmoji(status string) string {
+	switch status {
+	case "down":
+		return ":red_circle:"
+	case "degraded":
+		return ":large_yellow_circle:"
+	default:
+		return ":white_check_mark:"
+	}
+}
+
 func (n *Notifier) formatDiscordPayload(event string, data interface{}, baseURL string) ([]byte, error) {
 	var embed DiscordEmbed
 
@@ -333,7 +868,7 @@ func (n *Notifier) formatDiscordPayload(event string, data interface{}, baseURL
 
 	case storage.Maintenance:
 		embed = DiscordEmbed{
-			Title:       fmt.Sprintf("Scheduled Maintenance: %s", v.Title),
+			Title:       fmt.Sprintf("%s: %s", maintenanceHeading(v), v.Title),
 			Description: v.Description,
 			URL:         fmt.Sprintf("%s/maintenance/%s", baseURL, v.ID),
 			Color:       3447003, // Blue
@@ -344,6 +879,30 @@ func (n *Notifier) formatDiscordPayload(event string, data interface{}, baseURL
 			Timestamp: v.CreatedAt.Format(time.RFC3339),
 			Footer:    &DiscordEmbedFooter{Text: "Status Monitor"},
 		}
+
+	case ServiceEvent:
+		embed = DiscordEmbed{
+			Title:       v.title(),
+			Description: v.detail(),
+			URL:         baseURL,
+			Color:       n.serviceStatusToDiscordColor(v.Status),
+			Fields: []DiscordEmbedField{
+				{Name: "Status", Value: v.Status, Inline: true},
+				{Name: "Previous", Value: v.PreviousStatus, Inline: true},
+			},
+			Timestamp: v.Timestamp.Format(time.RFC3339),
+			Footer:    &DiscordEmbedFooter{Text: "Status Monitor"},
+		}
+
+	case Digest:
+		embed = DiscordEmbed{
+			Title:       v.title(),
+			Description: strings.Join(v.lines(), "\n"),
+			URL:         baseURL,
+			Color:       n.severityToDiscordColor(v.severity()),
+			Timestamp:   v.End.Format(time.RFC3339),
+			Footer:      &DiscordEmbedFooter{Text: "Status Monitor"},
+		}
 	}
 
 	return json.Marshal(DiscordPayload{
@@ -364,6 +923,28 @@ func (n *Notifier) severityToColor(severity string) string {
 	}
 }
 
+func (n *Notifier) serviceStatusToColor(status string) string {
+	switch status {
+	case "down":
+		return "#e74c3c"
+	case "degraded":
+		return "#f39c12"
+	default:
+		return "#2ecc71"
+	}
+}
+
+func (n *Notifier) serviceStatusToDiscordColor(status string) int {
+	switch status {
+	case "down":
+		return 15158332 // Red
+	case "degraded":
+		return 15105570 // Orange
+	default:
+		return 3066993 // Green
+	}
+}
+
 func (n *Notifier) severityToDiscordColor(severity string) int {
 	switch severity {
 	case "critical":
@@ -410,10 +991,10 @@ func (n *Notifier) formatMSTeamsPayload(event string, data interface{}, baseURL
 
 	case storage.Maintenance:
 		themeColor = "0078D7" // Blue
-		summary = fmt.Sprintf("Scheduled Maintenance: %s", v.Title)
+		summary = fmt.Sprintf("%s: %s", maintenanceHeading(v), v.Title)
 		section = MSTeamsSection{
 			ActivityTitle:    v.Title,
-			ActivitySubtitle: "Scheduled Maintenance",
+			ActivitySubtitle: maintenanceHeading(v),
 			Facts: []MSTeamsFact{
 				{Name: "Description", Value: v.Description},
 				{Name: "Start", Value: v.ScheduledStart.Format("Jan 02, 2006 15:04 MST")},
@@ -421,6 +1002,35 @@ func (n *Notifier) formatMSTeamsPayload(event string, data interface{}, baseURL
 			},
 			Markdown: true,
 		}
+
+	case ServiceEvent:
+		themeColor = strings.TrimPrefix(n.serviceStatusToColor(v.Status), "#")
+		summary = v.title()
+		section = MSTeamsSection{
+			ActivityTitle:    v.title(),
+			ActivitySubtitle: fmt.Sprintf("Status: %s | Previous: %s", v.Status, v.PreviousStatus),
+			Facts: []MSTeamsFact{
+				{Name: "Details", Value: v.detail()},
+				{Name: "Link", Value: fmt.Sprintf("[View Status Page](%s)", baseURL)},
+			},
+			Markdown: true,
+		}
+
+	case Digest:
+		themeColor = n.severityToTeamsColor(v.severity())
+		summary = v.title()
+		section = MSTeamsSection{
+			ActivityTitle:    v.title(),
+			ActivitySubtitle: fmt.Sprintf("%s - %s", v.Start.Format("Jan 02 15:04"), v.End.Format("15:04 MST")),
+			Markdown:         true,
+		}
+		for _, e := range v.Entries {
+			section.Facts = append(section.Facts, MSTeamsFact{Name: e.Timestamp.Format("15:04"), Value: e.Title})
+		}
+		section.Facts = append(section.Facts, MSTeamsFact{
+			Name:  "Link",
+			Value: fmt.Sprintf("[View Status Page](%s)", baseURL),
+		})
 	}
 
 	return json.Marshal(MSTeamsPayload{
@@ -447,10 +1057,7 @@ func (n *Notifier) severityToTeamsColor(severity string) string {
 
 // formatPagerDutyPayload formats payload for PagerDuty
 func (n *Notifier) formatPagerDutyPayload(event string, data interface{}, webhook WebhookConfig) ([]byte, error) {
-	routingKey := webhook.Headers["routing_key"]
-	if routingKey == "" {
-		routingKey = webhook.Headers["integration_key"]
-	}
+	routingKey := n.pagerDutyRoutingKey(webhook, event, data)
 
 	var eventAction string
 	var summary string
@@ -463,13 +1070,22 @@ func (n *Notifier) formatPagerDutyPayload(event string, data interface{}, webhoo
 		summary = fmt.Sprintf("[%s] %s: %s", v.Severity, v.Title, v.Message)
 		severity = n.severityToPagerDuty(v.Severity)
 
-		switch event {
-		case "incident.created":
-			eventAction = "trigger"
-		case "incident.resolved":
+		// Resolving closes the alert opened under the same dedup key
+		eventAction = "trigger"
+		if event == "incident.resolved" || v.Status == "resolved" {
+			eventAction = "resolve"
+		}
+
+	case ServiceEvent:
+		dedupKey = "service:" + v.Name
+		if v.Certificate != nil {
+			dedupKey = "certificate:" + v.Name
+		}
+		summary = fmt.Sprintf("%s: %s", v.title(), v.detail())
+		severity = n.severityToPagerDuty(v.severity())
+		eventAction = "trigger"
+		if event == EventServiceRecovered {
 			eventAction = "resolve"
-		default:
-			eventAction = "trigger"
 		}
 	}
 
@@ -499,20 +1115,50 @@ func (n *Notifier) severityToPagerDuty(severity string) string {
 	}
 }
 
-// formatOpsgeniePayload formats payload for Opsgenie
-func (n *Notifier) formatOpsgeniePayload(event string, data interface{}) ([]byte, error) {
+// formatOpsgeniePayload formats an Opsgenie create-alert request
+func (n *Notifier) formatOpsgeniePayload(event string, data interface{}, baseURL string) ([]byte, error) {
+	alias := opsgenieAlias(data)
+
 	switch v := data.(type) {
 	case storage.Incident:
 		return json.Marshal(OpsgeniePayload{
 			Message:     fmt.Sprintf("[%s] %s", v.Severity, v.Title),
-			Description: v.Message,
+			Alias:       alias,
+			Description: fmt.Sprintf("%s\n\n%s/incidents/%s", v.Message, baseURL, v.ID),
 			Priority:    n.severityToOpsgenie(v.Severity),
 			Tags:        append([]string{v.Status, v.Severity}, v.AffectedServices...),
+			Entity:      strings.Join(v.AffectedServices, ", "),
+			Source:      "status-monitor",
+		})
+
+	case storage.Maintenance:
+		return json.Marshal(OpsgeniePayload{
+			Message: maintenanceHeading(v) + ": " + v.Title,
+			Alias:   alias,
+			Description: fmt.Sprintf("%s\n%s - %s", v.Description,
+				v.ScheduledStart.Format("Jan 02, 2006 15:04 MST"),
+				v.ScheduledEnd.Format("Jan 02, 2006 15:04 MST")),
+			Priority: "P5",
+			Tags:     append([]string{"maintenance"}, v.AffectedServices...),
+			Source:   "status-monitor",
+		})
+
+	case ServiceEvent:
+		return json.Marshal(OpsgeniePayload{
+			Message:     v.title(),
+			Alias:       alias,
+			Description: v.detail(),
+			Priority:    n.severityToOpsgenie(v.severity()),
+			Tags:        []string{v.Status, v.Name},
+			Entity:      v.Name,
+			Source:      "status-monitor",
 		})
 	}
 
 	return json.Marshal(OpsgeniePayload{
 		Message: "Status Update",
+		Alias:   alias,
+		Source:  "status-monitor",
 	})
 }
 
@@ -0,0 +1,99 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/status/storage"
+)
+
+// Opsgenie Alerts API endpoints by region
+var opsgenieRegions = map[string]string{
+	"us": "https://api.opsgenie.com/v2/alerts",
+	"eu": "https://api.eu.opsgenie.com/v2/alerts",
+}
+
+// OpsgenieNote is the body of the add-note and close alert requests
+type OpsgenieNote struct {
+	Note   string `json:"note,omitempty"`
+	Source string `json:"source"`
+}
+
+// opsgenieChannel drives the Opsgenie Alerts API: new incidents and service
+// alerts create an alert keyed by alias, updates add a note to it, and
+// resolutions close it. Requests authenticate with the api_key option.
+type opsgenieChannel struct {
+	n *Notifier
+}
+
+func (c opsgenieChannel) Format(m Message) ([]byte, error) {
+	switch m.Event {
+	case "incident.updated":
+		inc, _ := m.Data.(storage.Incident)
+		return json.Marshal(OpsgenieNote{
+			Note:   fmt.Sprintf("[%s] %s", inc.Status, latestUpdate(inc)),
+			Source: "status-monitor",
+		})
+	case "maintenance.started":
+		maint, _ := m.Data.(storage.Maintenance)
+		return json.Marshal(OpsgenieNote{Note: maintenanceHeading(maint), Source: "status-monitor"})
+	case "incident.resolved", EventServiceRecovered, "maintenance.completed":
+		note := "Resolved"
+		if inc, ok := m.Data.(storage.Incident); ok {
+			note = latestUpdate(inc)
+		} else if ev, ok := m.Data.(ServiceEvent); ok {
+			note = ev.title()
+		} else if maint, ok := m.Data.(storage.Maintenance); ok {
+			note = maintenanceHeading(maint)
+		}
+		return json.Marshal(OpsgenieNote{Note: note, Source: "status-monitor"})
+	}
+	return c.n.formatOpsgeniePayload(m.Event, m.Data, m.BaseURL)
+}
+
+func (c opsgenieChannel) Send(m Message, body []byte) (*DeliveryResult, error) {
+	return c.n.post(m.Webhook, opsgenieTarget(m.Webhook, m.Event, m.Data), "application/json", body, authorize(m.Webhook))
+}
+
+// opsgenieTarget returns the API URL for an event: the alert's notes or
+// close action, or the alerts endpoint to create one
+func opsgenieTarget(webhook WebhookConfig, event string, data interface{}) string {
+	alertURL := strings.TrimRight(webhook.Endpoint(), "/") + "/" + url.PathEscape(opsgenieAlias(data))
+	switch event {
+	case "incident.updated", "maintenance.started":
+		return alertURL + "/notes?identifierType=alias"
+	case "incident.resolved", EventServiceRecovered, "maintenance.completed":
+		return alertURL + "/close?identifierType=alias"
+	}
+	return webhook.Endpoint()
+}
+
+// opsgenieAlias identifies the alert for a notification so that repeat
+// creates are deduplicated and later updates find the same alert
+func opsgenieAlias(data interface{}) string {
+	switch v := data.(type) {
+	case storage.Incident:
+		return "incident-" + v.ID
+	case storage.Maintenance:
+		return "maintenance-" + v.ID
+	case ServiceEvent:
+		return v.alertKey()
+	}
+	return "status"
+}
+
+// latestUpdate returns the most recent update message of an incident
+func latestUpdate(inc storage.Incident) string {
+	if len(inc.Updates) > 0 {
+		return inc.Updates[len(inc.Updates)-1].Message
+	}
+	return inc.Message
+}
+
+// opsgenieAuth sets the GenieKey authorization header
+func opsgenieAuth(apiKey string) func(*http.Request) {
+	return func(req *http.Request) { req.Header.Set("Authorization", "GenieKey "+apiKey) }
+}
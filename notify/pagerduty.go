@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// pagerDutyEventsURL is the default PagerDuty Events API v2 endpoint
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyChangePayload for PagerDuty Change Events
+type PagerDutyChangePayload struct {
+	RoutingKey string                 `json:"routing_key"`
+	Payload    PagerDutyChangeDetails `json:"payload"`
+	Links      []PagerDutyLink        `json:"links,omitempty"`
+}
+
+type PagerDutyChangeDetails struct {
+	Summary       string                 `json:"summary"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Source        string                 `json:"source"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type PagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text,omitempty"`
+}
+
+// pagerDutyChannel sends incidents and service alerts as trigger/resolve
+// events, and maintenance windows as Change Events
+type pagerDutyChannel struct {
+	n *Notifier
+}
+
+func (c pagerDutyChannel) Format(m Message) ([]byte, error) {
+	if maint, ok := m.Data.(storage.Maintenance); ok {
+		return c.n.formatPagerDutyChange(m.Event, maint, m.Webhook, m.BaseURL)
+	}
+	return c.n.formatPagerDutyPayload(m.Event, m.Data, m.Webhook)
+}
+
+func (c pagerDutyChannel) Send(m Message, payload []byte) (*DeliveryResult, error) {
+	target := m.Webhook.Endpoint()
+	if _, ok := m.Data.(storage.Maintenance); ok {
+		target = pagerDutyChangeURL(target)
+	}
+	return c.n.post(m.Webhook, target, "application/json", payload, nil)
+}
+
+// Probe only checks the Events API is reachable: routing keys cannot be
+// validated without sending an event
+func (c pagerDutyChannel) Probe(webhook WebhookConfig) error {
+	return c.n.probeHTTP(webhook, nil, false)
+}
+
+// pagerDutyChangeURL derives the Change Events endpoint from the Events API
+// endpoint (…/v2/enqueue -> …/v2/change/enqueue)
+func pagerDutyChangeURL(eventsURL string) string {
+	if base, ok := strings.CutSuffix(eventsURL, "/v2/enqueue"); ok {
+		return base + "/v2/change/enqueue"
+	}
+	return eventsURL
+}
+
+// formatPagerDutyChange formats a maintenance window as a Change Event
+func (n *Notifier) formatPagerDutyChange(event string, m storage.Maintenance, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	details := map[string]interface{}{
+		"event":  event,
+		"status": m.Status,
+		"start":  m.ScheduledStart.Format(time.RFC3339),
+		"end":    m.ScheduledEnd.Format(time.RFC3339),
+	}
+	if m.Description != "" {
+		details["description"] = m.Description
+	}
+	if len(m.AffectedServices) > 0 {
+		details["affected_services"] = m.AffectedServices
+	}
+
+	return json.Marshal(PagerDutyChangePayload{
+		RoutingKey: n.pagerDutyRoutingKey(webhook, event, m),
+		Payload: PagerDutyChangeDetails{
+			Summary:       fmt.Sprintf("%s: %s", maintenanceHeading(m), m.Title),
+			Timestamp:     time.Now().Format(time.RFC3339),
+			Source:        "status-monitor",
+			CustomDetails: details,
+		},
+		Links: []PagerDutyLink{{
+			Href: fmt.Sprintf("%s/maintenance/%s", baseURL, m.ID),
+			Text: "View maintenance",
+		}},
+	})
+}
+
+// pagerDutyRoutingKey returns the routing key for a notification. Options
+// of the form routing_key.service.<name>, routing_key.group.<name> and
+// routing_key.event.<pattern> override the default routing_key (or the
+// older integration_key name), in that order of precedence. Resolve events
+// must map to the same key as their trigger, so event overrides should use
+// patterns like "incident.*".
+func (n *Notifier) pagerDutyRoutingKey(webhook WebhookConfig, event string, data interface{}) string {
+	services := eventServices(data)
+	for _, svc := range services {
+		if key := webhook.Headers["routing_key.service."+svc]; key != "" {
+			return key
+		}
+	}
+
+	n.mu.RLock()
+	groups := n.serviceGroups
+	n.mu.RUnlock()
+	for _, svc := range services {
+		group := groups[svc]
+		if ev, ok := data.(ServiceEvent); ok && ev.Group != "" {
+			group = ev.Group
+		}
+		if key := webhook.Headers["routing_key.group."+group]; group != "" && key != "" {
+			return key
+		}
+	}
+
+	// The most specific event pattern wins: exact names over "prefix.*" over "*"
+	var best, bestKey string
+	for option, key := range webhook.Headers {
+		pattern, ok := strings.CutPrefix(option, "routing_key.event.")
+		if !ok || key == "" || !matchEvent(pattern, event) {
+			continue
+		}
+		if len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best, bestKey = pattern, key
+		}
+	}
+	if bestKey != "" {
+		return bestKey
+	}
+
+	if key := webhook.Headers["routing_key"]; key != "" {
+		return key
+	}
+	return webhook.Headers["integration_key"]
+}
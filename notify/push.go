@@ -0,0 +1,231 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/status/storage"
+)
+
+// NtfyPayload for publishing to an ntfy topic as JSON
+type NtfyPayload struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+}
+
+// GotifyPayload for the Gotify message API
+type GotifyPayload struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+// PushoverPayload for the Pushover messages API
+type PushoverPayload struct {
+	Token    string `json:"token"`
+	User     string `json:"user"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	URL      string `json:"url,omitempty"`
+	URLTitle string `json:"url_title,omitempty"`
+	Priority int    `json:"priority"`
+	Retry    int    `json:"retry,omitempty"`
+	Expire   int    `json:"expire,omitempty"`
+	Sound    string `json:"sound,omitempty"`
+	Device   string `json:"device,omitempty"`
+}
+
+// Pushover emergency priority defaults: re-alert every 60s for up to an hour
+const (
+	pushoverEmergency     = 2
+	pushoverDefaultRetry  = 60
+	pushoverDefaultExpire = 3600
+)
+
+// pushMessage holds the fields shared by the push notification formats
+type pushMessage struct {
+	title    string
+	message  string
+	priority int // 1 (min) to 5 (max), ntfy scale
+	tags     []string
+	link     string
+}
+
+func (n *Notifier) buildPushMessage(data interface{}, baseURL string) pushMessage {
+	switch v := data.(type) {
+	case storage.Incident:
+		msg := pushMessage{
+			title:    fmt.Sprintf("[%s] %s", v.Status, v.Title),
+			message:  v.Message,
+			priority: n.severityToPushPriority(v.Severity),
+			tags:     []string{"warning", v.Severity},
+			link:     fmt.Sprintf("%s/incidents/%s", baseURL, v.ID),
+		}
+		if v.Status == "resolved" {
+			msg.priority = 3
+			msg.tags = []string{"white_check_mark"}
+		}
+		if len(v.AffectedServices) > 0 {
+			msg.message += "\nAffected: " + strings.Join(v.AffectedServices, ", ")
+		}
+		return msg
+
+	case storage.Maintenance:
+		return pushMessage{
+			title: maintenanceHeading(v) + ": " + v.Title,
+			message: fmt.Sprintf("%s\n%s - %s", v.Description,
+				v.ScheduledStart.Format("Jan 02, 2006 15:04 MST"),
+				v.ScheduledEnd.Format("Jan 02, 2006 15:04 MST")),
+			priority: 2,
+			tags:     []string{"wrench"},
+			link:     baseURL,
+		}
+
+	case ServiceEvent:
+		msg := pushMessage{
+			title:    v.title(),
+			message:  v.detail(),
+			priority: n.severityToPushPriority(v.severity()),
+			tags:     []string{"warning", v.Status},
+			link:     baseURL,
+		}
+		if v.Status == "operational" {
+			msg.priority = 3
+			msg.tags = []string{"white_check_mark"}
+		}
+		return msg
+
+	case Digest:
+		return pushMessage{
+			title:    v.title(),
+			message:  strings.Join(v.lines(), "\n"),
+			priority: n.severityToPushPriority(v.severity()),
+			tags:     []string{"bell"},
+			link:     baseURL,
+		}
+	}
+
+	return pushMessage{title: "Status Update", priority: 3}
+}
+
+func (n *Notifier) severityToPushPriority(severity string) int {
+	switch severity {
+	case "critical":
+		return 5
+	case "major":
+		return 4
+	default:
+		return 3
+	}
+}
+
+// formatNtfyPayload formats a JSON publish request for ntfy. The topic is
+// required; priority and extra comma-separated tags may be overridden.
+func (n *Notifier) formatNtfyPayload(event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	msg := n.buildPushMessage(data, baseURL)
+
+	if p, err := strconv.Atoi(webhook.Headers["priority"]); err == nil {
+		msg.priority = p
+	}
+	for _, tag := range strings.Split(webhook.Headers["tags"], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			msg.tags = append(msg.tags, tag)
+		}
+	}
+
+	return json.Marshal(NtfyPayload{
+		Topic:    webhook.Headers["topic"],
+		Title:    msg.title,
+		Message:  msg.message,
+		Priority: msg.priority,
+		Tags:     msg.tags,
+		Click:    msg.link,
+	})
+}
+
+// formatGotifyPayload formats a Gotify message. Gotify priorities run 0-10,
+// so the 1-5 scale is doubled unless overridden.
+func (n *Notifier) formatGotifyPayload(event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	msg := n.buildPushMessage(data, baseURL)
+
+	priority := msg.priority * 2
+	if p, err := strconv.Atoi(webhook.Headers["priority"]); err == nil {
+		priority = p
+	}
+
+	payload := GotifyPayload{
+		Title:    msg.title,
+		Message:  msg.message,
+		Priority: priority,
+	}
+	if msg.link != "" {
+		payload.Extras = map[string]interface{}{
+			"client::notification": map[string]interface{}{
+				"click": map[string]string{"url": msg.link},
+			},
+		}
+	}
+
+	return json.Marshal(payload)
+}
+
+// formatPushoverPayload formats a Pushover message. Critical incidents use
+// emergency priority, which Pushover repeats every retry seconds until
+// acknowledged or expire seconds pass.
+func (n *Notifier) formatPushoverPayload(event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	msg := n.buildPushMessage(data, baseURL)
+
+	payload := PushoverPayload{
+		Token:    webhook.Headers["app_token"],
+		User:     webhook.Headers["user_key"],
+		Title:    msg.title,
+		Message:  msg.message,
+		URL:      msg.link,
+		Priority: n.pushPriorityToPushover(msg.priority),
+		Sound:    webhook.Headers["sound"],
+		Device:   webhook.Headers["device"],
+	}
+	if payload.Message == "" {
+		payload.Message = msg.title // Pushover rejects empty messages
+	}
+	if payload.URL != "" {
+		payload.URLTitle = "View on status page"
+	}
+	if p, err := strconv.Atoi(webhook.Headers["priority"]); err == nil {
+		payload.Priority = p
+	}
+
+	if payload.Priority == pushoverEmergency {
+		payload.Retry = pushoverDefaultRetry
+		if r, err := strconv.Atoi(webhook.Headers["retry"]); err == nil {
+			payload.Retry = r
+		}
+		payload.Expire = pushoverDefaultExpire
+		if e, err := strconv.Atoi(webhook.Headers["expire"]); err == nil {
+			payload.Expire = e
+		}
+	}
+
+	return json.Marshal(payload)
+}
+
+// pushPriorityToPushover maps the 1-5 push scale onto Pushover's -2..2
+func (n *Notifier) pushPriorityToPushover(priority int) int {
+	switch {
+	case priority >= 5:
+		return pushoverEmergency
+	case priority == 4:
+		return 1
+	case priority <= 2:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// Quiet hours actions
+const (
+	QuietSuppress = "suppress"
+	QuietDefer    = "defer"
+)
+
+// deferredFlushInterval is how often deferred notifications are checked
+// for webhooks whose quiet hours have ended
+const deferredFlushInterval = time.Minute
+
+// deferredNotification is held until the webhook's quiet hours end
+type deferredNotification struct {
+	webhookID string
+	event     string
+	data      interface{}
+	baseURL   string
+}
+
+// ValidateQuietHours checks a quiet hours schedule and action
+func ValidateQuietHours(q storage.QuietHours) error {
+	if q.Action != "" && q.Action != QuietSuppress && q.Action != QuietDefer {
+		return fmt.Errorf("invalid quiet hours action %q (want suppress or defer)", q.Action)
+	}
+	if q.MinSeverity != "" && severityRank[q.MinSeverity] == 0 {
+		return fmt.Errorf("invalid quiet hours min_severity %q", q.MinSeverity)
+	}
+	if len(q.Days) == 0 && q.From == "" {
+		return fmt.Errorf("quiet hours need days or from/to")
+	}
+	_, err := newSchedule(q.Days, q.From, q.To, q.Timezone)
+	return err
+}
+
+// inQuietHours reports whether the webhook's quiet hours hold back this
+// notification at now. Notifications at or above the quiet hours
+// min_severity (default critical) are always delivered.
+func inQuietHours(webhook WebhookConfig, data interface{}, now time.Time) bool {
+	q := webhook.QuietHours
+	if q == nil {
+		return false
+	}
+
+	min := q.MinSeverity
+	if min == "" {
+		min = "critical"
+	}
+	if severity := eventSeverity(data); severity != "" && severityRank[severity] >= severityRank[min] {
+		return false
+	}
+
+	sc, err := newSchedule(q.Days, q.From, q.To, q.Timezone)
+	if err != nil {
+		slog.Warn("Ignoring invalid quiet hours", "webhook", webhook.Name, "error", err)
+		return false
+	}
+	return sc.contains(now)
+}
+
+// holdForQuietHours suppresses or defers a notification that falls in the
+// webhook's quiet hours. It reports whether the notification was held.
+func (n *Notifier) holdForQuietHours(webhook WebhookConfig, event string, data interface{}, baseURL string, now time.Time) bool {
+	if !inQuietHours(webhook, data, now) {
+		return false
+	}
+
+	if webhook.QuietHours.Action != QuietDefer {
+		slog.Info("Notification suppressed during quiet hours", "webhook", webhook.Name, "event", event)
+		return true
+	}
+
+	n.deferMu.Lock()
+	n.deferred = append(n.deferred, deferredNotification{
+		webhookID: webhook.ID,
+		event:     event,
+		data:      data,
+		baseURL:   baseURL,
+	})
+	n.deferMu.Unlock()
+	n.deferOnce.Do(func() { go n.flushDeferred() })
+
+	slog.Info("Notification deferred until quiet hours end", "webhook", webhook.Name, "event", event)
+	return true
+}
+
+// flushDeferred delivers deferred notifications once their webhook's quiet
+// hours are over. Notifications for removed or disabled webhooks are dropped.
+// Webhooks are looked up without holding deferMu, as notify holds mu while
+// taking deferMu.
+func (n *Notifier) flushDeferred() {
+	ticker := time.NewTicker(deferredFlushInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		n.deferMu.Lock()
+		deferred := n.deferred
+		n.deferred = nil
+		n.deferMu.Unlock()
+
+		var pending []deferredNotification
+		for _, d := range deferred {
+			webhook := n.findWebhook(d.webhookID)
+			if webhook == nil || !webhook.Enabled {
+				continue
+			}
+			if inQuietHours(*webhook, d.data, now) {
+				pending = append(pending, d)
+				continue
+			}
+			go n.sendWebhook(*webhook, d.event, d.data, d.baseURL)
+		}
+
+		// Keep them ahead of any deferred while the lock was released
+		n.deferMu.Lock()
+		n.deferred = append(pending, n.deferred...)
+		n.deferMu.Unlock()
+	}
+}
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// RetryPolicy controls redelivery of failed webhook notifications
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts, including the first
+	InitialBackoff time.Duration // Delay before the first retry; doubles each attempt
+	MaxBackoff     time.Duration // Upper bound for the computed delay
+}
+
+// DefaultRetryPolicy retries for roughly five minutes before giving up
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 2 * time.Second,
+	MaxBackoff:     5 * time.Minute,
+}
+
+// DeadLetterStore persists deliveries that failed every attempt
+type DeadLetterStore interface {
+	SaveDeadLetter(dl storage.DeadLetter) (*storage.DeadLetter, error)
+}
+
+// SetRetryPolicy replaces the webhook retry policy
+func (n *Notifier) SetRetryPolicy(policy RetryPolicy) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	n.retry = policy
+}
+
+// SetDeadLetterStore sets where permanently failed deliveries are recorded
+func (n *Notifier) SetDeadLetterStore(store DeadLetterStore) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deadLetters = store
+}
+
+// sendWebhook delivers a notification, retrying transient failures with
+// exponential backoff and jitter, and dead-letters it when attempts run out.
+// Deliveries to a webhook whose circuit is open are dead-lettered unsent.
+func (n *Notifier) sendWebhook(webhook WebhookConfig, event string, data interface{}, baseURL string) {
+	n.mu.RLock()
+	policy := n.retry
+	n.mu.RUnlock()
+
+	id := newDeliveryID()
+	if !n.allowDelivery(webhook.ID, time.Now()) {
+		slog.Warn("Webhook circuit open, skipping delivery", "webhook", webhook.Name, "event", event, "delivery", id)
+		n.deliveries.failed(webhook, event)
+		n.logWebhookDelivery(id, webhook, event, 0, nil, errCircuitOpen)
+		n.deadLetter(id, webhook, event, data, 0, 0, errCircuitOpen)
+		return
+	}
+
+	var lastErr error
+	var lastStatus int
+	reached := make(map[string]bool)
+	attempt := 1
+	for ; ; attempt++ {
+		if attempt > 1 {
+			n.deliveries.retried(webhook, event)
+		}
+		result, err := n.deliver(id, webhook, event, data, baseURL, reached)
+		if err == nil && result.StatusCode < 400 {
+			n.recordDelivery(webhook, true, time.Now())
+			if !result.DryRun {
+				n.deliveries.sent(webhook, event)
+			}
+			n.logWebhookDelivery(id, webhook, event, attempt, result, nil)
+			if attempt > 1 {
+				slog.Info("Webhook delivered after retries", "webhook", webhook.Name, "event", event, "delivery", id, "attempts", attempt)
+			}
+			return
+		}
+
+		retryable := true
+		var retryAfter time.Duration
+		if err != nil {
+			lastErr = err
+			slog.Warn("Error sending webhook", "webhook", webhook.Name, "event", event, "delivery", id, "attempt", attempt, "max_attempts", policy.MaxAttempts, "error", err)
+		} else {
+			lastStatus = result.StatusCode
+			lastErr = fmt.Errorf("status %d: %s", result.StatusCode, result.Body)
+			retryable = isRetryableStatus(result.StatusCode)
+			retryAfter = result.RetryAfter
+			slog.Warn("Webhook returned an error status", "webhook", webhook.Name, "event", event, "delivery", id, "status", result.StatusCode, "attempt", attempt, "max_attempts", policy.MaxAttempts)
+		}
+
+		if !retryable || attempt >= policy.MaxAttempts {
+			break
+		}
+
+		// A Retry-After beyond max_backoff would hold this goroutine for as
+		// long as the target likes; dead-letter it for a later replay instead
+		delay := policy.backoff(attempt)
+		if retryAfter > 0 {
+			if policy.MaxBackoff > 0 && retryAfter > policy.MaxBackoff {
+				lastErr = fmt.Errorf("%w (Retry-After %s exceeds max_backoff %s)", lastErr, retryAfter, policy.MaxBackoff)
+				break
+			}
+			delay = retryAfter
+		}
+		time.Sleep(delay)
+	}
+
+	slog.Error("Webhook failed permanently", "webhook", webhook.Name, "event", event, "delivery", id, "attempts", attempt, "error", lastErr)
+	n.recordDelivery(webhook, false, time.Now())
+	n.deliveries.failed(webhook, event)
+	n.logWebhookDelivery(id, webhook, event, attempt, nil, lastErr)
+	n.deadLetter(id, webhook, event, data, attempt, lastStatus, lastErr)
+}
+
+// backoff returns the delay before retry number attempt (1-based), using
+// "equal jitter": half the exponential delay plus a random share of the rest
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryableStatus reports whether a response status indicates a
+// transient failure. Other 4xx responses will not succeed on retry.
+func isRetryableStatus(code int) bool {
+	return code == 408 || code == 429 || code >= 500
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or HTTP-date form
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func (n *Notifier) deadLetter(id string, webhook WebhookConfig, event string, data interface{}, attempts, status int, cause error) {
+	n.mu.RLock()
+	store := n.deadLetters
+	n.mu.RUnlock()
+
+	if store == nil {
+		return
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("Error encoding dead letter", "webhook", webhook.Name, "error", err)
+		return
+	}
+
+	dl := storage.DeadLetter{
+		DeliveryID:  id,
+		WebhookID:   webhook.ID,
+		WebhookName: webhook.Name,
+		Event:       event,
+		DataType:    dataType(data),
+		Data:        raw,
+		Attempts:    attempts,
+		LastStatus:  status,
+	}
+	if cause != nil {
+		dl.LastError = cause.Error()
+	}
+	if _, err := store.SaveDeadLetter(dl); err != nil {
+		slog.Error("Error saving dead letter", "webhook", webhook.Name, "error", err)
+	}
+}
+
+func dataType(data interface{}) string {
+	switch data.(type) {
+	case storage.Incident:
+		return "incident"
+	case storage.Maintenance:
+		return "maintenance"
+	case ServiceEvent:
+		return "service"
+	case Digest:
+		return "digest"
+	default:
+		return "unknown"
+	}
+}
+
+// Redeliver makes one more attempt at a dead-lettered notification
+func (n *Notifier) Redeliver(dl storage.DeadLetter, baseURL string) (*DeliveryResult, error) {
+	webhook := n.findWebhook(dl.WebhookID)
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+
+	var data interface{}
+	switch dl.DataType {
+	case "incident":
+		var incident storage.Incident
+		if err := json.Unmarshal(dl.Data, &incident); err != nil {
+			return nil, err
+		}
+		data = incident
+	case "maintenance":
+		var maintenance storage.Maintenance
+		if err := json.Unmarshal(dl.Data, &maintenance); err != nil {
+			return nil, err
+		}
+		data = maintenance
+	case "service":
+		var event ServiceEvent
+		if err := json.Unmarshal(dl.Data, &event); err != nil {
+			return nil, err
+		}
+		data = event
+	case "digest":
+		var digest Digest
+		if err := json.Unmarshal(dl.Data, &digest); err != nil {
+			return nil, err
+		}
+		data = digest
+	default:
+		return nil, fmt.Errorf("unknown data type %q", dl.DataType)
+	}
+
+	id := dl.DeliveryID
+	if id == "" {
+		id = newDeliveryID()
+	}
+	result, err := n.deliver(id, *webhook, dl.Event, data, baseURL, nil)
+	if err == nil && result.StatusCode < 400 {
+		n.recordDelivery(*webhook, true, time.Now())
+	}
+	return result, err
+}
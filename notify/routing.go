@@ -0,0 +1,255 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// Route sends matching notifications to specific webhooks instead of every
+// webhook subscribed to the event. Empty match fields match anything.
+type Route struct {
+	Name       string
+	Events     []string // Exact names, "incident.*" or "*"
+	Severities []string // minor, major, critical
+	Services   []string
+	Groups     []string
+	Days       []string // mon, tue, ... (default every day)
+	From       string   // Start of the time window, HH:MM
+	To         string   // End of the time window, HH:MM (may wrap past midnight)
+	Timezone   string   // IANA zone for Days/From/To (default local)
+	Webhooks   []string // Webhook IDs to notify
+	Continue   bool     // Keep evaluating later routes after a match
+
+	window schedule
+}
+
+// schedule is a compiled set of weekdays and a daily time window
+type schedule struct {
+	location *time.Location
+	from, to int // Minutes since midnight; -1 when unset
+	days     map[time.Weekday]bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// SetServiceGroups sets the service to group mapping used for group
+// matching when no routes are configured
+func (n *Notifier) SetServiceGroups(serviceGroups map[string]string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.serviceGroups = serviceGroups
+}
+
+// SetRoutes installs routing rules. Rules are evaluated in order; events
+// that match no rule fall back to each webhook's own events list.
+// serviceGroups maps service names to groups for group matching.
+func (n *Notifier) SetRoutes(routes []Route, serviceGroups map[string]string) error {
+	compiled := make([]Route, 0, len(routes))
+	for i, r := range routes {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("route %d", i+1)
+		}
+		if err := r.compile(); err != nil {
+			return fmt.Errorf("%s: %w", r.Name, err)
+		}
+		compiled = append(compiled, r)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.routes = compiled
+	n.serviceGroups = serviceGroups
+	return nil
+}
+
+func (r *Route) compile() error {
+	if len(r.Webhooks) == 0 {
+		return fmt.Errorf("no webhooks")
+	}
+	window, err := newSchedule(r.Days, r.From, r.To, r.Timezone)
+	if err != nil {
+		return err
+	}
+	r.window = window
+	return nil
+}
+
+// newSchedule compiles days, an HH:MM window and an IANA timezone
+func newSchedule(days []string, from, to, timezone string) (schedule, error) {
+	sc := schedule{location: time.Local}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return sc, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		sc.location = loc
+	}
+
+	var err error
+	if sc.from, err = parseClock(from); err != nil {
+		return sc, err
+	}
+	if sc.to, err = parseClock(to); err != nil {
+		return sc, err
+	}
+	if (sc.from < 0) != (sc.to < 0) {
+		return sc, fmt.Errorf("from and to must be set together")
+	}
+
+	if len(days) > 0 {
+		sc.days = make(map[time.Weekday]bool)
+		for _, d := range days {
+			day, ok := weekdays[strings.ToLower(d)[:min(3, len(d))]]
+			if !ok {
+				return sc, fmt.Errorf("invalid day %q", d)
+			}
+			sc.days[day] = true
+		}
+	}
+	return sc, nil
+}
+
+// parseClock parses HH:MM into minutes since midnight (-1 for empty)
+func parseClock(s string) (int, error) {
+	if s == "" {
+		return -1, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// route returns the webhook IDs selected by the routing rules. ok is false
+// when no rule matched and the flat per-webhook event lists apply.
+// Callers must hold n.mu.
+func (n *Notifier) route(event string, data interface{}, now time.Time) (targets map[string]bool, ok bool) {
+	if len(n.routes) == 0 {
+		return nil, false
+	}
+
+	severity := eventSeverity(data)
+	services := eventServices(data)
+
+	for _, r := range n.routes {
+		if !r.matches(event, severity, services, n.serviceGroups, now) {
+			continue
+		}
+		if targets == nil {
+			targets = make(map[string]bool)
+		}
+		for _, id := range r.Webhooks {
+			targets[id] = true
+		}
+		if !r.Continue {
+			break
+		}
+	}
+	return targets, targets != nil
+}
+
+func (r Route) matches(event, severity string, services []string, groups map[string]string, now time.Time) bool {
+	if len(r.Events) > 0 && !matchAny(r.Events, func(p string) bool { return matchEvent(p, event) }) {
+		return false
+	}
+	if len(r.Severities) > 0 && !matchAny(r.Severities, func(s string) bool { return s == severity }) {
+		return false
+	}
+	if len(r.Services) > 0 && !matchAny(r.Services, func(s string) bool { return contains(services, s) }) {
+		return false
+	}
+	if len(r.Groups) > 0 && !matchAny(r.Groups, func(g string) bool {
+		for _, svc := range services {
+			if groups[svc] == g {
+				return true
+			}
+		}
+		return false
+	}) {
+		return false
+	}
+	return r.window.contains(now)
+}
+
+// contains reports whether now falls within the schedule's days and hours
+func (sc schedule) contains(now time.Time) bool {
+	local := now.In(sc.location)
+	if sc.days != nil && !sc.days[local.Weekday()] {
+		return false
+	}
+	if sc.from < 0 {
+		return true
+	}
+	minute := local.Hour()*60 + local.Minute()
+	if sc.from <= sc.to {
+		return minute >= sc.from && minute < sc.to
+	}
+	// Window wraps past midnight
+	return minute >= sc.from || minute < sc.to
+}
+
+// matchEvent matches an event name against "*", "prefix.*" or an exact name
+func matchEvent(pattern, event string) bool {
+	if pattern == "*" || pattern == event {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, ".*"); ok {
+		return strings.HasPrefix(event, prefix+".")
+	}
+	return false
+}
+
+func matchAny(values []string, match func(string) bool) bool {
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// eventSeverity returns the severity carried by the notification data
+func eventSeverity(data interface{}) string {
+	switch v := data.(type) {
+	case storage.Incident:
+		return v.Severity
+	case ServiceEvent:
+		// Recoveries follow the alert they resolve
+		if v.Status == "operational" {
+			return ServiceEvent{Status: v.PreviousStatus}.severity()
+		}
+		return v.severity()
+	case Digest:
+		return v.severity()
+	}
+	return ""
+}
+
+// eventServices returns the services the notification is about
+func eventServices(data interface{}) []string {
+	switch v := data.(type) {
+	case storage.Incident:
+		return v.AffectedServices
+	case storage.Maintenance:
+		return v.AffectedServices
+	case ServiceEvent:
+		return []string{v.Name}
+	}
+	return nil
+}
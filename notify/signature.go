@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers added to signed generic webhook deliveries
+const (
+	HeaderSignature = "X-Status-Signature"
+	HeaderTimestamp = "X-Status-Timestamp"
+	HeaderDelivery  = "X-Status-Delivery"
+)
+
+// signRequest returns a request hook that signs body with secret. The
+// signature covers "<timestamp>.<body>" so a captured request cannot be
+// replayed with a fresh timestamp; receivers should reject stale
+// timestamps and delivery IDs they have already processed.
+func signRequest(id, secret string, body []byte) func(*http.Request) {
+	return func(req *http.Request) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderDelivery, id)
+		req.Header.Set(HeaderTimestamp, timestamp)
+		req.Header.Set(HeaderSignature, "sha256="+Sign(secret, timestamp, body))
+	}
+}
+
+// Sign computes the hex HMAC-SHA256 of "<timestamp>.<body>" with secret
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newDeliveryID returns a random identifier for a webhook delivery
+func newDeliveryID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,283 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+const (
+	mastodonMaxLength = 500
+	xMaxLength        = 280
+
+	// socialLinkLength is what Mastodon and X count for any URL
+	socialLinkLength = 23
+
+	xTweetsURL = "https://api.twitter.com/2/tweets"
+	xMeURL     = "https://api.twitter.com/2/users/me"
+)
+
+// socialEvents are posted by social channels with no events list: public
+// accounts announce incidents, not every update or service check
+var socialEvents = map[string][]string{
+	"mastodon": {"incident.created", "incident.resolved"},
+	"x":        {"incident.created", "incident.resolved"},
+}
+
+// mastodonChannel posts a status to a Mastodon account. url is the
+// instance, e.g. https://mastodon.social, and access_token a token with the
+// write:statuses scope. visibility defaults to public.
+type mastodonChannel struct {
+	n *Notifier
+}
+
+// MastodonStatus is the body of a Mastodon post status request
+type MastodonStatus struct {
+	Status     string `json:"status"`
+	Visibility string `json:"visibility,omitempty"` // public, unlisted, private or direct
+	Language   string `json:"language,omitempty"`
+}
+
+func (c mastodonChannel) Format(m Message) ([]byte, error) {
+	return json.Marshal(MastodonStatus{
+		Status:     formatSocialPost(m.Event, m.Data, m.BaseURL, mastodonMaxLength),
+		Visibility: m.Webhook.Headers["visibility"],
+		Language:   m.Webhook.Headers["language"],
+	})
+}
+
+func (c mastodonChannel) Send(m Message, body []byte) (*DeliveryResult, error) {
+	token := m.Webhook.Headers["access_token"]
+	if m.Webhook.URL == "" || token == "" {
+		return nil, fmt.Errorf("mastodon webhook requires url and access_token")
+	}
+	return c.n.post(m.Webhook, m.Webhook.Endpoint(), "application/json", body, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+		// Retries of a delivery must not post twice
+		req.Header.Set("Idempotency-Key", m.ID)
+	})
+}
+
+// Probe checks the access token against the account it belongs to
+func (c mastodonChannel) Probe(webhook WebhookConfig) error {
+	token := webhook.Headers["access_token"]
+	return c.n.probeGet(mastodonInstance(webhook)+"/api/v1/accounts/verify_credentials", func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+// mastodonInstance returns the instance URL of a mastodon webhook
+func mastodonInstance(webhook WebhookConfig) string {
+	return strings.TrimSuffix(strings.TrimRight(webhook.URL, "/"), "/api/v1/statuses")
+}
+
+// xChannel posts to X through the v2 API. It signs requests with OAuth 1.0a
+// when api_key, api_secret, access_token and access_token_secret are set,
+// and otherwise sends access_token as an OAuth 2.0 user token.
+type xChannel struct {
+	n *Notifier
+}
+
+// XPost is the body of an X create post request
+type XPost struct {
+	Text string `json:"text"`
+}
+
+func (c xChannel) Format(m Message) ([]byte, error) {
+	return json.Marshal(XPost{Text: formatSocialPost(m.Event, m.Data, m.BaseURL, xMaxLength)})
+}
+
+func (c xChannel) Send(m Message, body []byte) (*DeliveryResult, error) {
+	auth, err := xAuth(m.Webhook, http.MethodPost, m.Webhook.Endpoint())
+	if err != nil {
+		return nil, err
+	}
+	return c.n.post(m.Webhook, m.Webhook.Endpoint(), "application/json", body, auth)
+}
+
+// Probe checks the credentials against the account they belong to
+func (c xChannel) Probe(webhook WebhookConfig) error {
+	auth, err := xAuth(webhook, http.MethodGet, xMeURL)
+	if err != nil {
+		return err
+	}
+	return c.n.probeGet(xMeURL, auth)
+}
+
+// xAuth returns a function authorizing a request to X
+func xAuth(webhook WebhookConfig, method, target string) (func(*http.Request), error) {
+	h := webhook.Headers
+	if h["api_key"] != "" && h["api_secret"] != "" && h["access_token"] != "" && h["access_token_secret"] != "" {
+		return func(req *http.Request) {
+			req.Header.Set("Authorization", oauth1Header(method, target, h["api_key"], h["api_secret"], h["access_token"], h["access_token_secret"]))
+		}, nil
+	}
+	if token := h["access_token"]; token != "" {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }, nil
+	}
+	return nil, fmt.Errorf("x webhook requires access_token, plus api_key, api_secret and access_token_secret for OAuth 1.0a")
+}
+
+// oauth1Header signs a request with OAuth 1.0a HMAC-SHA1. JSON bodies are
+// not part of the signature, so only the URL's query parameters are.
+func oauth1Header(method, target, consumerKey, consumerSecret, token, tokenSecret string) string {
+	params := map[string]string{
+		"oauth_consumer_key":     consumerKey,
+		"oauth_nonce":            newDeliveryID(),
+		"oauth_signature_method": "HMAC-SHA1",
+		"oauth_timestamp":        strconv.FormatInt(time.Now().Unix(), 10),
+		"oauth_token":            token,
+		"oauth_version":          "1.0",
+	}
+
+	base := target
+	signed := make(map[string]string, len(params))
+	for k, v := range params {
+		signed[k] = v
+	}
+	if u, err := url.Parse(target); err == nil {
+		for k, vs := range u.Query() {
+			if len(vs) > 0 {
+				signed[k] = vs[0]
+			}
+		}
+		u.RawQuery, u.Fragment = "", ""
+		base = u.String()
+	}
+
+	keys := make([]string, 0, len(signed))
+	for k := range signed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = oauthEscape(k) + "=" + oauthEscape(signed[k])
+	}
+
+	baseString := method + "&" + oauthEscape(base) + "&" + oauthEscape(strings.Join(pairs, "&"))
+	mac := hmac.New(sha1.New, []byte(oauthEscape(consumerSecret)+"&"+oauthEscape(tokenSecret)))
+	mac.Write([]byte(baseString))
+	params["oauth_signature"] = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	keys = keys[:0]
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		fields[i] = fmt.Sprintf(`%s="%s"`, oauthEscape(k), oauthEscape(params[k]))
+	}
+	return "OAuth " + strings.Join(fields, ", ")
+}
+
+// oauthEscape percent-encodes s as RFC 5849 requires: everything but
+// unreserved characters
+func oauthEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// probeGet checks credentials with a GET request that needs them
+func (n *Notifier) probeGet(target string, prepare func(*http.Request)) error {
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", redactURLError(err))
+	}
+	prepare(req)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return redactURLError(err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("credentials rejected (status %d)", resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// formatSocialPost renders a public post announcing an incident, with a
+// link to it. The text is shortened to fit max characters, counting the
+// link as the networks do.
+func formatSocialPost(event string, data interface{}, baseURL string, max int) string {
+	var text, link string
+
+	switch v := data.(type) {
+	case storage.Incident:
+		if event == "incident.resolved" || v.Status == "resolved" {
+			text = fmt.Sprintf("✅ Resolved: %s", v.Title)
+			if msg := latestUpdate(v); msg != "" {
+				text += " - " + msg
+			}
+		} else {
+			text = fmt.Sprintf("%s %s incident: %s", socialSeverityEmoji(v.Severity), severityLabel(v.Severity), v.Title)
+			if v.Message != "" {
+				text += " - " + v.Message
+			}
+			if len(v.AffectedServices) > 0 {
+				text += "\nAffected: " + strings.Join(v.AffectedServices, ", ")
+			}
+		}
+		link = fmt.Sprintf("%s/incidents/%s", baseURL, v.ID)
+	case storage.Maintenance:
+		text = fmt.Sprintf("🔧 %s: %s %s - %s",
+			maintenanceHeading(v),
+			v.Title,
+			v.ScheduledStart.Format("Jan 02 15:04 MST"),
+			v.ScheduledEnd.Format("Jan 02 15:04 MST"))
+		link = baseURL
+	case ServiceEvent:
+		text = fmt.Sprintf("%s: %s", v.title(), v.detail())
+		link = baseURL
+	default:
+		text = "Status Update"
+		link = baseURL
+	}
+
+	// Shorten the text rather than the link
+	limit := max
+	if link != "" {
+		limit -= socialLinkLength + 1
+	}
+	if runes := []rune(text); len(runes) > limit {
+		text = strings.TrimSpace(string(runes[:limit-1])) + "…"
+	}
+	if link == "" {
+		return text
+	}
+	return text + "\n" + link
+}
+
+func socialSeverityEmoji(severity string) string {
+	switch severity {
+	case "critical":
+		return "🔴"
+	case "major":
+		return "🟠"
+	default:
+		return "🟡"
+	}
+}
+
+// severityLabel returns a severity as a word to start a sentence with
+func severityLabel(severity string) string {
+	if severity == "" {
+		return "New"
+	}
+	return strings.ToUpper(severity[:1]) + severity[1:]
+}
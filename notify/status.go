@@ -0,0 +1,209 @@
+package notify
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/status/monitor"
+)
+
+// Service status-change events
+const (
+	EventServiceDown      = "service.down"
+	EventServiceDegraded  = "service.degraded"
+	EventServiceRecovered = "service.recovered"
+)
+
+// StatusAlertConfig controls service status-change notifications
+type StatusAlertConfig struct {
+	Threshold int           // Consecutive checks in a new status before alerting
+	Cooldown  time.Duration // Minimum time between down/degraded alerts per service
+	Degraded  bool          // Also alert when a service becomes degraded
+}
+
+// ServiceEvent describes a change in a monitored service's status
+type ServiceEvent struct {
+	Name           string    `json:"name"`
+	Group          string    `json:"group,omitempty"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previous_status"`
+	ResponseTimeMs int64     `json:"response_time_ms"`
+	StatusCode     int       `json:"status_code,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+
+	Certificate *CertificateInfo `json:"certificate,omitempty"` // Set for certificate.expiring
+	Heartbeat   bool             `json:"heartbeat,omitempty"`   // Set for heartbeat
+}
+
+// serviceAlertState tracks confirmed and pending status for one service
+type serviceAlertState struct {
+	alerted   monitor.Status // Status last announced (operational until alerted)
+	pending   monitor.Status
+	count     int
+	lastAlert time.Time
+}
+
+// statusTracker debounces monitor updates into status-change events
+type statusTracker struct {
+	cfg    StatusAlertConfig
+	mu     sync.Mutex
+	states map[string]*serviceAlertState
+}
+
+// WatchMonitor subscribes to monitor updates and emits service.down,
+// service.degraded and service.recovered notifications. A new status must
+// hold for Threshold consecutive checks; repeat down/degraded alerts for a
+// service are suppressed within Cooldown. Recoveries are only sent for
+// services that were alerted as down or degraded.
+func (n *Notifier) WatchMonitor(mon *monitor.Monitor, cfg StatusAlertConfig, baseURL string) {
+	if cfg.Threshold < 1 {
+		cfg.Threshold = 1
+	}
+	tracker := &statusTracker{cfg: cfg, states: make(map[string]*serviceAlertState)}
+
+	ch := mon.Subscribe()
+	go func() {
+		defer mon.Unsubscribe(ch)
+		for status := range ch {
+			if event, ok := tracker.observe(status, time.Now()); ok {
+				slog.Warn("Service status changed", "service", event.Name, "status", event.Status, "previous", event.PreviousStatus)
+				n.NotifyServiceEvent(event, baseURL)
+			}
+		}
+	}()
+}
+
+// NotifyServiceEvent notifies about a service status change
+func (n *Notifier) NotifyServiceEvent(event ServiceEvent, baseURL string) {
+	n.notify(serviceEventName(event), event, baseURL)
+}
+
+func serviceEventName(event ServiceEvent) string {
+	switch monitor.Status(event.Status) {
+	case monitor.StatusDown:
+		return EventServiceDown
+	case monitor.StatusDegraded:
+		return EventServiceDegraded
+	default:
+		return EventServiceRecovered
+	}
+}
+
+// observe feeds one check result and returns an event when an alert is due
+func (t *statusTracker) observe(status *monitor.ServiceStatus, now time.Time) (ServiceEvent, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if status.Status == monitor.StatusUnknown {
+		return ServiceEvent{}, false
+	}
+
+	state, ok := t.states[status.Name]
+	if !ok {
+		state = &serviceAlertState{alerted: monitor.StatusOperational}
+		t.states[status.Name] = state
+	}
+
+	current := status.Status
+	if current == monitor.StatusDegraded && !t.cfg.Degraded {
+		// Degraded is not alerted on; treat it as no change
+		current = state.alerted
+	}
+
+	if current == state.alerted {
+		state.pending, state.count = "", 0
+		return ServiceEvent{}, false
+	}
+
+	if current != state.pending {
+		state.pending, state.count = current, 0
+	}
+	state.count++
+	if state.count < t.cfg.Threshold {
+		return ServiceEvent{}, false
+	}
+
+	previous := state.alerted
+	state.pending, state.count = "", 0
+
+	if current != monitor.StatusOperational {
+		if !state.lastAlert.IsZero() && now.Sub(state.lastAlert) < t.cfg.Cooldown {
+			return ServiceEvent{}, false
+		}
+		state.lastAlert = now
+	}
+	state.alerted = current
+
+	return ServiceEvent{
+		Name:           status.Name,
+		Group:          status.Group,
+		Status:         string(current),
+		PreviousStatus: string(previous),
+		ResponseTimeMs: status.ResponseTimeMs,
+		StatusCode:     status.StatusCode,
+		Error:          status.ErrorMessage,
+		Timestamp:      status.LastCheck,
+	}, true
+}
+
+// title returns a short human-readable summary of the event
+func (e ServiceEvent) title() string {
+	if e.Certificate != nil {
+		return certificateTitle(e.Name, e.Certificate)
+	}
+	if e.Heartbeat {
+		return e.Name
+	}
+	switch monitor.Status(e.Status) {
+	case monitor.StatusDown:
+		return fmt.Sprintf("%s is down", e.Name)
+	case monitor.StatusDegraded:
+		return fmt.Sprintf("%s is degraded", e.Name)
+	default:
+		return fmt.Sprintf("%s has recovered", e.Name)
+	}
+}
+
+// detail returns the error or response details for the event
+func (e ServiceEvent) detail() string {
+	if e.Certificate != nil {
+		return "Expires " + e.Certificate.ExpiresAt.Format("Jan 02, 2006 15:04 MST")
+	}
+	if e.Heartbeat {
+		return "Scheduled check that this channel works. No action is required."
+	}
+	if e.Error != "" {
+		return e.Error
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("HTTP %d in %dms", e.StatusCode, e.ResponseTimeMs)
+	}
+	return fmt.Sprintf("Responded in %dms", e.ResponseTimeMs)
+}
+
+// alertKey identifies the alert an event belongs to, keeping certificate
+// warnings apart from the service's down/degraded alerts
+func (e ServiceEvent) alertKey() string {
+	if e.Certificate != nil {
+		return "certificate-" + e.Name
+	}
+	if e.Heartbeat {
+		return "heartbeat"
+	}
+	return "service-" + e.Name
+}
+
+// severity maps the event to an incident-style severity for formatters
+func (e ServiceEvent) severity() string {
+	switch monitor.Status(e.Status) {
+	case monitor.StatusDown:
+		return "critical"
+	case monitor.StatusDegraded:
+		return "major"
+	default:
+		return "minor"
+	}
+}
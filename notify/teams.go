@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/status/storage"
+)
+
+// AdaptiveCardMessage for Teams Workflows webhooks, which only accept
+// Adaptive Cards
+type AdaptiveCardMessage struct {
+	Type        string                   `json:"type"`
+	Attachments []AdaptiveCardAttachment `json:"attachments"`
+}
+
+type AdaptiveCardAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string            `json:"$schema"`
+	Type    string            `json:"type"`
+	Version string            `json:"version"`
+	MSTeams map[string]string `json:"msteams,omitempty"`
+	Body    []AdaptiveElement `json:"body"`
+	Actions []AdaptiveAction  `json:"actions,omitempty"`
+}
+
+// AdaptiveElement is a TextBlock, Container or FactSet
+type AdaptiveElement struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Size     string            `json:"size,omitempty"`
+	Weight   string            `json:"weight,omitempty"`
+	IsSubtle bool              `json:"isSubtle,omitempty"`
+	Wrap     bool              `json:"wrap,omitempty"`
+	Style    string            `json:"style,omitempty"`
+	Bleed    bool              `json:"bleed,omitempty"`
+	Items    []AdaptiveElement `json:"items,omitempty"`
+	Facts    []AdaptiveFact    `json:"facts,omitempty"`
+}
+
+type AdaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type AdaptiveAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// teamsMessageCard reports whether a Teams webhook gets the legacy
+// MessageCard format. The format option ("adaptive" or "messagecard")
+// decides; otherwise Office 365 connector URLs get MessageCards and
+// everything else (Workflows) gets Adaptive Cards.
+func teamsMessageCard(webhook WebhookConfig) bool {
+	switch strings.ToLower(webhook.Headers["format"]) {
+	case "messagecard":
+		return true
+	case "adaptive":
+		return false
+	}
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return strings.HasSuffix(host, ".webhook.office.com") || host == "outlook.office.com"
+}
+
+// formatAdaptiveCardPayload formats an Adaptive Card with a colored header,
+// the message, a fact set and a button linking to the status page
+func (n *Notifier) formatAdaptiveCardPayload(event string, data interface{}, baseURL string) ([]byte, error) {
+	msg := n.buildChatMessage(data, baseURL)
+
+	header := AdaptiveElement{
+		Type:  "Container",
+		Style: adaptiveCardStyle(data),
+		Bleed: true,
+		Items: []AdaptiveElement{
+			{Type: "TextBlock", Text: msg.title, Size: "Large", Weight: "Bolder", Wrap: true},
+			{Type: "TextBlock", Text: msg.ts.Format("Jan 02, 2006 15:04 MST"), IsSubtle: true, Wrap: true},
+		},
+	}
+
+	body := []AdaptiveElement{header}
+	if msg.text != "" {
+		body = append(body, AdaptiveElement{Type: "TextBlock", Text: msg.text, Wrap: true})
+	}
+	if len(msg.fields) > 0 {
+		facts := make([]AdaptiveFact, 0, len(msg.fields))
+		for _, f := range msg.fields {
+			facts = append(facts, AdaptiveFact{Title: f.Title, Value: f.Value})
+		}
+		body = append(body, AdaptiveElement{Type: "FactSet", Facts: facts})
+	}
+
+	return json.Marshal(AdaptiveCardMessage{
+		Type: "message",
+		Attachments: []AdaptiveCardAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: AdaptiveCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				MSTeams: map[string]string{"width": "Full"},
+				Body:    body,
+				Actions: []AdaptiveAction{{Type: "Action.OpenUrl", Title: msg.linkText, URL: msg.link}},
+			},
+		}},
+	})
+}
+
+// adaptiveCardStyle maps the notification to a container style; Adaptive
+// Cards only support named colors
+func adaptiveCardStyle(data interface{}) string {
+	switch v := data.(type) {
+	case storage.Incident:
+		if v.Status == "resolved" {
+			return "good"
+		}
+	case storage.Maintenance:
+		if v.Status == "completed" {
+			return "good"
+		}
+		return "accent"
+	case ServiceEvent:
+		switch v.Status {
+		case "down":
+			return "attention"
+		case "degraded":
+			return "warning"
+		}
+		return "good"
+	}
+
+	switch eventSeverity(data) {
+	case "critical", "major":
+		return "attention"
+	case "minor":
+		return "warning"
+	}
+	return "emphasis"
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/status/storage"
+)
+
+// telegramEscaper escapes the characters reserved by Telegram MarkdownV2
+var telegramEscaper = strings.NewReplacer(
+	"_", "\\_", "*", "\\*", "[", "\\[", "]", "\\]", "(", "\\(", ")", "\\)",
+	"~", "\\~", "`", "\\`", ">", "\\>", "#", "\\#", "+", "\\+", "-", "\\-",
+	"=", "\\=", "|", "\\|", "{", "\\{", "}", "\\}", ".", "\\.", "!", "\\!",
+	"\\", "\\\\",
+)
+
+// telegramURLEscaper escapes the characters reserved inside a MarkdownV2 link target
+var telegramURLEscaper = strings.NewReplacer(")", "\\)", "\\", "\\\\")
+
+// formatTelegramPayload formats a MarkdownV2 message for the Telegram Bot API.
+// The chat is taken from the chat_id option.
+func (n *Notifier) formatTelegramPayload(event string, data interface{}, webhook WebhookConfig, baseURL string) ([]byte, error) {
+	esc := telegramEscaper.Replace
+	var lines []string
+
+	switch v := data.(type) {
+	case storage.Incident:
+		lines = append(lines,
+			fmt.Sprintf("%s *%s*", n.severityToEmoji(v.Severity, v.Status), esc(fmt.Sprintf("[%s] %s", v.Status, v.Title))),
+		)
+		if v.Message != "" {
+			lines = append(lines, "", esc(v.Message))
+		}
+		lines = append(lines, "", fmt.Sprintf("*Severity:* %s", esc(v.Severity)))
+		if len(v.AffectedServices) > 0 {
+			lines = append(lines, fmt.Sprintf("*Affected:* %s", esc(strings.Join(v.AffectedServices, ", "))))
+		}
+		lines = append(lines, "", fmt.Sprintf("[View incident](%s)",
+			telegramURLEscaper.Replace(fmt.Sprintf("%s/incidents/%s", baseURL, v.ID))))
+
+	case storage.Maintenance:
+		lines = append(lines,
+			fmt.Sprintf("🔧 *%s*", esc(maintenanceHeading(v)+": "+v.Title)),
+		)
+		if v.Description != "" {
+			lines = append(lines, "", esc(v.Description))
+		}
+		lines = append(lines, "",
+			fmt.Sprintf("*Start:* %s", esc(v.ScheduledStart.Format("Jan 02, 2006 15:04 MST"))),
+			fmt.Sprintf("*End:* %s", esc(v.ScheduledEnd.Format("Jan 02, 2006 15:04 MST"))),
+		)
+		if len(v.AffectedServices) > 0 {
+			lines = append(lines, fmt.Sprintf("*Affected:* %s", esc(strings.Join(v.AffectedServices, ", "))))
+		}
+		lines = append(lines, "", fmt.Sprintf("[View status page](%s)", telegramURLEscaper.Replace(baseURL)))
+
+	case ServiceEvent:
+		emoji := n.severityToEmoji(v.severity(), "")
+		if v.Status == "operational" {
+			emoji = "✅"
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s *%s*", emoji, esc(v.title())),
+			"", esc(v.detail()),
+			"", fmt.Sprintf("[View status page](%s)", telegramURLEscaper.Replace(baseURL)),
+		)
+
+	case Digest:
+		lines = append(lines, fmt.Sprintf("%s *%s*", n.severityToEmoji(v.severity(), ""), esc(v.title())), "")
+		for _, line := range v.lines() {
+			lines = append(lines, esc(line))
+		}
+		lines = append(lines, "", fmt.Sprintf("[View status page](%s)", telegramURLEscaper.Replace(baseURL)))
+
+	default:
+		lines = append(lines, esc("Status Update"))
+	}
+
+	return json.Marshal(TelegramPayload{
+		ChatID:                webhook.Headers["chat_id"],
+		Text:                  strings.Join(lines, "\n"),
+		ParseMode:             "MarkdownV2",
+		DisableWebPagePreview: true,
+	})
+}
+
+func (n *Notifier) severityToEmoji(severity, status string) string {
+	if status == "resolved" {
+		return "✅"
+	}
+	switch severity {
+	case "critical":
+		return "🔴"
+	case "major":
+		return "🟠"
+	case "minor":
+		return "🟡"
+	default:
+		return "⚪"
+	}
+}
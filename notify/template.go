@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// TemplateData is the context passed to webhook payload templates. Data
+// holds the notification object; exactly one of the typed fields is set
+// alongside it.
+type TemplateData struct {
+	ID          string // Delivery ID, stable across retries
+	Event       string
+	Timestamp   time.Time
+	BaseURL     string
+	Data        interface{}
+	Incident    *storage.Incident
+	Maintenance *storage.Maintenance
+	Service     *ServiceEvent
+	Digest      *Digest
+}
+
+// templateFuncs are available to payload templates
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"time": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+}
+
+// ValidateTemplate checks that a webhook payload template parses
+func ValidateTemplate(webhookType, text string) error {
+	if text == "" {
+		return nil
+	}
+	if webhookType == "twilio" || webhookType == "email" {
+		return fmt.Errorf("payload templates are not supported for %s webhooks", webhookType)
+	}
+	if _, err := template.New("payload").Funcs(templateFuncs).Parse(text); err != nil {
+		return fmt.Errorf("invalid payload template: %w", err)
+	}
+	return nil
+}
+
+// renderTemplate renders a webhook's payload template
+func renderTemplate(id string, webhook WebhookConfig, event string, data interface{}, baseURL string) ([]byte, error) {
+	tmpl, err := template.New("payload").Funcs(templateFuncs).Parse(webhook.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := TemplateData{
+		ID:        id,
+		Event:     event,
+		Timestamp: time.Now(),
+		BaseURL:   baseURL,
+		Data:      data,
+	}
+	switch v := data.(type) {
+	case storage.Incident:
+		ctx.Incident = &v
+	case storage.Maintenance:
+		ctx.Maintenance = &v
+	case ServiceEvent:
+		ctx.Service = &v
+	case Digest:
+		ctx.Digest = &v
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"time"
+
+	"github.com/status/storage"
+)
+
+// SetLocation sets the zone timestamps in notifications are shown in
+// (default local)
+func (n *Notifier) SetLocation(loc *time.Location) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.location = loc
+}
+
+// inLocation returns a copy of the notification data with its timestamps
+// in the notifier's zone, so every formatter shows the same times
+func (n *Notifier) inLocation(data interface{}) interface{} {
+	n.mu.RLock()
+	loc := n.location
+	n.mu.RUnlock()
+	if loc == nil {
+		return data
+	}
+
+	switch v := data.(type) {
+	case storage.Incident:
+		v.CreatedAt = v.CreatedAt.In(loc)
+		v.UpdatedAt = v.UpdatedAt.In(loc)
+		v.ResolvedAt = timeIn(v.ResolvedAt, loc)
+		v.AcknowledgedAt = timeIn(v.AcknowledgedAt, loc)
+		updates := make([]storage.IncidentUpdate, len(v.Updates))
+		for i, u := range v.Updates {
+			u.CreatedAt = u.CreatedAt.In(loc)
+			updates[i] = u
+		}
+		v.Updates = updates
+		return v
+
+	case storage.Maintenance:
+		v.ScheduledStart = v.ScheduledStart.In(loc)
+		v.ScheduledEnd = v.ScheduledEnd.In(loc)
+		v.CreatedAt = v.CreatedAt.In(loc)
+		v.UpdatedAt = v.UpdatedAt.In(loc)
+		return v
+
+	case ServiceEvent:
+		v.Timestamp = v.Timestamp.In(loc)
+		if v.Certificate != nil {
+			cert := *v.Certificate
+			cert.ExpiresAt = cert.ExpiresAt.In(loc)
+			v.Certificate = &cert
+		}
+		return v
+
+	case Digest:
+		v.Start = v.Start.In(loc)
+		v.End = v.End.In(loc)
+		entries := make([]DigestEntry, len(v.Entries))
+		for i, e := range v.Entries {
+			e.Timestamp = e.Timestamp.In(loc)
+			entries[i] = e
+		}
+		v.Entries = entries
+		return v
+	}
+	return data
+}
+
+func timeIn(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	local := t.In(loc)
+	return &local
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/status/storage"
+)
+
+// smsMaxLength keeps messages within two concatenated SMS segments
+const smsMaxLength = 320
+
+// twilioChannel sends an SMS through the Twilio Messages API to each number
+// in the comma-separated "to" option. The result reports the worst response,
+// and retries only send to the numbers that were not reached.
+type twilioChannel struct {
+	n *Notifier
+}
+
+func (c twilioChannel) Format(m Message) ([]byte, error) {
+	return []byte(c.n.formatSMS(m.Event, m.Data, m.BaseURL)), nil
+}
+
+func (c twilioChannel) Send(m Message, body []byte) (*DeliveryResult, error) {
+	webhook := m.Webhook
+	sid := webhook.Headers["account_sid"]
+	token := webhook.Headers["auth_token"]
+	from := webhook.Headers["from"]
+	if sid == "" || token == "" || from == "" {
+		return nil, fmt.Errorf("twilio webhook requires account_sid, auth_token and from")
+	}
+
+	var numbers []string
+	for _, to := range strings.Split(webhook.Headers["to"], ",") {
+		if to = strings.TrimSpace(to); to != "" {
+			numbers = append(numbers, to)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("twilio webhook has no destination numbers")
+	}
+
+	auth := func(req *http.Request) { req.SetBasicAuth(sid, token) }
+
+	var worst *DeliveryResult
+	var failed error
+	for _, to := range numbers {
+		if m.Reached[to] {
+			continue
+		}
+		form := url.Values{"To": {to}, "From": {from}, "Body": {string(body)}}
+		result, err := c.n.post(webhook, webhook.Endpoint(), "application/x-www-form-urlencoded", []byte(form.Encode()), auth)
+		if err != nil {
+			failed = fmt.Errorf("sending to %s: %w", to, err)
+			continue
+		}
+		if result.StatusCode < 400 && m.Reached != nil {
+			m.Reached[to] = true
+		}
+		if worst == nil || result.StatusCode > worst.StatusCode {
+			worst = result
+		}
+	}
+
+	if failed != nil {
+		return nil, failed
+	}
+	return worst, nil
+}
+
+// Probe checks the account credentials against the Messages API
+func (c twilioChannel) Probe(webhook WebhookConfig) error {
+	sid, token := webhook.Headers["account_sid"], webhook.Headers["auth_token"]
+	return c.n.probeHTTP(webhook, func(req *http.Request) { req.SetBasicAuth(sid, token) }, true)
+}
+
+// formatSMS renders a short plain-text message
+func (n *Notifier) formatSMS(event string, data interface{}, baseURL string) string {
+	var text, link string
+
+	switch v := data.(type) {
+	case storage.Incident:
+		text = fmt.Sprintf("[%s] %s - %s", strings.ToUpper(v.Severity), v.Title, v.Status)
+		if v.Message != "" {
+			text += ": " + v.Message
+		}
+		link = fmt.Sprintf(" %s/incidents/%s", baseURL, v.ID)
+	case storage.Maintenance:
+		text = fmt.Sprintf("[%s] %s %s - %s",
+			strings.ToUpper(maintenanceHeading(v)),
+			v.Title,
+			v.ScheduledStart.Format("Jan 02 15:04 MST"),
+			v.ScheduledEnd.Format("Jan 02 15:04 MST"))
+	case ServiceEvent:
+		text = fmt.Sprintf("[%s] %s: %s", strings.ToUpper(v.Status), v.title(), v.detail())
+	case Digest:
+		text = fmt.Sprintf("%s: %s", v.title(), strings.Join(v.lines(), "; "))
+		link = " " + baseURL
+	default:
+		text = "Status Update"
+	}
+
+	// Shorten the text rather than the link
+	max := smsMaxLength - len([]rune(link))
+	if runes := []rune(text); len(runes) > max {
+		text = string(runes[:max-1]) + "…"
+	}
+	return text + link
+}
@@ -0,0 +1,471 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// PushConfig holds the VAPID identity used for Web Push (RFC 8292)
+type PushConfig struct {
+	Subject       string        // mailto: or https: contact for push services
+	PublicKey     string        // Base64url uncompressed P-256 point
+	PrivateKey    string        // Base64url P-256 scalar
+	TTL           time.Duration // How long push services keep undelivered messages
+	EndpointHosts []string      // Push services subscriptions may use (default DefaultPushHosts)
+}
+
+// DefaultPushHosts are the push services of Chrome, Firefox, Edge and
+// Safari. Endpoints may be on these hosts or their subdomains.
+var DefaultPushHosts = []string{
+	"fcm.googleapis.com",
+	"android.googleapis.com",
+	"push.services.mozilla.com",
+	"notify.windows.com",
+	"push.apple.com",
+}
+
+// PushSubscriptionSource provides the browser subscriptions to notify
+type PushSubscriptionSource interface {
+	GetPushSubscriptions() []storage.PushSubscription
+	DeletePushSubscription(endpoint string) bool
+}
+
+// PushMessage is the JSON payload the service worker receives
+type PushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	URL   string `json:"url"`
+	Tag   string `json:"tag,omitempty"`
+}
+
+// webPush is the configured Web Push sender
+type webPush struct {
+	cfg    PushConfig
+	key    *ecdsa.PrivateKey
+	source PushSubscriptionSource
+	client *http.Client // Only connects to public addresses
+}
+
+// pushRecordSize is the aes128gcm record size; payloads fit in one record
+const pushRecordSize = 4096
+
+// pushConcurrency bounds the pushes sent at once for one event
+const pushConcurrency = 8
+
+// ErrNonPublicAddress is returned for a URL from a visitor that resolves to
+// an address the server will not connect to
+var ErrNonPublicAddress = errors.New("resolves to a loopback, private or link-local address")
+
+// GenerateVAPIDKeys creates a new VAPID key pair, base64url encoded
+func GenerateVAPIDKeys() (publicKey, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// EnablePush turns on Web Push delivery to browser subscriptions
+func (n *Notifier) EnablePush(cfg PushConfig, source PushSubscriptionSource) error {
+	d, err := decodeBase64URL(cfg.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+
+	// Round-trip through PKCS#8 to get an ECDSA signing key
+	der, err := x509.MarshalPKCS8PrivateKey(ecdhKey)
+	if err != nil {
+		return err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return err
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("invalid VAPID private key")
+	}
+
+	publicKey := base64.RawURLEncoding.EncodeToString(ecdhKey.PublicKey().Bytes())
+	if cfg.PublicKey != "" && strings.TrimRight(cfg.PublicKey, "=") != publicKey {
+		return fmt.Errorf("VAPID public key does not match the private key")
+	}
+	cfg.PublicKey = publicKey
+	if cfg.TTL <= 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if len(cfg.EndpointHosts) == 0 {
+		cfg.EndpointHosts = DefaultPushHosts
+	}
+
+	// Endpoints come from anyone who opens the status page, so even on an
+	// allowed host they must not reach into the local network
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.push = &webPush{
+		cfg:    cfg,
+		key:    key,
+		source: source,
+		client: PublicClient(10 * time.Second),
+	}
+	return nil
+}
+
+// PushEndpointAllowed reports whether a browser may subscribe with
+// endpoint: an https URL on one of the configured push service hosts
+func (n *Notifier) PushEndpointAllowed(endpoint string) bool {
+	n.mu.RLock()
+	push := n.push
+	n.mu.RUnlock()
+	return push != nil && push.allowed(endpoint)
+}
+
+// allowed reports whether endpoint is on one of the push service hosts
+func (p *webPush) allowed(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.User != nil {
+		return false
+	}
+	if u.Port() != "" && u.Port() != "443" {
+		return false
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	for _, allowed := range p.cfg.EndpointHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicClient returns an HTTP client for URLs anyone can submit, such as
+// push endpoints and WebSub callbacks. It refuses to connect to loopback,
+// private and link-local addresses; through an HTTPS_PROXY, the proxy is
+// what gets dialed and decides instead.
+func PublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !PublicIP(ip) {
+				return ErrNonPublicAddress
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	probe := &http.Request{URL: &url.URL{Scheme: "https", Host: DefaultPushHosts[0]}}
+	if proxy, _ := transport.Proxy(probe); proxy == nil {
+		transport.DialContext = dialer.DialContext
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// PublicIP reports whether ip is routable on the internet
+func PublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// VAPIDPublicKey returns the application server key browsers subscribe
+// with, or "" when Web Push is disabled
+func (n *Notifier) VAPIDPublicKey() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if n.push == nil {
+		return ""
+	}
+	return n.push.cfg.PublicKey
+}
+
+// sendPushNotifications pushes an event to every browser subscription
+// whose service selection overlaps the affected services
+func (n *Notifier) sendPushNotifications(event string, data interface{}, baseURL string) {
+	n.mu.RLock()
+	push := n.push
+	n.mu.RUnlock()
+
+	if push == nil {
+		return
+	}
+
+	msg := n.buildPushMessage(n.inLocation(data), baseURL)
+	payload, err := json.Marshal(PushMessage{
+		Title: msg.title,
+		Body:  msg.message,
+		URL:   msg.link,
+		Tag:   pushTag(data),
+	})
+	if err != nil {
+		slog.Error("Error encoding push message", "error", err)
+		return
+	}
+
+	urgency := "normal"
+	if msg.priority >= 4 {
+		urgency = "high"
+	}
+
+	affected := eventServices(data)
+	dryRun := n.DryRun()
+	var (
+		wg        sync.WaitGroup
+		delivered atomic.Int64
+		failed    atomic.Int64
+		skipped   int
+	)
+	sem := make(chan struct{}, pushConcurrency)
+	for _, sub := range push.source.GetPushSubscriptions() {
+		if !subscribedToServices(sub.Services, affected) {
+			continue
+		}
+		if !push.allowed(sub.Endpoint) {
+			// Stored before endpoint_hosts was narrowed
+			skipped++
+			continue
+		}
+		if dryRun {
+			delivered.Add(1)
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(sub storage.PushSubscription) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			status, err := n.sendPush(push, sub, payload, urgency)
+			switch {
+			case err != nil:
+				slog.Warn("Error sending push", "push_service", pushHost(sub.Endpoint), "error", err)
+				failed.Add(1)
+			case status == http.StatusNotFound || status == http.StatusGone:
+				// The browser unsubscribed or the subscription expired
+				push.source.DeletePushSubscription(sub.Endpoint)
+			case status >= 300:
+				slog.Warn("Push service returned an error status", "push_service", pushHost(sub.Endpoint), "status", status)
+				failed.Add(1)
+			default:
+				delivered.Add(1)
+			}
+		}(sub)
+	}
+	wg.Wait()
+	if skipped > 0 {
+		slog.Warn("Push subscriptions skipped as their push service is not in endpoint_hosts", "browsers", skipped)
+	}
+
+	sent, lost := delivered.Load(), failed.Load()
+	if sent > 0 && dryRun {
+		slog.Info("Dry run: push notification not sent", "event", event, "browsers", sent, "payload", string(payload))
+		n.deliveryLog.add(Delivery{Channel: webPushChannel, Event: event, DryRun: true, Recipients: int(sent), Payload: string(payload)})
+		return
+	}
+	n.deliveries.add(webPushChannel, event, int(sent), int(lost))
+	if sent > 0 {
+		slog.Info("Sent push notification", "event", event, "browsers", sent)
+	}
+	if sent > 0 || lost > 0 {
+		delivery := Delivery{Channel: webPushChannel, Event: event, Sent: sent > 0, Recipients: int(sent)}
+		if lost > 0 {
+			delivery.Error = fmt.Sprintf("%d of %d browsers failed", lost, sent+lost)
+		}
+		n.deliveryLog.add(delivery)
+	}
+}
+
+// sendPush encrypts payload for one subscription and posts it to the push
+// service, returning the response status
+func (n *Notifier) sendPush(push *webPush, sub storage.PushSubscription, payload []byte, urgency string) (int, error) {
+	body, err := encryptPushPayload(sub, payload)
+	if err != nil {
+		return 0, err
+	}
+	auth, err := push.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest("POST", sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(push.cfg.TTL.Seconds())))
+	req.Header.Set("Urgency", urgency)
+	req.Header.Set("Authorization", auth)
+
+	resp, err := push.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// vapidAuthorization returns the VAPID Authorization header for a push
+// service: an ES256 JWT scoped to the endpoint's origin plus the public key
+func (p *webPush) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": p.cfg.Subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`)) + "." + enc.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, p.key, hash[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, enc.EncodeToString(sig), p.cfg.PublicKey), nil
+}
+
+// encryptPushPayload encrypts payload for a subscription using the
+// aes128gcm content coding (RFC 8188) with Web Push keys (RFC 8291)
+func encryptPushPayload(sub storage.PushSubscription, payload []byte) ([]byte, error) {
+	uaPublic, err := decodeBase64URL(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	authSecret, err := decodeBase64URL(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth secret: %w", err)
+	}
+
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid p256dh key: %w", err)
+	}
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+
+	keyInfo := []byte("WebPush: info\x00")
+	keyInfo = append(keyInfo, uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := hkdf(authSecret, sharedSecret, keyInfo, 32)
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Single record: payload followed by the last-record delimiter
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	if len(plaintext)+gcm.Overhead() > pushRecordSize {
+		return nil, fmt.Errorf("payload too large")
+	}
+
+	header := make([]byte, 0, 21+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdf derives length (<= 32) bytes with HKDF-SHA256 (RFC 5869)
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(ikm)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{0x01})
+	return expand.Sum(nil)[:length]
+}
+
+// decodeBase64URL accepts base64url with or without padding, as browsers
+// and key generators differ
+func decodeBase64URL(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// pushTag groups notifications about the same incident or service so a
+// newer one replaces the older in the browser
+func pushTag(data interface{}) string {
+	switch v := data.(type) {
+	case storage.Incident:
+		return "incident-" + v.ID
+	case storage.Maintenance:
+		return "maintenance-" + v.ID
+	case ServiceEvent:
+		return v.alertKey()
+	}
+	return ""
+}
+
+func pushHost(endpoint string) string {
+	if u, err := url.Parse(endpoint); err == nil {
+		return u.Host
+	}
+	return endpoint
+}
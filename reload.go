@@ -0,0 +1,262 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/monitor"
+	"github.com/status/notify"
+	"github.com/status/storage"
+	"github.com/status/web"
+)
+
+// configWatchInterval is how often -watch checks the config file for changes
+const configWatchInterval = 2 * time.Second
+
+// configWebhooks builds the notifier webhooks defined in the config file:
+// the webhooks list followed by the notification URLs
+func configWebhooks(cfg *config.Config) ([]notify.WebhookConfig, error) {
+	var webhooks []notify.WebhookConfig
+	for _, wh := range cfg.Webhooks {
+		webhook, err := configWebhook(wh)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", wh.Name, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	for i, raw := range cfg.Notifications.URLs {
+		webhook, err := notify.ParseNotificationURL(raw)
+		if err != nil {
+			// The URL carries credentials, so only its position is reported
+			return nil, fmt.Errorf("notification URL %d: %w", i+1, err)
+		}
+		webhook.ID = config.NotificationURLID(i)
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// configWebhook converts and validates one webhook from the config file
+func configWebhook(wh config.WebhookConfig) (notify.WebhookConfig, error) {
+	webhook := notify.WebhookConfig{
+		ID:      wh.ID,
+		Name:    wh.Name,
+		URL:     wh.URL,
+		Type:    wh.Type,
+		Events:  wh.Events,
+		Headers: wh.Headers,
+		Enabled: wh.Enabled,
+		Secret:  wh.Secret,
+
+		Template: wh.Template,
+		DryRun:   wh.DryRun,
+	}
+	if err := notify.ValidateTemplate(wh.Type, wh.Template); err != nil {
+		return webhook, err
+	}
+	if wh.Digest > 0 {
+		webhook.Digest = wh.Digest.String()
+		if err := notify.ValidateDigest(wh.Type, webhook.Digest); err != nil {
+			return webhook, err
+		}
+	}
+	if q := wh.QuietHours; q != nil {
+		webhook.QuietHours = &storage.QuietHours{
+			Days:        q.Days,
+			From:        q.From,
+			To:          q.To,
+			Timezone:    q.Timezone,
+			Action:      q.Action,
+			MinSeverity: q.MinSeverity,
+		}
+		if err := notify.ValidateQuietHours(*webhook.QuietHours); err != nil {
+			return webhook, err
+		}
+	}
+	return webhook, nil
+}
+
+// notificationRoutes converts the configured routing rules
+func notificationRoutes(cfg *config.Config) []notify.Route {
+	var routes []notify.Route
+	for _, r := range cfg.Notifications.Routes {
+		routes = append(routes, notify.Route{
+			Name:       r.Name,
+			Events:     r.Events,
+			Severities: r.Severities,
+			Services:   r.Services,
+			Groups:     r.Groups,
+			Days:       r.Days,
+			From:       r.From,
+			To:         r.To,
+			Timezone:   r.Timezone,
+			Webhooks:   r.Webhooks,
+			Continue:   r.Continue,
+		})
+	}
+	return routes
+}
+
+func emailConfig(cfg *config.Config) notify.EmailConfig {
+	return notify.EmailConfig{
+		Host:      cfg.Email.Host,
+		Port:      cfg.Email.Port,
+		Username:  cfg.Email.Username,
+		Password:  cfg.Email.Password,
+		From:      cfg.Email.From,
+		FromName:  cfg.Email.FromName,
+		TLS:       cfg.Email.TLS,
+		BatchSize: cfg.Email.BatchSize,
+	}
+}
+
+func serviceGroups(cfg *config.Config) map[string]string {
+	groups := make(map[string]string)
+	for _, svc := range cfg.Services {
+		groups[svc.Name] = svc.Group
+	}
+	return groups
+}
+
+// reloadConfig loads the config file again, with the command-line
+// overrides, and applies it to the running monitor, notifier and server
+// along with the discovered services. Nothing is applied if the new file
+// fails to load or validate. It returns the configuration now in effect.
+func reloadConfig(path string, overrides config.Overrides, discovered []config.Service, current *config.Config, mon *monitor.Monitor, notifier *notify.Notifier, server *web.Server, store *storage.Storage) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return current, err
+	}
+	overrides.Apply(cfg)
+	if err := configureLogging(cfg.Logging); err != nil {
+		return current, err
+	}
+	loc, err := cfg.Location()
+	if err != nil {
+		return current, fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+	}
+	webhooks, err := configWebhooks(cfg)
+	if err != nil {
+		return current, err
+	}
+	effective := withDiscovered(cfg, discovered)
+	if err := notifier.SetRoutes(notificationRoutes(effective), serviceGroups(effective)); err != nil {
+		return current, fmt.Errorf("invalid notification route: %w", err)
+	}
+
+	// Webhooks created through the admin API are kept
+	for _, wh := range store.GetWebhooks() {
+		webhooks = append(webhooks, notify.WebhookFromStorage(wh))
+	}
+	notifier.SetWebhooks(webhooks)
+	notifier.SetRetryPolicy(notify.RetryPolicy{
+		MaxAttempts:    cfg.Notifications.Retry.MaxAttempts,
+		InitialBackoff: cfg.Notifications.Retry.InitialBackoff,
+		MaxBackoff:     cfg.Notifications.Retry.MaxBackoff,
+	})
+	notifier.SetBreakerPolicy(notify.BreakerPolicy{
+		Threshold: cfg.Notifications.Breaker.Threshold,
+		Cooldown:  cfg.Notifications.Breaker.Cooldown,
+	})
+	notifier.SetDedupWindow(cfg.Notifications.DedupWindow)
+	notifier.SetDryRun(cfg.Notifications.DryRun)
+	notifier.SetLocation(loc)
+	if cfg.Email.Enabled && current.Email.Enabled {
+		notifier.EnableEmail(emailConfig(cfg), store)
+	}
+
+	// A follower's storage is the leader's snapshot; the leader stores them
+	if !store.IsReplica() && store.Outage() == nil {
+		if err := syncIncidents(cfg, store); err != nil {
+			slog.Error("Config incidents not stored", "error", err)
+		}
+		if err := syncMaintenance(cfg, store, time.Now()); err != nil {
+			slog.Error("Config maintenance not stored", "error", err)
+		}
+	}
+
+	added, removed, changed := mon.SetServices(effective.Services)
+	server.Reload(effective)
+
+	slog.Info("Configuration reloaded", "services", len(effective.Services), "added", len(added), "removed", len(removed), "changed", len(changed), "webhooks", len(webhooks))
+	if fixed := restartRequired(current, cfg); len(fixed) > 0 {
+		slog.Warn("Some changes take effect after a restart", "settings", strings.Join(fixed, ", "))
+	}
+	return cfg, nil
+}
+
+// restartRequired lists the settings that differ between old and cfg but
+// are only read at startup
+func restartRequired(old, cfg *config.Config) []string {
+	settings := []struct {
+		name     string
+		old, new interface{}
+	}{
+		{"server", old.Server, cfg.Server},
+		{"base_path", old.BasePath, cfg.BasePath},
+		{"storage", old.Storage, cfg.Storage},
+		{"email.enabled", old.Email.Enabled, cfg.Email.Enabled},
+		{"push", old.Push, cfg.Push},
+		{"feeds.websub", old.Feeds.WebSub, cfg.Feeds.WebSub},
+		{"notifications.heartbeat", old.Notifications.Heartbeat, cfg.Notifications.Heartbeat},
+		{"notifications.status_changes", old.Notifications.StatusChanges, cfg.Notifications.StatusChanges},
+		{"notifications.certificates", old.Notifications.Certificates, cfg.Notifications.Certificates},
+		{"discovery", old.Discovery, cfg.Discovery},
+		{"ha", old.HA, cfg.HA},
+	}
+
+	var names []string
+	for _, s := range settings {
+		if !reflect.DeepEqual(s.old, s.new) {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+// watchConfig signals changed whenever the config file or a file it
+// includes is modified, added or removed, checking every interval. Editors
+// that replace files are handled, as they are looked up by path each time. A
+// remote config is fetched and compared by checksum.
+func watchConfig(path string, interval time.Duration, changed chan<- struct{}) {
+	var fetchErr string
+	fingerprint := func() string {
+		if config.IsRemote(path) {
+			data, err := config.Fetch(path)
+			if err != nil {
+				if err.Error() != fetchErr {
+					slog.Error("Fetching config failed, keeping the current configuration", "error", err)
+					fetchErr = err.Error()
+				}
+				return "" // Unchanged until the source is back
+			}
+			fetchErr = ""
+			return fmt.Sprintf("%x", sha256.Sum256(data))
+		}
+		files, err := config.Files(path)
+		if err != nil {
+			files = []string{path}
+		}
+		var sb strings.Builder
+		for _, file := range files {
+			if info, err := os.Stat(file); err == nil {
+				fmt.Fprintf(&sb, "%s %d %d\n", file, info.ModTime().UnixNano(), info.Size())
+			}
+		}
+		return sb.String()
+	}
+
+	last := fingerprint()
+	for range time.Tick(interval) {
+		if current := fingerprint(); current != "" && current != last {
+			last = current
+			changed <- struct{}{}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/status/config"
+)
+
+// runSchema implements "status schema": it prints a JSON Schema for the
+// config file, returning the process exit code
+func runSchema(args []string) int {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := fs.String("o", "", "Write the schema to this file instead of standard output")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s schema [-o file]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Prints a JSON Schema for the configuration file, for editors and CI.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	data, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "schema: %v\n", err)
+		return 1
+	}
+	data = append(data, '\n')
+
+	if *output == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "schema: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/status/monitor"
+	"github.com/status/web"
+)
+
+// runExportSite implements "status export-site": it writes a static copy
+// of the page, rendered by the running server or from the database
+func runExportSite(args []string) int {
+	fs := flag.NewFlagSet("export-site", flag.ExitOnError)
+	connect := serverFlags(fs)
+	out := fs.String("out", "public", "Directory to write the site to")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s export-site [-out dir] [flags]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Writes the status page, every incident's page, the feeds and the service")
+		fmt.Fprintln(fs.Output(), "badges as static files, for a CDN or object storage to serve while the")
+		fmt.Fprintln(fs.Output(), "server is down. Files already in the directory are overwritten.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	conn, err := connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-site: %v\n", err)
+		return 1
+	}
+	defer conn.Close()
+
+	count := 0
+	put := func(name string, data []byte) error {
+		path, err := sitePath(*out, name)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		count++
+		return os.WriteFile(path, data, 0644)
+	}
+	if conn.client != nil {
+		err = downloadSite(conn.client, put)
+	} else {
+		// Without the server, the last stored check results are shown
+		mon := monitor.NewMonitor(conn.cfg.Services, conn.store)
+		err = web.NewServer(conn.cfg, mon, conn.store, nil).ExportSite(put)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-site: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d files to %s\n", count, *out)
+	return 0
+}
+
+// sitePath returns where a file of the site goes in dir, refusing names
+// that would land outside it
+func sitePath(dir, name string) (string, error) {
+	local := filepath.FromSlash(name)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("refusing to write %q outside %s", name, dir)
+	}
+	return filepath.Join(dir, local), nil
+}
+
+// downloadSite fetches the site rendered by a running server and passes
+// each file to put
+func downloadSite(client *apiClient, put func(name string, data []byte) error) error {
+	resp, err := client.send(http.MethodGet, "/api/site", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var result struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&result) != nil || result.Error == "" {
+			result.Error = resp.Status
+		}
+		return fmt.Errorf("%s (%d)", result.Error, resp.StatusCode)
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := put(hdr.Name, data); err != nil {
+			return err
+		}
+	}
+}
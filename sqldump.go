@@ -0,0 +1,511 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// sqlRow is a row from a SQL dump, by column name. NULL columns are absent.
+type sqlRow map[string]string
+
+// str returns a column's value, or "" if it is NULL
+func (r sqlRow) str(col string) string {
+	return r[col]
+}
+
+// int returns a column's value as an integer, or 0 if it is NULL or not a
+// number
+func (r sqlRow) int(col string) int64 {
+	n, _ := strconv.ParseInt(r[col], 10, 64)
+	return n
+}
+
+// float returns a column's value as a number, or 0 if it is NULL or not a
+// number
+func (r sqlRow) float(col string) float64 {
+	f, _ := strconv.ParseFloat(r[col], 64)
+	return f
+}
+
+// time returns a column's DATETIME or TIMESTAMP value in loc, or nil if it
+// is NULL or MySQL's zero date
+func (r sqlRow) time(col string, loc *time.Location) *time.Time {
+	v, ok := r[col]
+	if !ok || v == "" || strings.HasPrefix(v, "0000-00-00") {
+		return nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999Z07:00", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+			return &t
+		}
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		t := time.Unix(secs, 0)
+		return &t
+	}
+	return nil
+}
+
+// readSQLDump returns the rows inserted into the named tables by a dump
+// from mysqldump or SQLite's .dump. Column names come from each INSERT's
+// column list, or else the table's CREATE TABLE statement. Other
+// statements are ignored.
+func readSQLDump(data []byte, tables []string) (map[string][]sqlRow, error) {
+	lx := &sqlLexer{data: data, mysql: isMySQLDump(data)}
+	want := make(map[string]bool)
+	for _, t := range tables {
+		want[t] = true
+	}
+	columns := make(map[string][]string)
+	rows := make(map[string][]sqlRow)
+
+	for {
+		stmt, err := lx.statement()
+		if err != nil {
+			return nil, err
+		}
+		if stmt == nil {
+			return rows, nil
+		}
+		switch {
+		case stmt.keyword(0, "CREATE") && stmt.find("TABLE") > 0:
+			name, cols := createTableColumns(stmt)
+			if want[name] {
+				columns[name] = cols
+			}
+		case stmt.keyword(0, "INSERT") || stmt.keyword(0, "REPLACE"):
+			if name, _ := stmt.tableName(stmt.find("INTO") + 1); !want[name] {
+				continue
+			}
+			name, inserted, err := insertRows(stmt, columns)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", stmt[0].line, err)
+			}
+			rows[name] = append(rows[name], inserted...)
+		}
+	}
+}
+
+// isMySQLDump reports whether data looks like mysqldump output, whose
+// strings use backslash escapes
+func isMySQLDump(data []byte) bool {
+	head := data[:min(len(data), 4096)]
+	return bytes.Contains(head, []byte("MySQL dump")) || bytes.Contains(head, []byte("MariaDB dump")) || bytes.Contains(head, []byte("/*!40"))
+}
+
+// Kinds of SQL tokens
+const (
+	sqlIdent  = iota // Bare or quoted identifier, or keyword
+	sqlString        // String literal, unescaped
+	sqlNumber
+	sqlPunct
+)
+
+type sqlToken struct {
+	kind   int
+	text   string
+	quoted bool // An identifier in quotes, never a keyword
+	line   int
+}
+
+type sqlStatement []sqlToken
+
+// keyword reports whether token i is the keyword kw
+func (s sqlStatement) keyword(i int, kw string) bool {
+	return i < len(s) && s[i].kind == sqlIdent && !s[i].quoted && strings.EqualFold(s[i].text, kw)
+}
+
+// find returns the index of keyword kw, or -1
+func (s sqlStatement) find(kw string) int {
+	for i := range s {
+		if s.keyword(i, kw) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s sqlStatement) punct(i int, p string) bool {
+	return i < len(s) && s[i].kind == sqlPunct && s[i].text == p
+}
+
+// tableName reads a possibly schema-qualified table name at i, returning
+// the name and the index after it
+func (s sqlStatement) tableName(i int) (string, int) {
+	if i >= len(s) {
+		return "", i
+	}
+	name := s[i].text
+	for s.punct(i+1, ".") && i+2 < len(s) {
+		i += 2
+		name = s[i].text
+	}
+	return name, i + 1
+}
+
+// createTableColumns returns the table and column names a CREATE TABLE
+// statement defines
+func createTableColumns(stmt sqlStatement) (string, []string) {
+	i := stmt.find("TABLE") + 1
+	if stmt.keyword(i, "IF") {
+		i += 3 // IF NOT EXISTS
+	}
+	name, i := stmt.tableName(i)
+	if !stmt.punct(i, "(") {
+		return name, nil
+	}
+
+	var cols []string
+	depth, start := 0, true
+	for i++; i < len(stmt); i++ {
+		t := stmt[i]
+		switch {
+		case t.kind == sqlPunct && t.text == "(":
+			depth++
+		case t.kind == sqlPunct && t.text == ")":
+			if depth == 0 {
+				return name, cols
+			}
+			depth--
+		case t.kind == sqlPunct && t.text == "," && depth == 0:
+			start = true
+			continue
+		case start && t.kind == sqlIdent:
+			switch strings.ToUpper(t.text) {
+			case "PRIMARY", "KEY", "UNIQUE", "CONSTRAINT", "INDEX", "FOREIGN", "CHECK", "FULLTEXT", "SPATIAL":
+				if !t.quoted {
+					break
+				}
+				fallthrough
+			default:
+				cols = append(cols, t.text)
+			}
+		}
+		start = false
+	}
+	return name, cols
+}
+
+// insertRows returns the table and rows of an INSERT statement
+func insertRows(stmt sqlStatement, columns map[string][]string) (string, []sqlRow, error) {
+	i := stmt.find("INTO")
+	if i < 0 {
+		return "", nil, errors.New("INSERT without INTO")
+	}
+	name, i := stmt.tableName(i + 1)
+	cols := columns[name]
+	if stmt.punct(i, "(") {
+		cols = nil
+		for i++; i < len(stmt) && !stmt.punct(i, ")"); i++ {
+			if stmt[i].kind == sqlIdent {
+				cols = append(cols, stmt[i].text)
+			}
+		}
+		i++
+	}
+	if !stmt.keyword(i, "VALUES") {
+		return name, nil, nil // INSERT ... SELECT
+	}
+
+	var rows []sqlRow
+	for i++; i < len(stmt); {
+		if !stmt.punct(i, "(") {
+			break
+		}
+		row := make(sqlRow)
+		for col := 0; ; col++ {
+			v, null, next, err := sqlValue(stmt, i+1)
+			if err != nil {
+				return name, nil, err
+			}
+			if col < len(cols) && !null {
+				row[cols[col]] = v
+			}
+			i = next
+			if stmt.punct(i, ")") {
+				break
+			}
+			if !stmt.punct(i, ",") {
+				return name, nil, fmt.Errorf("unexpected %q in values for %s", tokenText(stmt, i), name)
+			}
+		}
+		if cols == nil {
+			return name, nil, fmt.Errorf("no columns known for %s: include its CREATE TABLE in the dump", name)
+		}
+		rows = append(rows, row)
+		i++
+		if stmt.punct(i, ",") {
+			i++
+		}
+	}
+	return name, rows, nil
+}
+
+func tokenText(stmt sqlStatement, i int) string {
+	if i >= len(stmt) {
+		return "end of statement"
+	}
+	return stmt[i].text
+}
+
+// sqlValue evaluates the value at i: a literal, NULL, or the replace(),
+// char() and unistr() calls SQLite's .dump writes for control characters.
+// It returns the value, whether it is NULL, and the index after it.
+func sqlValue(stmt sqlStatement, i int) (string, bool, int, error) {
+	if i >= len(stmt) {
+		return "", false, i, errors.New("unexpected end of statement")
+	}
+	t := stmt[i]
+	switch {
+	case t.kind == sqlString:
+		return t.text, false, i + 1, nil
+	case t.kind == sqlNumber:
+		if h, ok := strings.CutPrefix(strings.ToLower(t.text), "0x"); ok {
+			b, err := hex.DecodeString(h)
+			return string(b), false, i + 1, err
+		}
+		return t.text, false, i + 1, nil
+	case t.kind == sqlPunct && (t.text == "-" || t.text == "+") && i+1 < len(stmt) && stmt[i+1].kind == sqlNumber:
+		return strings.TrimPrefix(t.text, "+") + stmt[i+1].text, false, i + 2, nil
+	case t.kind != sqlIdent:
+		return "", false, i, fmt.Errorf("unexpected %q in values", t.text)
+	}
+
+	word := strings.ToLower(t.text)
+	switch {
+	case word == "null":
+		return "", true, i + 1, nil
+	case word == "true":
+		return "1", false, i + 1, nil
+	case word == "false":
+		return "0", false, i + 1, nil
+	case (word == "x" || strings.HasPrefix(word, "_")) && i+1 < len(stmt) && stmt[i+1].kind == sqlString:
+		// X'hex' blobs and MySQL charset introducers such as _binary'...'
+		if word == "x" {
+			b, err := hex.DecodeString(stmt[i+1].text)
+			return string(b), false, i + 2, err
+		}
+		return stmt[i+1].text, false, i + 2, nil
+	case !stmt.punct(i+1, "("):
+		return "", false, i, fmt.Errorf("unexpected %q in values", t.text)
+	}
+
+	var args []string
+	i += 2
+	for !stmt.punct(i, ")") {
+		v, _, next, err := sqlValue(stmt, i)
+		if err != nil {
+			return "", false, i, err
+		}
+		args = append(args, v)
+		i = next
+		if stmt.punct(i, ",") {
+			i++
+		}
+	}
+	i++
+
+	switch {
+	case word == "replace" && len(args) == 3:
+		return strings.ReplaceAll(args[0], args[1], args[2]), false, i, nil
+	case word == "char":
+		var b strings.Builder
+		for _, a := range args {
+			n, err := strconv.Atoi(a)
+			if err != nil {
+				return "", false, i, fmt.Errorf("char(%s)", a)
+			}
+			b.WriteRune(rune(n))
+		}
+		return b.String(), false, i, nil
+	case word == "unistr" && len(args) == 1:
+		s, err := unistr(args[0])
+		return s, false, i, err
+	}
+	return "", false, i, fmt.Errorf("unsupported function %s() in values", t.text)
+}
+
+// unistr decodes SQLite's unistr() escapes: \XXXX, \uXXXX, \+XXXXXX,
+// \UXXXXXXXX and \\
+func unistr(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '\\')
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		s = s[i+1:]
+		digits := 4
+		switch {
+		case strings.HasPrefix(s, "\\"):
+			b.WriteByte('\\')
+			s = s[1:]
+			continue
+		case strings.HasPrefix(s, "u"):
+			s = s[1:]
+		case strings.HasPrefix(s, "+"):
+			s, digits = s[1:], 6
+		case strings.HasPrefix(s, "U"):
+			s, digits = s[1:], 8
+		}
+		if len(s) < digits {
+			return "", errors.New("truncated unistr() escape")
+		}
+		n, err := strconv.ParseUint(s[:digits], 16, 32)
+		if err != nil {
+			return "", fmt.Errorf("bad unistr() escape: %w", err)
+		}
+		b.WriteRune(rune(n))
+		s = s[digits:]
+	}
+}
+
+// sqlLexer splits a dump into statements of tokens
+type sqlLexer struct {
+	data  []byte
+	pos   int
+	line  int
+	mysql bool // Backslash escapes in strings
+}
+
+// statement returns the tokens of the next non-empty statement, without
+// its semicolon, or nil at the end of the dump
+func (lx *sqlLexer) statement() (sqlStatement, error) {
+	var stmt sqlStatement
+	for {
+		t, ok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return stmt, nil
+		}
+		if t.kind == sqlPunct && t.text == ";" {
+			if len(stmt) > 0 {
+				return stmt, nil
+			}
+			continue
+		}
+		stmt = append(stmt, t)
+	}
+}
+
+// next returns the next token, skipping space and comments
+func (lx *sqlLexer) next() (sqlToken, bool, error) {
+	if lx.line == 0 {
+		lx.line = 1
+	}
+	for lx.pos < len(lx.data) {
+		c := lx.data[lx.pos]
+		rest := lx.data[lx.pos:]
+		switch {
+		case c == '\n':
+			lx.line++
+			lx.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			lx.pos++
+		case bytes.HasPrefix(rest, []byte("--")) || (c == '#' && lx.mysql):
+			end := bytes.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			lx.pos += end
+		case bytes.HasPrefix(rest, []byte("/*")):
+			end := bytes.Index(rest[2:], []byte("*/"))
+			if end < 0 {
+				return sqlToken{}, false, fmt.Errorf("line %d: unterminated comment", lx.line)
+			}
+			lx.line += bytes.Count(rest[:end+4], []byte("\n"))
+			lx.pos += end + 4
+		case c == '\'' || c == '"' && lx.mysql:
+			// MySQL also takes double quotes for strings, unless in ANSI mode
+			s, err := lx.quoted(c, lx.mysql)
+			return sqlToken{kind: sqlString, text: s, line: lx.line}, err == nil, err
+		case c == '`' || c == '"':
+			s, err := lx.quoted(c, false)
+			return sqlToken{kind: sqlIdent, text: s, quoted: true, line: lx.line}, err == nil, err
+		case c == '[':
+			end := bytes.IndexByte(rest, ']')
+			if end < 0 {
+				return sqlToken{}, false, fmt.Errorf("line %d: unterminated identifier", lx.line)
+			}
+			lx.pos += end + 1
+			return sqlToken{kind: sqlIdent, text: string(rest[1:end]), quoted: true, line: lx.line}, true, nil
+		case c >= '0' && c <= '9' || c == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9':
+			n := 1
+			for n < len(rest) && (isSQLWordByte(rest[n]) || rest[n] == '.' ||
+				(rest[n] == '-' || rest[n] == '+') && (rest[n-1] == 'e' || rest[n-1] == 'E')) {
+				n++
+			}
+			lx.pos += n
+			return sqlToken{kind: sqlNumber, text: string(rest[:n]), line: lx.line}, true, nil
+		case isSQLWordByte(c) || c >= utf8.RuneSelf:
+			n := 1
+			for n < len(rest) && (isSQLWordByte(rest[n]) || rest[n] == '$' || rest[n] >= utf8.RuneSelf) {
+				n++
+			}
+			lx.pos += n
+			return sqlToken{kind: sqlIdent, text: string(rest[:n]), line: lx.line}, true, nil
+		default:
+			lx.pos++
+			return sqlToken{kind: sqlPunct, text: string(c), line: lx.line}, true, nil
+		}
+	}
+	return sqlToken{}, false, nil
+}
+
+// quoted reads a string or identifier in quote characters, where a doubled
+// quote stands for one, and with backslash escapes if escapes is set
+func (lx *sqlLexer) quoted(quote byte, escapes bool) (string, error) {
+	start := lx.line
+	var b strings.Builder
+	for lx.pos++; lx.pos < len(lx.data); lx.pos++ {
+		c := lx.data[lx.pos]
+		switch {
+		case c == quote:
+			if lx.pos+1 < len(lx.data) && lx.data[lx.pos+1] == quote {
+				b.WriteByte(quote)
+				lx.pos++
+				continue
+			}
+			lx.pos++
+			return b.String(), nil
+		case c == '\\' && escapes && lx.pos+1 < len(lx.data):
+			lx.pos++
+			switch e := lx.data[lx.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '0':
+				b.WriteByte(0)
+			case 'Z':
+				b.WriteByte(0x1a)
+			case 'b':
+				b.WriteByte('\b')
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			if c == '\n' {
+				lx.line++
+			}
+			b.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("line %d: unterminated quoted text", start)
+}
+
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// statuspageAPI is the base URL of Atlassian Statuspage's manage API
+const statuspageAPI = "https://api.statuspage.io/v1"
+
+// statuspagePerPage is the page size used when listing incidents from the
+// manage API, which returns at most 100
+const statuspagePerPage = 100
+
+// sourceStatuspage marks incidents and maintenance imported from Statuspage
+const sourceStatuspage = "statuspage"
+
+// statuspageComponent is a component or component group, as returned by
+// both the manage API and a page's public API
+type statuspageComponent struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Position    int    `json:"position"`
+	Group       bool   `json:"group"`
+	GroupID     string `json:"group_id"`
+}
+
+// statuspageIncident is an incident or scheduled maintenance
+type statuspageIncident struct {
+	ID             string                `json:"id"`
+	Name           string                `json:"name"`
+	Status         string                `json:"status"`
+	Impact         string                `json:"impact"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	ResolvedAt     *time.Time            `json:"resolved_at"`
+	ScheduledFor   *time.Time            `json:"scheduled_for"`
+	ScheduledUntil *time.Time            `json:"scheduled_until"`
+	Components     []statuspageComponent `json:"components"`
+	Updates        []struct {
+		ID        string     `json:"id"`
+		Status    string     `json:"status"`
+		Body      string     `json:"body"`
+		CreatedAt time.Time  `json:"created_at"`
+		DisplayAt *time.Time `json:"display_at"`
+	} `json:"incident_updates"`
+	PostmortemBody        string     `json:"postmortem_body"` // Manage API only
+	PostmortemPublishedAt *time.Time `json:"postmortem_published_at"`
+}
+
+// statuspageSource fetches a page's components and incidents, from the
+// manage API with a key or otherwise from the page's public API, which
+// only lists the most recent incidents
+type statuspageSource struct {
+	pageID  string
+	apiKey  string
+	pageURL string
+	http    *http.Client
+}
+
+// runImportStatuspage implements "status import-statuspage": it copies a
+// Statuspage page's components, incidents and maintenance into storage
+func runImportStatuspage(args []string) int {
+	fs := flag.NewFlagSet("import-statuspage", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Configuration file naming the data directory and services")
+	dataDir := fs.String("data-dir", os.Getenv("STATUS_DATA_DIR"), "Data directory, overriding the config file's storage.data_dir (env STATUS_DATA_DIR)")
+	pageID := fs.String("page-id", "", "Statuspage page ID, for the manage API")
+	apiKey := fs.String("api-key", os.Getenv("STATUSPAGE_API_KEY"), "Statuspage API key (env STATUSPAGE_API_KEY)")
+	pageURL := fs.String("url", "", "Public URL of the page, such as https://status.example.com, when no API key is available")
+	servicesOut := fs.String("services-out", "", "Write the components as a services list for the config file")
+	dryRun := fs.Bool("dry-run", false, "Fetch and report what would be imported without writing")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s import-statuspage (-page-id id -api-key key | -url url) [flags]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Copies component groups, incidents with their updates and scheduled")
+		fmt.Fprintln(fs.Output(), "maintenance from Atlassian Statuspage into the database, keeping their IDs")
+		fmt.Fprintln(fs.Output(), "and times, so running it again updates rather than duplicates them.")
+		fmt.Fprintln(fs.Output(), "Incidents name components as affected services; name services in the")
+		fmt.Fprintln(fs.Output(), "config file after the components to link them. Stop the server first.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if (*pageID == "") == (*pageURL == "") || (*pageID != "" && *apiKey == "") {
+		fs.Usage()
+		return 2
+	}
+
+	src := &statuspageSource{
+		pageID:  *pageID,
+		apiKey:  *apiKey,
+		pageURL: strings.TrimRight(*pageURL, "/"),
+		http:    &http.Client{Timeout: apiTimeout},
+	}
+	components, err := src.components()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-statuspage: components: %v\n", err)
+		return 1
+	}
+	incidents, err := src.incidents()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-statuspage: incidents: %v\n", err)
+		return 1
+	}
+	groups := statuspageGroups(components)
+
+	var imported []storage.Incident
+	var maintenance []storage.Maintenance
+	for _, inc := range incidents {
+		if inc.ScheduledFor != nil || inc.Impact == "maintenance" {
+			maintenance = append(maintenance, statuspageMaintenance(inc))
+		} else {
+			imported = append(imported, statuspageIncidentRecord(inc))
+		}
+	}
+
+	if *servicesOut != "" {
+		if err := writeImportedServices(*servicesOut, "Statuspage", components); err != nil {
+			fmt.Fprintf(os.Stderr, "import-statuspage: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Wrote %d services to %s\n", len(components)-len(groups), *servicesOut)
+	}
+	if *dryRun {
+		fmt.Printf("Would import %d groups, %d incidents and %d maintenance windows\n", len(groups), len(imported), len(maintenance))
+		return 0
+	}
+
+	cfg, err := loadCommandConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-statuspage: %v\n", err)
+		return 1
+	}
+	dir := *dataDir
+	if dir == "" {
+		dir = cfg.Storage.DataDir
+	}
+	store, err := openStorage(dir, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import-statuspage: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	for _, g := range groups {
+		if _, err := store.SaveGroup(g); err != nil {
+			fmt.Fprintf(os.Stderr, "import-statuspage: group %s: %v\n", g.Name, err)
+			return 1
+		}
+	}
+	for _, inc := range imported {
+		if _, _, err := store.PutIncident(inc); err != nil {
+			fmt.Fprintf(os.Stderr, "import-statuspage: incident %s: %v\n", inc.ID, err)
+			return 1
+		}
+	}
+	for _, m := range maintenance {
+		if _, err := store.PutMaintenance(m); err != nil {
+			fmt.Fprintf(os.Stderr, "import-statuspage: maintenance %s: %v\n", m.ID, err)
+			return 1
+		}
+		// New windows are stored as scheduled; keep Statuspage's status so
+		// the scheduler doesn't announce windows that have long finished
+		if _, err := store.UpdateMaintenance(m.ID, m.Status); err != nil {
+			fmt.Fprintf(os.Stderr, "import-statuspage: maintenance %s: %v\n", m.ID, err)
+			return 1
+		}
+	}
+	fmt.Printf("Imported %d groups, %d incidents and %d maintenance windows\n", len(groups), len(imported), len(maintenance))
+
+	if missing := unconfiguredComponents(cfg, components); len(missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Components without a service of the same name in %s: %s\n", *configPath, strings.Join(missing, ", "))
+	}
+	return 0
+}
+
+// get fetches path from the manage API, or from the public API when no
+// page ID is set, decoding the JSON response into out
+func (s *statuspageSource) get(path string, out interface{}) error {
+	target := s.pageURL + "/api/v2/" + path
+	if s.pageID != "" {
+		target = statuspageAPI + "/pages/" + url.PathEscape(s.pageID) + "/" + path
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	if s.pageID != "" {
+		req.Header.Set("Authorization", "OAuth "+s.apiKey)
+	}
+	req.Header.Set("User-Agent", "status/"+version)
+
+	// The manage API allows about one request a second
+	for attempt := 0; ; attempt++ {
+		resp, err := s.http.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < 3 {
+			resp.Body.Close()
+			time.Sleep(time.Duration(attempt+1) * time.Second)
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET %s: %s", target, resp.Status)
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// components returns the page's components and groups
+func (s *statuspageSource) components() ([]statuspageComponent, error) {
+	var components []statuspageComponent
+	if s.pageID != "" {
+		return components, s.get("components", &components)
+	}
+	var summary struct {
+		Components []statuspageComponent `json:"components"`
+	}
+	err := s.get("summary.json", &summary)
+	return summary.Components, err
+}
+
+// incidents returns the page's incidents and scheduled maintenance: all of
+// them from the manage API, the most recent from the public API
+func (s *statuspageSource) incidents() ([]statuspageIncident, error) {
+	if s.pageID == "" {
+		var incidents, scheduled struct {
+			Incidents []statuspageIncident `json:"incidents"`
+			Scheduled []statuspageIncident `json:"scheduled_maintenances"`
+		}
+		if err := s.get("incidents.json", &incidents); err != nil {
+			return nil, err
+		}
+		if err := s.get("scheduled-maintenances.json", &scheduled); err != nil {
+			return nil, err
+		}
+		return append(incidents.Incidents, scheduled.Scheduled...), nil
+	}
+
+	var all []statuspageIncident
+	for page := 1; ; page++ {
+		var batch []statuspageIncident
+		if err := s.get(fmt.Sprintf("incidents?page=%d&per_page=%d", page, statuspagePerPage), &batch); err != nil {
+			return nil, err
+		}
+		all = append(all, batch...)
+		if len(batch) < statuspagePerPage {
+			return all, nil
+		}
+	}
+}
+
+// statuspageGroups converts component groups, with their components in
+// Statuspage's order
+func statuspageGroups(components []statuspageComponent) []storage.ComponentGroup {
+	sorted := append([]statuspageComponent(nil), components...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+
+	names := make(map[string]string)
+	for _, c := range sorted {
+		names[c.ID] = c.Name
+	}
+	var groups []storage.ComponentGroup
+	index := make(map[string]int)
+	for _, c := range sorted {
+		if c.Group {
+			index[c.ID] = len(groups)
+			groups = append(groups, storage.ComponentGroup{
+				Name:        c.Name,
+				Description: c.Description,
+				Position:    len(groups) + 1,
+				Components:  []string{},
+			})
+		}
+	}
+	for _, c := range sorted {
+		if i, ok := index[c.GroupID]; ok && !c.Group {
+			groups[i].Components = append(groups[i].Components, c.Name)
+		}
+	}
+	return groups
+}
+
+// statuspageIncidentRecord converts an incident. Statuspage's impact
+// becomes the severity, and a published postmortem becomes the last update.
+func statuspageIncidentRecord(inc statuspageIncident) storage.Incident {
+	out := storage.Incident{
+		ID:               inc.ID,
+		Title:            inc.Name,
+		Status:           inc.Status,
+		Severity:         inc.Impact,
+		AffectedServices: []string{},
+		Source:           sourceStatuspage,
+		CreatedAt:        inc.CreatedAt,
+		UpdatedAt:        inc.UpdatedAt,
+		ResolvedAt:       inc.ResolvedAt,
+		Updates:          []storage.IncidentUpdate{},
+	}
+	switch out.Status {
+	case "investigating", "identified", "monitoring", "resolved":
+	case "postmortem":
+		out.Status = "resolved"
+	default:
+		out.Status = "investigating"
+	}
+	if out.Severity != "major" && out.Severity != "critical" {
+		out.Severity = "minor"
+	}
+	for _, c := range inc.Components {
+		out.AffectedServices = append(out.AffectedServices, c.Name)
+	}
+
+	// Updates are listed newest first
+	for i := len(inc.Updates) - 1; i >= 0; i-- {
+		u := inc.Updates[i]
+		at := u.CreatedAt
+		if u.DisplayAt != nil {
+			at = *u.DisplayAt
+		}
+		status := u.Status
+		if status == "postmortem" {
+			status = "resolved"
+		}
+		out.Updates = append(out.Updates, storage.IncidentUpdate{ID: u.ID, Status: status, Message: u.Body, CreatedAt: at})
+	}
+	if len(out.Updates) > 0 {
+		out.Message = out.Updates[0].Message
+	}
+	if inc.PostmortemBody != "" && inc.PostmortemPublishedAt != nil {
+		out.Updates = append(out.Updates, storage.IncidentUpdate{
+			ID:        inc.ID + "-postmortem",
+			Status:    "resolved",
+			Message:   "**Postmortem**\n\n" + inc.PostmortemBody,
+			CreatedAt: *inc.PostmortemPublishedAt,
+		})
+	}
+	if out.Status == "resolved" && out.ResolvedAt == nil {
+		out.ResolvedAt = &out.UpdatedAt
+	}
+	return out
+}
+
+// statuspageMaintenance converts a scheduled maintenance. Its description
+// is the first update; verifying counts as still in progress.
+func statuspageMaintenance(inc statuspageIncident) storage.Maintenance {
+	m := storage.Maintenance{
+		ID:               inc.ID,
+		Title:            inc.Name,
+		AffectedServices: []string{},
+		Source:           sourceStatuspage,
+		CreatedAt:        inc.CreatedAt,
+		UpdatedAt:        inc.UpdatedAt,
+	}
+	for _, c := range inc.Components {
+		m.AffectedServices = append(m.AffectedServices, c.Name)
+	}
+	if n := len(inc.Updates); n > 0 {
+		m.Description = inc.Updates[n-1].Body
+	}
+
+	m.ScheduledStart = inc.CreatedAt
+	if inc.ScheduledFor != nil {
+		m.ScheduledStart = *inc.ScheduledFor
+	}
+	m.ScheduledEnd = m.ScheduledStart.Add(time.Hour)
+	if inc.ScheduledUntil != nil && inc.ScheduledUntil.After(m.ScheduledStart) {
+		m.ScheduledEnd = *inc.ScheduledUntil
+	}
+
+	switch inc.Status {
+	case "in_progress", "verifying":
+		m.Status = "in_progress"
+	case "completed":
+		m.Status = "completed"
+	default:
+		m.Status = "scheduled"
+	}
+	return m
+}
+
+// writeImportedServices writes the components, other than groups, as a
+// services list to paste into the config file. Each needs its check set.
+// Importers from other products convert their components to Statuspage's
+// shape to use it.
+func writeImportedServices(path, product string, components []statuspageComponent) error {
+	type service struct {
+		Name        string `yaml:"name"`
+		Group       string `yaml:"group,omitempty"`
+		Description string `yaml:"description,omitempty"`
+		Type        string `yaml:"type"`
+		URL         string `yaml:"url"`
+	}
+	groups := make(map[string]string)
+	for _, c := range components {
+		if c.Group {
+			groups[c.ID] = c.Name
+		}
+	}
+	sorted := append([]statuspageComponent(nil), components...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+
+	var services []service
+	for _, c := range sorted {
+		if !c.Group {
+			services = append(services, service{
+				Name:        c.Name,
+				Group:       groups[c.GroupID],
+				Description: c.Description,
+				Type:        "http",
+				URL:         "https://example.com/health",
+			})
+		}
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Services from %s components. Set each url, or type and host,\n# to what should be checked.\n", product)
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}{"services": services}); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// unconfiguredComponents returns the names of components, other than
+// groups, with no service of that name in the config file
+func unconfiguredComponents(cfg *config.Config, components []statuspageComponent) []string {
+	services := make(map[string]bool)
+	for _, svc := range cfg.Services {
+		services[svc.Name] = true
+	}
+	var missing []string
+	for _, c := range components {
+		if !c.Group && !services[c.Name] {
+			missing = append(missing, c.Name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// dumpFormat and dumpVersion identify an export written by Export
+const (
+	dumpFormat  = "status-export"
+	dumpVersion = 1
+)
+
+// dump is the JSON form of the whole database
+type dump struct {
+	Format     string                 `json:"format"`
+	Version    int                    `json:"version"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Buckets    map[string]*dumpBucket `json:"buckets"`
+}
+
+// dumpBucket holds a bucket's entries and nested buckets (per-service check
+// logs and rollups)
+type dumpBucket struct {
+	Entries []dumpEntry            `json:"entries,omitempty"`
+	Buckets map[string]*dumpBucket `json:"buckets,omitempty"`
+}
+
+// dumpEntry is a key and value. Values stored as JSON are written as JSON,
+// others (such as settings) as text; keys that are not text, such as check
+// log timestamps, are hex-encoded.
+type dumpEntry struct {
+	Key    string          `json:"key,omitempty"`
+	KeyHex string          `json:"key_hex,omitempty"`
+	Value  json.RawMessage `json:"value,omitempty"`
+	Text   *string         `json:"text,omitempty"`
+}
+
+// Export writes every bucket as JSON, for backups and moving a page to
+// another host. The export includes secrets: API token hashes, subscriber
+// tokens and generated VAPID keys.
+func (s *Storage) Export(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d := dump{Format: dumpFormat, Version: dumpVersion, ExportedAt: time.Now().UTC(), Buckets: make(map[string]*dumpBucket)}
+	err := s.view(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if b := tx.Bucket(name); b != nil {
+				d.Buckets[string(name)] = exportBucket(b)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// exportBucket reads a bucket and its nested buckets
+func exportBucket(b *bolt.Bucket) *dumpBucket {
+	db := &dumpBucket{}
+	b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			if nested := b.Bucket(k); nested != nil {
+				if db.Buckets == nil {
+					db.Buckets = make(map[string]*dumpBucket)
+				}
+				db.Buckets[string(k)] = exportBucket(nested)
+			}
+			return nil
+		}
+
+		var e dumpEntry
+		if utf8.Valid(k) {
+			e.Key = string(k)
+		} else {
+			e.KeyHex = hex.EncodeToString(k)
+		}
+		var compact bytes.Buffer
+		if json.Compact(&compact, v) == nil && bytes.Equal(compact.Bytes(), v) {
+			e.Value = append(json.RawMessage(nil), v...)
+		} else {
+			text := string(v)
+			e.Text = &text
+		}
+		db.Entries = append(db.Entries, e)
+		return nil
+	})
+	return db
+}
+
+// Import writes an export from Export to the database and returns the
+// number of entries written. Entries replace those with the same key;
+// with replace, every bucket is emptied first so the database matches the
+// export exactly.
+func (s *Storage) Import(r io.Reader, replace bool) (int, error) {
+	var d dump
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return 0, fmt.Errorf("invalid export: %w", err)
+	}
+	if d.Format != dumpFormat {
+		return 0, fmt.Errorf("not a status export (format %q)", d.Format)
+	}
+	if d.Version > dumpVersion {
+		return 0, fmt.Errorf("export version %d is newer than this version supports (%d)", d.Version, dumpVersion)
+	}
+	known := make(map[string]bool)
+	for _, name := range buckets {
+		known[string(name)] = true
+	}
+	for name := range d.Buckets {
+		if !known[name] {
+			return 0, fmt.Errorf("unknown bucket %q in export", name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	written := 0
+	err := s.update(func(tx *bolt.Tx) error {
+		if replace {
+			for _, name := range buckets {
+				if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+					return err
+				}
+				if _, err := tx.CreateBucket(name); err != nil {
+					return err
+				}
+			}
+		}
+		for name, db := range d.Buckets {
+			n, err := importBucket(tx.Bucket([]byte(name)), db)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			written += n
+		}
+		return indexAPITokens(tx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// importBucket writes a bucket's entries and nested buckets
+func importBucket(b *bolt.Bucket, db *dumpBucket) (int, error) {
+	written := 0
+	for _, e := range db.Entries {
+		key := []byte(e.Key)
+		if e.KeyHex != "" {
+			var err error
+			if key, err = hex.DecodeString(e.KeyHex); err != nil {
+				return written, fmt.Errorf("key_hex %q: %w", e.KeyHex, err)
+			}
+		}
+		if len(key) == 0 {
+			return written, fmt.Errorf("entry without a key")
+		}
+		// JSON values were stored compact; the export indents them
+		var value []byte
+		if e.Text != nil {
+			value = []byte(*e.Text)
+		} else if len(e.Value) > 0 {
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, e.Value); err != nil {
+				return written, err
+			}
+			value = compact.Bytes()
+		}
+		if err := b.Put(key, value); err != nil {
+			return written, err
+		}
+		written++
+	}
+	for name, nested := range db.Buckets {
+		child, err := b.CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return written, fmt.Errorf("%s: %w", name, err)
+		}
+		n, err := importBucket(child, nested)
+		written += n
+		if err != nil {
+			return written, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return written, nil
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrUnavailable is returned by storage whose database could not be opened
+var ErrUnavailable = errors.New("storage is unavailable")
+
+// outageRecovery is how long writes must keep succeeding before an outage
+// ends, so storage failing now and then, as on a nearly full disk, is not
+// reported as recovered between failures
+const outageRecovery = 30 * time.Second
+
+// Outage describes why storage is not saving changes
+type Outage struct {
+	Reason string    `json:"reason"`
+	Since  time.Time `json:"since"`
+}
+
+// Unavailable returns storage without a database, for a server to keep
+// serving from memory when the database cannot be opened: reads find
+// nothing and writes fail with ErrUnavailable. err is why it could not be
+// opened, reported by Outage.
+func Unavailable(dataDir string, err error) *Storage {
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	return &Storage{
+		dataDir: dataDir,
+		outage:  &Outage{Reason: err.Error(), Since: time.Now()},
+	}
+}
+
+// Outage reports why storage is not saving changes: the database could not
+// be opened, or writes to it are failing. It is nil while storage works,
+// and clears once writes have succeeded for outageRecovery.
+func (s *Storage) Outage() *Outage {
+	s.outageMu.Lock()
+	defer s.outageMu.Unlock()
+	if s.outage == nil {
+		return nil
+	}
+	o := *s.outage
+	return &o
+}
+
+// view runs fn in a read-only transaction
+func (s *Storage) view(fn func(*bolt.Tx) error) error {
+	if s.db == nil {
+		return ErrUnavailable
+	}
+	return s.db.View(fn)
+}
+
+// update runs fn in a read-write transaction. A transaction that fails to
+// commit, rather than one fn rejects, starts or extends an outage.
+func (s *Storage) update(fn func(*bolt.Tx) error) error {
+	if s.db == nil {
+		return ErrUnavailable
+	}
+	var fnErr error
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		fnErr = fn(tx)
+		return fnErr
+	})
+
+	switch {
+	case err == nil:
+		s.outageMu.Lock()
+		if s.outage != nil && time.Since(s.lastFailure) >= outageRecovery {
+			s.outage = nil
+		}
+		s.outageMu.Unlock()
+	case err != fnErr && !s.db.IsReadOnly() && !errors.Is(err, bolt.ErrDatabaseNotOpen):
+		// A replica refuses writes and a closed database is shutting down;
+		// anything else is the disk or the database failing
+		s.outageMu.Lock()
+		if s.outage == nil {
+			s.outage = &Outage{Since: time.Now()}
+		}
+		s.outage.Reason = err.Error()
+		s.lastFailure = time.Now()
+		s.outageMu.Unlock()
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// snapshotFile is the copy of the database a leader in HA mode publishes
+// in the data directory for followers, which cannot open the database
+// while the leader has it open
+const snapshotFile = "status.snapshot.db"
+
+// ErrNoSnapshot is returned by OpenReplica before a leader has published
+// a snapshot
+var ErrNoSnapshot = errors.New("no snapshot has been published yet")
+
+// WriteSnapshot publishes a consistent copy of the database for followers,
+// replacing the previous one in a single step
+func (s *Storage) WriteSnapshot() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tmp, err := os.CreateTemp(s.dataDir, snapshotFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = s.view(func(tx *bolt.Tx) error {
+		_, err := tx.WriteTo(tmp)
+		return err
+	})
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(s.dataDir, snapshotFile))
+}
+
+// OpenReplica opens the latest snapshot in dataDir read-only, for a
+// follower to serve reads from. Writes fail. Refresh moves the replica to
+// newer snapshots and Promote turns it into the primary.
+func OpenReplica(dataDir string) (*Storage, error) {
+	if dataDir == "" {
+		dataDir = "data"
+	}
+	s := &Storage{dataDir: dataDir}
+	if _, err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh opens the latest snapshot in place of the one a replica has
+// open, if a newer one has been published, and reports whether it did
+func (s *Storage) Refresh() (bool, error) {
+	path := filepath.Join(s.dataDir, snapshotFile)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, ErrNoSnapshot
+	}
+	if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	current := s.snapshotAt
+	s.mu.RUnlock()
+	if info.ModTime().Equal(current) {
+		return false, nil
+	}
+
+	// The leader replaces the snapshot by renaming a new file over it, so
+	// the open one is never written to
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: 1 * time.Second})
+	if err != nil {
+		return false, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+
+	s.mu.Lock()
+	old := s.db
+	s.db = db
+	s.snapshotAt = info.ModTime()
+	s.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return true, nil
+}
+
+// IsReplica reports whether the storage serves a snapshot rather than the
+// database itself
+func (s *Storage) IsReplica() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db != nil && s.db.IsReadOnly()
+}
+
+// Promote opens the database itself, read-write, in place of a replica's
+// snapshot. It returns ErrDatabaseLocked while a previous leader still has
+// the database open.
+func (s *Storage) Promote() error {
+	if !s.IsReplica() {
+		return nil
+	}
+	db, err := openDatabase(s.dataDir)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	old := s.db
+	s.db = db
+	s.snapshotAt = time.Time{}
+	s.mu.Unlock()
+
+	return old.Close()
+}
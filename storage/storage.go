@@ -1,11 +1,20 @@
 package storage
 
 import (
+	"bytes"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,13 +27,60 @@ var (
 	bucketMaintenance  = []byte("maintenance")
 	bucketHistory      = []byte("history")
 	bucketCheckHistory = []byte("check_history")
+	bucketCheckLog     = []byte("check_log")
+	bucketRollups      = []byte("rollups")
+	bucketAPITokens    = []byte("api_tokens")
+	bucketWebhooks     = []byte("webhooks")
+	bucketGroups       = []byte("groups")
+	bucketSubscribers  = []byte("subscribers")
+	bucketDeadLetters  = []byte("dead_letters")
+	bucketPush         = []byte("push_subscriptions")
+	bucketWebSub       = []byte("websub_subscriptions")
+	bucketSettings     = []byte("settings")
+
+	// bucketAPITokenHashes indexes API token IDs by hash. It is derived from
+	// bucketAPITokens, so it is rebuilt when the database is opened or
+	// imported rather than exported.
+	bucketAPITokenHashes = []byte("api_token_hashes")
+)
+
+// buckets lists every top-level bucket, created when the database is opened
+var buckets = [][]byte{bucketIncidents, bucketMaintenance, bucketHistory, bucketCheckHistory, bucketCheckLog, bucketRollups, bucketAPITokens, bucketWebhooks, bucketGroups, bucketSubscribers, bucketDeadLetters, bucketPush, bucketWebSub, bucketSettings}
+
+// ErrDatabaseLocked is returned by NewStorage when another process, such as
+// a running server, has the database open
+var ErrDatabaseLocked = errors.New("database is in use by another process (is the server running?)")
+
+// Retention for downsampled check data
+const (
+	rawRetention    = 7 * 24 * time.Hour
+	rollupRetention = 90 * 24 * time.Hour
+	rollupKeyFormat = "2006-01-02T15"
+)
+
+// maxHistoryDays is how many days of daily history are kept per service
+const maxHistoryDays = 90
+
+// maxDeadLetters caps the dead-letter list; the oldest entries are dropped
+const maxDeadLetters = 500
+
+// Supported history resolutions
+const (
+	ResolutionRaw  = "raw"
+	ResolutionHour = "hour"
+	ResolutionDay  = "day"
 )
 
 // Storage handles persistent data storage using BoltDB
 type Storage struct {
-	dataDir string
-	db      *bolt.DB
-	mu      sync.RWMutex
+	dataDir    string
+	db         *bolt.DB
+	mu         sync.RWMutex
+	snapshotAt time.Time // Modification time of the snapshot a replica has open
+
+	outageMu    sync.Mutex
+	outage      *Outage   // Why changes are not being saved; nil while they are
+	lastFailure time.Time // When a write last failed
 }
 
 // Incident represents a status incident
@@ -35,10 +91,24 @@ type Incident struct {
 	Severity         string           `json:"severity"` // minor, major, critical
 	Message          string           `json:"message"`
 	AffectedServices []string         `json:"affected_services"`
+	Source           string           `json:"source,omitempty"` // "auto" when opened from failing checks, "config" when declared in the config file, "statuspage" or "cachet" when imported
 	CreatedAt        time.Time        `json:"created_at"`
 	UpdatedAt        time.Time        `json:"updated_at"`
 	ResolvedAt       *time.Time       `json:"resolved_at,omitempty"`
+	AcknowledgedAt   *time.Time       `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy   string           `json:"acknowledged_by,omitempty"`
+	EscalationLevel  int              `json:"escalation_level,omitempty"` // Escalation steps already notified
 	Updates          []IncidentUpdate `json:"updates"`
+	Attachments      []Attachment     `json:"attachments,omitempty"` // Postmortems, reports and other documents
+}
+
+// Attachment is a document linked to an incident, such as a postmortem
+type Attachment struct {
+	Title    string    `json:"title"`
+	URL      string    `json:"url"`
+	MimeType string    `json:"mime_type"`
+	Size     int64     `json:"size_in_bytes,omitempty"`
+	AddedAt  time.Time `json:"added_at"`
 }
 
 // IncidentUpdate represents an update to an incident
@@ -57,7 +127,8 @@ type Maintenance struct {
 	AffectedServices []string  `json:"affected_services"`
 	ScheduledStart   time.Time `json:"scheduled_start"`
 	ScheduledEnd     time.Time `json:"scheduled_end"`
-	Status           string    `json:"status"` // scheduled, in_progress, completed
+	Status           string    `json:"status"`           // scheduled, in_progress, completed
+	Source           string    `json:"source,omitempty"` // "config" when declared in the config file, "statuspage" or "cachet" when imported
 	CreatedAt        time.Time `json:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at"`
 }
@@ -89,6 +160,130 @@ type ServiceCheckHistory struct {
 	ErrorMessage string       `json:"error_message,omitempty"`
 }
 
+// Rollup holds aggregated check results for a time bucket (hour or day)
+type Rollup struct {
+	Timestamp     time.Time `json:"timestamp"`
+	TotalChecks   int       `json:"total_checks"`
+	SuccessChecks int       `json:"success_checks"`
+	UptimePercent float64   `json:"uptime_percent"`
+	AvgResponseMs int64     `json:"avg_response_ms"`
+	MinResponseMs int64     `json:"min_response_ms"`
+	MaxResponseMs int64     `json:"max_response_ms"`
+	ResponseSumMs int64     `json:"response_sum_ms"`
+}
+
+// APIToken represents an API token. Only the SHA-256 hash of the token
+// is persisted; the plaintext is returned once at creation time.
+type APIToken struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // First characters of the token, for identification
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// ComponentGroup holds display metadata and ordering for a service group
+type ComponentGroup struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Collapsed   bool      `json:"collapsed"`
+	Position    int       `json:"position"`
+	Components  []string  `json:"components,omitempty"` // Explicit component order
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Webhook represents a webhook managed at runtime through the admin API
+type Webhook struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	URL       string            `json:"url"`
+	Type      string            `json:"type"`
+	Events    []string          `json:"events"`
+	Headers   map[string]string `json:"headers"`
+	Enabled   bool              `json:"enabled"`
+	Secret    string            `json:"secret,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	Digest     string      `json:"digest,omitempty"`   // Coalescing window, e.g. "15m"
+	Template   string      `json:"template,omitempty"` // Custom payload template
+	DryRun     bool        `json:"dry_run,omitempty"`  // Log notifications instead of sending them
+}
+
+// QuietHours suppresses or defers a webhook's lower-severity notifications
+// during a recurring window
+type QuietHours struct {
+	Days        []string `json:"days,omitempty"`         // mon, tue, ... (default every day)
+	From        string   `json:"from,omitempty"`         // HH:MM
+	To          string   `json:"to,omitempty"`           // HH:MM, may wrap past midnight
+	Timezone    string   `json:"timezone,omitempty"`     // IANA zone (default local)
+	Action      string   `json:"action,omitempty"`       // suppress (default) or defer
+	MinSeverity string   `json:"min_severity,omitempty"` // Still delivered during quiet hours (default critical)
+}
+
+// Subscriber represents an email subscriber. Notifications are only sent
+// once the address has been verified.
+type Subscriber struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Services   []string   `json:"services"` // Empty means all services
+	Verified   bool       `json:"verified"`
+	Token      string     `json:"-"` // Used for verify and unsubscribe links
+	CreatedAt  time.Time  `json:"created_at"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// DeadLetter records a notification that could not be delivered after all
+// retry attempts
+type DeadLetter struct {
+	ID          string          `json:"id"`
+	DeliveryID  string          `json:"delivery_id"`
+	WebhookID   string          `json:"webhook_id"`
+	WebhookName string          `json:"webhook_name"`
+	Event       string          `json:"event"`
+	DataType    string          `json:"data_type"` // incident, maintenance
+	Data        json.RawMessage `json:"data"`
+	Attempts    int             `json:"attempts"`
+	LastStatus  int             `json:"last_status,omitempty"`
+	LastError   string          `json:"last_error"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// PushSubscription is a browser's Web Push subscription. The endpoint
+// identifies it; P256dh and Auth are the browser's encryption keys.
+type PushSubscription struct {
+	Endpoint  string    `json:"endpoint"`
+	P256dh    string    `json:"p256dh"`
+	Auth      string    `json:"auth"`
+	Services  []string  `json:"services,omitempty"` // Empty means all services
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebSubSubscription is a verified subscription to a feed on the built-in
+// WebSub hub. Callback and Topic identify it.
+type WebSubSubscription struct {
+	Callback  string    `json:"callback"`
+	Topic     string    `json:"topic"`
+	Secret    string    `json:"secret,omitempty"` // Signs deliveries (X-Hub-Signature)
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// storedSubscriber is the persisted form of Subscriber (includes the token)
+type storedSubscriber struct {
+	Subscriber
+	Token string `json:"token"`
+}
+
+// storedAPIToken is the persisted form of APIToken (includes the hash)
+type storedAPIToken struct {
+	APIToken
+	Hash string `json:"hash"`
+}
+
 // NewStorage creates a new storage instance with BoltDB
 func NewStorage(dataDir string) (*Storage, error) {
 	if dataDir == "" {
@@ -100,34 +295,45 @@ func NewStorage(dataDir string) (*Storage, error) {
 		return nil, err
 	}
 
-	// Open BoltDB database
+	db, err := openDatabase(dataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Storage{
+		dataDir: dataDir,
+		db:      db,
+	}
+
+	return s, nil
+}
+
+// openDatabase opens the BoltDB database in dataDir read-write, creating
+// any missing buckets
+func openDatabase(dataDir string) (*bolt.DB, error) {
 	dbPath := filepath.Join(dataDir, "status.db")
 	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		err = ErrDatabaseLocked
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
 	// Create buckets
 	err = db.Update(func(tx *bolt.Tx) error {
-		buckets := [][]byte{bucketIncidents, bucketMaintenance, bucketHistory, bucketCheckHistory}
 		for _, bucket := range buckets {
 			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
 				return err
 			}
 		}
-		return nil
+		return indexAPITokens(tx)
 	})
 	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to create buckets: %w", err)
 	}
-
-	s := &Storage{
-		dataDir: dataDir,
-		db:      db,
-	}
-
-	return s, nil
+	return db, nil
 }
 
 // Close closes the database
@@ -142,6 +348,18 @@ func (s *Storage) Close() error {
 
 // CreateIncident creates a new incident
 func (s *Storage) CreateIncident(incident Incident) (*Incident, error) {
+	created, _, err := s.createIncident(incident, false)
+	return created, err
+}
+
+// CreateIncidentIfAbsent creates the incident unless one with its ID is
+// stored, checking and writing in one transaction. It returns the stored
+// incident and whether it was created.
+func (s *Storage) CreateIncidentIfAbsent(incident Incident) (*Incident, bool, error) {
+	return s.createIncident(incident, true)
+}
+
+func (s *Storage) createIncident(incident Incident, ifAbsent bool) (*Incident, bool, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -161,8 +379,17 @@ func (s *Storage) CreateIncident(incident Incident) (*Incident, error) {
 		})
 	}
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	var existing *Incident
+	err := s.update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketIncidents)
+		if data := b.Get([]byte(incident.ID)); ifAbsent && data != nil {
+			var inc Incident
+			if err := json.Unmarshal(data, &inc); err != nil {
+				return err
+			}
+			existing = &inc
+			return nil
+		}
 		data, err := json.Marshal(incident)
 		if err != nil {
 			return err
@@ -171,9 +398,12 @@ func (s *Storage) CreateIncident(incident Incident) (*Incident, error) {
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	if existing != nil {
+		return existing, false, nil
 	}
-	return &incident, nil
+	return &incident, true, nil
 }
 
 // UpdateIncident updates an existing incident
@@ -183,7 +413,7 @@ func (s *Storage) UpdateIncident(id string, status string, message string) (*Inc
 
 	var incident *Incident
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	err := s.update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketIncidents)
 		data := b.Get([]byte(id))
 		if data == nil {
@@ -227,14 +457,206 @@ func (s *Storage) UpdateIncident(id string, status string, message string) (*Inc
 	return incident, nil
 }
 
-// GetIncidents returns all incidents
+// AcknowledgeIncident records who acknowledged an incident, stopping
+// further escalation. Acknowledging twice keeps the first acknowledgment.
+func (s *Storage) AcknowledgeIncident(id string, by string) (*Incident, error) {
+	return s.modifyIncident(id, func(inc *Incident) {
+		if inc.AcknowledgedAt != nil {
+			return
+		}
+		now := time.Now()
+		inc.AcknowledgedAt = &now
+		inc.AcknowledgedBy = by
+	})
+}
+
+// SetIncidentEscalation records how many escalation steps have been notified
+func (s *Storage) SetIncidentEscalation(id string, level int) (*Incident, error) {
+	return s.modifyIncident(id, func(inc *Incident) {
+		inc.EscalationLevel = level
+	})
+}
+
+// AddIncidentAttachment attaches a document to an incident, replacing an
+// attachment with the same URL
+func (s *Storage) AddIncidentAttachment(id string, att Attachment) (*Incident, error) {
+	return s.modifyIncident(id, func(inc *Incident) {
+		now := time.Now()
+		att.AddedAt = now
+		for i, existing := range inc.Attachments {
+			if existing.URL == att.URL {
+				inc.Attachments[i] = att
+				inc.UpdatedAt = now
+				return
+			}
+		}
+		inc.Attachments = append(inc.Attachments, att)
+		inc.UpdatedAt = now
+	})
+}
+
+// RemoveIncidentAttachment removes the attachment with the given URL
+func (s *Storage) RemoveIncidentAttachment(id, url string) (*Incident, error) {
+	return s.modifyIncident(id, func(inc *Incident) {
+		for i, existing := range inc.Attachments {
+			if existing.URL == url {
+				inc.Attachments = append(inc.Attachments[:i], inc.Attachments[i+1:]...)
+				inc.UpdatedAt = time.Now()
+				return
+			}
+		}
+	})
+}
+
+// modifyIncident applies fn to a stored incident. It returns nil if the
+// incident does not exist.
+func (s *Storage) modifyIncident(id string, fn func(*Incident)) (*Incident, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var incident *Incident
+
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketIncidents)
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+
+		var inc Incident
+		if err := json.Unmarshal(data, &inc); err != nil {
+			return err
+		}
+		fn(&inc)
+
+		newData, err := json.Marshal(inc)
+		if err != nil {
+			return err
+		}
+
+		incident = &inc
+		return b.Put([]byte(id), newData)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return incident, nil
+}
+
+// PutIncident creates or replaces the incident with inc's ID as given,
+// for incidents declared outside the API such as in the config file.
+// Acknowledgment, escalation and attachments recorded at runtime are kept.
+// It reports whether the stored incident changed.
+func (s *Storage) PutIncident(inc Incident) (*Incident, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketIncidents)
+		existing := b.Get([]byte(inc.ID))
+		if existing != nil {
+			var old Incident
+			if err := json.Unmarshal(existing, &old); err == nil {
+				inc.AcknowledgedAt = old.AcknowledgedAt
+				inc.AcknowledgedBy = old.AcknowledgedBy
+				inc.EscalationLevel = old.EscalationLevel
+				inc.Attachments = old.Attachments
+			}
+		}
+
+		data, err := json.Marshal(inc)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(data, existing) {
+			return nil
+		}
+		changed = true
+		return b.Put([]byte(inc.ID), data)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &inc, changed, nil
+}
+
+// ReviseIncident sets the title, severity, affected services and status of
+// the stored incident with inc's ID to inc's, for incidents declared with
+// PUT. A changed status or a message other than the latest is recorded as
+// an update. It returns nil if there is no such incident, and reports
+// whether anything changed.
+func (s *Storage) ReviseIncident(inc Incident) (*Incident, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var incident *Incident
+	changed := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketIncidents)
+		data := b.Get([]byte(inc.ID))
+		if data == nil {
+			return nil
+		}
+
+		var stored Incident
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return err
+		}
+		incident = &stored
+
+		latest := stored.Message
+		if len(stored.Updates) > 0 {
+			latest = stored.Updates[len(stored.Updates)-1].Message
+		}
+		newUpdate := inc.Status != stored.Status || (inc.Message != "" && inc.Message != latest)
+		if !newUpdate && inc.Title == stored.Title && inc.Severity == stored.Severity &&
+			slices.Equal(inc.AffectedServices, stored.AffectedServices) {
+			return nil
+		}
+
+		now := time.Now()
+		if newUpdate {
+			stored.Updates = append(stored.Updates, IncidentUpdate{
+				ID:        generateID(),
+				Status:    inc.Status,
+				Message:   inc.Message,
+				CreatedAt: now,
+			})
+		}
+		if inc.Status == "resolved" && stored.Status != "resolved" {
+			stored.ResolvedAt = &now
+		} else if inc.Status != "resolved" {
+			stored.ResolvedAt = nil
+		}
+		stored.Title = inc.Title
+		stored.Severity = inc.Severity
+		stored.AffectedServices = inc.AffectedServices
+		stored.Status = inc.Status
+		stored.UpdatedAt = now
+
+		newData, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		changed = true
+		return b.Put([]byte(inc.ID), newData)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return incident, changed, nil
+}
+
+// GetIncidents returns incidents, newest first
 func (s *Storage) GetIncidents(limit int, activeOnly bool) []Incident {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	var incidents []Incident
 
-	s.db.View(func(tx *bolt.Tx) error {
+	s.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketIncidents)
 		c := b.Cursor()
 
@@ -249,13 +671,18 @@ func (s *Storage) GetIncidents(limit int, activeOnly bool) []Incident {
 			}
 
 			incidents = append(incidents, inc)
-			if limit > 0 && len(incidents) >= limit {
-				break
-			}
 		}
 		return nil
 	})
 
+	// Generated IDs sort by creation time, but incidents put with their
+	// own IDs do not
+	sort.SliceStable(incidents, func(i, j int) bool {
+		return incidents[i].CreatedAt.After(incidents[j].CreatedAt)
+	})
+	if limit > 0 && len(incidents) > limit {
+		incidents = incidents[:limit]
+	}
 	return incidents
 }
 
@@ -266,7 +693,7 @@ func (s *Storage) GetIncident(id string) *Incident {
 
 	var incident *Incident
 
-	s.db.View(func(tx *bolt.Tx) error {
+	s.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketIncidents)
 		data := b.Get([]byte(id))
 		if data == nil {
@@ -289,7 +716,7 @@ func (s *Storage) DeleteIncident(id string) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	err := s.update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketIncidents)
 		return b.Delete([]byte(id))
 	})
@@ -313,7 +740,7 @@ func (s *Storage) CreateMaintenance(m Maintenance) (*Maintenance, error) {
 		m.Status = "scheduled"
 	}
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	err := s.update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketMaintenance)
 		data, err := json.Marshal(m)
 		if err != nil {
@@ -328,6 +755,69 @@ func (s *Storage) CreateMaintenance(m Maintenance) (*Maintenance, error) {
 	return &m, nil
 }
 
+// PutMaintenance creates or replaces the maintenance window with m's ID,
+// for windows declared in the config file or with PUT. The
+// stored status is kept unless the window moved, since the scheduler
+// advances it. It reports whether the stored window changed.
+func (s *Storage) PutMaintenance(m Maintenance) (bool, error) {
+	_, changed, err := s.ReplaceMaintenance(m)
+	return changed, err
+}
+
+// ReplaceMaintenance is PutMaintenance also returning the window it
+// replaced, read in the same transaction; nil means m was created.
+func (s *Storage) ReplaceMaintenance(m Maintenance) (*Maintenance, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var previous *Maintenance
+	changed := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketMaintenance)
+		existing := b.Get([]byte(m.ID))
+		m.Status = "scheduled"
+		m.CreatedAt = time.Now()
+		if existing != nil {
+			var old Maintenance
+			if err := json.Unmarshal(existing, &old); err == nil {
+				if old.ScheduledStart.Equal(m.ScheduledStart) && old.ScheduledEnd.Equal(m.ScheduledEnd) {
+					m.Status = old.Status
+				}
+				m.CreatedAt = old.CreatedAt
+				m.UpdatedAt = old.UpdatedAt
+				previous = &old
+			}
+		}
+
+		data, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(data, existing) {
+			return nil
+		}
+		changed = true
+		m.UpdatedAt = time.Now()
+		if data, err = json.Marshal(m); err != nil {
+			return err
+		}
+		return b.Put([]byte(m.ID), data)
+	})
+	return previous, changed, err
+}
+
+// DeleteMaintenance deletes a maintenance window
+func (s *Storage) DeleteMaintenance(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMaintenance).Delete([]byte(id))
+	})
+
+	return err == nil
+}
+
 // GetMaintenance returns all maintenance windows
 func (s *Storage) GetMaintenance(upcoming bool) []Maintenance {
 	s.mu.RLock()
@@ -335,7 +825,7 @@ func (s *Storage) GetMaintenance(upcoming bool) []Maintenance {
 
 	var maintenance []Maintenance
 
-	s.db.View(func(tx *bolt.Tx) error {
+	s.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketMaintenance)
 		c := b.Cursor()
 
@@ -365,7 +855,7 @@ func (s *Storage) UpdateMaintenance(id string, status string) (*Maintenance, err
 
 	var maintenance *Maintenance
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	err := s.update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketMaintenance)
 		data := b.Get([]byte(id))
 		if data == nil {
@@ -402,7 +892,7 @@ func (s *Storage) RecordDailyStatus(serviceName string, status DailyStatus) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.db.Update(func(tx *bolt.Tx) error {
+	s.update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketHistory)
 
 		// Get existing history for this service
@@ -427,8 +917,55 @@ func (s *Storage) RecordDailyStatus(serviceName string, status DailyStatus) {
 		}
 
 		// Keep only last 90 days
-		if len(history) > 90 {
-			history = history[len(history)-90:]
+		if len(history) > maxHistoryDays {
+			history = history[len(history)-maxHistoryDays:]
+		}
+
+		data, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
+	})
+}
+
+// MergeHistory adds days to a service's daily history, for history from
+// before the service was monitored. A day already recorded is replaced by
+// merge(existing, day), or by the new day if merge is nil. The history is
+// kept in date order and trimmed to the latest 90 days.
+func (s *Storage) MergeHistory(serviceName string, days []DailyStatus, merge func(existing, day DailyStatus) DailyStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketHistory)
+
+		var history []DailyStatus
+		key := []byte(serviceName)
+		if data := b.Get(key); data != nil {
+			json.Unmarshal(data, &history)
+		}
+
+		index := make(map[string]int)
+		for i, d := range history {
+			index[d.Date] = i
+		}
+		for _, day := range days {
+			i, ok := index[day.Date]
+			switch {
+			case !ok:
+				index[day.Date] = len(history)
+				history = append(history, day)
+			case merge != nil:
+				history[i] = merge(history[i], day)
+			default:
+				history[i] = day
+			}
+		}
+
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Date < history[j].Date })
+		if len(history) > maxHistoryDays {
+			history = history[len(history)-maxHistoryDays:]
 		}
 
 		data, err := json.Marshal(history)
@@ -446,7 +983,7 @@ func (s *Storage) GetHistory(serviceName string, days int) []DailyStatus {
 
 	var history []DailyStatus
 
-	s.db.View(func(tx *bolt.Tx) error {
+	s.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketHistory)
 		data := b.Get([]byte(serviceName))
 		if data != nil {
@@ -468,7 +1005,7 @@ func (s *Storage) GetAllHistory(days int) map[string][]DailyStatus {
 
 	result := make(map[string][]DailyStatus)
 
-	s.db.View(func(tx *bolt.Tx) error {
+	s.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketHistory)
 		c := b.Cursor()
 
@@ -498,7 +1035,7 @@ func (s *Storage) SaveServiceCheckHistory(serviceName string, history []CheckPoi
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.db.Update(func(tx *bolt.Tx) error {
+	s.update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketCheckHistory)
 
 		data := ServiceCheckHistory{
@@ -524,7 +1061,7 @@ func (s *Storage) GetServiceCheckHistory(serviceName string) *ServiceCheckHistor
 
 	var history *ServiceCheckHistory
 
-	s.db.View(func(tx *bolt.Tx) error {
+	s.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketCheckHistory)
 		data := b.Get([]byte(serviceName))
 		if data == nil {
@@ -549,7 +1086,7 @@ func (s *Storage) GetAllServiceCheckHistory() map[string]*ServiceCheckHistory {
 
 	result := make(map[string]*ServiceCheckHistory)
 
-	s.db.View(func(tx *bolt.Tx) error {
+	s.view(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucketCheckHistory)
 		c := b.Cursor()
 
@@ -566,6 +1103,1171 @@ func (s *Storage) GetAllServiceCheckHistory() map[string]*ServiceCheckHistory {
 	return result
 }
 
+// === Downsampled Check Data ===
+
+// RecordCheck appends a raw check result and folds it into the hourly rollup.
+// Raw points are kept for 7 days, hourly rollups for 90 days.
+func (s *Storage) RecordCheck(serviceName string, cp CheckPoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.update(func(tx *bolt.Tx) error {
+		// Raw check log, keyed by big-endian timestamp for ordered range scans
+		logBucket, err := tx.Bucket(bucketCheckLog).CreateBucketIfNotExists([]byte(serviceName))
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(cp)
+		if err != nil {
+			return err
+		}
+		if err := logBucket.Put(timeKey(cp.Timestamp), data); err != nil {
+			return err
+		}
+		pruneBefore(logBucket, timeKey(cp.Timestamp.Add(-rawRetention)))
+
+		// Hourly rollup
+		rollupBucket, err := tx.Bucket(bucketRollups).CreateBucketIfNotExists([]byte(serviceName))
+		if err != nil {
+			return err
+		}
+		hour := cp.Timestamp.UTC().Truncate(time.Hour)
+		key := []byte(hour.Format(rollupKeyFormat))
+
+		r := Rollup{Timestamp: hour}
+		if existing := rollupBucket.Get(key); existing != nil {
+			json.Unmarshal(existing, &r)
+		}
+		r.add(cp)
+
+		data, err = json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if err := rollupBucket.Put(key, data); err != nil {
+			return err
+		}
+		pruneBefore(rollupBucket, []byte(cp.Timestamp.Add(-rollupRetention).UTC().Format(rollupKeyFormat)))
+		return nil
+	})
+}
+
+// GetCheckPoints returns raw check results for a service within [from, to]
+func (s *Storage) GetCheckPoints(serviceName string, from, to time.Time) []CheckPoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var points []CheckPoint
+
+	s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketCheckLog).Bucket([]byte(serviceName))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		end := timeKey(to)
+		for k, v := c.Seek(timeKey(from)); k != nil && bytes.Compare(k, end) <= 0; k, v = c.Next() {
+			var cp CheckPoint
+			if err := json.Unmarshal(v, &cp); err != nil {
+				continue
+			}
+			points = append(points, cp)
+		}
+		return nil
+	})
+
+	return points
+}
+
+// GetRollups returns hourly or daily aggregates for a service within [from, to].
+// Daily rollups are derived from the hourly buckets (UTC days).
+func (s *Storage) GetRollups(serviceName string, from, to time.Time, resolution string) []Rollup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var hourly []Rollup
+
+	s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketRollups).Bucket([]byte(serviceName))
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		start := []byte(from.UTC().Truncate(time.Hour).Format(rollupKeyFormat))
+		end := []byte(to.UTC().Format(rollupKeyFormat))
+		for k, v := c.Seek(start); k != nil && bytes.Compare(k, end) <= 0; k, v = c.Next() {
+			var r Rollup
+			if err := json.Unmarshal(v, &r); err != nil {
+				continue
+			}
+			hourly = append(hourly, r)
+		}
+		return nil
+	})
+
+	if resolution != ResolutionDay {
+		return hourly
+	}
+
+	var daily []Rollup
+	for _, h := range hourly {
+		day := time.Date(h.Timestamp.Year(), h.Timestamp.Month(), h.Timestamp.Day(), 0, 0, 0, 0, time.UTC)
+		if len(daily) == 0 || !daily[len(daily)-1].Timestamp.Equal(day) {
+			daily = append(daily, Rollup{Timestamp: day})
+		}
+		daily[len(daily)-1].merge(h)
+	}
+	return daily
+}
+
+// add folds a single check result into the rollup
+func (r *Rollup) add(cp CheckPoint) {
+	r.merge(Rollup{
+		TotalChecks:   1,
+		SuccessChecks: boolToInt(cp.Status == "operational" || cp.Status == "degraded"),
+		MinResponseMs: cp.ResponseTimeMs,
+		MaxResponseMs: cp.ResponseTimeMs,
+		ResponseSumMs: cp.ResponseTimeMs,
+	})
+}
+
+// merge combines another rollup into this one and recomputes derived fields
+func (r *Rollup) merge(o Rollup) {
+	if o.TotalChecks == 0 {
+		return
+	}
+	if r.TotalChecks == 0 || o.MinResponseMs < r.MinResponseMs {
+		r.MinResponseMs = o.MinResponseMs
+	}
+	if o.MaxResponseMs > r.MaxResponseMs {
+		r.MaxResponseMs = o.MaxResponseMs
+	}
+	r.TotalChecks += o.TotalChecks
+	r.SuccessChecks += o.SuccessChecks
+	r.ResponseSumMs += o.ResponseSumMs
+	r.AvgResponseMs = r.ResponseSumMs / int64(r.TotalChecks)
+	r.UptimePercent = float64(r.SuccessChecks) / float64(r.TotalChecks) * 100
+}
+
+// timeKey encodes a timestamp as a sortable 8-byte key
+func timeKey(t time.Time) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(t.UnixNano()))
+	return k
+}
+
+// pruneBefore deletes all keys in b that sort before cutoff
+func pruneBefore(b *bolt.Bucket, cutoff []byte) {
+	c := b.Cursor()
+	for k, _ := c.First(); k != nil && bytes.Compare(k, cutoff) < 0; k, _ = c.First() {
+		if err := b.Delete(k); err != nil {
+			return
+		}
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// === Component Groups ===
+
+// SaveGroup creates or replaces group metadata. New groups are placed after
+// existing ones unless a position is given.
+func (s *Storage) SaveGroup(g ComponentGroup) (*ComponentGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g.UpdatedAt = time.Now()
+
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketGroups)
+		if b.Get([]byte(g.Name)) == nil && g.Position == 0 {
+			b.ForEach(func(k, v []byte) error {
+				var existing ComponentGroup
+				if json.Unmarshal(v, &existing) == nil && existing.Position >= g.Position {
+					g.Position = existing.Position + 1
+				}
+				return nil
+			})
+		}
+
+		data, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(g.Name), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetGroups returns all group metadata ordered by position
+func (s *Storage) GetGroups() []ComponentGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := []ComponentGroup{}
+
+	s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketGroups).ForEach(func(k, v []byte) error {
+			var g ComponentGroup
+			if err := json.Unmarshal(v, &g); err != nil {
+				return nil
+			}
+			groups = append(groups, g)
+			return nil
+		})
+	})
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Position < groups[j].Position
+	})
+	return groups
+}
+
+// GetGroup returns metadata for a single group
+func (s *Storage) GetGroup(name string) *ComponentGroup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var group *ComponentGroup
+
+	s.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketGroups).Get([]byte(name))
+		if data == nil {
+			return nil
+		}
+		var g ComponentGroup
+		if err := json.Unmarshal(data, &g); err != nil {
+			return err
+		}
+		group = &g
+		return nil
+	})
+
+	return group
+}
+
+// DeleteGroup removes group metadata
+func (s *Storage) DeleteGroup(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketGroups)
+		if b.Get([]byte(name)) == nil {
+			return nil
+		}
+		found = true
+		return b.Delete([]byte(name))
+	})
+
+	return err == nil && found
+}
+
+// SetGroupOrder assigns positions to groups in the given order, creating
+// metadata entries for groups that have none
+func (s *Storage) SetGroupOrder(names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketGroups)
+		for i, name := range names {
+			g := ComponentGroup{Name: name}
+			if data := b.Get([]byte(name)); data != nil {
+				json.Unmarshal(data, &g)
+			}
+			g.Position = i + 1
+			g.UpdatedAt = time.Now()
+
+			data, err := json.Marshal(g)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(name), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// === Webhooks ===
+
+// SaveWebhook creates or replaces a webhook
+func (s *Storage) SaveWebhook(wh Webhook) (*Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if wh.ID == "" {
+		wh.ID = generateID()
+	}
+	if wh.CreatedAt.IsZero() {
+		wh.CreatedAt = time.Now()
+	}
+	wh.UpdatedAt = time.Now()
+
+	err := s.update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(wh)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(bucketWebhooks).Put([]byte(wh.ID), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &wh, nil
+}
+
+// PutWebhook creates or replaces the webhook with wh's ID, keeping its
+// creation time, in one transaction. It reports whether it was created.
+func (s *Storage) PutWebhook(wh Webhook) (*Webhook, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketWebhooks)
+		wh.CreatedAt = time.Now()
+		if existing := b.Get([]byte(wh.ID)); existing == nil {
+			created = true
+		} else {
+			var old Webhook
+			if err := json.Unmarshal(existing, &old); err == nil {
+				wh.CreatedAt = old.CreatedAt
+			}
+		}
+		wh.UpdatedAt = time.Now()
+
+		data, err := json.Marshal(wh)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(wh.ID), data)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &wh, created, nil
+}
+
+// GetWebhooks returns all stored webhooks
+func (s *Storage) GetWebhooks() []Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := []Webhook{}
+
+	s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketWebhooks).ForEach(func(k, v []byte) error {
+			var wh Webhook
+			if err := json.Unmarshal(v, &wh); err != nil {
+				return nil
+			}
+			webhooks = append(webhooks, wh)
+			return nil
+		})
+	})
+
+	return webhooks
+}
+
+// GetWebhook returns a stored webhook by ID
+func (s *Storage) GetWebhook(id string) *Webhook {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var webhook *Webhook
+
+	s.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketWebhooks).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		var wh Webhook
+		if err := json.Unmarshal(data, &wh); err != nil {
+			return err
+		}
+		webhook = &wh
+		return nil
+	})
+
+	return webhook
+}
+
+// DeleteWebhook deletes a stored webhook
+func (s *Storage) DeleteWebhook(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketWebhooks)
+		if b.Get([]byte(id)) == nil {
+			return nil
+		}
+		found = true
+		return b.Delete([]byte(id))
+	})
+
+	return err == nil && found
+}
+
+// === API Tokens ===
+
+// CreateAPIToken creates a new token and returns it along with the plaintext
+// secret, which cannot be recovered later
+func (s *Storage) CreateAPIToken(name string, scopes []string, expiresAt *time.Time) (*APIToken, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secret := "st_" + randomString(40)
+	token := APIToken{
+		ID:        generateID(),
+		Name:      name,
+		Prefix:    secret[:8],
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	err := s.update(func(tx *bolt.Tx) error {
+		hash := hashToken(secret)
+		data, err := json.Marshal(storedAPIToken{APIToken: token, Hash: hash})
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketAPITokenHashes).Put([]byte(hash), []byte(token.ID)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketAPITokens).Put([]byte(token.ID), data)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &token, secret, nil
+}
+
+// GetAPITokens returns all tokens (without hashes)
+func (s *Storage) GetAPITokens() []APIToken {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := []APIToken{}
+
+	s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAPITokens).ForEach(func(k, v []byte) error {
+			var t storedAPIToken
+			if err := json.Unmarshal(v, &t); err != nil {
+				return nil
+			}
+			tokens = append(tokens, t.APIToken)
+			return nil
+		})
+	})
+
+	return tokens
+}
+
+// HasAPITokens reports whether any API tokens exist
+func (s *Storage) HasAPITokens() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := false
+	s.view(func(tx *bolt.Tx) error {
+		k, _ := tx.Bucket(bucketAPITokens).Cursor().First()
+		found = k != nil
+		return nil
+	})
+	return found
+}
+
+// DeleteAPIToken revokes a token
+func (s *Storage) DeleteAPIToken(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAPITokens)
+		data := b.Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		found = true
+		var t storedAPIToken
+		if json.Unmarshal(data, &t) == nil {
+			if err := tx.Bucket(bucketAPITokenHashes).Delete([]byte(t.Hash)); err != nil {
+				return err
+			}
+		}
+		return b.Delete([]byte(id))
+	})
+
+	return err == nil && found
+}
+
+// indexAPITokens rebuilds the index of API token IDs by hash
+func indexAPITokens(tx *bolt.Tx) error {
+	if err := tx.DeleteBucket(bucketAPITokenHashes); err != nil && err != bolt.ErrBucketNotFound {
+		return err
+	}
+	index, err := tx.CreateBucket(bucketAPITokenHashes)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketAPITokens).ForEach(func(k, v []byte) error {
+		var t storedAPIToken
+		if json.Unmarshal(v, &t) != nil || t.Hash == "" {
+			return nil
+		}
+		return index.Put([]byte(t.Hash), k)
+	})
+}
+
+// ValidateAPIToken looks up a token by its plaintext secret. Expired tokens
+// are rejected. The last-used timestamp is refreshed at most once a minute,
+// in its own transaction, so most requests only read.
+func (s *Storage) ValidateAPIToken(secret string) *APIToken {
+	if secret == "" {
+		return nil
+	}
+
+	hash := hashToken(secret)
+	now := time.Now()
+	var token *storedAPIToken
+	var id []byte
+
+	s.mu.RLock()
+	s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAPITokens)
+		match := func(k, v []byte) bool {
+			var t storedAPIToken
+			if v == nil || json.Unmarshal(v, &t) != nil {
+				return false
+			}
+			if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hash)) != 1 {
+				return false
+			}
+			token, id = &t, append([]byte(nil), k...)
+			return true
+		}
+
+		// Snapshots from before the index was added are scanned
+		if index := tx.Bucket(bucketAPITokenHashes); index != nil {
+			if k := index.Get([]byte(hash)); k != nil {
+				match(k, b.Get(k))
+			}
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if match(k, v) {
+				return nil
+			}
+		}
+		return nil
+	})
+	s.mu.RUnlock()
+
+	if token == nil || (token.ExpiresAt != nil && now.After(*token.ExpiresAt)) {
+		return nil
+	}
+
+	// A replica cannot record use, but still validates
+	if s.IsReplica() || (token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) <= time.Minute) {
+		return &token.APIToken
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAPITokens)
+		var t storedAPIToken
+		if data := b.Get(id); data == nil || json.Unmarshal(data, &t) != nil {
+			return nil
+		}
+		t.LastUsedAt = &now
+		data, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		return b.Put(id, data)
+	})
+	token.LastUsedAt = &now
+	return &token.APIToken
+}
+
+// scopeRanks orders the token scopes; each grants those ranked below it
+var scopeRanks = map[string]int{"read": 1, "write": 2, "admin": 3}
+
+// HasScope reports whether the token grants the given scope. Scopes are
+// ordered, so admin implies write and write implies read.
+func (t *APIToken) HasScope(scope string) bool {
+	want, known := scopeRanks[scope]
+	for _, sc := range t.Scopes {
+		if sc == scope || known && scopeRanks[sc] >= want {
+			return true
+		}
+	}
+	return false
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// === Subscribers ===
+
+// CreateSubscriber adds an unverified subscriber, or updates the services
+// of an unverified subscriber with the same email address, keeping its
+// token. A verified subscriber is returned unchanged: anyone may submit an
+// address, so its services only change through the preferences link.
+func (s *Storage) CreateSubscriber(email string, services []string) (*Subscriber, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	email = strings.ToLower(strings.TrimSpace(email))
+	var sub Subscriber
+
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketSubscribers)
+
+		found := false
+		b.ForEach(func(k, v []byte) error {
+			var stored storedSubscriber
+			if err := json.Unmarshal(v, &stored); err == nil && stored.Email == email {
+				sub = stored.Subscriber
+				sub.Token = stored.Token
+				found = true
+			}
+			return nil
+		})
+
+		if found && sub.Verified {
+			return nil
+		}
+		if !found {
+			sub = Subscriber{
+				ID:        generateID(),
+				Email:     email,
+				Token:     randomString(32),
+				CreatedAt: time.Now(),
+			}
+		}
+		sub.Services = services
+
+		return putSubscriber(b, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetSubscribers returns all subscribers, optionally only verified ones
+func (s *Storage) GetSubscribers(verifiedOnly bool) []Subscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscribers := []Subscriber{}
+
+	s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSubscribers).ForEach(func(k, v []byte) error {
+			var stored storedSubscriber
+			if err := json.Unmarshal(v, &stored); err != nil {
+				return nil
+			}
+			if verifiedOnly && !stored.Verified {
+				return nil
+			}
+			sub := stored.Subscriber
+			sub.Token = stored.Token
+			subscribers = append(subscribers, sub)
+			return nil
+		})
+	})
+
+	return subscribers
+}
+
+// VerifySubscriber marks the subscriber owning token as verified
+func (s *Storage) VerifySubscriber(token string) *Subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var verified *Subscriber
+
+	s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketSubscribers)
+		sub := findSubscriberByToken(b, token)
+		if sub == nil {
+			return nil
+		}
+		if !sub.Verified {
+			now := time.Now()
+			sub.Verified = true
+			sub.VerifiedAt = &now
+			if err := putSubscriber(b, *sub); err != nil {
+				return err
+			}
+		}
+		verified = sub
+		return nil
+	})
+
+	return verified
+}
+
+// GetSubscriberByToken returns the subscriber owning token
+func (s *Storage) GetSubscriberByToken(token string) *Subscriber {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sub *Subscriber
+	s.view(func(tx *bolt.Tx) error {
+		sub = findSubscriberByToken(tx.Bucket(bucketSubscribers), token)
+		return nil
+	})
+	return sub
+}
+
+// UpdateSubscriberServices replaces the services of the subscriber owning
+// token. An empty list subscribes to all services.
+func (s *Storage) UpdateSubscriberServices(token string, services []string) *Subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var updated *Subscriber
+
+	s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketSubscribers)
+		sub := findSubscriberByToken(b, token)
+		if sub == nil {
+			return nil
+		}
+		sub.Services = services
+		if err := putSubscriber(b, *sub); err != nil {
+			return err
+		}
+		updated = sub
+		return nil
+	})
+
+	return updated
+}
+
+// DeleteSubscriberByToken removes the subscriber owning token
+func (s *Storage) DeleteSubscriberByToken(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketSubscribers)
+		sub := findSubscriberByToken(b, token)
+		if sub == nil {
+			return nil
+		}
+		found = true
+		return b.Delete([]byte(sub.ID))
+	})
+
+	return err == nil && found
+}
+
+func findSubscriberByToken(b *bolt.Bucket, token string) *Subscriber {
+	if token == "" {
+		return nil
+	}
+
+	var sub *Subscriber
+	b.ForEach(func(k, v []byte) error {
+		var stored storedSubscriber
+		if err := json.Unmarshal(v, &stored); err != nil {
+			return nil
+		}
+		if subtle.ConstantTimeCompare([]byte(stored.Token), []byte(token)) == 1 {
+			found := stored.Subscriber
+			found.Token = stored.Token
+			sub = &found
+		}
+		return nil
+	})
+	return sub
+}
+
+func putSubscriber(b *bolt.Bucket, sub Subscriber) error {
+	data, err := json.Marshal(storedSubscriber{Subscriber: sub, Token: sub.Token})
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(sub.ID), data)
+}
+
+// === Push Subscriptions ===
+
+// ErrPushLimit is returned by SavePushSubscription when a new endpoint
+// would exceed the limit on push subscriptions
+var ErrPushLimit = errors.New("too many push subscriptions")
+
+// SavePushSubscription adds a push subscription, replacing any existing
+// subscription with the same endpoint. A new endpoint is refused with
+// ErrPushLimit once limit subscriptions are stored (0 for no limit).
+func (s *Storage) SavePushSubscription(sub PushSubscription, limit int) (*PushSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketPush)
+		if limit > 0 && b.Get([]byte(sub.Endpoint)) == nil && b.Stats().KeyN >= limit {
+			return ErrPushLimit
+		}
+		data, err := json.Marshal(sub)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(sub.Endpoint), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetPushSubscriptions returns all push subscriptions
+func (s *Storage) GetPushSubscriptions() []PushSubscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := []PushSubscription{}
+
+	s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPush).ForEach(func(k, v []byte) error {
+			var sub PushSubscription
+			if err := json.Unmarshal(v, &sub); err != nil {
+				return nil
+			}
+			subs = append(subs, sub)
+			return nil
+		})
+	})
+
+	return subs
+}
+
+// DeletePushSubscription removes the subscription for endpoint
+func (s *Storage) DeletePushSubscription(endpoint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketPush)
+		if b.Get([]byte(endpoint)) == nil {
+			return nil
+		}
+		found = true
+		return b.Delete([]byte(endpoint))
+	})
+
+	return err == nil && found
+}
+
+// === WebSub Subscriptions ===
+
+func webSubKey(callback, topic string) []byte {
+	return []byte(callback + "\n" + topic)
+}
+
+// ErrWebSubLimit is returned by SaveWebSubSubscription when a new
+// subscription would exceed the limit in total or for its topic
+var ErrWebSubLimit = errors.New("too many WebSub subscriptions")
+
+// SaveWebSubSubscription creates or renews a WebSub subscription. A new
+// subscription is refused with ErrWebSubLimit once limit subscriptions,
+// or perTopic to its topic, are stored (0 for no limit).
+func (s *Storage) SaveWebSubSubscription(sub WebSubSubscription, limit, perTopic int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub.CreatedAt.IsZero() {
+		sub.CreatedAt = time.Now()
+	}
+
+	return s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketWebSub)
+		key := webSubKey(sub.Callback, sub.Topic)
+		if b.Get(key) == nil {
+			if limit > 0 && b.Stats().KeyN >= limit {
+				return ErrWebSubLimit
+			}
+			if perTopic > 0 {
+				count := 0
+				suffix := []byte("\n" + sub.Topic)
+				c := b.Cursor()
+				for k, _ := c.First(); k != nil; k, _ = c.Next() {
+					if bytes.HasSuffix(k, suffix) {
+						count++
+					}
+				}
+				if count >= perTopic {
+					return ErrWebSubLimit
+				}
+			}
+		}
+		data, err := json.Marshal(sub)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
+	})
+}
+
+// GetWebSubSubscriptions returns all WebSub subscriptions
+func (s *Storage) GetWebSubSubscriptions() []WebSubSubscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := []WebSubSubscription{}
+
+	s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketWebSub).ForEach(func(k, v []byte) error {
+			var sub WebSubSubscription
+			if err := json.Unmarshal(v, &sub); err != nil {
+				return nil
+			}
+			subs = append(subs, sub)
+			return nil
+		})
+	})
+
+	return subs
+}
+
+// DeleteWebSubSubscription removes the subscription of callback to topic
+func (s *Storage) DeleteWebSubSubscription(callback, topic string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketWebSub)
+		key := webSubKey(callback, topic)
+		if b.Get(key) == nil {
+			return nil
+		}
+		found = true
+		return b.Delete(key)
+	})
+
+	return err == nil && found
+}
+
+// === Settings ===
+
+// GetSetting returns a persisted setting, or "" if unset
+func (s *Storage) GetSetting(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var value string
+	s.view(func(tx *bolt.Tx) error {
+		value = string(tx.Bucket(bucketSettings).Get([]byte(key)))
+		return nil
+	})
+	return value
+}
+
+// SetSetting persists a setting
+func (s *Storage) SetSetting(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSettings).Put([]byte(key), []byte(value))
+	})
+}
+
+// === Announcement ===
+
+// settingAnnouncement is the settings key of the announcement set through the API
+const settingAnnouncement = "announcement"
+
+// Announcement is a site-wide banner for notices that aren't incidents
+type Announcement struct {
+	Text      string     `json:"text"`  // Markdown
+	Level     string     `json:"level"` // info, warning
+	StartsAt  *time.Time `json:"starts_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Active reports whether the announcement is shown at t
+func (a *Announcement) Active(t time.Time) bool {
+	return a != nil && a.Text != "" &&
+		(a.StartsAt == nil || !t.Before(*a.StartsAt)) &&
+		(a.EndsAt == nil || t.Before(*a.EndsAt))
+}
+
+// GetAnnouncement returns the stored announcement, or nil if none is set
+func (s *Storage) GetAnnouncement() *Announcement {
+	data := s.GetSetting(settingAnnouncement)
+	if data == "" {
+		return nil
+	}
+	var a Announcement
+	if err := json.Unmarshal([]byte(data), &a); err != nil {
+		return nil
+	}
+	return &a
+}
+
+// SaveAnnouncement replaces the stored announcement
+func (s *Storage) SaveAnnouncement(a Announcement) (*Announcement, error) {
+	a.UpdatedAt = time.Now()
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SetSetting(settingAnnouncement, string(data)); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAnnouncement removes the stored announcement
+func (s *Storage) DeleteAnnouncement() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSettings).Delete([]byte(settingAnnouncement))
+	})
+}
+
+// === Dead Letters ===
+
+// SaveDeadLetter records a permanently failed delivery
+func (s *Storage) SaveDeadLetter(dl DeadLetter) (*DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dl.ID == "" {
+		dl.ID = generateID()
+	}
+	if dl.CreatedAt.IsZero() {
+		dl.CreatedAt = time.Now()
+	}
+
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeadLetters)
+		data, err := json.Marshal(dl)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(dl.ID), data); err != nil {
+			return err
+		}
+
+		// IDs are time-ordered, so trim from the front
+		count := 0
+		b.ForEach(func(k, v []byte) error {
+			count++
+			return nil
+		})
+		c := b.Cursor()
+		for k, _ := c.First(); k != nil && count > maxDeadLetters; k, _ = c.First() {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+			count--
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &dl, nil
+}
+
+// GetDeadLetters returns failed deliveries, newest first
+func (s *Storage) GetDeadLetters() []DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	letters := []DeadLetter{}
+
+	s.view(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketDeadLetters).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var dl DeadLetter
+			if err := json.Unmarshal(v, &dl); err != nil {
+				continue
+			}
+			letters = append(letters, dl)
+		}
+		return nil
+	})
+
+	return letters
+}
+
+// GetDeadLetter returns a single failed delivery
+func (s *Storage) GetDeadLetter(id string) *DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var dl *DeadLetter
+	s.view(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucketDeadLetters).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		var d DeadLetter
+		if err := json.Unmarshal(data, &d); err != nil {
+			return err
+		}
+		dl = &d
+		return nil
+	})
+	return dl
+}
+
+// DeleteDeadLetter removes a failed delivery from the list
+func (s *Storage) DeleteDeadLetter(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	err := s.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeadLetters)
+		if b.Get([]byte(id)) == nil {
+			return nil
+		}
+		found = true
+		return b.Delete([]byte(id))
+	})
+
+	return err == nil && found
+}
+
 // Helper to generate unique IDs using crypto/rand for proper entropy
 func generateID() string {
 	return time.Now().Format("20060102150405") + randomString(6)
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/monitor"
+	"github.com/status/web"
+)
+
+// sdNotify sends a state such as "READY=1" to systemd over $NOTIFY_SOCKET,
+// as sd_notify(3) does. Without the socket (not started by systemd, or
+// without Type=notify) it does nothing.
+func sdNotify(state string) error {
+	path := os.Getenv("NOTIFY_SOCKET")
+	if path == "" {
+		return nil
+	}
+	// An abstract socket is given with a leading @
+	if strings.HasPrefix(path, "@") {
+		path = "\x00" + path[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// notifySystemd sends a state to systemd, logging failures
+func notifySystemd(state string) {
+	if err := sdNotify(state); err != nil {
+		slog.Warn("systemd notification failed", "state", state, "error", err)
+	}
+}
+
+// watchdogInterval returns the watchdog timeout systemd set for this
+// process with WatchdogSec=, or 0 if there is none
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// runWatchdog sends WATCHDOG=1 keepalives at half the watchdog timeout
+// while healthy reports no problem, until stop is closed. While a problem
+// persists keepalives stop, so systemd restarts a wedged instance once the
+// timeout passes.
+func runWatchdog(timeout time.Duration, healthy func(now time.Time) error, stop <-chan struct{}) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	failing := false
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			if err := healthy(now); err != nil {
+				if !failing {
+					slog.Error("Watchdog: withholding keepalive", "error", err)
+					notifySystemd(fmt.Sprintf("STATUS=Unhealthy: %v", err))
+				}
+				failing = true
+				continue
+			}
+			if failing {
+				slog.Info("Watchdog: healthy again")
+				notifySystemd("STATUS=Running")
+			}
+			failing = false
+			notifySystemd("WATCHDOG=1")
+		}
+	}
+}
+
+// healthCheck reports a problem when checks stop completing or the
+// maintenance scheduler stops running
+func healthCheck(mon *monitor.Monitor, server *web.Server) func(now time.Time) error {
+	return func(now time.Time) error {
+		if stalled := mon.Stalled(now); len(stalled) > 0 {
+			return fmt.Errorf("checks not completing for %s", strings.Join(stalled, ", "))
+		}
+		if server.SchedulerStalled(now) {
+			return errors.New("maintenance scheduler not running")
+		}
+		return nil
+	}
+}
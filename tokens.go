@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/status/web"
+)
+
+// runTokenCreate implements "status token create": it stores a new API
+// token and prints its secret. This is how the first token is made on a
+// server without credentials in its config, as the API refuses to.
+func runTokenCreate(args []string) int {
+	fs := flag.NewFlagSet("token create", flag.ExitOnError)
+	open := storageFlags(fs, true)
+	name := fs.String("name", "", "Name to identify the token by (required)")
+	scopes := fs.String("scopes", web.ScopeAdmin, "Comma-separated scopes: read, write and admin")
+	expiresIn := fs.Duration("expires-in", 0, "Expire the token after this long, such as 720h (default never)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s token create -name name [-scopes list] [-expires-in duration] [-config path | -data-dir dir]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Creates an API token and prints it; it cannot be shown again. Once a token")
+		fmt.Fprintln(fs.Output(), "exists, the admin API needs credentials. Stop the server first.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *name == "" || *expiresIn < 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var list []string
+	for _, scope := range strings.Split(*scopes, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope != web.ScopeRead && scope != web.ScopeWrite && scope != web.ScopeAdmin {
+			fmt.Fprintf(os.Stderr, "token create: invalid scope %q (use read, write or admin)\n", scope)
+			return 2
+		}
+		list = append(list, scope)
+	}
+	var expiresAt *time.Time
+	if *expiresIn > 0 {
+		t := time.Now().Add(*expiresIn)
+		expiresAt = &t
+	}
+
+	store, err := open()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "token create: %v\n", err)
+		return 1
+	}
+	defer store.Close()
+
+	token, secret, err := store.CreateAPIToken(*name, list, expiresAt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "token create: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Created token %s (%s) with scopes %s\n", token.ID, token.Name, strings.Join(token.Scopes, ", "))
+	fmt.Println(secret)
+	return 0
+}
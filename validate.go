@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/status/config"
+	"github.com/status/notify"
+)
+
+// runValidate implements "status validate": it checks a config file and
+// prints each problem with its line, returning the process exit code
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	configPath := fs.String("config", "config.yaml", "Path to configuration file")
+	offline := fs.Bool("offline", false, "Skip checks that need the network (DNS resolvers)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s validate [-offline] [-config path | path]\n\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Checks a configuration file and exits non-zero if it has problems.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		*configPath = fs.Arg(0)
+	}
+
+	var problems, warnings []config.Problem
+	f, err := config.ParseFile(*configPath)
+	var decodeErr *config.DecodeError
+	switch {
+	case errors.As(err, &decodeErr):
+		// Settings that cannot be read are reported alone, as the checks
+		// below need the decoded config
+		f, problems = decodeErr.File, decodeErr.Problems
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "%s: %v\n", config.Redact(*configPath), err)
+		return 1
+	default:
+		problems = f.Check(!*offline)
+		problems = append(problems, checkNotifications(f)...)
+		warnings = f.Lint()
+	}
+
+	for _, p := range problems {
+		printProblem(f, p, "")
+	}
+	for _, w := range warnings {
+		printProblem(f, w, "warning: ")
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d problem(s)\n", config.Redact(f.Path), len(problems))
+		return 1
+	}
+
+	cfg := f.Config
+	fmt.Printf("%s: OK (%d services, %d webhooks, %d notification URLs, %d included files",
+		config.Redact(f.Path), len(cfg.Services), len(cfg.Webhooks), len(cfg.Notifications.URLs), len(f.Included))
+	if len(warnings) > 0 {
+		fmt.Printf(", %d warning(s)", len(warnings))
+	}
+	fmt.Println(")")
+	return 0
+}
+
+// printProblem prints a problem or warning with its file, line and source
+func printProblem(f *config.File, p config.Problem, prefix string) {
+	fmt.Fprintf(os.Stderr, "%s:%d: %s%s\n", config.Redact(p.File), p.Line, prefix, p.Error())
+	if src := f.Source(p.File, p.Line); src != "" {
+		fmt.Fprintf(os.Stderr, "%6d | %s\n", p.Line, src)
+	}
+}
+
+// checkNotifications validates webhooks, notification URLs and routing
+// rules, which need the notify package
+func checkNotifications(f *config.File) []config.Problem {
+	var problems []config.Problem
+	cfg := f.Config
+	ids := make(map[string]bool)
+
+	for i, wh := range cfg.Webhooks {
+		switch {
+		case wh.ID == "":
+			problems = append(problems, f.Problem("id is required", "webhooks", i))
+		case ids[wh.ID]:
+			problems = append(problems, f.Problem(fmt.Sprintf("duplicate webhook id %q", wh.ID), "webhooks", i, "id"))
+		}
+		ids[wh.ID] = true
+
+		if wh.Type != "" && !notify.IsChannelType(wh.Type) {
+			problems = append(problems, f.Problem(fmt.Sprintf("unknown webhook type %q (want one of %v)", wh.Type, notify.ChannelTypes()), "webhooks", i, "type"))
+			continue
+		}
+		webhook, err := configWebhook(wh)
+		if err != nil {
+			problems = append(problems, f.Problem(err.Error(), "webhooks", i))
+			continue
+		}
+		if wh.Type != "email" {
+			if u, err := url.Parse(webhook.Endpoint()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				problems = append(problems, f.Problem("url must be an absolute http(s) URL", "webhooks", i, "url"))
+			}
+		}
+	}
+
+	for i, raw := range cfg.Notifications.URLs {
+		if _, err := notify.ParseNotificationURL(raw); err != nil {
+			problems = append(problems, f.Problem(err.Error(), "notifications", "urls", i))
+		}
+	}
+
+	for i, route := range notificationRoutes(cfg) {
+		// Routes are compiled one at a time so each problem has its line
+		if route.Name == "" {
+			route.Name = fmt.Sprintf("route %d", i+1)
+		}
+		if err := notify.NewNotifier(nil).SetRoutes([]notify.Route{route}, nil); err != nil {
+			problems = append(problems, f.Problem(err.Error(), "notifications", "routes", i))
+		}
+	}
+
+	return problems
+}
@@ -0,0 +1,659 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/notify"
+	"github.com/status/storage"
+)
+
+// === Admin: API Tokens ===
+
+// CreateTokenRequest is the body accepted by POST /api/admin/tokens
+type CreateTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresIn string     `json:"expires_in,omitempty"` // Go duration, e.g. "720h"
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateTokenResponse includes the plaintext token, shown only once
+type CreateTokenResponse struct {
+	storage.APIToken
+	Token string `json:"token"`
+}
+
+func (s *Server) handleAdminTokens(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.jsonResponse(w, s.storage.GetAPITokens())
+
+	case http.MethodPost:
+		// Without credentials anyone reaches this, and the first token would
+		// lock the operator out of their own admin API
+		if !s.hasAuth() {
+			s.jsonError(w, "Set api.key, bearer_token or basic_auth in the config file, or create the first token with \"status token create\"", http.StatusForbidden)
+			return
+		}
+
+		var req CreateTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Name == "" {
+			s.jsonError(w, "Token name required", http.StatusBadRequest)
+			return
+		}
+
+		if len(req.Scopes) == 0 {
+			req.Scopes = []string{ScopeWrite}
+		}
+		for _, scope := range req.Scopes {
+			if scope != ScopeRead && scope != ScopeWrite && scope != ScopeAdmin {
+				s.jsonError(w, "Invalid scope: "+scope+" (use read, write or admin)", http.StatusBadRequest)
+				return
+			}
+		}
+
+		expiresAt := req.ExpiresAt
+		if req.ExpiresIn != "" {
+			d, err := time.ParseDuration(req.ExpiresIn)
+			if err != nil || d <= 0 {
+				s.jsonError(w, "Invalid expires_in duration", http.StatusBadRequest)
+				return
+			}
+			t := time.Now().Add(d)
+			expiresAt = &t
+		}
+
+		token, secret, err := s.storage.CreateAPIToken(req.Name, req.Scopes, expiresAt)
+		if err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		s.jsonResponse(w, CreateTokenResponse{APIToken: *token, Token: secret})
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAdminToken(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/admin/tokens/")
+	if id == "" {
+		s.jsonError(w, "Token ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		if s.storage.DeleteAPIToken(id) {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			s.jsonError(w, "Token not found", http.StatusNotFound)
+		}
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// === Admin: Webhooks ===
+
+// Webhook sources
+const (
+	webhookSourceConfig  = "config"
+	webhookSourceRuntime = "api"
+)
+
+// WebhookInfo is a webhook as returned by the admin API. Webhooks defined in
+// the config file are read-only.
+type WebhookInfo struct {
+	notify.WebhookConfig
+	Headers       map[string]string     `json:"headers"`                  // Without secret options and credential headers
+	SecretHeaders []string              `json:"secret_headers,omitempty"` // Names of the secret headers set, whose values are never returned
+	Source        string                `json:"source"`
+	SecretSet     bool                  `json:"secret_set"` // The secret itself is never returned
+	Circuit       notify.CircuitStatus  `json:"circuit"`
+	Health        *notify.ChannelHealth `json:"health,omitempty"` // Latest heartbeat, if any
+}
+
+func (s *Server) newWebhookInfo(wh notify.WebhookConfig, source string) WebhookInfo {
+	info := WebhookInfo{
+		WebhookConfig: wh,
+		Headers:       map[string]string{},
+		Source:        source,
+		SecretSet:     wh.Secret != "",
+		Circuit:       s.notifier.CircuitStatus(wh.ID),
+		Health:        s.notifier.ChannelHealth(wh.ID),
+	}
+	for key, value := range wh.Headers {
+		if notify.IsSecretHeader(wh.Type, key) {
+			info.SecretHeaders = append(info.SecretHeaders, key)
+		} else {
+			info.Headers[key] = value
+		}
+	}
+	sort.Strings(info.SecretHeaders)
+	return info
+}
+
+// WebhookRequest is the body accepted when creating or updating a webhook.
+// Omitted fields are left unchanged on update.
+type WebhookRequest struct {
+	ID      string            `json:"id"`
+	Name    *string           `json:"name"`
+	URL     *string           `json:"url"`
+	Type    *string           `json:"type"`
+	Events  []string          `json:"events"`
+	Headers map[string]string `json:"headers"` // On PATCH, replaces the headers but keeps secret ones left out
+	Enabled *bool             `json:"enabled"`
+	Secret  *string           `json:"secret"` // Empty string removes the secret
+
+	QuietHours *storage.QuietHours `json:"quiet_hours"` // An empty object removes quiet hours
+	Digest     *string             `json:"digest"`      // Empty string disables the digest
+	Template   *string             `json:"template"`    // Empty string restores the built-in payload
+	DryRun     *bool               `json:"dry_run"`
+}
+
+func (s *Server) handleAdminWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		webhooks := []WebhookInfo{}
+		if s.notifier != nil {
+			for _, wh := range s.notifier.Webhooks() {
+				webhooks = append(webhooks, s.newWebhookInfo(wh, s.webhookSource(wh.ID)))
+			}
+		}
+		s.jsonResponse(w, webhooks)
+
+	case http.MethodPost:
+		if s.notifier == nil {
+			s.jsonError(w, "Notifications are not configured", http.StatusServiceUnavailable)
+			return
+		}
+
+		var req WebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.ID != "" && s.findWebhook(req.ID) != nil {
+			s.jsonError(w, "Webhook ID already exists", http.StatusConflict)
+			return
+		}
+		s.createWebhook(w, req)
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// newWebhook builds a webhook from a request alone, with defaults for the
+// fields it omits
+func newWebhook(req WebhookRequest) (storage.Webhook, error) {
+	wh := storage.Webhook{ID: req.ID, Type: "generic", Enabled: true}
+
+	// Accept an Apprise-style notification URL in place of url and type
+	if req.URL != nil && req.Type == nil && notify.IsNotificationURL(*req.URL) {
+		parsed, err := notify.ParseNotificationURL(*req.URL)
+		if err != nil {
+			return wh, err
+		}
+		wh.Name, wh.URL, wh.Type, wh.Headers, wh.Events = parsed.Name, parsed.URL, parsed.Type, parsed.Headers, parsed.Events
+		req.URL = nil
+	}
+	applyWebhookRequest(&wh, req)
+	return wh, nil
+}
+
+// createWebhook stores a new webhook and adds it to the notifier
+func (s *Server) createWebhook(w http.ResponseWriter, req WebhookRequest) {
+	wh, err := newWebhook(req)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := validateWebhook(wh); msg != "" {
+		s.jsonError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	saved, err := s.storage.SaveWebhook(wh)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.notifier.AddWebhook(notify.WebhookFromStorage(*saved))
+
+	w.WriteHeader(http.StatusCreated)
+	s.jsonResponse(w, s.newWebhookInfo(notify.WebhookFromStorage(*saved), webhookSourceRuntime))
+}
+
+func (s *Server) handleAdminWebhook(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/webhooks/")
+	id, action, _ := strings.Cut(path, "/")
+	if id == "" {
+		s.jsonError(w, "Webhook ID required", http.StatusBadRequest)
+		return
+	}
+	if s.notifier == nil {
+		s.jsonError(w, "Notifications are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch action {
+	case "":
+		s.handleAdminWebhookItem(w, r, id)
+	case "enable", "disable":
+		if r.Method != http.MethodPost {
+			s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		enabled := action == "enable"
+		s.updateWebhook(w, id, WebhookRequest{Enabled: &enabled})
+	case "test":
+		s.testWebhook(w, r, id)
+	case "probe":
+		s.probeWebhook(w, r, id)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleAdminWebhookItem(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		wh := s.findWebhook(id)
+		if wh == nil {
+			s.jsonError(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		s.jsonResponse(w, s.newWebhookInfo(*wh, s.webhookSource(id)))
+
+	case http.MethodPut, http.MethodPatch:
+		var req WebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPut {
+			s.putWebhook(w, id, req)
+			return
+		}
+		s.updateWebhook(w, id, req)
+
+	case http.MethodDelete:
+		if s.webhookSource(id) == webhookSourceConfig {
+			s.jsonError(w, "Webhook is defined in the config file and cannot be deleted", http.StatusConflict)
+			return
+		}
+		if !s.storage.DeleteWebhook(id) {
+			s.jsonError(w, "Webhook not found", http.StatusNotFound)
+			return
+		}
+		s.notifier.RemoveWebhook(id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// putWebhook creates the webhook with the given ID or replaces it with the
+// body: fields the body omits, secrets included, are reset to their
+// defaults, so a declarative tool applying the same body gets the same
+// webhook
+func (s *Server) putWebhook(w http.ResponseWriter, id string, req WebhookRequest) {
+	if s.webhookSource(id) == webhookSourceConfig {
+		s.jsonError(w, "Webhook is defined in the config file and cannot be modified", http.StatusConflict)
+		return
+	}
+	if !validDeclaredID(id) && s.storage.GetWebhook(id) == nil {
+		s.jsonError(w, "Webhook ID may only contain letters, digits, '-', '_' and '.'", http.StatusBadRequest)
+		return
+	}
+
+	req.ID = id
+	wh, err := newWebhook(req)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if msg := validateWebhook(wh); msg != "" {
+		s.jsonError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	// Concurrent PUTs to a new ID create it once
+	saved, created, err := s.storage.PutWebhook(wh)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	cfg := notify.WebhookFromStorage(*saved)
+	s.notifier.AddWebhook(cfg)
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	s.jsonResponse(w, s.newWebhookInfo(cfg, webhookSourceRuntime))
+}
+
+// updateWebhook applies a partial update to a stored webhook and hot-swaps
+// it in the notifier
+func (s *Server) updateWebhook(w http.ResponseWriter, id string, req WebhookRequest) {
+	if s.webhookSource(id) == webhookSourceConfig {
+		s.jsonError(w, "Webhook is defined in the config file and cannot be modified", http.StatusConflict)
+		return
+	}
+
+	wh := s.storage.GetWebhook(id)
+	if wh == nil {
+		s.jsonError(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	applyWebhookRequest(wh, req)
+	if msg := validateWebhook(*wh); msg != "" {
+		s.jsonError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	saved, err := s.storage.SaveWebhook(*wh)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cfg := notify.WebhookFromStorage(*saved)
+	s.notifier.AddWebhook(cfg)
+
+	s.jsonResponse(w, s.newWebhookInfo(cfg, webhookSourceRuntime))
+}
+
+// findWebhook returns the active webhook with the given ID
+func (s *Server) findWebhook(id string) *notify.WebhookConfig {
+	for _, wh := range s.notifier.Webhooks() {
+		if wh.ID == id {
+			return &wh
+		}
+	}
+	return nil
+}
+
+// webhookSource reports whether a webhook comes from the config file or the API
+func (s *Server) webhookSource(id string) string {
+	for _, wh := range s.cfg().Webhooks {
+		if wh.ID == id {
+			return webhookSourceConfig
+		}
+	}
+	for i := range s.cfg().Notifications.URLs {
+		if config.NotificationURLID(i) == id {
+			return webhookSourceConfig
+		}
+	}
+	return webhookSourceRuntime
+}
+
+func applyWebhookRequest(wh *storage.Webhook, req WebhookRequest) {
+	if req.Name != nil {
+		wh.Name = *req.Name
+	}
+	if req.URL != nil {
+		wh.URL = *req.URL
+	}
+	if req.Type != nil {
+		wh.Type = *req.Type
+	}
+	if req.Events != nil {
+		wh.Events = req.Events
+	}
+	if req.Headers != nil {
+		// Secret headers are never returned, so ones the request leaves out
+		// are kept; an empty value removes one
+		headers := make(map[string]string, len(req.Headers))
+		for key, value := range wh.Headers {
+			if _, ok := req.Headers[key]; !ok && notify.IsSecretHeader(wh.Type, key) {
+				headers[key] = value
+			}
+		}
+		for key, value := range req.Headers {
+			if value != "" || !notify.IsSecretHeader(wh.Type, key) {
+				headers[key] = value
+			}
+		}
+		wh.Headers = headers
+	}
+	if req.Enabled != nil {
+		wh.Enabled = *req.Enabled
+	}
+	if req.Secret != nil {
+		wh.Secret = *req.Secret
+	}
+	if req.Digest != nil {
+		wh.Digest = *req.Digest
+	}
+	if req.Template != nil {
+		wh.Template = *req.Template
+	}
+	if req.DryRun != nil {
+		wh.DryRun = *req.DryRun
+	}
+	if q := req.QuietHours; q != nil {
+		wh.QuietHours = q
+		if len(q.Days) == 0 && q.From == "" && q.To == "" {
+			wh.QuietHours = nil
+		}
+	}
+}
+
+func validateWebhook(wh storage.Webhook) string {
+	if wh.Name == "" {
+		return "Webhook name required"
+	}
+	if !notify.IsChannelType(wh.Type) {
+		return "Invalid webhook type: " + wh.Type
+	}
+	if wh.Type == "email" {
+		if wh.Headers["host"] == "" || wh.Headers["from"] == "" || wh.Headers["to"] == "" {
+			return "Email webhooks require host, from and to headers"
+		}
+	} else if u, err := url.Parse(notify.WebhookFromStorage(wh).Endpoint()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "Webhook url must be an absolute http(s) URL"
+	}
+	if wh.Type == "telegram" && wh.Headers["chat_id"] == "" {
+		return "Telegram webhooks require a chat_id header"
+	}
+	if wh.Type == "twilio" && (wh.Headers["auth_token"] == "" || wh.Headers["from"] == "" || wh.Headers["to"] == "") {
+		return "Twilio webhooks require auth_token, from and to headers"
+	}
+	if wh.Type == "mastodon" && wh.Headers["access_token"] == "" {
+		return "Mastodon webhooks require an access_token header"
+	}
+	if wh.Type == "x" && wh.Headers["access_token"] == "" {
+		return "X webhooks require an access_token header"
+	}
+	if v := wh.Headers["visibility"]; wh.Type == "mastodon" && v != "" && v != "public" && v != "unlisted" && v != "private" && v != "direct" {
+		return "Mastodon visibility must be public, unlisted, private or direct"
+	}
+	if wh.Type == "ntfy" && wh.Headers["topic"] == "" {
+		return "ntfy webhooks require a topic header"
+	}
+	if wh.Type == "gotify" && wh.Headers["app_token"] == "" {
+		return "Gotify webhooks require an app_token header"
+	}
+	if region := strings.ToLower(wh.Headers["region"]); wh.Type == "opsgenie" && region != "" && region != "us" && region != "eu" {
+		return "Opsgenie region must be us or eu"
+	}
+	if format := strings.ToLower(wh.Headers["format"]); (wh.Type == "teams" || wh.Type == "msteams") && format != "" && format != "adaptive" && format != "messagecard" {
+		return "Teams format must be adaptive or messagecard"
+	}
+	if wh.Type == "pushover" && (wh.Headers["app_token"] == "" || wh.Headers["user_key"] == "") {
+		return "Pushover webhooks require app_token and user_key headers"
+	}
+	if err := notify.ValidateDigest(wh.Type, wh.Digest); err != nil {
+		return err.Error()
+	}
+	if err := notify.ValidateTemplate(wh.Type, wh.Template); err != nil {
+		return err.Error()
+	}
+	if wh.QuietHours != nil {
+		if err := notify.ValidateQuietHours(*wh.QuietHours); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// testWebhook fires a synthetic incident at a webhook and reports the
+// target's response
+func (s *Server) testWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.notifier == nil {
+		s.jsonError(w, "Notifications are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := s.notifier.TestWebhook(id, s.cfg().BaseURL)
+	if errors.Is(err, notify.ErrWebhookNotFound) {
+		s.jsonError(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.jsonError(w, "Delivery failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	s.jsonResponse(w, result)
+}
+
+// probeWebhook checks a webhook's target without sending a notification
+// and reports its health
+func (s *Server) probeWebhook(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	health, err := s.notifier.ProbeWebhook(id)
+	if errors.Is(err, notify.ErrWebhookNotFound) {
+		s.jsonError(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, health)
+}
+
+// === Admin: Deliveries ===
+
+// handleAdminDeliveries lists recent deliveries, newest first. With
+// ?dry_run=true only the dry runs are listed.
+func (s *Server) handleAdminDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	deliveries := []notify.Delivery{}
+	if s.notifier != nil {
+		deliveries = s.notifier.Deliveries()
+	}
+	if r.URL.Query().Get("dry_run") == "true" {
+		dryRuns := deliveries[:0]
+		for _, d := range deliveries {
+			if d.DryRun {
+				dryRuns = append(dryRuns, d)
+			}
+		}
+		deliveries = dryRuns
+	}
+	s.jsonResponse(w, deliveries)
+}
+
+// === Admin: Dead Letters ===
+
+func (s *Server) handleAdminDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.jsonResponse(w, s.storage.GetDeadLetters())
+}
+
+func (s *Server) handleAdminDeadLetter(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/dead-letters/")
+	id, action, _ := strings.Cut(path, "/")
+	if id == "" {
+		s.jsonError(w, "Dead letter ID required", http.StatusBadRequest)
+		return
+	}
+
+	dl := s.storage.GetDeadLetter(id)
+	if dl == nil {
+		s.jsonError(w, "Dead letter not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		s.jsonResponse(w, dl)
+
+	case action == "" && r.Method == http.MethodDelete:
+		s.storage.DeleteDeadLetter(id)
+		w.WriteHeader(http.StatusNoContent)
+
+	case action == "retry" && r.Method == http.MethodPost:
+		s.retryDeadLetter(w, *dl)
+
+	case action == "" || action == "retry":
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// retryDeadLetter makes one delivery attempt and drops the dead letter if
+// the target accepts it
+func (s *Server) retryDeadLetter(w http.ResponseWriter, dl storage.DeadLetter) {
+	if s.notifier == nil {
+		s.jsonError(w, "Notifications are not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := s.notifier.Redeliver(dl, s.cfg().BaseURL)
+	if errors.Is(err, notify.ErrWebhookNotFound) {
+		s.jsonError(w, "Webhook no longer exists", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.jsonError(w, "Delivery failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	if result.StatusCode < 400 {
+		s.storage.DeleteDeadLetter(dl.ID)
+	}
+	s.jsonResponse(w, result)
+}
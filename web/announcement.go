@@ -0,0 +1,164 @@
+package web
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/status/markdown"
+	"github.com/status/storage"
+)
+
+// EventAnnouncement is sent to WebSocket clients when the announcement is
+// set or cleared through the API
+const EventAnnouncement = "announcement"
+
+// AnnouncementInfo is the announcement as returned by the API
+type AnnouncementInfo struct {
+	Text     string        `json:"text"`
+	HTML     template.HTML `json:"html"`
+	Level    string        `json:"level"`
+	StartsAt *time.Time    `json:"starts_at,omitempty"`
+	EndsAt   *time.Time    `json:"ends_at,omitempty"`
+	Active   bool          `json:"active"`
+	Source   string        `json:"source"` // config, api, or storage for the warning shown during a storage outage
+}
+
+// AnnouncementRequest is the body accepted by PUT /api/announcement
+type AnnouncementRequest struct {
+	Text     string     `json:"text"`
+	Level    string     `json:"level"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+// configAnnouncement returns the announcement set in the config file, or nil
+func (s *Server) configAnnouncement() *storage.Announcement {
+	a := s.cfg().Announcement
+	if a.Text == "" {
+		return nil
+	}
+	announcement := &storage.Announcement{Text: a.Text, Level: a.Level}
+	if !a.Start.IsZero() {
+		announcement.StartsAt = &a.Start
+	}
+	if !a.End.IsZero() {
+		announcement.EndsAt = &a.End
+	}
+	return announcement
+}
+
+// announcement returns the current announcement, from the config file or
+// else the API, whether or not it is showing now; nil if there is none
+func (s *Server) announcement() *AnnouncementInfo {
+	source := "config"
+	a := s.configAnnouncement()
+	if a == nil {
+		source = "api"
+		if a = s.storage.GetAnnouncement(); a == nil {
+			return nil
+		}
+	}
+	level := a.Level
+	if level == "" {
+		level = "info"
+	}
+	return &AnnouncementInfo{
+		Text:     a.Text,
+		HTML:     template.HTML(markdown.ToHTML(a.Text)),
+		Level:    level,
+		StartsAt: a.StartsAt,
+		EndsAt:   a.EndsAt,
+		Active:   a.Active(time.Now()),
+		Source:   source,
+	}
+}
+
+// activeAnnouncement returns the announcement if it is showing now. A
+// storage outage shows a warning in its place.
+func (s *Server) activeAnnouncement() *AnnouncementInfo {
+	if o := s.storage.Outage(); o != nil {
+		return outageAnnouncement(o)
+	}
+	if a := s.announcement(); a != nil && a.Active {
+		return a
+	}
+	return nil
+}
+
+func (s *Server) handleAPIAnnouncement(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a := s.announcement()
+		if a == nil {
+			s.jsonError(w, "No announcement", http.StatusNotFound)
+			return
+		}
+		s.jsonResponse(w, a)
+
+	case http.MethodPut:
+		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			if s.configAnnouncement() != nil {
+				s.jsonError(w, "Announcement is defined in the config file and cannot be modified", http.StatusConflict)
+				return
+			}
+			var req AnnouncementRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Text == "" {
+				s.jsonError(w, "Invalid request body - expected {\"text\": \"...\"}", http.StatusBadRequest)
+				return
+			}
+			if req.Level == "" {
+				req.Level = "info"
+			}
+			if req.Level != "info" && req.Level != "warning" {
+				s.jsonError(w, "level must be info or warning", http.StatusBadRequest)
+				return
+			}
+			if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+				s.jsonError(w, "ends_at must be after starts_at", http.StatusBadRequest)
+				return
+			}
+
+			if _, err := s.storage.SaveAnnouncement(storage.Announcement{
+				Text:     req.Text,
+				Level:    req.Level,
+				StartsAt: req.StartsAt,
+				EndsAt:   req.EndsAt,
+			}); err != nil {
+				s.jsonError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			a := s.announcement()
+			s.broadcastAnnouncement()
+			s.jsonResponse(w, a)
+		})(w, r)
+
+	case http.MethodDelete:
+		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			if s.configAnnouncement() != nil {
+				s.jsonError(w, "Announcement is defined in the config file and cannot be modified", http.StatusConflict)
+				return
+			}
+			if err := s.storage.DeleteAnnouncement(); err != nil {
+				s.jsonError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			s.broadcastAnnouncement()
+			s.jsonResponse(w, map[string]string{"message": "Announcement removed"})
+		})(w, r)
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// broadcastAnnouncement sends the announcement showing now, or null, to
+// WebSocket clients
+func (s *Server) broadcastAnnouncement() {
+	s.respCache.invalidate()
+	s.broadcast(map[string]interface{}{
+		"type":         EventAnnouncement,
+		"announcement": s.activeAnnouncement(),
+	})
+}
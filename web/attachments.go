@@ -0,0 +1,112 @@
+package web
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// attachmentProbeTimeout bounds the HEAD request that discovers an
+// attachment's type and size
+const attachmentProbeTimeout = 5 * time.Second
+
+// handleIncidentAttachments attaches documents such as postmortems and SLA
+// reports to an incident (POST) or removes them (DELETE ?url=). Feeds list
+// them as JSON Feed attachments, RSS enclosures and Atom enclosure links.
+func (s *Server) handleIncidentAttachments(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodPost:
+		var att storage.Attachment
+		if err := json.NewDecoder(r.Body).Decode(&att); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		u, err := url.Parse(att.URL)
+		if err != nil || att.URL == "" {
+			s.jsonError(w, "url is required", http.StatusBadRequest)
+			return
+		}
+		if !u.IsAbs() {
+			// Site-relative, e.g. /api/reports/sla?period=2026-09&format=html
+			u, err = url.Parse(s.cfg().BaseURL + "/" + strings.TrimPrefix(att.URL, "/"))
+		}
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			s.jsonError(w, "url must be an http(s) URL or a path on this site", http.StatusBadRequest)
+			return
+		}
+		att.URL = u.String()
+		if att.Title == "" {
+			att.Title = path.Base(u.Path)
+		}
+		if att.MimeType == "" || att.Size == 0 {
+			s.probeAttachment(&att)
+		}
+
+		incident, err := s.storage.AddIncidentAttachment(id, att)
+		if err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if incident == nil {
+			s.jsonError(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		s.jsonResponse(w, incident)
+
+	case http.MethodDelete:
+		target := r.URL.Query().Get("url")
+		if target == "" {
+			s.jsonError(w, "url query parameter required", http.StatusBadRequest)
+			return
+		}
+		incident, err := s.storage.RemoveIncidentAttachment(id, target)
+		if err != nil {
+			s.jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if incident == nil {
+			s.jsonError(w, "Incident not found", http.StatusNotFound)
+			return
+		}
+		s.jsonResponse(w, incident)
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// probeAttachment fills in a missing type and size from a HEAD request to
+// the attachment, falling back to the type implied by its extension
+func (s *Server) probeAttachment(att *storage.Attachment) {
+	client := &http.Client{Timeout: attachmentProbeTimeout}
+	if resp, err := client.Head(att.URL); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			if att.MimeType == "" {
+				if mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+					att.MimeType = mt
+				}
+			}
+			if att.Size == 0 {
+				att.Size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+			}
+		}
+	}
+
+	if att.MimeType == "" {
+		if u, err := url.Parse(att.URL); err == nil {
+			att.MimeType, _, _ = mime.ParseMediaType(mime.TypeByExtension(path.Ext(u.Path)))
+		}
+	}
+	if att.MimeType == "" {
+		att.MimeType = "application/octet-stream"
+	}
+}
@@ -0,0 +1,239 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"text/template"
+
+	"github.com/status/config"
+	"github.com/status/monitor"
+	"github.com/status/storage"
+)
+
+// IncidentSourceAuto marks incidents opened from failing health checks
+const IncidentSourceAuto = "auto"
+
+// autoIncidentData is the template context for auto-incident titles and messages
+type autoIncidentData struct {
+	Name           string
+	Group          string
+	Description    string
+	URL            string
+	Status         string
+	StatusCode     int
+	ResponseTimeMs int64
+	Error          string
+}
+
+// autoIncidentState tracks consecutive failures and the last status
+// reported on a service's open incident
+type autoIncidentState struct {
+	failures int
+	reported monitor.Status
+}
+
+// autoIncidentTemplates are the parsed title and message templates of one
+// configuration
+type autoIncidentTemplates struct {
+	cfg     *config.Config
+	title   *template.Template
+	message *template.Template
+	err     error
+}
+
+// parseAutoIncidentTemplates parses the templates of cfg, logging if they
+// are invalid
+func parseAutoIncidentTemplates(cfg *config.Config) *autoIncidentTemplates {
+	t := &autoIncidentTemplates{cfg: cfg}
+	t.title, t.err = template.New("title").Parse(cfg.AutoIncidents.Title)
+	if t.err != nil {
+		slog.Error("Auto incidents disabled: invalid title template", "error", t.err)
+		return t
+	}
+	t.message, t.err = template.New("message").Parse(cfg.AutoIncidents.Message)
+	if t.err != nil {
+		slog.Error("Auto incidents disabled: invalid message template", "error", t.err)
+	}
+	return t
+}
+
+// runAutoIncidents opens an incident once a service has failed Threshold
+// consecutive checks, appends an update whenever its status changes, and
+// resolves it (or moves it to monitoring for acknowledgment) on recovery.
+// The settings are read for each check result, so a reload that enables,
+// disables or changes them applies to the next one.
+func (s *Server) runAutoIncidents() {
+	var templates *autoIncidentTemplates
+	states := make(map[string]*autoIncidentState)
+
+	ch := s.monitor.Subscribe()
+	defer s.monitor.Unsubscribe(ch)
+
+	for status := range ch {
+		current := s.cfg()
+		cfg := current.AutoIncidents
+		if !cfg.Enabled {
+			// Count afresh if they are enabled again
+			clear(states)
+			continue
+		}
+		if templates == nil || templates.cfg != current {
+			templates = parseAutoIncidentTemplates(current)
+		}
+		if templates.err != nil {
+			continue
+		}
+
+		threshold := cfg.Threshold
+		if threshold < 1 {
+			threshold = 1
+		}
+
+		if status.Status == monitor.StatusUnknown {
+			continue
+		}
+
+		state, ok := states[status.Name]
+		if !ok {
+			state = &autoIncidentState{}
+			states[status.Name] = state
+		}
+
+		open := s.openAutoIncident(status.Name)
+
+		if status.Status == monitor.StatusOperational {
+			state.failures = 0
+			state.reported = monitor.StatusOperational
+			if open != nil && open.Status != "monitoring" {
+				s.recoverAutoIncident(*open, status.Name)
+			}
+			continue
+		}
+
+		state.failures++
+
+		if open != nil {
+			if state.reported == "" {
+				// First result since startup; adopt the existing incident
+				state.reported = status.Status
+				continue
+			}
+			if status.Status != state.reported {
+				state.reported = status.Status
+				s.updateAutoIncident(*open, "investigating", fmt.Sprintf("%s is now %s.", status.Name, status.Status))
+			}
+			continue
+		}
+
+		if state.failures < threshold || s.inMaintenance(status.Name) {
+			continue
+		}
+
+		data := newAutoIncidentData(status)
+		incident := storage.Incident{
+			Title:            renderAutoIncident(templates.title, data),
+			Status:           "investigating",
+			Severity:         cfg.DownSeverity,
+			Message:          renderAutoIncident(templates.message, data),
+			AffectedServices: []string{status.Name},
+			Source:           IncidentSourceAuto,
+		}
+		if status.Status == monitor.StatusDegraded {
+			incident.Severity = cfg.DegradedSeverity
+		}
+
+		created, err := s.storage.CreateIncident(incident)
+		if err != nil {
+			slog.Error("Failed to open incident", "service", status.Name, "error", err)
+			continue
+		}
+		state.reported = status.Status
+		s.respCache.invalidate()
+
+		slog.Info("Opened incident", "incident", created.ID, "service", status.Name, "status", status.Status)
+		if s.notifier != nil {
+			s.notifier.NotifyIncidentCreated(*created, current.BaseURL)
+		}
+	}
+}
+
+// recoverAutoIncident resolves the incident, or moves it to monitoring when
+// auto-resolve is off so an operator can acknowledge the recovery
+func (s *Server) recoverAutoIncident(incident storage.Incident, name string) {
+	if s.cfg().AutoIncidents.AutoResolve {
+		s.updateAutoIncident(incident, "resolved", fmt.Sprintf("%s has recovered.", name))
+		return
+	}
+	s.updateAutoIncident(incident, "monitoring", fmt.Sprintf("%s has recovered. Awaiting acknowledgment.", name))
+}
+
+// updateAutoIncident appends an update to an automatic incident and notifies
+func (s *Server) updateAutoIncident(incident storage.Incident, status, message string) {
+	updated, err := s.storage.UpdateIncident(incident.ID, status, message)
+	if err != nil || updated == nil {
+		slog.Error("Failed to update incident", "incident", incident.ID, "error", err)
+		return
+	}
+	s.respCache.invalidate()
+
+	if s.notifier == nil {
+		return
+	}
+	if status == "resolved" {
+		s.notifier.NotifyIncidentResolved(*updated, s.cfg().BaseURL)
+	} else {
+		s.notifier.NotifyIncidentUpdated(*updated, s.cfg().BaseURL)
+	}
+}
+
+// openAutoIncident returns the unresolved automatic incident for a service
+func (s *Server) openAutoIncident(name string) *storage.Incident {
+	for _, inc := range s.storage.GetIncidents(0, true) {
+		if inc.Source != IncidentSourceAuto {
+			continue
+		}
+		for _, affected := range inc.AffectedServices {
+			if affected == name {
+				return &inc
+			}
+		}
+	}
+	return nil
+}
+
+// inMaintenance reports whether a service is covered by an active maintenance window
+func (s *Server) inMaintenance(name string) bool {
+	for _, m := range s.activeMaintenance(nil) {
+		for _, affected := range m.AffectedServices {
+			if affected == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newAutoIncidentData(status *monitor.ServiceStatus) autoIncidentData {
+	return autoIncidentData{
+		Name:           status.Name,
+		Group:          status.Group,
+		Description:    status.Description,
+		URL:            status.URL,
+		Status:         string(status.Status),
+		StatusCode:     status.StatusCode,
+		ResponseTimeMs: status.ResponseTimeMs,
+		Error:          status.ErrorMessage,
+	}
+}
+
+// renderAutoIncident executes a title or message template, falling back to
+// a plain summary if execution fails
+func renderAutoIncident(tmpl *template.Template, data autoIncidentData) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		slog.Error("Failed to render auto-incident", "template", tmpl.Name(), "error", err)
+		return fmt.Sprintf("%s is %s", data.Name, data.Status)
+	}
+	return buf.String()
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// responseCacheTTL bounds how stale a cached public response can be
+// when no status change has invalidated it.
+const responseCacheTTL = 5 * time.Second
+
+// responseCache holds encoded API responses for hot public endpoints
+type responseCache struct {
+	mu       sync.Mutex
+	entries  map[string]*cachedResponse
+	building map[string]*cacheBuild // Responses being built, by key
+	gen      uint64                 // Bumped by invalidate
+
+	onInvalidate func() // Called after invalidate, e.g. to announce feed changes
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+// cacheBuild is a response being built, which requests for the same key
+// wait for instead of building it again
+type cacheBuild struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// errBuildPanicked is what requests waiting on a build get if it panicked
+var errBuildPanicked = errors.New("building the response panicked")
+
+func newResponseCache() *responseCache {
+	return &responseCache{
+		entries:  make(map[string]*cachedResponse),
+		building: make(map[string]*cacheBuild),
+	}
+}
+
+// load returns the cached response for key, or builds it with build. Only
+// one build runs per key at a time; requests arriving meanwhile share its
+// result. A response built across an invalidate is returned but not cached,
+// since it may predate the change, and requests after the invalidate start
+// a build of their own.
+func (c *responseCache) load(key string, build func() ([]byte, error)) (body []byte, hit bool, err error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.body, true, nil
+	}
+	if b, ok := c.building[key]; ok {
+		c.mu.Unlock()
+		<-b.done
+		return b.body, false, b.err
+	}
+	b := &cacheBuild{done: make(chan struct{})}
+	c.building[key] = b
+	gen := c.gen
+	c.mu.Unlock()
+
+	b.err = errBuildPanicked // Until build returns
+	defer func() {
+		c.mu.Lock()
+		if c.building[key] == b {
+			delete(c.building, key)
+		}
+		if b.err == nil && c.gen == gen {
+			c.entries[key] = &cachedResponse{body: b.body, expires: time.Now().Add(responseCacheTTL)}
+		}
+		c.mu.Unlock()
+		close(b.done)
+	}()
+	b.body, b.err = build()
+	return b.body, false, b.err
+}
+
+// invalidate drops every cached response
+func (c *responseCache) invalidate() {
+	c.mu.Lock()
+	c.entries = make(map[string]*cachedResponse)
+	c.building = make(map[string]*cacheBuild)
+	c.gen++
+	c.mu.Unlock()
+
+	if c.onInvalidate != nil {
+		c.onInvalidate()
+	}
+}
+
+// cachedJSON serves a successful API response from the cache, building and
+// encoding it with build on a miss.
+func (s *Server) cachedJSON(w http.ResponseWriter, key string, withMeta bool, build func() interface{}) {
+	body, hit, err := s.respCache.load(key, func() ([]byte, error) {
+		resp := APIResponse{
+			Success: true,
+			Data:    build(),
+		}
+		if withMeta {
+			resp.Meta = &APIMeta{GeneratedAt: time.Now().Format(time.RFC3339)}
+		}
+
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(resp); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		s.jsonError(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if hit {
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Cache", "MISS")
+	}
+	w.Write(body)
+}
@@ -0,0 +1,122 @@
+package web
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// processStart is when the process started, for the uptime in /debug/counts
+var processStart = time.Now()
+
+// profileSlack is added to a profile's duration when extending the write
+// deadline for /debug/pprof/profile and /debug/pprof/trace
+const profileSlack = 10 * time.Second
+
+// registerDebug adds pprof, expvar and /debug/counts, for diagnosing memory
+// and goroutine growth in long-running instances. They need admin scope and
+// are not served at all while the API has no credentials configured.
+func (s *Server) registerDebug(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", s.debugEndpoint(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", s.debugEndpoint(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", s.debugEndpoint(longProfile(pprof.Profile)))
+	mux.HandleFunc("/debug/pprof/symbol", s.debugEndpoint(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", s.debugEndpoint(longProfile(pprof.Trace)))
+	mux.HandleFunc("/debug/vars", s.debugEndpoint(expvar.Handler().ServeHTTP))
+	mux.HandleFunc("/debug/counts", s.debugEndpoint(s.handleDebugCounts))
+}
+
+// debugEndpoint serves h to admin-scoped requests, and answers 404 while no
+// API credentials are configured, since requireScope would let everyone in
+func (s *Server) debugEndpoint(h http.HandlerFunc) http.HandlerFunc {
+	authed := s.requireScope(ScopeAdmin, h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.hasAuth() {
+			http.NotFound(w, r)
+			return
+		}
+		authed(w, r)
+	}
+}
+
+// longProfile lets CPU profiles and traces run longer than the server's
+// write timeout: pprof refuses a ?seconds= beyond it, so the deadline is
+// pushed out for this response and the timeout hidden from pprof.
+func longProfile(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seconds, err := strconv.Atoi(r.URL.Query().Get("seconds"))
+		if err != nil || seconds <= 0 {
+			seconds = 30 // pprof's default
+		}
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(time.Duration(seconds)*time.Second + profileSlack)); err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), http.ServerContextKey, &http.Server{}))
+		}
+		h(w, r)
+	}
+}
+
+// debugCounts is the /debug/counts response
+type debugCounts struct {
+	Uptime              string         `json:"uptime"`
+	Goroutines          int            `json:"goroutines"`
+	HeapAllocBytes      uint64         `json:"heap_alloc_bytes"`
+	HeapObjects         uint64         `json:"heap_objects"`
+	SysBytes            uint64         `json:"sys_bytes"`
+	GCCycles            uint32         `json:"gc_cycles"`
+	WebSocketClients    int            `json:"websocket_clients"`
+	WebSocketPages      map[string]int `json:"websocket_clients_by_page,omitempty"`
+	Subscribers         int            `json:"subscribers"`
+	VerifiedSubscribers int            `json:"verified_subscribers"`
+	PushSubscriptions   int            `json:"push_subscriptions"`
+	WebSubSubscriptions int            `json:"websub_subscriptions"`
+	FeedCacheEntries    int            `json:"feed_cache_entries"`
+	MonitoredServices   int            `json:"monitored_services"`
+}
+
+// handleDebugCounts reports goroutines, memory and the sizes of the
+// server's long-lived collections, the usual suspects when memory grows
+func (s *Server) handleDebugCounts(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	counts := debugCounts{
+		Uptime:            time.Since(processStart).Round(time.Second).String(),
+		Goroutines:        runtime.NumGoroutine(),
+		HeapAllocBytes:    mem.HeapAlloc,
+		HeapObjects:       mem.HeapObjects,
+		SysBytes:          mem.Sys,
+		GCCycles:          mem.NumGC,
+		PushSubscriptions: len(s.storage.GetPushSubscriptions()),
+		MonitoredServices: len(s.monitor.GetAllStatuses()),
+	}
+
+	s.clientMu.RLock()
+	counts.WebSocketClients = len(s.clients)
+	for client := range s.clients {
+		page := client.page
+		if counts.WebSocketPages == nil {
+			counts.WebSocketPages = make(map[string]int)
+		}
+		if page == "" {
+			page = "/"
+		}
+		counts.WebSocketPages[page]++
+	}
+	s.clientMu.RUnlock()
+
+	for _, sub := range s.storage.GetSubscribers(false) {
+		counts.Subscribers++
+		if sub.Verified {
+			counts.VerifiedSubscribers++
+		}
+	}
+	counts.WebSubSubscriptions = len(s.storage.GetWebSubSubscriptions())
+
+	counts.FeedCacheEntries = s.feedGenerator().CachedFeeds()
+
+	s.jsonResponse(w, counts)
+}
@@ -0,0 +1,200 @@
+package web
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// === Declarative API ===
+//
+// PUT with a full object at an ID chosen by the client creates the object
+// or brings it in line with the body, so tools such as Terraform and
+// Ansible can apply the same declaration repeatedly without duplicates.
+
+// maxDeclaredIDLength bounds IDs chosen by clients
+const maxDeclaredIDLength = 128
+
+// validDeclaredID reports whether a client-chosen ID is usable: letters,
+// digits, '-', '_' and '.', as IDs appear in page URLs and file names
+func validDeclaredID(id string) bool {
+	if id == "" || len(id) > maxDeclaredIDLength || strings.Trim(id, ".") == "" {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// IncidentRequest is the body accepted by PUT /api/incidents/{id}. With a
+// title it declares the whole incident; without one, only status and
+// message are used, as by PATCH.
+type IncidentRequest struct {
+	Title            string   `json:"title"`
+	Status           string   `json:"status"`   // Default investigating
+	Severity         string   `json:"severity"` // Default minor
+	Message          string   `json:"message"`
+	AffectedServices []string `json:"affected_services"`
+}
+
+// putIncident creates the incident with the given ID as declared, or
+// brings the stored one in line with it. Repeating a request changes
+// nothing and sends no notifications.
+func (s *Server) putIncident(w http.ResponseWriter, id string, req IncidentRequest) {
+	if req.Status == "" {
+		req.Status = "investigating"
+	}
+	if req.Severity == "" {
+		req.Severity = "minor"
+	}
+	if req.AffectedServices == nil {
+		req.AffectedServices = []string{}
+	}
+	switch {
+	case !slices.Contains([]string{"investigating", "identified", "monitoring", "resolved"}, req.Status):
+		s.jsonError(w, "status must be investigating, identified, monitoring or resolved", http.StatusBadRequest)
+		return
+	case !slices.Contains([]string{"minor", "major", "critical"}, req.Severity):
+		s.jsonError(w, "severity must be minor, major or critical", http.StatusBadRequest)
+		return
+	}
+
+	inc := storage.Incident{
+		ID:               id,
+		Title:            req.Title,
+		Status:           req.Status,
+		Severity:         req.Severity,
+		Message:          req.Message,
+		AffectedServices: req.AffectedServices,
+	}
+
+	if !validDeclaredID(id) && s.storage.GetIncident(id) == nil {
+		s.jsonError(w, "Incident ID may only contain letters, digits, '-', '_' and '.'", http.StatusBadRequest)
+		return
+	}
+	if inc.Status == "resolved" {
+		now := time.Now()
+		inc.ResolvedAt = &now
+	}
+	// Concurrent PUTs to a new ID create it once; the others revise it
+	existing, created, err := s.storage.CreateIncidentIfAbsent(inc)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if created {
+		if s.notifier != nil {
+			s.notifier.NotifyIncidentCreated(*existing, s.cfg().BaseURL)
+		}
+		w.WriteHeader(http.StatusCreated)
+		s.jsonResponse(w, existing)
+		return
+	}
+	if existing.Source == "config" {
+		s.jsonError(w, "Incident is declared in the config file and cannot be replaced", http.StatusConflict)
+		return
+	}
+
+	updated, changed, err := s.storage.ReviseIncident(inc)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if updated == nil {
+		s.jsonError(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+	if changed && s.notifier != nil {
+		if updated.Status == "resolved" && existing.Status != "resolved" {
+			s.notifier.NotifyIncidentResolved(*updated, s.cfg().BaseURL)
+		} else {
+			s.notifier.NotifyIncidentUpdated(*updated, s.cfg().BaseURL)
+		}
+	}
+	s.jsonResponse(w, updated)
+}
+
+// putMaintenance creates the maintenance window with the given ID as
+// declared, or brings the stored one in line with it. The scheduler
+// advances the status as usual unless the body sets one.
+func (s *Server) putMaintenance(w http.ResponseWriter, id string, m storage.Maintenance) {
+	switch {
+	case m.ScheduledStart.IsZero() || !m.ScheduledEnd.After(m.ScheduledStart):
+		s.jsonError(w, "scheduled_start and a later scheduled_end are required", http.StatusBadRequest)
+		return
+	case m.Status != "" && !slices.Contains([]string{"scheduled", "in_progress", "completed"}, m.Status):
+		s.jsonError(w, "status must be scheduled, in_progress or completed", http.StatusBadRequest)
+		return
+	}
+
+	current := s.findMaintenance(id)
+	if current == nil && !validDeclaredID(id) {
+		s.jsonError(w, "Maintenance ID may only contain letters, digits, '-', '_' and '.'", http.StatusBadRequest)
+		return
+	}
+	if current != nil && current.Source == "config" {
+		s.jsonError(w, "Maintenance is declared in the config file and cannot be replaced", http.StatusConflict)
+		return
+	}
+
+	status := m.Status
+	m.ID = id
+	m.Source = ""
+	if m.AffectedServices == nil {
+		m.AffectedServices = []string{}
+	}
+	// The window replaced is read as it is written, so concurrent PUTs to
+	// a new ID announce it once
+	existing, _, err := s.storage.ReplaceMaintenance(m)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	stored := s.findMaintenance(id)
+	if stored == nil {
+		s.jsonError(w, "Maintenance not found", http.StatusNotFound)
+		return
+	}
+	if status != "" && status != stored.Status {
+		updated, err := s.storage.UpdateMaintenance(id, status)
+		if err != nil || updated == nil {
+			s.jsonError(w, "Failed to set maintenance status", http.StatusInternalServerError)
+			return
+		}
+		stored = updated
+	}
+
+	// A new window is stored as scheduled before any status in the body
+	previous := "scheduled"
+	if existing != nil {
+		previous = existing.Status
+	} else if s.notifier != nil {
+		s.notifier.NotifyMaintenanceScheduled(*stored, s.cfg().BaseURL)
+	}
+	s.broadcastMaintenance(previous, *stored)
+	s.notifyMaintenance(previous, *stored)
+
+	if existing == nil {
+		w.WriteHeader(http.StatusCreated)
+	}
+	s.jsonResponse(w, stored)
+}
+
+// findMaintenance returns the stored maintenance window with the given ID
+func (s *Server) findMaintenance(id string) *storage.Maintenance {
+	for _, m := range s.storage.GetMaintenance(false) {
+		if m.ID == id {
+			return &m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/status/config"
+)
+
+// endpointSwitch picks one of the endpoints settings
+type endpointSwitch func(config.EndpointsConfig) bool
+
+func publicAPI(e config.EndpointsConfig) bool     { return e.PublicAPI }
+func feedEndpoints(e config.EndpointsConfig) bool { return e.Feeds }
+func webSocket(e config.EndpointsConfig) bool     { return e.WebSocket }
+func subscriptions(e config.EndpointsConfig) bool { return e.Subscriptions }
+func metrics(e config.EndpointsConfig) bool       { return e.Metrics }
+
+// endpoint serves h while its switch is on in the current configuration,
+// so reloads take effect without a restart, and answers 404 otherwise
+func (s *Server) endpoint(on endpointSwitch, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !on(s.cfg().Endpoints) {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// endpointRead is endpoint for routes that also take authenticated writes:
+// only GET and HEAD requests are switched off
+func (s *Server) endpointRead(on endpointSwitch, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && !on(s.cfg().Endpoints) {
+			http.NotFound(w, r)
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,108 @@
+package web
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// escalationCheckInterval is how often open incidents are checked for due
+// escalation steps
+const escalationCheckInterval = 30 * time.Second
+
+// runEscalations notifies each escalation step's webhooks once an incident
+// has stayed unacknowledged for the step's delay.
+func (s *Server) runEscalations() {
+	s.escalate(time.Now())
+
+	ticker := time.NewTicker(escalationCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.escalate(now)
+	}
+}
+
+// escalate sends any escalation steps due at now
+func (s *Server) escalate(now time.Time) {
+	for _, inc := range s.storage.GetIncidents(0, true) {
+		if inc.AcknowledgedAt != nil {
+			continue
+		}
+		policy := s.escalationPolicy(inc)
+		if policy == nil {
+			continue
+		}
+
+		level := inc.EscalationLevel
+		for level < len(policy.Steps) && now.Sub(inc.CreatedAt) >= policy.Steps[level].After {
+			slog.Info("Escalating incident", "incident", inc.ID, "policy", policy.Name, "step", level+1)
+			if s.notifier != nil {
+				s.notifier.NotifyIncidentEscalated(inc, policy.Steps[level].Webhooks, s.cfg().BaseURL)
+			}
+			level++
+		}
+
+		if level != inc.EscalationLevel {
+			if _, err := s.storage.SetIncidentEscalation(inc.ID, level); err != nil {
+				slog.Error("Failed to record escalation", "incident", inc.ID, "error", err)
+			}
+		}
+	}
+}
+
+// escalationPolicy returns the first policy covering the incident
+func (s *Server) escalationPolicy(inc storage.Incident) *config.EscalationConfig {
+	for i, policy := range s.cfg().Notifications.Escalations {
+		if len(policy.Severities) > 0 && !containsAny(policy.Severities, []string{inc.Severity}) {
+			continue
+		}
+		if len(policy.Services) > 0 && !containsAny(policy.Services, inc.AffectedServices) {
+			continue
+		}
+		return &s.cfg().Notifications.Escalations[i]
+	}
+	return nil
+}
+
+// handleIncidentAck acknowledges an incident, stopping its escalation
+func (s *Server) handleIncidentAck(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		By string `json:"by"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.By == "" {
+		if username, _, ok := r.BasicAuth(); ok {
+			req.By = username
+		} else {
+			req.By = "api"
+		}
+	}
+
+	incident, err := s.storage.AcknowledgeIncident(id, req.By)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if incident == nil {
+		s.jsonError(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+
+	requestLogger(r).Info("Incident acknowledged", "incident", id, "by", incident.AcknowledgedBy)
+	s.jsonResponse(w, incident)
+}
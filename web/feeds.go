@@ -0,0 +1,269 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/feeds"
+	"github.com/status/monitor"
+	"github.com/status/storage"
+)
+
+// Feed limits
+const (
+	feedDefaultLimit = 50  // Items per page when feeds.limit is not set
+	feedMaxLimit     = 500 // Most items per page, whatever ?limit= asks for
+)
+
+// feedScope limits a feed to the incidents of one service or group
+type feedScope struct {
+	service string
+	group   string
+	path    string // Appended to /feed/<format> in the feed's own links
+}
+
+// parseFeedScope reads the scope from /feed/<format>/<service> or the
+// service and group query parameters. ok is false, after writing a 404,
+// when the service or group does not exist.
+func (s *Server) parseFeedScope(w http.ResponseWriter, r *http.Request, format string) (scope feedScope, ok bool) {
+	q := r.URL.Query()
+	if name := strings.TrimPrefix(r.URL.Path, "/feed/"+format+"/"); name != r.URL.Path && name != "" {
+		scope = feedScope{service: name, path: "/" + url.PathEscape(name)}
+	} else if name := q.Get("service"); name != "" {
+		scope = feedScope{service: name, path: "?service=" + url.QueryEscape(name)}
+	} else if name := q.Get("group"); name != "" {
+		scope = feedScope{group: name, path: "?group=" + url.QueryEscape(name)}
+	}
+
+	switch {
+	case scope.service != "" && s.monitor.GetStatus(scope.service) == nil:
+		http.Error(w, "Service not found", http.StatusNotFound)
+		return scope, false
+	case scope.group != "" && len(s.scopeStatuses(scope)) == 0:
+		http.Error(w, "Group not found", http.StatusNotFound)
+		return scope, false
+	}
+	return scope, true
+}
+
+// parseFeedFilter reads the severity (comma-separated) and active query
+// parameters, returning the filter and its canonical query string. ok is
+// false, after writing a 400, for an unknown severity.
+func parseFeedFilter(w http.ResponseWriter, r *http.Request) (filter feeds.IncidentFilter, query string, ok bool) {
+	q := r.URL.Query()
+	var params []string
+
+	if v := q.Get("severity"); v != "" {
+		for _, sev := range strings.Split(v, ",") {
+			sev = strings.ToLower(strings.TrimSpace(sev))
+			switch sev {
+			case "":
+				continue
+			case "minor", "major", "critical":
+				filter.Severities = append(filter.Severities, sev)
+			default:
+				http.Error(w, "Unknown severity: "+sev, http.StatusBadRequest)
+				return filter, "", false
+			}
+		}
+		if len(filter.Severities) > 0 {
+			params = append(params, "severity="+strings.Join(filter.Severities, ","))
+		}
+	}
+	if v := q.Get("active"); v == "true" || v == "1" {
+		filter.ActiveOnly = true
+		params = append(params, "active=true")
+	}
+	if v := q.Get("since"); v != "" {
+		since, canonical, err := parseSince(v, time.Now())
+		if err != nil {
+			http.Error(w, "Invalid since: "+err.Error(), http.StatusBadRequest)
+			return filter, "", false
+		}
+		filter.Since = since
+		params = append(params, "since="+canonical)
+	}
+	return filter, strings.Join(params, "&"), true
+}
+
+// parseSince reads a since value: an RFC 3339 time, a date (2006-01-02) or
+// an age such as 7d or 12h. It returns the time and the value to use in
+// feed links: ages are kept relative, times are normalized to UTC.
+func parseSince(v string, now time.Time) (since time.Time, canonical string, err error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, -n), v, nil
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil && d > 0 {
+		return now.Add(-d), v, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, t.UTC().Format(time.RFC3339), nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, t.Format("2006-01-02"), nil
+	}
+	return time.Time{}, "", fmt.Errorf("want a time (RFC 3339), a date (YYYY-MM-DD) or an age such as 7d or 12h")
+}
+
+// parseFeedLimit reads the limit query parameter, the items per page,
+// defaulting to the configured limit and capped at feedMaxLimit. The
+// canonical parameter is empty for the default.
+func (s *Server) parseFeedLimit(w http.ResponseWriter, r *http.Request) (limit int, query string, ok bool) {
+	limit = s.cfg().Feeds.Limit
+	if limit <= 0 {
+		limit = feedDefaultLimit
+	}
+	limit = min(limit, feedMaxLimit)
+
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return limit, "", true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return 0, "", false
+	}
+	n = min(n, feedMaxLimit)
+	if n == limit {
+		return limit, "", true
+	}
+	return n, "limit=" + strconv.Itoa(n), true
+}
+
+// parseFeedPage reads the page query parameter, 1 when absent. ok is false,
+// after writing a 400, when it is not a positive number.
+func parseFeedPage(w http.ResponseWriter, r *http.Request) (page int, ok bool) {
+	v := r.URL.Query().Get("page")
+	if v == "" {
+		return 1, true
+	}
+	page, err := strconv.Atoi(v)
+	if err != nil || page < 1 {
+		http.Error(w, "Invalid page", http.StatusBadRequest)
+		return 0, false
+	}
+	return page, true
+}
+
+// name returns the service or group the scope is limited to
+func (sc feedScope) name() string {
+	if sc.service != "" {
+		return sc.service
+	}
+	return sc.group
+}
+
+// key identifies the scope in the feed cache
+func (sc feedScope) key() string {
+	return sc.service + "|" + sc.group
+}
+
+// scopeStatuses returns the statuses of the services in scope
+func (s *Server) scopeStatuses(scope feedScope) []*monitor.ServiceStatus {
+	statuses := s.monitor.GetAllStatuses()
+	if scope.name() == "" {
+		return statuses
+	}
+
+	var matched []*monitor.ServiceStatus
+	for _, st := range statuses {
+		if st.Name == scope.service || (scope.group != "" && st.Group == scope.group) {
+			matched = append(matched, st)
+		}
+	}
+	return matched
+}
+
+// scopeIncidents returns the incidents affecting a service in scope
+func (s *Server) scopeIncidents(scope feedScope) []storage.Incident {
+	if scope.name() == "" {
+		return s.storage.GetIncidents(0, false)
+	}
+
+	services := make(map[string]bool)
+	for _, st := range s.scopeStatuses(scope) {
+		services[st.Name] = true
+	}
+
+	var matched []storage.Incident
+	for _, inc := range s.storage.GetIncidents(0, false) {
+		for _, name := range inc.AffectedServices {
+			if services[name] {
+				matched = append(matched, inc)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// scopeMaintenance returns up to limit maintenance windows in scope that
+// pass filter. Windows without affected services are site-wide
+// and in every scope; severity filters leave maintenance out.
+func (s *Server) scopeMaintenance(scope feedScope, filter feeds.IncidentFilter, limit int) []storage.Maintenance {
+	if len(filter.Severities) > 0 {
+		return nil
+	}
+
+	services := make(map[string]bool)
+	for _, st := range s.scopeStatuses(scope) {
+		services[st.Name] = true
+	}
+
+	var matched []storage.Maintenance
+	for _, m := range s.storage.GetMaintenance(false) {
+		if (filter.ActiveOnly && m.Status == "completed") || m.UpdatedAt.Before(filter.Since) {
+			continue
+		}
+		inScope := scope.name() == "" || len(m.AffectedServices) == 0
+		for _, name := range m.AffectedServices {
+			inScope = inScope || services[name]
+		}
+		if !inScope {
+			continue
+		}
+		matched = append(matched, m)
+		if len(matched) == limit {
+			break
+		}
+	}
+	return matched
+}
+
+// handleIncidentFeed serves /feed/incidents/<id>.atom, an Atom feed with an
+// entry per update of one incident
+func (s *Server) handleIncidentFeed(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/feed/incidents/"), ".atom")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	inc := s.storage.GetIncident(id)
+	if inc == nil {
+		http.Error(w, "Incident not found", http.StatusNotFound)
+		return
+	}
+
+	// An external hub is only pinged for the site-wide feeds
+	fg := s.feedGenerator()
+	if hub := s.feedHub(true); hub != "" {
+		fg = fg.WithHub(hub)
+	}
+
+	key := fmt.Sprintf("%d|%d", inc.UpdatedAt.UnixNano(), len(inc.Updates))
+	feed, err := fg.Cached("incident/"+id, key, inc.UpdatedAt, func() ([]byte, error) {
+		return fg.GenerateIncidentAtom(*inc)
+	})
+	if err != nil {
+		http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+		return
+	}
+	s.writeFeed(w, r, feed, "application/atom+xml; charset=utf-8")
+}
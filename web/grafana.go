@@ -0,0 +1,439 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/status/monitor"
+	"github.com/status/storage"
+)
+
+// === Grafana Data Source ===
+//
+// /api/grafana/ speaks the protocol of Grafana's JSON data source plugin,
+// so dashboards can chart the response times and uptime the page shows and
+// mark incidents and maintenance as annotations. /api/grafana/series
+// returns the same series as flat rows for the Infinity plugin.
+
+// grafanaPath is where the data source is served, after the base path and
+// any page path
+const grafanaPath = "/api/grafana/"
+
+// Metrics offered to Grafana
+const (
+	grafanaResponseTime = "response_time"
+	grafanaUptime       = "uptime"
+	grafanaStatus       = "status"
+)
+
+// grafanaMetric describes a metric for the query editor's metric picker
+type grafanaMetric struct {
+	Label    string           `json:"label"`
+	Value    string           `json:"value"`
+	Payloads []grafanaPayload `json:"payloads"`
+}
+
+// grafanaPayload is an option shown in the query editor for a metric
+type grafanaPayload struct {
+	Label       string          `json:"label"`
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Placeholder string          `json:"placeholder,omitempty"`
+	Options     []grafanaOption `json:"options"`
+}
+
+type grafanaOption struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// grafanaRange is the dashboard's time range
+type grafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// grafanaQueryRequest is the body of POST /api/grafana/query
+type grafanaQueryRequest struct {
+	Range      grafanaRange `json:"range"`
+	IntervalMs int64        `json:"intervalMs"`
+	Targets    []struct {
+		Target  string          `json:"target"`
+		Type    string          `json:"type"` // timeserie or table; status is always a table
+		Hide    bool            `json:"hide"`
+		Payload json.RawMessage `json:"payload"` // {"service": "..."}; all services when omitted
+	} `json:"targets"`
+}
+
+// grafanaSeries is a time series: values with their times in milliseconds
+type grafanaSeries struct {
+	Target     string           `json:"target"`
+	Datapoints [][2]interface{} `json:"datapoints"`
+}
+
+type grafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []grafanaColumn `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+type grafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// grafanaAnnotation marks an incident or maintenance window on a chart
+type grafanaAnnotation struct {
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"timeEnd,omitempty"`
+	Title   string   `json:"title"`
+	Text    string   `json:"text"`
+	Tags    []string `json:"tags"`
+}
+
+// grafanaRow is a point of GET /api/grafana/series
+type grafanaRow struct {
+	Time    time.Time `json:"time"`
+	Service string    `json:"service"`
+	Value   *float64  `json:"value"`
+}
+
+// readRequest reports whether r only reads data: GET, HEAD and OPTIONS
+// requests, and the Grafana data source's queries, which Grafana POSTs
+func readRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodPost:
+		return strings.Contains(r.URL.Path, grafanaPath)
+	}
+	return false
+}
+
+func (s *Server) handleGrafana(w http.ResponseWriter, r *http.Request) {
+	action := strings.TrimPrefix(r.URL.Path, grafanaPath)
+	switch action {
+	case "":
+		// Grafana's "Save & test"
+		s.jsonResponse(w, map[string]string{"status": "ok"})
+		return
+	case "series":
+		s.handleGrafanaSeries(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch action {
+	case "metrics":
+		services := []grafanaOption{}
+		for _, st := range s.pageStatuses(s.pageFor(r)) {
+			services = append(services, grafanaOption{Label: st.Name, Value: st.Name})
+		}
+		payloads := []grafanaPayload{{Label: "Service", Name: "service", Type: "select", Placeholder: "All services", Options: services}}
+		writeGrafanaJSON(w, []grafanaMetric{
+			{Label: "Response time (ms)", Value: grafanaResponseTime, Payloads: payloads},
+			{Label: "Uptime (%)", Value: grafanaUptime, Payloads: payloads},
+			{Label: "Current status (table)", Value: grafanaStatus, Payloads: []grafanaPayload{}},
+		})
+	case "search":
+		// Metric names for older versions of the plugin
+		writeGrafanaJSON(w, []string{grafanaResponseTime, grafanaUptime, grafanaStatus})
+	case "query":
+		s.grafanaQuery(w, r)
+	case "annotations":
+		s.grafanaAnnotations(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// grafanaQuery answers a panel's queries: a series per service for
+// response_time and uptime, and a table of current statuses for status
+func (s *Server) grafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var req grafanaQueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	from, to := grafanaSpan(req.Range)
+	step := grafanaStep(from, to, time.Duration(req.IntervalMs)*time.Millisecond)
+
+	results := []interface{}{}
+	for _, t := range req.Targets {
+		if t.Hide {
+			continue
+		}
+		var payload struct {
+			Service string `json:"service"`
+		}
+		json.Unmarshal(t.Payload, &payload)
+
+		switch t.Target {
+		case grafanaStatus:
+			results = append(results, s.grafanaStatusTable(r, payload.Service))
+		case grafanaResponseTime, grafanaUptime:
+			names, ok := s.grafanaServices(r, payload.Service)
+			if !ok {
+				s.jsonError(w, "Service not found", http.StatusNotFound)
+				return
+			}
+			for _, name := range names {
+				results = append(results, grafanaSeries{
+					Target:     name,
+					Datapoints: s.grafanaPoints(name, t.Target, from, to, step),
+				})
+			}
+		default:
+			s.jsonError(w, "Unknown metric - use response_time, uptime or status", http.StatusBadRequest)
+			return
+		}
+	}
+	writeGrafanaJSON(w, results)
+}
+
+// handleGrafanaSeries serves a metric as flat rows for the Infinity plugin:
+// ?metric=response_time|uptime&service=&from=&to=&step=
+func (s *Server) handleGrafanaSeries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	metric := query.Get("metric")
+	if metric == "" {
+		metric = grafanaResponseTime
+	}
+	if metric != grafanaResponseTime && metric != grafanaUptime {
+		s.jsonError(w, "Invalid metric - use response_time or uptime", http.StatusBadRequest)
+		return
+	}
+
+	var span grafanaRange
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &span.From}, {"to", &span.To}} {
+		v := query.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTimeParam(v)
+		if err != nil {
+			s.jsonError(w, "Invalid '"+p.name+"' parameter - use RFC 3339 or unix seconds", http.StatusBadRequest)
+			return
+		}
+		*p.t = t
+	}
+	from, to := grafanaSpan(span)
+	var step time.Duration
+	if v := query.Get("step"); v != "" {
+		d, err := parseChartDuration(v)
+		if err != nil || d <= 0 {
+			s.jsonError(w, "Invalid step (e.g. 1m, 5m, 1h)", http.StatusBadRequest)
+			return
+		}
+		step = d
+	}
+	step = grafanaStep(from, to, step)
+
+	names, ok := s.grafanaServices(r, query.Get("service"))
+	if !ok {
+		s.jsonError(w, "Service not found", http.StatusNotFound)
+		return
+	}
+	rows := []grafanaRow{}
+	for _, name := range names {
+		for _, p := range s.grafanaPoints(name, metric, from, to, step) {
+			row := grafanaRow{Time: time.UnixMilli(p[1].(int64)).UTC(), Service: name}
+			if v, ok := p[0].(float64); ok {
+				row.Value = &v
+			}
+			rows = append(rows, row)
+		}
+	}
+	writeGrafanaJSON(w, rows)
+}
+
+// grafanaServices returns the named service, or every service on the
+// request's page when name is empty; ok is false if it is not on the page
+func (s *Server) grafanaServices(r *http.Request, name string) ([]string, bool) {
+	page := s.pageFor(r)
+	if name != "" {
+		return []string{name}, s.monitor.GetStatus(name) != nil && s.onPage(page, name)
+	}
+	var names []string
+	for _, st := range s.pageStatuses(page) {
+		names = append(names, st.Name)
+	}
+	return names, true
+}
+
+// grafanaSpan returns the queried time range, defaulting to the last day
+func grafanaSpan(span grafanaRange) (time.Time, time.Time) {
+	to := span.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := span.From
+	if from.IsZero() || !from.Before(to) {
+		from = to.Add(-24 * time.Hour)
+	}
+	return from, to
+}
+
+// grafanaStep returns the width of the points of a series: the interval
+// Grafana asks for, widened to keep within the limits of /api/charts
+func grafanaStep(from, to time.Time, interval time.Duration) time.Duration {
+	step := interval
+	if step <= 0 {
+		step = 5 * time.Minute
+	}
+	if step < minChartStep {
+		step = minChartStep
+	}
+	if span := to.Sub(from); span/step > maxChartBuckets {
+		step = (span + maxChartBuckets - 1) / maxChartBuckets
+	}
+	return step
+}
+
+// grafanaPoints returns a service's response times or uptime between from
+// and to as [value, unix ms] pairs. Ranges within raw check retention are
+// bucketed by step, with null values for steps without checks; older ones
+// come from the hourly or, for steps of a day or more, daily rollups.
+func (s *Server) grafanaPoints(name, metric string, from, to time.Time, step time.Duration) [][2]interface{} {
+	points := [][2]interface{}{}
+	if time.Since(from) <= maxChartPeriod {
+		start, end := from.Truncate(step), to.Truncate(step)
+		if end.Before(to) {
+			end = end.Add(step)
+		}
+		for _, b := range bucketCheckPoints(s.storage.GetCheckPoints(name, start, end), start, end, step) {
+			t, _ := time.Parse(time.RFC3339, b.Timestamp)
+			var value interface{}
+			switch {
+			case metric == grafanaUptime && b.UptimePercent != nil:
+				value = *b.UptimePercent
+			case metric == grafanaResponseTime && b.AvgMs != nil:
+				value = float64(*b.AvgMs)
+			}
+			points = append(points, [2]interface{}{value, t.UnixMilli()})
+		}
+		return points
+	}
+
+	resolution := storage.ResolutionHour
+	if step >= 24*time.Hour {
+		resolution = storage.ResolutionDay
+	}
+	for _, rollup := range s.storage.GetRollups(name, from, to, resolution) {
+		if rollup.TotalChecks == 0 {
+			continue
+		}
+		value := rollup.UptimePercent
+		if metric == grafanaResponseTime {
+			value = float64(rollup.AvgResponseMs)
+		}
+		points = append(points, [2]interface{}{value, rollup.Timestamp.UnixMilli()})
+	}
+	return points
+}
+
+// grafanaStatusTable lists the current status of the named service, or of
+// every service on the page
+func (s *Server) grafanaStatusTable(r *http.Request, name string) grafanaTable {
+	table := grafanaTable{
+		Type: "table",
+		Columns: []grafanaColumn{
+			{Text: "Service", Type: "string"},
+			{Text: "Group", Type: "string"},
+			{Text: "Status", Type: "string"},
+			{Text: "Up", Type: "number"}, // 1 operational or degraded, 0 otherwise, for thresholds
+			{Text: "Uptime (%)", Type: "number"},
+			{Text: "Response time (ms)", Type: "number"},
+			{Text: "Last check", Type: "time"},
+		},
+		Rows: [][]interface{}{},
+	}
+	for _, st := range s.pageStatuses(s.pageFor(r)) {
+		if name != "" && st.Name != name {
+			continue
+		}
+		up := 0
+		if st.Status == monitor.StatusOperational || st.Status == monitor.StatusDegraded {
+			up = 1
+		}
+		table.Rows = append(table.Rows, []interface{}{
+			st.Name, groupName(st), string(st.Status), up, st.Uptime, st.ResponseTimeMs, st.LastCheck.UnixMilli(),
+		})
+	}
+	return table
+}
+
+// grafanaAnnotations returns the page's incidents and maintenance windows
+// that overlap the dashboard's range. The annotation's query text may be
+// "incidents" or "maintenance" to show only those.
+func (s *Server) grafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Range      grafanaRange `json:"range"`
+		Annotation struct {
+			Query string `json:"query"`
+		} `json:"annotation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	from, to := grafanaSpan(req.Range)
+	kind := strings.TrimSpace(req.Annotation.Query)
+	page := s.pageFor(r)
+
+	overlaps := func(start time.Time, end *time.Time) bool {
+		return start.Before(to) && (end == nil || end.After(from))
+	}
+	annotations := []grafanaAnnotation{}
+	if kind == "" || kind == "incidents" {
+		for _, inc := range s.pageIncidents(page, 0, false) {
+			if !overlaps(inc.CreatedAt, inc.ResolvedAt) {
+				continue
+			}
+			a := grafanaAnnotation{
+				Time:  inc.CreatedAt.UnixMilli(),
+				Title: inc.Title,
+				Text:  inc.Message,
+				Tags:  append([]string{"incident", inc.Severity, inc.Status}, inc.AffectedServices...),
+			}
+			if inc.ResolvedAt != nil {
+				a.TimeEnd = inc.ResolvedAt.UnixMilli()
+			}
+			annotations = append(annotations, a)
+		}
+	}
+	if kind == "" || kind == "maintenance" {
+		for _, m := range s.pageMaintenance(page, s.storage.GetMaintenance(false)) {
+			if !overlaps(m.ScheduledStart, &m.ScheduledEnd) {
+				continue
+			}
+			annotations = append(annotations, grafanaAnnotation{
+				Time:    m.ScheduledStart.UnixMilli(),
+				TimeEnd: m.ScheduledEnd.UnixMilli(),
+				Title:   m.Title,
+				Text:    m.Description,
+				Tags:    append([]string{"maintenance", m.Status}, m.AffectedServices...),
+			})
+		}
+	}
+	writeGrafanaJSON(w, annotations)
+}
+
+// writeGrafanaJSON writes v as is: the plugins expect bare arrays rather
+// than the API's success envelope
+func writeGrafanaJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,312 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/status/config"
+	"github.com/status/monitor"
+	"github.com/status/storage"
+)
+
+// === Component Groups ===
+
+const defaultGroupName = "Services"
+
+// GroupInfo describes a service group as rendered on the page
+type GroupInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Collapsed   bool     `json:"collapsed"`
+	Position    int      `json:"position"`
+	Components  []string `json:"components"`
+}
+
+// GroupRequest is the body accepted by PUT /api/groups/{name}
+type GroupRequest struct {
+	Description *string  `json:"description"`
+	Collapsed   *bool    `json:"collapsed"`
+	Position    *int     `json:"position"`
+	Components  []string `json:"components"`
+}
+
+// GroupOrderRequest is the body accepted by PUT /api/groups
+type GroupOrderRequest struct {
+	Groups []string `json:"groups"`
+}
+
+func (s *Server) handleAPIGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.jsonResponse(w, s.groupInfos(s.pageStatuses(s.pageFor(r))))
+
+	case http.MethodPut:
+		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			var req GroupOrderRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Groups) == 0 {
+				s.jsonError(w, "Invalid request body - expected {\"groups\": [...]}", http.StatusBadRequest)
+				return
+			}
+			if err := s.storage.SetGroupOrder(req.Groups); err != nil {
+				s.jsonError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			s.jsonResponse(w, s.groupInfos(s.orderedStatuses()))
+		})(w, r)
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleAPIGroup(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/api/groups/")
+	if name == "" {
+		s.jsonError(w, "Group name required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		for _, g := range s.groupInfos(s.pageStatuses(s.pageFor(r))) {
+			if g.Name == name {
+				s.jsonResponse(w, g)
+				return
+			}
+		}
+		s.jsonError(w, "Group not found", http.StatusNotFound)
+
+	case http.MethodPut, http.MethodPatch:
+		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			if s.configGroup(name) != nil {
+				s.jsonError(w, "Group is defined in the config file and cannot be modified", http.StatusConflict)
+				return
+			}
+			var req GroupRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+
+			g := storage.ComponentGroup{Name: name}
+			existing := s.storage.GetGroup(name)
+			if existing != nil {
+				g = *existing
+			}
+			if req.Description != nil {
+				g.Description = *req.Description
+			}
+			if req.Collapsed != nil {
+				g.Collapsed = *req.Collapsed
+			}
+			if req.Position != nil {
+				g.Position = *req.Position
+			}
+			if req.Components != nil {
+				g.Components = req.Components
+			}
+
+			saved, err := s.storage.SaveGroup(g)
+			if err != nil {
+				s.jsonError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if existing == nil {
+				w.WriteHeader(http.StatusCreated)
+			}
+			s.jsonResponse(w, saved)
+		})(w, r)
+
+	case http.MethodDelete:
+		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			if s.configGroup(name) != nil {
+				s.jsonError(w, "Group is defined in the config file and cannot be deleted", http.StatusConflict)
+				return
+			}
+			if s.storage.DeleteGroup(name) {
+				w.WriteHeader(http.StatusNoContent)
+			} else {
+				s.jsonError(w, "Group not found", http.StatusNotFound)
+			}
+		})(w, r)
+
+	default:
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// groupMeta returns group metadata in display order: groups defined in the
+// config file, in the order listed, then those set through the API. An API
+// entry for a config group only contributes its component order.
+func (s *Server) groupMeta() []storage.ComponentGroup {
+	stored := s.storage.GetGroups()
+	components := make(map[string][]string)
+	for _, g := range stored {
+		components[g.Name] = g.Components
+	}
+
+	var groups []storage.ComponentGroup
+	for i, g := range s.cfg().Groups {
+		groups = append(groups, storage.ComponentGroup{
+			Name:        g.Name,
+			Description: g.Description,
+			Collapsed:   g.Collapsed,
+			Position:    i + 1,
+			Components:  components[g.Name],
+		})
+	}
+	for _, g := range stored {
+		if s.configGroup(g.Name) == nil {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// configGroup returns the group of that name defined in the config file
+func (s *Server) configGroup(name string) *config.GroupConfig {
+	for _, g := range s.cfg().Groups {
+		if g.Name == name {
+			return &g
+		}
+	}
+	return nil
+}
+
+// orderedStatuses returns all service statuses sorted by group and then
+// component. Groups are ordered by their configured order, then position:
+// config groups as listed, groups set through the API, then the rest in
+// order of first appearance. Components are ordered by their configured
+// order, then the group's explicit component order, then config order.
+func (s *Server) orderedStatuses() []*monitor.ServiceStatus {
+	statuses := s.monitor.GetAllStatuses()
+	meta := s.groupMeta()
+
+	groupRank := make(map[string]int)
+	componentRank := make(map[string]map[string]int)
+	for i, g := range meta {
+		groupRank[g.Name] = i
+		componentRank[g.Name] = make(map[string]int)
+		for j, c := range g.Components {
+			componentRank[g.Name][c] = j
+		}
+	}
+
+	// Unordered groups follow in order of first appearance
+	next := len(meta)
+	for _, st := range statuses {
+		if _, ok := groupRank[groupName(st)]; !ok {
+			groupRank[groupName(st)] = next
+			next++
+		}
+	}
+
+	groupOrder := make(map[string]int)
+	for _, g := range s.cfg().Groups {
+		groupOrder[g.Name] = g.Order
+	}
+
+	rank := func(st *monitor.ServiceStatus) int {
+		if r, ok := componentRank[groupName(st)][st.Name]; ok {
+			return r
+		}
+		return len(statuses)
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if ga, gb := groupName(a), groupName(b); ga != gb {
+			if groupOrder[ga] != groupOrder[gb] {
+				return groupOrder[ga] < groupOrder[gb]
+			}
+			return groupRank[ga] < groupRank[gb]
+		}
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		return rank(a) < rank(b)
+	})
+	return statuses
+}
+
+// orderedGroups maps group names to their services, encoded as a JSON
+// object whose keys keep display order
+type orderedGroups struct {
+	names    []string
+	services map[string][]*monitor.ServiceStatus
+}
+
+// groupStatuses groups already ordered statuses by group
+func groupStatuses(statuses []*monitor.ServiceStatus) orderedGroups {
+	groups := orderedGroups{services: make(map[string][]*monitor.ServiceStatus)}
+	for _, st := range statuses {
+		name := groupName(st)
+		if _, ok := groups.services[name]; !ok {
+			groups.names = append(groups.names, name)
+		}
+		groups.services[name] = append(groups.services[name], st)
+	}
+	return groups
+}
+
+func (g orderedGroups) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range g.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(g.services[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// groupInfos builds group metadata for already ordered statuses
+func (s *Server) groupInfos(statuses []*monitor.ServiceStatus) []GroupInfo {
+	meta := make(map[string]storage.ComponentGroup)
+	for _, g := range s.groupMeta() {
+		meta[g.Name] = g
+	}
+
+	groups := []GroupInfo{}
+	index := make(map[string]int)
+	for _, st := range statuses {
+		name := groupName(st)
+		i, ok := index[name]
+		if !ok {
+			m := meta[name]
+			groups = append(groups, GroupInfo{
+				Name:        name,
+				Description: m.Description,
+				Collapsed:   m.Collapsed,
+				Position:    len(groups),
+				Components:  []string{},
+			})
+			i = len(groups) - 1
+			index[name] = i
+		}
+		groups[i].Components = append(groups[i].Components, st.Name)
+	}
+	return groups
+}
+
+func groupName(st *monitor.ServiceStatus) string {
+	if st.Group == "" {
+		return defaultGroupName
+	}
+	return st.Group
+}
@@ -0,0 +1,99 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// SetFollower makes the server a follower in HA mode, or updates where
+// its leader takes writes. A follower forwards writes to leaderURL, or
+// refuses them while it is unknown, and leaves the background tasks that
+// write or notify to the leader until Lead is called. Call it before Start
+// for a server that starts as a follower.
+func (s *Server) SetFollower(leaderURL string) {
+	var target *url.URL
+	if u, err := url.Parse(leaderURL); err == nil && u.Host != "" {
+		target = u
+	}
+	s.leaderURL.Store(target)
+	s.follower.Store(true)
+}
+
+// Lead makes the server the leader: it takes writes itself and runs the
+// background tasks that write or notify. Further calls do nothing.
+func (s *Server) Lead() {
+	s.follower.Store(false)
+	s.leading.Do(func() {
+		// Start daily history recorder
+		go s.recordDailyHistory()
+
+		// Start maintenance window scheduler
+		go s.runMaintenanceScheduler()
+
+		// Announce feed changes to the WebSub hub
+		if s.websub != nil {
+			go s.runWebSub()
+		}
+
+		// Escalate unacknowledged incidents
+		if len(s.cfg().Notifications.Escalations) > 0 {
+			go s.runEscalations()
+		}
+
+		// Open incidents automatically from failing checks, while
+		// auto_incidents is enabled
+		go s.runAutoIncidents()
+	})
+}
+
+// IsLeader reports whether the server takes writes itself: always, unless
+// it is a follower in HA mode
+func (s *Server) IsLeader() bool {
+	return !s.follower.Load()
+}
+
+// DataChanged drops cached responses, for a follower whose storage has
+// moved to a newer snapshot
+func (s *Server) DataChanged() {
+	s.feedGenerator().ResetCache()
+	s.respCache.invalidate()
+}
+
+// withFollower forwards writes to the leader while the server is a
+// follower, keeping the client's Host so pages selected by hostname work.
+// Reads are served from the follower's snapshot.
+func (s *Server) withFollower(next http.Handler) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(s.leaderURL.Load())
+			pr.Out.Host = pr.In.Host
+			pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+			pr.SetXForwarded()
+			// The leader logs the request under the same ID
+			pr.Out.Header.Set("X-Request-ID", pr.In.Header.Get("X-Request-ID"))
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			resp.Header.Del("X-Request-ID")
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			requestLogger(r).Warn("Forwarding to the leader failed", "leader", s.leaderURL.Load().String(), "error", err)
+			s.jsonError(w, "Leader unreachable, try again shortly", http.StatusBadGateway)
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.follower.Load() || readRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.leaderURL.Load() == nil {
+			w.Header().Set("Retry-After", "5")
+			s.jsonError(w, "This instance is a follower and the leader is unknown, try again shortly", http.StatusServiceUnavailable)
+			return
+		}
+		r.Header.Set("X-Request-ID", w.Header().Get("X-Request-ID"))
+		proxy.ServeHTTP(w, r)
+	})
+}
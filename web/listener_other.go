@@ -0,0 +1,14 @@
+//go:build !unix
+
+package web
+
+import (
+	"errors"
+	"os"
+)
+
+// ListenerFile is not supported here: sockets can't be passed to another
+// process the way startUpgrade does on Unix
+func (s *Server) ListenerFile() (*os.File, error) {
+	return nil, errors.New("handing over the listening socket is not supported on this platform")
+}
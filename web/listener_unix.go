@@ -0,0 +1,39 @@
+//go:build unix
+
+package web
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// ListenerFile returns a duplicate of the listening socket, to hand over to
+// a process replacing this one. Call it once Ready is closed. Unlike the
+// listener's own File method, it leaves the socket non-blocking, which the
+// two descriptors share: a blocked accept would keep Stop from returning
+// if the handover fails.
+func (s *Server) ListenerFile() (*os.File, error) {
+	ln, ok := s.listener.(syscall.Conn)
+	if !ok {
+		return nil, fmt.Errorf("cannot hand over a %T", s.listener)
+	}
+	rc, err := ln.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var dup int
+	var dupErr error
+	if err := rc.Control(func(fd uintptr) {
+		dup, dupErr = syscall.Dup(int(fd))
+		if dupErr == nil {
+			syscall.CloseOnExec(dup)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	if dupErr != nil {
+		return nil, os.NewSyscallError("dup", dupErr)
+	}
+	return os.NewFile(uintptr(dup), "listener"), nil
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// maintenanceCheckInterval is how often the scheduler looks for maintenance
+// windows that have started or finished.
+const maintenanceCheckInterval = 30 * time.Second
+
+// Maintenance lifecycle events sent to WebSocket clients
+const (
+	EventMaintenanceStart = "maintenance.start"
+	EventMaintenanceEnd   = "maintenance.end"
+)
+
+// runMaintenanceScheduler moves maintenance windows through their lifecycle
+// (scheduled -> in_progress -> completed), broadcasting and notifying each
+// transition.
+func (s *Server) runMaintenanceScheduler() {
+	s.advanceMaintenance(time.Now())
+	s.schedulerRun.Store(time.Now().UnixNano())
+
+	ticker := time.NewTicker(maintenanceCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.advanceMaintenance(now)
+		s.schedulerRun.Store(time.Now().UnixNano())
+	}
+}
+
+// SchedulerStalled reports whether the maintenance scheduler has missed
+// three runs, as when storage is blocking it
+func (s *Server) SchedulerStalled(now time.Time) bool {
+	last := s.schedulerRun.Load()
+	return last != 0 && now.Sub(time.Unix(0, last)) > 3*maintenanceCheckInterval
+}
+
+// advanceMaintenance applies any transitions due at now
+func (s *Server) advanceMaintenance(now time.Time) {
+	for _, m := range s.storage.GetMaintenance(true) {
+		var status string
+		switch {
+		case m.Status == "scheduled" && !now.Before(m.ScheduledEnd):
+			// Window passed entirely while we weren't running
+			status = "completed"
+		case m.Status == "scheduled" && !now.Before(m.ScheduledStart):
+			status = "in_progress"
+		case m.Status == "in_progress" && !m.ScheduledEnd.IsZero() && !now.Before(m.ScheduledEnd):
+			status = "completed"
+		default:
+			continue
+		}
+
+		updated, err := s.storage.UpdateMaintenance(m.ID, status)
+		if err != nil || updated == nil {
+			slog.Error("Failed to update maintenance", "maintenance", m.ID, "error", err)
+			continue
+		}
+
+		slog.Info("Maintenance status changed", "maintenance", updated.ID, "title", updated.Title, "status", status)
+		s.broadcastMaintenance(m.Status, *updated)
+		s.notifyMaintenance(m.Status, *updated)
+	}
+}
+
+// notifyMaintenance sends maintenance.started when a window enters
+// in_progress and maintenance.completed when it is completed
+func (s *Server) notifyMaintenance(previous string, m storage.Maintenance) {
+	if s.notifier == nil || m.Status == previous {
+		return
+	}
+	switch m.Status {
+	case "in_progress":
+		s.notifier.NotifyMaintenanceStarted(m, s.cfg().BaseURL)
+	case "completed":
+		s.notifier.NotifyMaintenanceCompleted(m, s.cfg().BaseURL)
+	}
+}
+
+// broadcastMaintenance notifies clients when a window enters or leaves in_progress
+func (s *Server) broadcastMaintenance(previous string, m storage.Maintenance) {
+	var event string
+	switch {
+	case m.Status == "in_progress" && previous != "in_progress":
+		event = EventMaintenanceStart
+	case m.Status != "in_progress" && previous == "in_progress":
+		event = EventMaintenanceEnd
+	default:
+		return
+	}
+
+	s.respCache.invalidate()
+	s.broadcastPages(func(p *config.PageConfig) interface{} {
+		if !s.affectsPage(p, m.AffectedServices) {
+			return nil
+		}
+		return map[string]interface{}{
+			"type":        event,
+			"maintenance": m,
+		}
+	})
+}
+
+// activeMaintenance returns the maintenance windows currently in progress
+// that belong on a page
+func (s *Server) activeMaintenance(p *config.PageConfig) []storage.Maintenance {
+	active := make([]storage.Maintenance, 0)
+	for _, m := range s.pageMaintenance(p, s.storage.GetMaintenance(true)) {
+		if m.Status == "in_progress" {
+			active = append(active, m)
+		}
+	}
+	return active
+}
+
+// broadcast sends a message to every connected WebSocket client,
+// dropping clients whose connection has failed.
+func (s *Server) broadcast(data interface{}) {
+	s.broadcastPages(func(*config.PageConfig) interface{} { return data })
+}
+
+// broadcastPages queues for each connected WebSocket client the message
+// built for its page, or nothing if that is nil. Messages are built and
+// encoded once per page; clients that can't keep up are dropped.
+func (s *Server) broadcastPages(build func(p *config.PageConfig) interface{}) {
+	s.clientMu.RLock()
+	defer s.clientMu.RUnlock()
+
+	messages := make(map[string][]byte)
+	for client := range s.clients {
+		msg, ok := messages[client.page]
+		if !ok {
+			if data := build(s.page(client.page)); data != nil {
+				msg, _ = json.Marshal(data)
+			}
+			messages[client.page] = msg
+		}
+		if msg != nil {
+			client.queue(msg)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/status/markdown"
+	"github.com/status/storage"
+)
+
+// outageCheckInterval is how often the server looks for storage starting
+// or ending an outage, to show or clear the warning on open pages
+const outageCheckInterval = 5 * time.Second
+
+// outageText is the warning shown in place of the announcement while
+// storage is not saving changes
+const outageText = "**Some status information is unavailable.** Incidents and maintenance can't be updated right now and may be missing or out of date. Service checks below are live."
+
+// outageAnnouncement returns the warning shown during a storage outage
+func outageAnnouncement(o *storage.Outage) *AnnouncementInfo {
+	since := o.Since
+	return &AnnouncementInfo{
+		Text:     outageText,
+		HTML:     template.HTML(markdown.ToHTML(outageText)),
+		Level:    "warning",
+		StartsAt: &since,
+		Active:   true,
+		Source:   "storage",
+	}
+}
+
+// withStorage refuses changes while storage is not saving them, rather
+// than let each handler fail on its own. Reads are served from what
+// storage still has and the monitor's state.
+func (s *Server) withStorage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if readRequest(r) || s.storage.Outage() == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", "30")
+		s.jsonError(w, "Storage is unavailable, so changes can't be saved; try again later", http.StatusServiceUnavailable)
+	})
+}
+
+// watchStorage logs storage outages as they start and end, and shows or
+// clears the warning on open pages
+func (s *Server) watchStorage() {
+	down := s.storage.Outage() != nil
+
+	ticker := time.NewTicker(outageCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		o := s.storage.Outage()
+		switch {
+		case o != nil && !down:
+			slog.Error("Storage failed, showing a warning and refusing changes until writes succeed again", "error", o.Reason)
+		case o == nil && down:
+			slog.Info("Storage is saving changes again")
+		default:
+			continue
+		}
+		down = o != nil
+		s.broadcastAnnouncement()
+	}
+}
@@ -0,0 +1,259 @@
+package web
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/status/config"
+	"github.com/status/monitor"
+	"github.com/status/storage"
+)
+
+// pageKey is the request context key of the status page being served
+type pageKey struct{}
+
+// mainPageRoutes are only served for the main page: feeds, subscriptions
+// and metrics cover every service, so they would show other pages'
+// services on a page with a subset
+var mainPageRoutes = []string{"/feed", "/websub", "/api/subscribe", "/api/push", "/sw.js", "/metrics", "/api/metrics"}
+
+// pageView is what a page shows in place of the main page's settings
+type pageView struct {
+	Title       string
+	Description string
+	Logo        string
+	Theme       config.ThemeConfig
+}
+
+// withPages serves requests for the pages in the config, matched by
+// hostname and path in the order listed, with the page in the request
+// context. A page's path is stripped, so it has every route of the main
+// page below it.
+func (s *Server) withPages(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := s.matchPage(r)
+		if p == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if p.Path != "" {
+			if r.URL.Path == p.Path {
+				http.Redirect(w, r, s.cfg().BasePath+p.Path+"/", http.StatusMovedPermanently)
+				return
+			}
+			r = r.Clone(r.Context())
+			r.URL.Path = strings.TrimPrefix(r.URL.Path, p.Path)
+			r.URL.RawPath = ""
+		}
+		for _, route := range mainPageRoutes {
+			if r.URL.Path == route || strings.HasPrefix(r.URL.Path, route+"/") {
+				http.NotFound(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), pageKey{}, p.Name)))
+	})
+}
+
+// matchPage returns the configured page a request is for, or nil for the
+// main page
+func (s *Server) matchPage(r *http.Request) *config.PageConfig {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for i := range s.cfg().Pages {
+		p := &s.cfg().Pages[i]
+		if p.Hostname != "" && !strings.EqualFold(p.Hostname, host) {
+			continue
+		}
+		if p.Path != "" && r.URL.Path != p.Path && !strings.HasPrefix(r.URL.Path, p.Path+"/") {
+			continue
+		}
+		return p
+	}
+	return nil
+}
+
+// pageFor returns the page a request is served for, or nil for the main page
+func (s *Server) pageFor(r *http.Request) *config.PageConfig {
+	name, _ := r.Context().Value(pageKey{}).(string)
+	return s.page(name)
+}
+
+// page returns the configured page of that name; nil for "" or a page
+// removed by a reload
+func (s *Server) page(name string) *config.PageConfig {
+	if name == "" {
+		return nil
+	}
+	for i := range s.cfg().Pages {
+		if s.cfg().Pages[i].Name == name {
+			return &s.cfg().Pages[i]
+		}
+	}
+	return nil
+}
+
+// pageName returns the name of a page, "" for the main page
+func pageName(p *config.PageConfig) string {
+	if p == nil {
+		return ""
+	}
+	return p.Name
+}
+
+// pageCacheKey scopes a response cache key to a page
+func pageCacheKey(key string, p *config.PageConfig) string {
+	if p == nil {
+		return key
+	}
+	return key + "@" + p.Name
+}
+
+// summaryPageID is a page's id in /api/summary
+func summaryPageID(p *config.PageConfig) string {
+	if p == nil {
+		return "status"
+	}
+	return p.Name
+}
+
+// pageBasePath is the path a page's links start with
+func (s *Server) pageBasePath(p *config.PageConfig) string {
+	if p == nil {
+		return s.cfg().BasePath
+	}
+	return s.cfg().BasePath + p.Path
+}
+
+// pageBaseURL is the absolute URL of a page: the main page's, on the
+// page's hostname and under its path
+func (s *Server) pageBaseURL(p *config.PageConfig) string {
+	base := s.cfg().BaseURL
+	if p == nil {
+		return base
+	}
+	if p.Hostname != "" {
+		if u, err := url.Parse(base); err == nil {
+			u.Host = p.Hostname
+			base = u.String()
+		}
+	}
+	return base + p.Path
+}
+
+// pageEndpoints returns the endpoints switched on for a page; feeds,
+// subscriptions and metrics only exist for the main page
+func (s *Server) pageEndpoints(p *config.PageConfig) config.EndpointsConfig {
+	e := s.cfg().Endpoints
+	if p != nil {
+		e.Feeds, e.Subscriptions, e.Metrics = false, false, false
+	}
+	return e
+}
+
+// pageView returns a page's title, description, logo and theme, falling
+// back to the main page's
+func (s *Server) pageView(p *config.PageConfig) pageView {
+	cfg := s.cfg()
+	v := pageView{Title: cfg.Title, Description: cfg.Description, Logo: cfg.Logo, Theme: cfg.Theme}
+	if p == nil {
+		return v
+	}
+	if p.Title != "" {
+		v.Title = p.Title
+	}
+	if p.Description != "" {
+		v.Description = p.Description
+	}
+	if p.Logo != "" {
+		v.Logo = p.Logo
+	}
+	if p.Theme != nil {
+		v.Theme = *p.Theme
+	}
+	return v
+}
+
+// pageStatuses returns the ordered statuses of the services on a page
+func (s *Server) pageStatuses(p *config.PageConfig) []*monitor.ServiceStatus {
+	statuses := s.orderedStatuses()
+	if p == nil {
+		return statuses
+	}
+	shown := make([]*monitor.ServiceStatus, 0, len(statuses))
+	for _, st := range statuses {
+		if p.Shows(st.Name, groupName(st)) {
+			shown = append(shown, st)
+		}
+	}
+	return shown
+}
+
+// pageOverall returns the overall status of a page's services
+func (s *Server) pageOverall(p *config.PageConfig, statuses []*monitor.ServiceStatus) monitor.Status {
+	if p == nil {
+		return s.monitor.GetOverallStatus()
+	}
+	return monitor.OverallStatus(statuses)
+}
+
+// onPage reports whether the named service is shown on a page
+func (s *Server) onPage(p *config.PageConfig, service string) bool {
+	if p == nil {
+		return true
+	}
+	st := s.monitor.GetStatus(service)
+	return st != nil && p.Shows(st.Name, groupName(st))
+}
+
+// affectsPage reports whether an incident or maintenance window affecting
+// these services belongs on a page: it affects one of the page's services,
+// or names none and so concerns everything
+func (s *Server) affectsPage(p *config.PageConfig, services []string) bool {
+	if p == nil || len(services) == 0 {
+		return true
+	}
+	for _, name := range services {
+		if s.onPage(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageIncidents returns up to limit incidents (0 for all) that belong on
+// a page, newest first
+func (s *Server) pageIncidents(p *config.PageConfig, limit int, activeOnly bool) []storage.Incident {
+	if p == nil {
+		return s.storage.GetIncidents(limit, activeOnly)
+	}
+	shown := make([]storage.Incident, 0)
+	for _, inc := range s.storage.GetIncidents(0, activeOnly) {
+		if limit > 0 && len(shown) == limit {
+			break
+		}
+		if s.affectsPage(p, inc.AffectedServices) {
+			shown = append(shown, inc)
+		}
+	}
+	return shown
+}
+
+// pageMaintenance returns the maintenance windows that belong on a page
+func (s *Server) pageMaintenance(p *config.PageConfig, maintenance []storage.Maintenance) []storage.Maintenance {
+	if p == nil {
+		return maintenance
+	}
+	shown := make([]storage.Maintenance, 0, len(maintenance))
+	for _, m := range maintenance {
+		if s.affectsPage(p, m.AffectedServices) {
+			shown = append(shown, m)
+		}
+	}
+	return shown
+}
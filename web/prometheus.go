@@ -0,0 +1,117 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/status/monitor"
+	"github.com/status/notify"
+)
+
+// handlePrometheusMetrics exposes service and notification metrics in the
+// Prometheus text exposition format
+func (s *Server) handlePrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	statuses := s.monitor.GetAllStatuses()
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	writeMetricHeader(w, "status_service_up", "gauge", "Whether the service is operational (1) or not (0)")
+	for _, st := range statuses {
+		up := 0
+		if st.Status == monitor.StatusOperational {
+			up = 1
+		}
+		fmt.Fprintf(w, "status_service_up{%s} %d\n", serviceLabels(st), up)
+	}
+	writeMetricHeader(w, "status_service_response_time_ms", "gauge", "Response time of the last check in milliseconds")
+	for _, st := range statuses {
+		fmt.Fprintf(w, "status_service_response_time_ms{%s} %d\n", serviceLabels(st), st.ResponseTimeMs)
+	}
+	writeMetricHeader(w, "status_service_uptime_percent", "gauge", "Uptime over the check history")
+	for _, st := range statuses {
+		fmt.Fprintf(w, "status_service_uptime_percent{%s} %g\n", serviceLabels(st), st.Uptime)
+	}
+
+	writeMetricHeader(w, "status_incidents_active", "gauge", "Unresolved incidents")
+	fmt.Fprintf(w, "status_incidents_active %d\n", len(s.storage.GetIncidents(0, true)))
+
+	if s.cfg().HA.Enabled {
+		leader := 0
+		if s.IsLeader() {
+			leader = 1
+		}
+		writeMetricHeader(w, "status_ha_leader", "gauge", "Whether this instance is the HA leader (1) or a follower (0)")
+		fmt.Fprintf(w, "status_ha_leader %d\n", leader)
+	}
+
+	storageUp := 1
+	if s.storage.Outage() != nil {
+		storageUp = 0
+	}
+	writeMetricHeader(w, "status_storage_up", "gauge", "Whether storage is saving changes (1) or in an outage (0)")
+	fmt.Fprintf(w, "status_storage_up %d\n", storageUp)
+
+	if s.notifier == nil {
+		return
+	}
+	stats := s.notifier.Stats()
+
+	writeMetricHeader(w, "status_notifications_total", "counter", "Notifications passed the deduplication filter")
+	fmt.Fprintf(w, "status_notifications_total %d\n", stats.Sent)
+	writeMetricHeader(w, "status_notifications_suppressed_total", "counter", "Duplicate notifications suppressed, by event")
+	for _, event := range sortedKeys(stats.SuppressedByEvent) {
+		fmt.Fprintf(w, "status_notifications_suppressed_total{event=\"%s\"} %d\n", escapeLabel(event), stats.SuppressedByEvent[event])
+	}
+
+	writeMetricHeader(w, "status_notification_deliveries_total", "counter", "Webhook deliveries, subscriber emails and pushes by channel, event and result")
+	eachDelivery(stats.Deliveries, func(labels string, c notify.DeliveryCounts) {
+		fmt.Fprintf(w, "status_notification_deliveries_total{%s,result=\"sent\"} %d\n", labels, c.Sent)
+		fmt.Fprintf(w, "status_notification_deliveries_total{%s,result=\"failed\"} %d\n", labels, c.Failed)
+	})
+	writeMetricHeader(w, "status_notification_retries_total", "counter", "Webhook delivery retry attempts by channel and event")
+	eachDelivery(stats.Deliveries, func(labels string, c notify.DeliveryCounts) {
+		fmt.Fprintf(w, "status_notification_retries_total{%s} %d\n", labels, c.Retried)
+	})
+}
+
+// eachDelivery calls fn with the channel and event labels of each delivery
+// counter, in a stable order
+func eachDelivery(deliveries map[string]map[string]notify.DeliveryCounts, fn func(labels string, c notify.DeliveryCounts)) {
+	for _, channel := range sortedKeys(deliveries) {
+		events := deliveries[channel]
+		for _, event := range sortedKeys(events) {
+			fn(fmt.Sprintf("channel=\"%s\",event=\"%s\"", escapeLabel(channel), escapeLabel(event)), events[event])
+		}
+	}
+}
+
+func writeMetricHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func serviceLabels(st *monitor.ServiceStatus) string {
+	return fmt.Sprintf("service=\"%s\",group=\"%s\"", escapeLabel(st.Name), escapeLabel(st.Group))
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/status/storage"
+)
+
+// === Web Push Handlers ===
+
+// pushEnabled reports whether browsers can subscribe to push notifications
+func (s *Server) pushEnabled() bool {
+	return s.notifier != nil && s.notifier.VAPIDPublicKey() != ""
+}
+
+// handlePushKey returns the VAPID public key browsers subscribe with
+func (s *Server) handlePushKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.pushEnabled() {
+		s.jsonError(w, "Push notifications are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	s.jsonResponse(w, map[string]string{"public_key": s.notifier.VAPIDPublicKey()})
+}
+
+// handlePushSubscribe stores a browser's PushSubscription (as returned by
+// PushSubscription.toJSON()) with an optional services filter
+func (s *Server) handlePushSubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.pushEnabled() {
+		s.jsonError(w, "Push notifications are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+		Keys     struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+		Services []string `json:"services"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// The server posts to the endpoint, so only browsers' push services
+	// are accepted rather than any URL a visitor sends
+	if !s.notifier.PushEndpointAllowed(req.Endpoint) {
+		s.jsonError(w, "endpoint must be an https URL of a known push service", http.StatusBadRequest)
+		return
+	}
+	if !validPushKey(req.Keys.P256dh, 65) || !validPushKey(req.Keys.Auth, 16) {
+		s.jsonError(w, "Invalid subscription keys", http.StatusBadRequest)
+		return
+	}
+	if unknown := s.unknownServices(req.Services); len(unknown) > 0 {
+		s.jsonError(w, "Unknown services: "+strings.Join(unknown, ", "), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.storage.SavePushSubscription(storage.PushSubscription{
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+		Services: req.Services,
+	}, s.cfg().Push.MaxSubscriptions); errors.Is(err, storage.ErrPushLimit) {
+		s.jsonError(w, "No more browsers can subscribe to push notifications", http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	s.jsonResponse(w, map[string]string{"message": "Subscribed to push notifications."})
+}
+
+// handlePushUnsubscribe removes a browser's subscription by endpoint
+func (s *Server) handlePushUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil || req.Endpoint == "" {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if !s.storage.DeletePushSubscription(req.Endpoint) {
+		s.jsonError(w, "Subscription not found", http.StatusNotFound)
+		return
+	}
+	s.jsonResponse(w, map[string]string{"message": "Unsubscribed from push notifications."})
+}
+
+// handleServiceWorker serves the push service worker from the site root so
+// its scope covers the whole status page
+func (s *Server) handleServiceWorker(w http.ResponseWriter, r *http.Request) {
+	data, err := staticFiles.ReadFile("static/sw.js")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(data)
+}
+
+// validPushKey checks a base64url key decodes to the expected length
+func validPushKey(key string, size int) bool {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(key, "="))
+	return err == nil && len(b) == size
+}
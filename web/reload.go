@@ -0,0 +1,42 @@
+package web
+
+import (
+	"github.com/status/config"
+	"github.com/status/feeds"
+)
+
+// cfg returns the current configuration
+func (s *Server) cfg() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// feedGenerator returns the feed generator for the current configuration
+func (s *Server) feedGenerator() *feeds.FeedGenerator {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.feedGen
+}
+
+func newFeedGenerator(cfg *config.Config) *feeds.FeedGenerator {
+	fg := feeds.NewFeedGenerator(cfg.Title, cfg.BaseURL)
+	fg.SetLanguage(cfg.Feeds.Language, cfg.Feeds.Translations)
+	fg.SetLocation(configLocation(cfg))
+	return fg
+}
+
+// Reload applies a new configuration to the running server: title, theme,
+// services, feeds, integrations and the rest take effect on the next
+// request. The listen address, timeouts and base path are fixed when the
+// server starts.
+func (s *Server) Reload(cfg *config.Config) {
+	s.configMu.Lock()
+	s.config = cfg
+	s.feedGen = newFeedGenerator(cfg)
+	s.loc = configLocation(cfg)
+	s.configMu.Unlock()
+
+	// The new feed generator starts with an empty cache
+	s.respCache.invalidate()
+}
@@ -0,0 +1,229 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/storage"
+)
+
+// === SLA Reports ===
+
+const defaultSLO = 99.9
+
+// SLAReport summarizes uptime against objectives for a reporting period
+type SLAReport struct {
+	Title       string             `json:"title"`
+	Period      string             `json:"period"`
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	GeneratedAt time.Time          `json:"generated_at"`
+	Services    []SLAServiceReport `json:"services"`
+	Incidents   []storage.Incident `json:"incidents"`
+}
+
+// SLAServiceReport holds SLA figures for a single service
+type SLAServiceReport struct {
+	Name                   string  `json:"name"`
+	Group                  string  `json:"group,omitempty"`
+	SLO                    float64 `json:"slo"`
+	UptimePercent          float64 `json:"uptime_percent"`
+	Met                    bool    `json:"met"`
+	DowntimeMinutes        float64 `json:"downtime_minutes"`
+	AllowedDowntimeMinutes float64 `json:"allowed_downtime_minutes"`
+	TotalChecks            int     `json:"total_checks"`
+	SuccessChecks          int     `json:"success_checks"`
+	Incidents              int     `json:"incidents"`
+	NoData                 bool    `json:"no_data,omitempty"`
+}
+
+func (s *Server) handleAPISLAReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		// Default to the previous calendar month
+		period = time.Now().UTC().AddDate(0, -1, 0).Format("2006-01")
+	}
+
+	from, to, err := parseReportPeriod(period)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var filter []string
+	if v := r.URL.Query().Get("services"); v != "" {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				filter = append(filter, name)
+			}
+		}
+	}
+
+	report := s.buildSLAReport(s.pageFor(r), period, from, to, filter)
+
+	if r.URL.Query().Get("format") == "html" {
+		s.renderSLAReport(w, r, report)
+		return
+	}
+
+	s.jsonResponse(w, report)
+}
+
+// buildSLAReport computes uptime from hourly rollups, falling back to the
+// daily history when no rollups exist for a service in the period. Only the
+// page's services and incidents are included.
+func (s *Server) buildSLAReport(p *config.PageConfig, period string, from, to time.Time, filter []string) SLAReport {
+	report := SLAReport{
+		Title:       s.pageView(p).Title,
+		Period:      period,
+		From:        from,
+		To:          to,
+		GeneratedAt: time.Now(),
+		Services:    []SLAServiceReport{},
+		Incidents:   []storage.Incident{},
+	}
+
+	// Incidents that overlap the period
+	for _, inc := range s.storage.GetIncidents(0, false) {
+		if inc.CreatedAt.After(to) {
+			continue
+		}
+		if inc.ResolvedAt != nil && inc.ResolvedAt.Before(from) {
+			continue
+		}
+		if len(filter) > 0 && !containsAny(inc.AffectedServices, filter) {
+			continue
+		}
+		if !s.affectsPage(p, inc.AffectedServices) {
+			continue
+		}
+		report.Incidents = append(report.Incidents, inc)
+	}
+
+	periodMinutes := to.Sub(from).Minutes()
+	if now := time.Now(); to.After(now) {
+		periodMinutes = now.Sub(from).Minutes()
+	}
+
+	for _, svc := range s.cfg().Services {
+		if len(filter) > 0 && !containsAny([]string{svc.Name}, filter) {
+			continue
+		}
+		if !s.onPage(p, svc.Name) {
+			continue
+		}
+
+		slo := svc.SLO
+		if slo == 0 {
+			slo = defaultSLO
+		}
+
+		sr := SLAServiceReport{
+			Name:                   svc.Name,
+			Group:                  svc.Group,
+			SLO:                    slo,
+			AllowedDowntimeMinutes: periodMinutes * (100 - slo) / 100,
+		}
+
+		rollups := s.storage.GetRollups(svc.Name, from, to.Add(-time.Nanosecond), storage.ResolutionHour)
+		if len(rollups) > 0 {
+			for _, h := range rollups {
+				sr.TotalChecks += h.TotalChecks
+				sr.SuccessChecks += h.SuccessChecks
+				sr.DowntimeMinutes += (100 - h.UptimePercent) / 100 * 60
+			}
+		} else {
+			fromDay, toDay := from.Format("2006-01-02"), to.Format("2006-01-02")
+			for _, d := range s.storage.GetHistory(svc.Name, 0) {
+				if d.Date < fromDay || d.Date >= toDay {
+					continue
+				}
+				sr.TotalChecks += d.TotalChecks
+				sr.SuccessChecks += d.SuccessChecks
+				sr.DowntimeMinutes += (100 - d.UptimePercent) / 100 * 24 * 60
+			}
+		}
+
+		if sr.TotalChecks > 0 {
+			sr.UptimePercent = float64(sr.SuccessChecks) / float64(sr.TotalChecks) * 100
+			sr.Met = sr.UptimePercent >= slo
+		} else {
+			sr.NoData = true
+			sr.UptimePercent = 100
+			sr.Met = true
+		}
+
+		for _, inc := range report.Incidents {
+			if containsAny(inc.AffectedServices, []string{svc.Name}) {
+				sr.Incidents++
+			}
+		}
+
+		report.Services = append(report.Services, sr)
+	}
+
+	return report
+}
+
+func (s *Server) renderSLAReport(w http.ResponseWriter, r *http.Request, report SLAReport) {
+	loc := s.viewerLocation(w, r)
+	tmpl, err := template.New("sla.html").Funcs(template.FuncMap{
+		"pct":   func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) + "%" },
+		"mins":  func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) },
+		"local": func(t time.Time) time.Time { return t.In(loc) },
+	}).ParseFS(templateFiles, "templates/sla.html")
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		requestLogger(r).Error("SLA report template error", "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, report); err != nil {
+		requestLogger(r).Error("SLA report template execution error", "error", err)
+	}
+}
+
+// parseReportPeriod accepts "2024", "2024-Q4" or "2024-10" and returns the
+// half-open UTC interval [from, to)
+func parseReportPeriod(period string) (time.Time, time.Time, error) {
+	if t, err := time.Parse("2006-01", period); err == nil {
+		return t, t.AddDate(0, 1, 0), nil
+	}
+
+	if year, quarter, ok := strings.Cut(period, "-Q"); ok {
+		y, err1 := strconv.Atoi(year)
+		q, err2 := strconv.Atoi(quarter)
+		if err1 == nil && err2 == nil && q >= 1 && q <= 4 {
+			from := time.Date(y, time.Month((q-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+			return from, from.AddDate(0, 3, 0), nil
+		}
+	}
+
+	if t, err := time.Parse("2006", period); err == nil {
+		return t, t.AddDate(1, 0, 0), nil
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q - use YYYY, YYYY-QN or YYYY-MM", period)
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, v := range values {
+		for _, c := range candidates {
+			if v == c {
+				return true
+			}
+		}
+	}
+	return false
+}
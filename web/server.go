@@ -1,21 +1,26 @@
 package web
 
 import (
-	"context"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/fs"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/status/config"
 	"github.com/status/feeds"
+	"github.com/status/markdown"
 	"github.com/status/monitor"
 	"github.com/status/notify"
 	"github.com/status/storage"
@@ -29,25 +34,38 @@ var templateFiles embed.FS
 
 // Server represents the web server
 type Server struct {
-	config      *config.Config
-	monitor     *monitor.Monitor
-	storage     *storage.Storage
-	notifier    *notify.Notifier
-	feedGen     *feeds.FeedGenerator
-	upgrader    websocket.Upgrader
-	clients     map[*websocket.Conn]bool
-	clientMu    sync.RWMutex
-	server      *http.Server
+	config       *config.Config
+	feedGen      *feeds.FeedGenerator
+	loc          *time.Location // Display zone from the config
+	configMu     sync.RWMutex   // Guards config, feedGen and loc, replaced on reload
+	monitor      *monitor.Monitor
+	storage      *storage.Storage
+	notifier     *notify.Notifier
+	upgrader     websocket.Upgrader
+	clients      map[*wsClient]struct{}
+	clientMu     sync.RWMutex
+	closing      bool // Stop has closed the clients; guarded by clientMu
+	server       *http.Server
+	respCache    *responseCache
+	websub       *webSub
+	schedulerRun atomic.Int64            // Unix nanoseconds of the maintenance scheduler's last run
+	ready        chan struct{}           // Closed once the server is listening
+	draining     atomic.Bool             // Shutting down, so /readyz reports not ready
+	listener     net.Listener            // The socket served; set before Start when inherited
+	follower     atomic.Bool             // A follower in HA mode, forwarding writes to the leader
+	leaderURL    atomic.Pointer[url.URL] // Where a follower forwards writes, nil while unknown
+	leading      sync.Once               // Starts the leader's background tasks
 }
 
 // NewServer creates a new web server instance
 func NewServer(cfg *config.Config, mon *monitor.Monitor, store *storage.Storage, notif *notify.Notifier) *Server {
-	return &Server{
+	s := &Server{
 		config:   cfg,
 		monitor:  mon,
 		storage:  store,
 		notifier: notif,
-		feedGen:  feeds.NewFeedGenerator(cfg.Title, cfg.BaseURL),
+		feedGen:  newFeedGenerator(cfg),
+		loc:      configLocation(cfg),
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
 				return true
@@ -55,8 +73,13 @@ func NewServer(cfg *config.Config, mon *monitor.Monitor, store *storage.Storage,
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
-		clients: make(map[*websocket.Conn]bool),
+		clients:   make(map[*wsClient]struct{}),
+		respCache: newResponseCache(),
+		ready:     make(chan struct{}),
+		websub:    newWebSub(cfg.Feeds.WebSub, cfg.BaseURL),
 	}
+	s.respCache.onInvalidate = s.feedsChanged
+	return s
 }
 
 // Start starts the web server
@@ -74,68 +97,154 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/favicon.ico", s.handleFavicon)
 	mux.HandleFunc("/favicon.svg", s.handleFavicon)
 
+	// Readiness for load balancers and orchestrators
+	mux.HandleFunc("/readyz", s.handleReadyz)
+
 	// === Public API Routes ===
-	mux.HandleFunc("/api/status", s.handleAPIStatus)
-	mux.HandleFunc("/api/status/", s.handleAPIServiceStatus)
-	mux.HandleFunc("/api/summary", s.handleAPISummary)
-	mux.HandleFunc("/api/components", s.handleAPIComponents)
+	mux.HandleFunc("/api/status", s.endpoint(publicAPI, s.handleAPIStatus))
+	mux.HandleFunc("/api/status/", s.endpoint(publicAPI, s.handleAPIServiceStatus))
+	mux.HandleFunc("/api/summary", s.endpoint(publicAPI, s.handleAPISummary))
+	mux.HandleFunc("/api/components", s.endpoint(publicAPI, s.handleAPIComponents))
+	mux.HandleFunc("/api/groups", s.endpointRead(publicAPI, s.handleAPIGroups))
+	mux.HandleFunc("/api/groups/", s.endpointRead(publicAPI, s.handleAPIGroup))
 
 	// History API
-	mux.HandleFunc("/api/history", s.handleAPIHistory)
-	mux.HandleFunc("/api/history/", s.handleAPIServiceHistory)
-	mux.HandleFunc("/api/uptime", s.handleAPIUptime)
+	mux.HandleFunc("/api/history", s.endpoint(publicAPI, s.handleAPIHistory))
+	mux.HandleFunc("/api/history/", s.endpoint(publicAPI, s.handleAPIServiceHistory))
+	mux.HandleFunc("/api/uptime", s.endpoint(publicAPI, s.handleAPIUptime))
+	mux.HandleFunc("/api/charts/", s.endpoint(publicAPI, s.handleAPIChart))
+
+	// Badges (Shields.io endpoint schema)
+	mux.HandleFunc("/api/badge/", s.endpoint(publicAPI, s.handleAPIBadge))
+
+	// Reports API
+	mux.HandleFunc("/api/reports/sla", s.endpoint(publicAPI, s.handleAPISLAReport))
+
+	// Grafana data source (JSON and Infinity plugins)
+	mux.HandleFunc("/api/grafana/", s.endpoint(publicAPI, s.handleGrafana))
 
 	// Incidents API (public read, authenticated write)
-	mux.HandleFunc("/api/incidents", s.handleAPIIncidents)
-	mux.HandleFunc("/api/incidents/", s.handleAPIIncident)
+	mux.HandleFunc("/api/incidents", s.endpointRead(publicAPI, s.handleAPIIncidents))
+	mux.HandleFunc("/api/incidents/", s.endpointRead(publicAPI, s.handleAPIIncident))
 
 	// Maintenance API
-	mux.HandleFunc("/api/maintenance", s.handleAPIMaintenance)
-	mux.HandleFunc("/api/maintenance/", s.handleAPIMaintenanceItem)
+	mux.HandleFunc("/api/maintenance", s.endpointRead(publicAPI, s.handleAPIMaintenance))
+	mux.HandleFunc("/api/maintenance/", s.endpointRead(publicAPI, s.handleAPIMaintenanceItem))
+	mux.HandleFunc("/api/announcement", s.endpointRead(publicAPI, s.handleAPIAnnouncement))
 
 	// Metrics API
-	mux.HandleFunc("/api/metrics", s.handleAPIMetrics)
+	mux.HandleFunc("/api/metrics", s.endpoint(metrics, s.handleAPIMetrics))
+	mux.HandleFunc("/metrics", s.endpoint(metrics, s.handlePrometheusMetrics)) // Prometheus exporter
+
+	// === Admin API Routes ===
+	mux.HandleFunc("/api/admin/tokens", s.requireScope(ScopeAdmin, s.handleAdminTokens))
+	mux.HandleFunc("/api/admin/tokens/", s.requireScope(ScopeAdmin, s.handleAdminToken))
+	mux.HandleFunc("/api/admin/webhooks", s.requireScope(ScopeAdmin, s.handleAdminWebhooks))
+	mux.HandleFunc("/api/admin/webhooks/", s.requireScope(ScopeAdmin, s.handleAdminWebhook))
+	mux.HandleFunc("/api/admin/deliveries", s.requireScope(ScopeAdmin, s.handleAdminDeliveries))
+	mux.HandleFunc("/api/admin/dead-letters", s.requireScope(ScopeAdmin, s.handleAdminDeadLetters))
+	mux.HandleFunc("/api/admin/dead-letters/", s.requireScope(ScopeAdmin, s.handleAdminDeadLetter))
+
+	// Profiling and runtime counts, admin only
+	s.registerDebug(mux)
+
+	// Static copy of the page, for hosting as a fallback
+	mux.HandleFunc("/api/site", s.requireScope(ScopeRead, s.handleSiteExport))
 
 	// API Documentation
-	mux.HandleFunc("/api/", s.handleAPIDocs)
+	mux.HandleFunc("/api/", s.endpoint(publicAPI, s.handleAPIDocs))
 
 	// === Feed Routes ===
-	mux.HandleFunc("/feed/rss", s.handleRSSFeed)
-	mux.HandleFunc("/feed/atom", s.handleAtomFeed)
-	mux.HandleFunc("/feed/json", s.handleJSONFeed)
-	mux.HandleFunc("/feed", s.handleRSSFeed) // Default to RSS
+	mux.HandleFunc("/feed/rss", s.endpoint(feedEndpoints, s.handleRSSFeed))
+	mux.HandleFunc("/feed/atom", s.endpoint(feedEndpoints, s.handleAtomFeed))
+	mux.HandleFunc("/feed/json", s.endpoint(feedEndpoints, s.handleJSONFeed))
+	mux.HandleFunc("/feed/rss/", s.endpoint(feedEndpoints, s.handleRSSFeed)) // Per-service feeds
+	mux.HandleFunc("/feed/atom/", s.endpoint(feedEndpoints, s.handleAtomFeed))
+	mux.HandleFunc("/feed/json/", s.endpoint(feedEndpoints, s.handleJSONFeed))
+	mux.HandleFunc("/feed/incidents/", s.endpoint(feedEndpoints, s.handleIncidentFeed)) // Per-incident update feeds
+	mux.HandleFunc("/feed", s.endpoint(feedEndpoints, s.handleRSSFeed))                 // Default to RSS
+	mux.HandleFunc("/websub", s.endpoint(feedEndpoints, s.handleWebSubHub))
+
+	// === Integration Routes ===
+	mux.HandleFunc("/api/integrations/slack/commands", s.handleSlackCommand)
+	mux.HandleFunc("/api/integrations/slack/interactive", s.handleSlackInteractive)
 
 	// === Subscription Routes ===
-	mux.HandleFunc("/api/subscribe", s.handleSubscribe)
+	mux.HandleFunc("/api/subscribe", s.endpoint(subscriptions, s.handleSubscribe))
+	mux.HandleFunc("/api/subscribe/verify", s.endpoint(subscriptions, s.handleSubscribeVerify))
+	mux.HandleFunc("/api/subscribe/unsubscribe", s.endpoint(subscriptions, s.handleUnsubscribe))
+	mux.HandleFunc("/api/subscribe/preferences", s.endpoint(subscriptions, s.handleSubscribePreferences))
+
+	// === Web Push Routes ===
+	mux.HandleFunc("/api/push/key", s.endpoint(subscriptions, s.handlePushKey))
+	mux.HandleFunc("/api/push/subscribe", s.endpoint(subscriptions, s.handlePushSubscribe))
+	mux.HandleFunc("/api/push/unsubscribe", s.endpoint(subscriptions, s.handlePushUnsubscribe))
+	mux.HandleFunc("/sw.js", s.endpoint(subscriptions, s.handleServiceWorker))
 
 	// WebSocket endpoint
-	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/ws", s.endpoint(webSocket, s.handleWebSocket))
 
 	// Main pages
 	mux.HandleFunc("/", s.handleIndex)
 	mux.HandleFunc("/history", s.handleHistoryPage)
+	mux.HandleFunc("/embed", s.handleEmbed)
 	mux.HandleFunc("/incidents/", s.handleIncidentPage)
 
+	// Serve the configured pages by hostname or path, then mount everything
+	// under the base path when not served from root
+	handler := s.withPages(mux)
+	if base := s.cfg().BasePath; base != "" {
+		root := http.NewServeMux()
+		root.Handle(base+"/", http.StripPrefix(base, handler))
+		root.HandleFunc(base, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, base+"/", http.StatusMovedPermanently)
+		})
+		handler = root
+	}
+
 	s.server = &http.Server{
-		Addr:         fmt.Sprintf(":%d", s.config.Server.Port),
-		Handler:      s.withMiddleware(mux),
-		ReadTimeout:  s.config.Server.ReadTimeout,
-		WriteTimeout: s.config.Server.WriteTimeout,
+		Addr:         fmt.Sprintf(":%d", s.cfg().Server.Port),
+		Handler:      s.withRequestID(s.withFollower(s.withStorage(s.withMiddleware(handler)))),
+		ReadTimeout:  s.cfg().Server.ReadTimeout,
+		WriteTimeout: s.cfg().Server.WriteTimeout,
 	}
 
 	// Start broadcasting updates
 	go s.broadcastUpdates()
 
-	// Start daily history recorder
-	go s.recordDailyHistory()
+	// Warn open pages while storage is not saving changes
+	go s.watchStorage()
 
-	log.Printf("Starting server on http://localhost:%d", s.config.Server.Port)
-	return s.server.ListenAndServe()
+	// A follower in HA mode leaves history, maintenance, WebSub,
+	// escalations and automatic incidents to the leader
+	if s.IsLeader() {
+		s.Lead()
+	}
+
+	ln := s.listener
+	if ln == nil {
+		slog.Info("Starting server", "url", fmt.Sprintf("http://localhost:%d%s", s.cfg().Server.Port, s.cfg().BasePath))
+		ln, err = net.Listen("tcp", s.server.Addr)
+		if err != nil {
+			return err
+		}
+		s.listener = ln
+	} else {
+		slog.Info("Serving on the inherited socket", "address", ln.Addr().String())
+	}
+	close(s.ready)
+	return s.server.Serve(ln)
+}
+
+// Ready is closed once the server is listening for requests
+func (s *Server) Ready() <-chan struct{} {
+	return s.ready
 }
 
-// Stop gracefully stops the server
-func (s *Server) Stop(ctx context.Context) error {
-	return s.server.Shutdown(ctx)
+// SetListener makes Start serve ln, a socket handed over by the process
+// this one replaces, instead of listening on the configured port
+func (s *Server) SetListener(ln net.Listener) {
+	s.listener = ln
 }
 
 // Middleware
@@ -143,8 +252,17 @@ func (s *Server) withMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID")
+
+		// Disallow framing by other sites; /embed relaxes this
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+		w.Header().Set("Content-Security-Policy", "frame-ancestors 'self'")
+
+		// Any write may change incidents, maintenance or groups
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions {
+			defer s.respCache.invalidate()
+		}
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -155,24 +273,34 @@ func (s *Server) withMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// Token scopes
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
 // Auth middleware for admin endpoints - supports multiple auth methods
 func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireScope(ScopeWrite, next)
+}
+
+// requireScope authenticates the request and checks that it carries the given
+// scope. Static credentials from the config grant every scope; stored API
+// tokens grant only the scopes they were created with.
+func (s *Server) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Check if any auth is configured
-		hasAuth := s.config.API.Key != "" ||
-			s.config.API.BearerToken != "" ||
-			s.config.API.BasicAuth.Enabled
-
-		if !hasAuth {
+		if !s.hasAuth() {
 			next(w, r)
 			return
 		}
 
 		// Check IP whitelist first
-		if len(s.config.API.AllowedIPs) > 0 {
+		if len(s.cfg().API.AllowedIPs) > 0 {
 			clientIP := getClientIP(r)
 			ipAllowed := false
-			for _, ip := range s.config.API.AllowedIPs {
+			for _, ip := range s.cfg().API.AllowedIPs {
 				if ip == clientIP || ip == "*" {
 					ipAllowed = true
 					break
@@ -184,38 +312,48 @@ func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
 			}
 		}
 
+		apiKey := r.Header.Get("X-API-Key")
+		if apiKey == "" {
+			apiKey = r.Header.Get("X-Api-Key") // Case variation
+		}
+		if apiKey == "" {
+			apiKey = r.URL.Query().Get("api_key")
+		}
+
+		var bearer string
+		if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+			bearer = strings.TrimPrefix(authHeader, "Bearer ")
+		}
+
 		// 1. Check X-API-Key header
-		if s.config.API.Key != "" {
-			apiKey := r.Header.Get("X-API-Key")
-			if apiKey == "" {
-				apiKey = r.Header.Get("X-Api-Key") // Case variation
-			}
-			if apiKey == "" {
-				apiKey = r.URL.Query().Get("api_key")
-			}
-			if apiKey == s.config.API.Key {
-				next(w, r)
-				return
-			}
+		if s.cfg().API.Key != "" && apiKey == s.cfg().API.Key {
+			next(w, r)
+			return
 		}
 
 		// 2. Check Bearer token
-		if s.config.API.BearerToken != "" {
-			authHeader := r.Header.Get("Authorization")
-			if strings.HasPrefix(authHeader, "Bearer ") {
-				token := strings.TrimPrefix(authHeader, "Bearer ")
-				if token == s.config.API.BearerToken {
-					next(w, r)
-					return
-				}
-			}
+		if s.cfg().API.BearerToken != "" && bearer == s.cfg().API.BearerToken {
+			next(w, r)
+			return
 		}
 
 		// 3. Check Basic Auth
-		if s.config.API.BasicAuth.Enabled {
+		if s.cfg().API.BasicAuth.Enabled {
 			username, password, ok := r.BasicAuth()
-			if ok && username == s.config.API.BasicAuth.Username &&
-				password == s.config.API.BasicAuth.Password {
+			if ok && username == s.cfg().API.BasicAuth.Username &&
+				password == s.cfg().API.BasicAuth.Password {
+				next(w, r)
+				return
+			}
+		}
+
+		// 4. Check stored API tokens (X-API-Key or Bearer)
+		for _, secret := range []string{apiKey, bearer} {
+			if token := s.storage.ValidateAPIToken(secret); token != nil {
+				if !token.HasScope(scope) {
+					s.jsonError(w, fmt.Sprintf("Forbidden - token lacks %q scope", scope), http.StatusForbidden)
+					return
+				}
 				next(w, r)
 				return
 			}
@@ -227,6 +365,14 @@ func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// hasAuth reports whether any API credentials are configured or stored
+func (s *Server) hasAuth() bool {
+	return s.cfg().API.Key != "" ||
+		s.cfg().API.BearerToken != "" ||
+		s.cfg().API.BasicAuth.Enabled ||
+		s.storage.HasAPITokens()
+}
+
 // getClientIP extracts client IP from request
 func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header
@@ -269,45 +415,210 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
+	s.renderIndex(w, r, nil)
+}
+
+// pageMeta is the head metadata of a page: its title and the OpenGraph and
+// Twitter card tags used when a link to it is unfurled
+type pageMeta struct {
+	Title       string
+	Description string
+	URL         string
+	Image       string
+	Feed        string // Incident update feed, on incident pages
+}
+
+// indexMeta returns the head metadata for a status page, or for an
+// incident's page when inc is set
+func (s *Server) indexMeta(p *config.PageConfig, inc *storage.Incident) pageMeta {
+	view := s.pageView(p)
+	baseURL := s.pageBaseURL(p)
+	meta := pageMeta{
+		Title:       view.Title,
+		Description: view.Description,
+		URL:         baseURL + "/",
+		Image:       view.Logo,
+	}
+	if strings.HasPrefix(meta.Image, "/") {
+		meta.Image = s.cfg().BaseURL + meta.Image
+	}
+	if inc == nil {
+		return meta
+	}
+
+	message := inc.Message
+	if len(inc.Updates) > 0 {
+		message = inc.Updates[len(inc.Updates)-1].Message
+	}
+	meta.Title = inc.Title + " - " + view.Title
+	meta.Description = fmt.Sprintf("[%s] %s", strings.ToUpper(inc.Severity), inc.Status)
+	if message != "" {
+		meta.Description += ": " + truncateRunes(message, 200)
+	}
+	meta.URL = baseURL + "/incidents/" + url.PathEscape(inc.ID)
+	if s.pageEndpoints(p).Feeds {
+		meta.Feed = s.feedGenerator().IncidentFeedURL(url.PathEscape(inc.ID))
+	}
+	return meta
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "…"
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
 
-	tmpl, err := template.ParseFS(templateFiles, "templates/index.html")
+// renderIndex renders the status page. With inc set it is that incident's
+// page: the incident is listed on its own and the head describes it. Times
+// are shown in the viewer's zone.
+func (s *Server) renderIndex(w http.ResponseWriter, r *http.Request, inc *storage.Incident) {
+	loc := s.viewerLocation(w, r)
+	tmpl, err := template.New("index.html").Funcs(template.FuncMap{
+		// Incident messages are Markdown, rendered to sanitized HTML
+		"markdown": func(s string) template.HTML { return template.HTML(markdown.ToHTML(s)) },
+		"local":    func(t time.Time) time.Time { return t.In(loc) },
+	}).ParseFS(templateFiles, "templates/index.html")
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		log.Printf("Template error: %v", err)
+		requestLogger(r).Error("Template error", "error", err)
 		return
 	}
 
+	p := s.pageFor(r)
+	view := s.pageView(p)
+	statuses := s.pageStatuses(p)
+
 	// Get active incidents
-	incidents := s.storage.GetIncidents(5, true)
+	incidents := s.pageIncidents(p, 5, true)
+	if inc != nil {
+		incidents = []storage.Incident{*inc}
+	}
 
 	// Get upcoming maintenance
-	maintenance := s.storage.GetMaintenance(true)
+	maintenance := s.pageMaintenance(p, s.storage.GetMaintenance(true))
 
 	data := struct {
-		Title       string
-		Description string
-		Logo        string
-		BaseURL     string
-		Theme       config.ThemeConfig
-		Services    []*monitor.ServiceStatus
-		Incidents   []storage.Incident
-		Maintenance []storage.Maintenance
-		Overall     monitor.Status
+		Title         string
+		Description   string
+		Logo          string
+		BaseURL       string
+		BasePath      string
+		Theme         config.ThemeConfig
+		Services      []*monitor.ServiceStatus
+		Incidents     []storage.Incident
+		Maintenance   []storage.Maintenance
+		Overall       monitor.Status
+		PushEnabled   bool
+		Meta          pageMeta
+		Detail        bool   // Incident page: show each incident's updates
+		Timezone      string // IANA zone for times rendered by scripts; empty is the browser's
+		Endpoints     config.EndpointsConfig
+		Announcement  *AnnouncementInfo
+		InitialStatus interface{} // Embedded when the page cannot fetch /api/status
+		Snapshot      *time.Time  // When a static copy of the page was rendered
 	}{
-		Title:       s.config.Title,
-		Description: s.config.Description,
-		Logo:        s.config.Logo,
-		BaseURL:     s.config.BaseURL,
-		Theme:       s.config.Theme,
-		Services:    s.monitor.GetAllStatuses(),
-		Incidents:   incidents,
-		Maintenance: maintenance,
-		Overall:     s.monitor.GetOverallStatus(),
+		Title:        view.Title,
+		Description:  view.Description,
+		Logo:         view.Logo,
+		BaseURL:      s.cfg().BaseURL,
+		BasePath:     s.pageBasePath(p),
+		Theme:        view.Theme,
+		Services:     statuses,
+		Incidents:    incidents,
+		Maintenance:  maintenance,
+		Overall:      s.pageOverall(p, statuses),
+		PushEnabled:  s.pushEnabled() && s.pageEndpoints(p).Subscriptions,
+		Meta:         s.indexMeta(p, inc),
+		Detail:       inc != nil,
+		Timezone:     zoneName(loc),
+		Endpoints:    s.pageEndpoints(p),
+		Announcement: s.activeAnnouncement(),
+	}
+	if snapshot, ok := siteSnapshot(r); ok {
+		// A static copy can't fetch /api/status, get live updates or take
+		// subscriptions
+		data.Snapshot = &snapshot
+		data.Endpoints.PublicAPI, data.Endpoints.WebSocket, data.Endpoints.Subscriptions = false, false, false
+		data.PushEnabled = false
+	}
+	if !data.Endpoints.PublicAPI {
+		data.InitialStatus = map[string]interface{}{
+			"overall":    data.Overall,
+			"services":   data.Services,
+			"group_meta": s.groupInfos(data.Services),
+		}
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Template execution error: %v", err)
+		requestLogger(r).Error("Template execution error", "error", err)
+	}
+}
+
+// handleEmbed serves a compact, frame-friendly status widget.
+// Query parameters: group (filter), theme (light|dark), show_uptime (true|false).
+func (s *Server) handleEmbed(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := template.ParseFS(templateFiles, "templates/embed.html")
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		requestLogger(r).Error("Embed template error", "error", err)
+		return
+	}
+
+	query := r.URL.Query()
+	group := query.Get("group")
+
+	theme := query.Get("theme")
+	if theme != "light" && theme != "dark" {
+		theme = "light"
+		if s.cfg().Theme.DarkMode {
+			theme = "dark"
+		}
+	}
+
+	p := s.pageFor(r)
+	view := s.pageView(p)
+	statuses := s.pageStatuses(p)
+	services := make([]*monitor.ServiceStatus, 0)
+	for _, status := range statuses {
+		if group != "" && groupName(status) != group {
+			continue
+		}
+		services = append(services, status)
+	}
+
+	data := struct {
+		Title      string
+		BaseURL    string
+		BasePath   string
+		Theme      string
+		Colors     config.ThemeConfig
+		Group      string
+		ShowUptime bool
+		Services   []*monitor.ServiceStatus
+		Overall    monitor.Status
+	}{
+		Title:      view.Title,
+		BaseURL:    s.cfg().BaseURL,
+		BasePath:   s.pageBasePath(p),
+		Theme:      theme,
+		Colors:     view.Theme,
+		Group:      group,
+		ShowUptime: query.Get("show_uptime") != "false",
+		Services:   services,
+		Overall:    s.pageOverall(p, statuses),
+	}
+
+	// Allow this route to be framed by any site
+	w.Header().Del("X-Frame-Options")
+	w.Header().Set("Content-Security-Policy", "frame-ancestors *")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "public, max-age=30")
+	if err := tmpl.Execute(w, data); err != nil {
+		requestLogger(r).Error("Embed template execution error", "error", err)
 	}
 }
 
@@ -318,7 +629,12 @@ func (s *Server) handleHistoryPage(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleIncidentPage(w http.ResponseWriter, r *http.Request) {
 	// Serve incident detail page
-	s.handleIndex(w, r)
+	inc := s.storage.GetIncident(strings.TrimPrefix(r.URL.Path, "/incidents/"))
+	if inc == nil || !s.affectsPage(s.pageFor(r), inc.AffectedServices) {
+		http.NotFound(w, r)
+		return
+	}
+	s.renderIndex(w, r, inc)
 }
 
 // handleAPIDocs serves the API documentation page
@@ -332,23 +648,25 @@ func (s *Server) handleAPIDocs(w http.ResponseWriter, r *http.Request) {
 	tmpl, err := template.ParseFS(templateFiles, "templates/api.html")
 	if err != nil {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		log.Printf("API docs template error: %v", err)
+		requestLogger(r).Error("API docs template error", "error", err)
 		return
 	}
 
 	data := struct {
-		Title   string
-		BaseURL string
-		Theme   config.ThemeConfig
+		Title    string
+		BaseURL  string
+		BasePath string
+		Theme    config.ThemeConfig
 	}{
-		Title:   s.config.Title,
-		BaseURL: s.config.BaseURL,
-		Theme:   s.config.Theme,
+		Title:    s.cfg().Title,
+		BaseURL:  s.cfg().BaseURL,
+		BasePath: s.cfg().BasePath,
+		Theme:    s.cfg().Theme,
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("API docs template execution error: %v", err)
+		requestLogger(r).Error("API docs template execution error", "error", err)
 	}
 }
 
@@ -362,19 +680,20 @@ type APIResponse struct {
 }
 
 type APIMeta struct {
-	Page       int    `json:"page,omitempty"`
-	PerPage    int    `json:"per_page,omitempty"`
-	Total      int    `json:"total,omitempty"`
+	Page        int    `json:"page,omitempty"`
+	PerPage     int    `json:"per_page,omitempty"`
+	Total       int    `json:"total,omitempty"`
 	GeneratedAt string `json:"generated_at"`
 }
 
 // Summary response like Cloudflare/GitHub
 type SummaryResponse struct {
-	Page       PageInfo       `json:"page"`
-	Status     StatusInfo     `json:"status"`
-	Components []ComponentInfo `json:"components"`
-	Incidents  []IncidentInfo  `json:"incidents"`
-	Maintenance []MaintenanceInfo `json:"scheduled_maintenances"`
+	Page         PageInfo          `json:"page"`
+	Status       StatusInfo        `json:"status"`
+	Announcement *AnnouncementInfo `json:"announcement"` // Banner showing now, or null
+	Components   []ComponentInfo   `json:"components"`
+	Incidents    []IncidentInfo    `json:"incidents"`
+	Maintenance  []MaintenanceInfo `json:"scheduled_maintenances"`
 }
 
 type PageInfo struct {
@@ -401,16 +720,16 @@ type ComponentInfo struct {
 }
 
 type IncidentInfo struct {
-	ID               string        `json:"id"`
-	Name             string        `json:"name"`
-	Status           string        `json:"status"`
-	Impact           string        `json:"impact"`
-	CreatedAt        string        `json:"created_at"`
-	UpdatedAt        string        `json:"updated_at"`
-	ResolvedAt       string        `json:"resolved_at,omitempty"`
-	Shortlink        string        `json:"shortlink"`
-	AffectedComponents []string    `json:"affected_components"`
-	Updates          []UpdateInfo  `json:"incident_updates"`
+	ID                 string       `json:"id"`
+	Name               string       `json:"name"`
+	Status             string       `json:"status"`
+	Impact             string       `json:"impact"`
+	CreatedAt          string       `json:"created_at"`
+	UpdatedAt          string       `json:"updated_at"`
+	ResolvedAt         string       `json:"resolved_at,omitempty"`
+	Shortlink          string       `json:"shortlink"`
+	AffectedComponents []string     `json:"affected_components"`
+	Updates            []UpdateInfo `json:"incident_updates"`
 }
 
 type UpdateInfo struct {
@@ -421,11 +740,11 @@ type UpdateInfo struct {
 }
 
 type MaintenanceInfo struct {
-	ID             string   `json:"id"`
-	Name           string   `json:"name"`
-	Status         string   `json:"status"`
-	ScheduledFor   string   `json:"scheduled_for"`
-	ScheduledUntil string   `json:"scheduled_until"`
+	ID                 string   `json:"id"`
+	Name               string   `json:"name"`
+	Status             string   `json:"status"`
+	ScheduledFor       string   `json:"scheduled_for"`
+	ScheduledUntil     string   `json:"scheduled_until"`
 	AffectedComponents []string `json:"affected_components"`
 }
 
@@ -435,10 +754,18 @@ func (s *Server) handleAPISummary(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	statuses := s.monitor.GetAllStatuses()
-	incidents := s.storage.GetIncidents(10, false)
-	maintenance := s.storage.GetMaintenance(true)
-	overall := s.monitor.GetOverallStatus()
+	p := s.pageFor(r)
+	s.cachedJSON(w, pageCacheKey("summary", p), false, func() interface{} {
+		return s.buildSummary(p)
+	})
+}
+
+// buildSummary assembles the Statuspage-compatible summary document for a page
+func (s *Server) buildSummary(p *config.PageConfig) interface{} {
+	statuses := s.pageStatuses(p)
+	incidents := s.pageIncidents(p, 10, false)
+	maintenance := s.pageMaintenance(p, s.storage.GetMaintenance(true))
+	overall := s.pageOverall(p, statuses)
 
 	// Build components
 	components := make([]ComponentInfo, 0, len(statuses))
@@ -481,7 +808,7 @@ func (s *Server) handleAPISummary(w http.ResponseWriter, r *http.Request) {
 			CreatedAt:          inc.CreatedAt.Format(time.RFC3339),
 			UpdatedAt:          inc.UpdatedAt.Format(time.RFC3339),
 			ResolvedAt:         resolvedAt,
-			Shortlink:          fmt.Sprintf("%s/incidents/%s", s.config.BaseURL, inc.ID),
+			Shortlink:          fmt.Sprintf("%s/incidents/%s", s.cfg().BaseURL, inc.ID),
 			AffectedComponents: inc.AffectedServices,
 			Updates:            updates,
 		})
@@ -514,21 +841,22 @@ func (s *Server) handleAPISummary(w http.ResponseWriter, r *http.Request) {
 
 	summary := SummaryResponse{
 		Page: PageInfo{
-			ID:        "status",
-			Name:      s.config.Title,
-			URL:       s.config.BaseURL,
+			ID:        summaryPageID(p),
+			Name:      s.pageView(p).Title,
+			URL:       s.pageBaseURL(p),
 			UpdatedAt: time.Now().Format(time.RFC3339),
 		},
 		Status: StatusInfo{
 			Indicator:   indicator,
 			Description: description,
 		},
-		Components:  components,
-		Incidents:   incidentInfos,
-		Maintenance: maintenanceInfos,
+		Announcement: s.activeAnnouncement(),
+		Components:   components,
+		Incidents:    incidentInfos,
+		Maintenance:  maintenanceInfos,
 	}
 
-	s.jsonResponse(w, summary)
+	return summary
 }
 
 func (s *Server) handleAPIStatus(w http.ResponseWriter, r *http.Request) {
@@ -537,26 +865,18 @@ func (s *Server) handleAPIStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	statuses := s.monitor.GetAllStatuses()
-	overall := s.monitor.GetOverallStatus()
+	p := s.pageFor(r)
+	s.cachedJSON(w, pageCacheKey("status", p), true, func() interface{} {
+		statuses := s.pageStatuses(p)
+		overall := s.pageOverall(p, statuses)
 
-	// Group services
-	groups := make(map[string][]*monitor.ServiceStatus)
-	for _, status := range statuses {
-		group := status.Group
-		if group == "" {
-			group = "Services"
+		return map[string]interface{}{
+			"overall":    overall,
+			"services":   statuses,
+			"groups":     groupStatuses(statuses),
+			"group_meta": s.groupInfos(statuses),
 		}
-		groups[group] = append(groups[group], status)
-	}
-
-	data := map[string]interface{}{
-		"overall":  overall,
-		"services": statuses,
-		"groups":   groups,
-	}
-
-	s.jsonResponseWithMeta(w, data)
+	})
 }
 
 func (s *Server) handleAPIServiceStatus(w http.ResponseWriter, r *http.Request) {
@@ -572,7 +892,7 @@ func (s *Server) handleAPIServiceStatus(w http.ResponseWriter, r *http.Request)
 	}
 
 	status := s.monitor.GetStatus(name)
-	if status == nil {
+	if status == nil || !s.onPage(s.pageFor(r), name) {
 		s.jsonError(w, "Service not found", http.StatusNotFound)
 		return
 	}
@@ -586,7 +906,7 @@ func (s *Server) handleAPIComponents(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	statuses := s.monitor.GetAllStatuses()
+	statuses := s.pageStatuses(s.pageFor(r))
 	components := make([]ComponentInfo, 0, len(statuses))
 
 	for _, status := range statuses {
@@ -619,6 +939,13 @@ func (s *Server) handleAPIHistory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	history := s.storage.GetAllHistory(days)
+	if p := s.pageFor(r); p != nil {
+		for name := range history {
+			if !s.onPage(p, name) {
+				delete(history, name)
+			}
+		}
+	}
 	s.jsonResponse(w, history)
 }
 
@@ -633,9 +960,19 @@ func (s *Server) handleAPIServiceHistory(w http.ResponseWriter, r *http.Request)
 		s.jsonError(w, "Service name required", http.StatusBadRequest)
 		return
 	}
+	if !s.onPage(s.pageFor(r), name) {
+		s.jsonError(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("from") != "" || query.Get("to") != "" || query.Get("resolution") != "" {
+		s.serviceHistoryRange(w, r, name)
+		return
+	}
 
 	days := 90
-	if d := r.URL.Query().Get("days"); d != "" {
+	if d := query.Get("days"); d != "" {
 		fmt.Sscanf(d, "%d", &days)
 	}
 
@@ -643,13 +980,109 @@ func (s *Server) handleAPIServiceHistory(w http.ResponseWriter, r *http.Request)
 	s.jsonResponse(w, history)
 }
 
+// HistoryRangeResponse is returned by /api/history/{service} when a time
+// range or resolution is requested
+type HistoryRangeResponse struct {
+	Service    string      `json:"service"`
+	Resolution string      `json:"resolution"`
+	From       string      `json:"from"`
+	To         string      `json:"to"`
+	Points     interface{} `json:"points"`
+}
+
+// serviceHistoryRange serves raw, hourly or daily history for a time window
+func (s *Server) serviceHistoryRange(w http.ResponseWriter, r *http.Request, name string) {
+	query := r.URL.Query()
+
+	resolution := query.Get("resolution")
+	if resolution == "" {
+		resolution = storage.ResolutionHour
+	}
+
+	var defaultSpan time.Duration
+	switch resolution {
+	case storage.ResolutionRaw:
+		defaultSpan = 24 * time.Hour
+	case storage.ResolutionHour:
+		defaultSpan = 7 * 24 * time.Hour
+	case storage.ResolutionDay:
+		defaultSpan = 90 * 24 * time.Hour
+	default:
+		s.jsonError(w, "Invalid resolution - use raw, hour or day", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now()
+	if v := query.Get("to"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			s.jsonError(w, "Invalid 'to' parameter - use RFC 3339 or unix seconds", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+
+	from := to.Add(-defaultSpan)
+	if v := query.Get("from"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			s.jsonError(w, "Invalid 'from' parameter - use RFC 3339 or unix seconds", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+
+	if !from.Before(to) {
+		s.jsonError(w, "'from' must be before 'to'", http.StatusBadRequest)
+		return
+	}
+
+	resp := HistoryRangeResponse{
+		Service:    name,
+		Resolution: resolution,
+		From:       from.Format(time.RFC3339),
+		To:         to.Format(time.RFC3339),
+	}
+
+	if resolution == storage.ResolutionRaw {
+		points := s.storage.GetCheckPoints(name, from, to)
+		if points == nil {
+			points = []storage.CheckPoint{}
+		}
+		resp.Points = points
+	} else {
+		points := s.storage.GetRollups(name, from, to, resolution)
+		if points == nil {
+			points = []storage.Rollup{}
+		}
+		resp.Points = points
+	}
+
+	s.jsonResponse(w, resp)
+}
+
+// parseTimeParam accepts RFC 3339 timestamps, plain dates or unix seconds
+func parseTimeParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", v); err == nil {
+		return t, nil
+	}
+	secs, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(secs, 0), nil
+}
+
 func (s *Server) handleAPIUptime(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	statuses := s.monitor.GetAllStatuses()
+	statuses := s.pageStatuses(s.pageFor(r))
 	uptime := make(map[string]float64)
 
 	for _, status := range statuses {
@@ -659,6 +1092,219 @@ func (s *Server) handleAPIUptime(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, uptime)
 }
 
+// === Badge API ===
+
+// ShieldsBadge follows the Shields.io endpoint badge schema
+// (https://shields.io/badges/endpoint-badge)
+type ShieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	IsError       bool   `json:"isError,omitempty"`
+	CacheSeconds  int    `json:"cacheSeconds,omitempty"`
+}
+
+func (s *Server) handleAPIBadge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/badge/")
+	badge := ShieldsBadge{SchemaVersion: 1, Label: name, CacheSeconds: 300}
+	if label := r.URL.Query().Get("label"); label != "" {
+		badge.Label = label
+	}
+
+	status := s.monitor.GetStatus(name)
+	switch {
+	case name == "" || status == nil || !s.onPage(s.pageFor(r), name):
+		badge.Message = "not found"
+		badge.Color = "lightgrey"
+		badge.IsError = true
+
+	case r.URL.Query().Get("type") == "uptime":
+		badge.Message = fmt.Sprintf("%.2f%%", status.Uptime)
+		switch {
+		case status.Uptime >= 99.9:
+			badge.Color = "brightgreen"
+		case status.Uptime >= 99:
+			badge.Color = "green"
+		case status.Uptime >= 95:
+			badge.Color = "yellow"
+		default:
+			badge.Color = "red"
+		}
+
+	default:
+		badge.Message = string(status.Status)
+		switch status.Status {
+		case monitor.StatusOperational:
+			badge.Color = "brightgreen"
+		case monitor.StatusDegraded:
+			badge.Color = "yellow"
+		case monitor.StatusDown:
+			badge.Color = "red"
+		default:
+			badge.Color = "lightgrey"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(badge)
+}
+
+// === Charts API ===
+
+// ChartResponse holds a bucketed response-time series for a service
+type ChartResponse struct {
+	Service string        `json:"service"`
+	Period  string        `json:"period"`
+	Step    string        `json:"step"`
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Series  []ChartBucket `json:"series"`
+}
+
+// ChartBucket is a single aggregated point. Response fields are null when
+// the bucket contains no checks so charts render a gap.
+type ChartBucket struct {
+	Timestamp     string   `json:"timestamp"`
+	Count         int      `json:"count"`
+	AvgMs         *int64   `json:"avg_ms"`
+	MinMs         *int64   `json:"min_ms"`
+	MaxMs         *int64   `json:"max_ms"`
+	P95Ms         *int64   `json:"p95_ms"`
+	UptimePercent *float64 `json:"uptime_percent"`
+}
+
+const (
+	maxChartPeriod  = 7 * 24 * time.Hour // matches raw check retention
+	minChartStep    = time.Minute
+	maxChartBuckets = 2000
+)
+
+func (s *Server) handleAPIChart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/charts/")
+	if name == "" {
+		s.jsonError(w, "Service name required", http.StatusBadRequest)
+		return
+	}
+	if s.monitor.GetStatus(name) == nil || !s.onPage(s.pageFor(r), name) {
+		s.jsonError(w, "Service not found", http.StatusNotFound)
+		return
+	}
+
+	period := 24 * time.Hour
+	if p := r.URL.Query().Get("period"); p != "" {
+		d, err := parseChartDuration(p)
+		if err != nil || d <= 0 {
+			s.jsonError(w, "Invalid period (e.g. 1h, 24h, 7d)", http.StatusBadRequest)
+			return
+		}
+		period = d
+	}
+	if period > maxChartPeriod {
+		period = maxChartPeriod
+	}
+
+	step := 5 * time.Minute
+	if st := r.URL.Query().Get("step"); st != "" {
+		d, err := parseChartDuration(st)
+		if err != nil || d <= 0 {
+			s.jsonError(w, "Invalid step (e.g. 1m, 5m, 1h)", http.StatusBadRequest)
+			return
+		}
+		step = d
+	}
+	if step < minChartStep {
+		step = minChartStep
+	}
+	if period/step > maxChartBuckets {
+		s.jsonError(w, fmt.Sprintf("Too many buckets - at most %d per request", maxChartBuckets), http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().Truncate(step).Add(step)
+	from := to.Add(-period)
+	points := s.storage.GetCheckPoints(name, from, to)
+
+	s.jsonResponse(w, ChartResponse{
+		Service: name,
+		Period:  period.String(),
+		Step:    step.String(),
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		Series:  bucketCheckPoints(points, from, to, step),
+	})
+}
+
+// bucketCheckPoints groups raw checks into fixed-width buckets and computes
+// avg/min/max/p95 response times and uptime for each
+func bucketCheckPoints(points []storage.CheckPoint, from, to time.Time, step time.Duration) []ChartBucket {
+	n := int(to.Sub(from) / step)
+	samples := make([][]int64, n)
+	success := make([]int, n)
+
+	for _, p := range points {
+		i := int(p.Timestamp.Sub(from) / step)
+		if i < 0 || i >= n {
+			continue
+		}
+		samples[i] = append(samples[i], p.ResponseTimeMs)
+		if p.Status == string(monitor.StatusOperational) || p.Status == string(monitor.StatusDegraded) {
+			success[i]++
+		}
+	}
+
+	series := make([]ChartBucket, n)
+	for i := range series {
+		b := ChartBucket{
+			Timestamp: from.Add(time.Duration(i) * step).Format(time.RFC3339),
+			Count:     len(samples[i]),
+		}
+		if b.Count > 0 {
+			vals := samples[i]
+			sort.Slice(vals, func(x, y int) bool { return vals[x] < vals[y] })
+
+			var sum int64
+			for _, v := range vals {
+				sum += v
+			}
+			avg := sum / int64(len(vals))
+			p95 := vals[(len(vals)*95+99)/100-1]
+			uptime := float64(success[i]) / float64(len(vals)) * 100
+
+			b.AvgMs = &avg
+			b.MinMs = &vals[0]
+			b.MaxMs = &vals[len(vals)-1]
+			b.P95Ms = &p95
+			b.UptimePercent = &uptime
+		}
+		series[i] = b
+	}
+	return series
+}
+
+// parseChartDuration extends time.ParseDuration with a "d" (day) suffix
+func parseChartDuration(v string) (time.Duration, error) {
+	if strings.HasSuffix(v, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(v, "d"))
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(v)
+}
+
 // === Incidents API ===
 
 func (s *Server) handleAPIIncidents(w http.ResponseWriter, r *http.Request) {
@@ -670,7 +1316,7 @@ func (s *Server) handleAPIIncidents(w http.ResponseWriter, r *http.Request) {
 			fmt.Sscanf(l, "%d", &limit)
 		}
 
-		incidents := s.storage.GetIncidents(limit, activeOnly)
+		incidents := s.pageIncidents(s.pageFor(r), limit, activeOnly)
 		s.jsonResponse(w, incidents)
 
 	case http.MethodPost:
@@ -696,7 +1342,7 @@ func (s *Server) createIncident(w http.ResponseWriter, r *http.Request) {
 
 	// Notify webhooks
 	if s.notifier != nil {
-		s.notifier.NotifyIncidentCreated(*created, s.config.BaseURL)
+		s.notifier.NotifyIncidentCreated(*created, s.cfg().BaseURL)
 	}
 
 	w.WriteHeader(http.StatusCreated)
@@ -709,11 +1355,23 @@ func (s *Server) handleAPIIncident(w http.ResponseWriter, r *http.Request) {
 		s.jsonError(w, "Incident ID required", http.StatusBadRequest)
 		return
 	}
+	if incidentID, ok := strings.CutSuffix(id, "/ack"); ok {
+		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			s.handleIncidentAck(w, r, incidentID)
+		})(w, r)
+		return
+	}
+	if incidentID, ok := strings.CutSuffix(id, "/attachments"); ok {
+		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+			s.handleIncidentAttachments(w, r, incidentID)
+		})(w, r)
+		return
+	}
 
 	switch r.Method {
 	case http.MethodGet:
 		incident := s.storage.GetIncident(id)
-		if incident == nil {
+		if incident == nil || !s.affectsPage(s.pageFor(r), incident.AffectedServices) {
 			s.jsonError(w, "Incident not found", http.StatusNotFound)
 			return
 		}
@@ -721,14 +1379,15 @@ func (s *Server) handleAPIIncident(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPut, http.MethodPatch:
 		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
-			var update struct {
-				Status  string `json:"status"`
-				Message string `json:"message"`
-			}
+			var update IncidentRequest
 			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 				s.jsonError(w, "Invalid request body", http.StatusBadRequest)
 				return
 			}
+			if r.Method == http.MethodPut && update.Title != "" {
+				s.putIncident(w, id, update)
+				return
+			}
 
 			updated, err := s.storage.UpdateIncident(id, update.Status, update.Message)
 			if err != nil {
@@ -743,9 +1402,9 @@ func (s *Server) handleAPIIncident(w http.ResponseWriter, r *http.Request) {
 			// Notify webhooks
 			if s.notifier != nil {
 				if update.Status == "resolved" {
-					s.notifier.NotifyIncidentResolved(*updated, s.config.BaseURL)
+					s.notifier.NotifyIncidentResolved(*updated, s.cfg().BaseURL)
 				} else {
-					s.notifier.NotifyIncidentUpdated(*updated, s.config.BaseURL)
+					s.notifier.NotifyIncidentUpdated(*updated, s.cfg().BaseURL)
 				}
 			}
 
@@ -772,7 +1431,7 @@ func (s *Server) handleAPIMaintenance(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
 		upcoming := r.URL.Query().Get("upcoming") != "false"
-		maintenance := s.storage.GetMaintenance(upcoming)
+		maintenance := s.pageMaintenance(s.pageFor(r), s.storage.GetMaintenance(upcoming))
 		s.jsonResponse(w, maintenance)
 
 	case http.MethodPost:
@@ -791,7 +1450,7 @@ func (s *Server) handleAPIMaintenance(w http.ResponseWriter, r *http.Request) {
 
 			// Notify webhooks
 			if s.notifier != nil {
-				s.notifier.NotifyMaintenanceScheduled(*created, s.config.BaseURL)
+				s.notifier.NotifyMaintenanceScheduled(*created, s.cfg().BaseURL)
 			}
 
 			w.WriteHeader(http.StatusCreated)
@@ -813,13 +1472,20 @@ func (s *Server) handleAPIMaintenanceItem(w http.ResponseWriter, r *http.Request
 	switch r.Method {
 	case http.MethodPut, http.MethodPatch:
 		s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
-			var update struct {
-				Status string `json:"status"`
-			}
+			var update storage.Maintenance
 			if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
 				s.jsonError(w, "Invalid request body", http.StatusBadRequest)
 				return
 			}
+			if r.Method == http.MethodPut && update.Title != "" {
+				s.putMaintenance(w, id, update)
+				return
+			}
+
+			previous := ""
+			if m := s.findMaintenance(id); m != nil {
+				previous = m.Status
+			}
 
 			updated, _ := s.storage.UpdateMaintenance(id, update.Status)
 			if updated == nil {
@@ -827,6 +1493,9 @@ func (s *Server) handleAPIMaintenanceItem(w http.ResponseWriter, r *http.Request
 				return
 			}
 
+			s.broadcastMaintenance(previous, *updated)
+			s.notifyMaintenance(previous, *updated)
+
 			s.jsonResponse(w, updated)
 		})(w, r)
 
@@ -846,6 +1515,8 @@ type MetricsResponse struct {
 	AverageResponseMs int64   `json:"average_response_ms"`
 	ActiveIncidents   int     `json:"active_incidents"`
 	TotalIncidents    int     `json:"total_incidents"`
+
+	Notifications *notify.NotificationStats `json:"notifications,omitempty"`
 }
 
 func (s *Server) handleAPIMetrics(w http.ResponseWriter, r *http.Request) {
@@ -890,14 +1561,18 @@ func (s *Server) handleAPIMetrics(w http.ResponseWriter, r *http.Request) {
 	if responseCount > 0 {
 		metrics.AverageResponseMs = totalResponseTime / responseCount
 	}
+	if s.notifier != nil {
+		stats := s.notifier.Stats()
+		metrics.Notifications = &stats
+	}
 
 	s.jsonResponse(w, metrics)
 }
 
 // === Feed Handlers ===
 
-func (s *Server) getStatusSummary() *feeds.StatusSummary {
-	statuses := s.monitor.GetAllStatuses()
+func (s *Server) getStatusSummary(scope feedScope) *feeds.StatusSummary {
+	statuses := s.scopeStatuses(scope)
 	summary := &feeds.StatusSummary{
 		Overall: string(s.monitor.GetOverallStatus()),
 		Total:   len(statuses),
@@ -916,109 +1591,288 @@ func (s *Server) getStatusSummary() *feeds.StatusSummary {
 		}
 	}
 
+	// A scoped summary applies the monitor's overall rule to its services
+	if scope.name() != "" {
+		switch {
+		case summary.Down > summary.Total/2:
+			summary.Overall = string(monitor.StatusDown)
+		case summary.Down > 0 || summary.Degraded > 0:
+			summary.Overall = string(monitor.StatusDegraded)
+		default:
+			summary.Overall = string(monitor.StatusOperational)
+		}
+	}
+
 	return summary
 }
 
 func (s *Server) handleRSSFeed(w http.ResponseWriter, r *http.Request) {
-	incidents := s.storage.GetIncidents(50, false)
-	status := s.getStatusSummary()
-	feed, err := s.feedGen.GenerateRSSWithStatus(incidents, status)
+	s.serveFeed(w, r, "rss", "application/rss+xml; charset=utf-8", func(fg *feeds.FeedGenerator, incidents []storage.Incident, status *feeds.StatusSummary) ([]byte, error) {
+		feed, err := fg.GenerateRSSWithStatus(incidents, status)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), feed...), nil
+	})
+}
+
+func (s *Server) handleAtomFeed(w http.ResponseWriter, r *http.Request) {
+	s.serveFeed(w, r, "atom", "application/atom+xml; charset=utf-8", (*feeds.FeedGenerator).GenerateAtomWithStatus)
+}
+
+func (s *Server) handleJSONFeed(w http.ResponseWriter, r *http.Request) {
+	s.serveFeed(w, r, "json", "application/feed+json; charset=utf-8", (*feeds.FeedGenerator).GenerateJSONWithStatus)
+}
+
+// serveFeed generates (or reuses) a feed and answers conditional GETs.
+// The feed generator caches output per format, scope, filter and page,
+// regenerated only when the latest incident update or the current status
+// summary changes. Feeds are
+// paged (RFC 5005); only the first page carries the status summary,
+// maintenance windows and the WebSub hub.
+func (s *Server) serveFeed(w http.ResponseWriter, r *http.Request, format, contentType string, generate func(*feeds.FeedGenerator, []storage.Incident, *feeds.StatusSummary) ([]byte, error)) {
+	scope, ok := s.parseFeedScope(w, r, format)
+	if !ok {
+		return
+	}
+	filter, filterQuery, ok := parseFeedFilter(w, r)
+	if !ok {
+		return
+	}
+	page, ok := parseFeedPage(w, r)
+	if !ok {
+		return
+	}
+	limit, limitQuery, ok := s.parseFeedLimit(w, r)
+	if !ok {
+		return
+	}
+	if limitQuery != "" {
+		filterQuery = strings.TrimPrefix(filterQuery+"&"+limitQuery, "&")
+	}
+
+	fg := s.feedGenerator()
+	path := scope.path
+	if filterQuery != "" {
+		if strings.Contains(path, "?") {
+			path += "&" + filterQuery
+		} else {
+			path += "?" + filterQuery
+		}
+	}
+	if path != "" {
+		fg = fg.Scoped(scope.name(), path)
+	}
+	if hub := s.feedHub(filterQuery != ""); hub != "" && page == 1 {
+		fg = fg.WithHub(hub)
+	}
+
+	incidents := s.scopeIncidents(scope)
+	if !filter.IsZero() {
+		incidents = fg.FilterIncidents(incidents, filter)
+	}
+	total := len(incidents)
+	lastPage := max((total+limit-1)/limit, 1)
+	if page > lastPage {
+		http.Error(w, "Page not found", http.StatusNotFound)
+		return
+	}
+	fg = fg.Paged(page, lastPage)
+	incidents = incidents[(page-1)*limit : min(page*limit, total)]
+
+	var lastModified time.Time
+	for _, inc := range incidents {
+		if inc.UpdatedAt.After(lastModified) {
+			lastModified = inc.UpdatedAt
+		}
+	}
+	key := fmt.Sprintf("%d|%d|%d", lastModified.UnixNano(), len(incidents), total)
+
+	// List maintenance windows on the first page
+	if page == 1 {
+		maintenance := s.scopeMaintenance(scope, filter, limit)
+		var maintenanceModified time.Time
+		for _, m := range maintenance {
+			if m.UpdatedAt.After(maintenanceModified) {
+				maintenanceModified = m.UpdatedAt
+			}
+		}
+		if maintenanceModified.After(lastModified) {
+			lastModified = maintenanceModified
+		}
+		fg = fg.WithMaintenance(maintenance)
+		key += fmt.Sprintf("|%d|%d", maintenanceModified.UnixNano(), len(maintenance))
+	}
+
+	// Lead the first page with a current-status entry unless disabled
+	var status *feeds.StatusSummary
+	if s.cfg().Feeds.StatusSummary && page == 1 {
+		status = s.getStatusSummary(scope)
+		key += fmt.Sprintf("|%s|%d|%d|%d", status.Overall, status.Operational, status.Degraded, status.Down)
+	}
+
+	feed, err := fg.Cached(format, key, lastModified, func() ([]byte, error) {
+		return generate(fg, incidents, status)
+	})
 	if err != nil {
 		http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
 		return
 	}
+	s.writeFeed(w, r, feed, contentType)
+}
 
-	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+// writeFeed serves a rendered feed and answers conditional GETs
+func (s *Server) writeFeed(w http.ResponseWriter, r *http.Request, feed *feeds.Feed, contentType string) {
+	w.Header().Set("Content-Type", contentType)
 	w.Header().Set("Cache-Control", "public, max-age=300") // 5 min cache
-	w.Write([]byte(xml.Header))
-	w.Write(feed)
-}
+	w.Header().Set("ETag", feed.ETag)
+	if !feed.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", feed.LastModified.UTC().Format(http.TimeFormat))
+	}
 
-func (s *Server) handleAtomFeed(w http.ResponseWriter, r *http.Request) {
-	incidents := s.storage.GetIncidents(50, false)
-	status := s.getStatusSummary()
-	feed, err := s.feedGen.GenerateAtomWithStatus(incidents, status)
-	if err != nil {
-		http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
+	if isNotModified(r, feed.ETag, feed.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=300")
-	w.Write(feed)
+	w.Write(feed.Body)
 }
 
-func (s *Server) handleJSONFeed(w http.ResponseWriter, r *http.Request) {
-	incidents := s.storage.GetIncidents(50, false)
-	status := s.getStatusSummary()
-	feed, err := s.feedGen.GenerateJSONWithStatus(incidents, status)
-	if err != nil {
-		http.Error(w, "Failed to generate feed", http.StatusInternalServerError)
-		return
+// isNotModified evaluates If-None-Match and If-Modified-Since request headers.
+// If-None-Match takes precedence as per RFC 9110.
+func isNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
 	}
 
-	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
-	w.Header().Set("Cache-Control", "public, max-age=300")
-	w.Write(feed)
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err == nil && !lastModified.Truncate(time.Second).After(t) {
+			return true
+		}
+	}
+	return false
 }
 
-// === Subscription Handler ===
+// === WebSocket Handler ===
 
-func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+const (
+	// wsWriteTimeout bounds writing one message to a WebSocket client
+	wsWriteTimeout = 10 * time.Second
+	// wsSendBuffer is how many messages may wait for a client before it is
+	// dropped as too slow
+	wsSendBuffer = 32
+)
+
+// wsClient is a WebSocket connection and the page it watches, "" for the
+// main page. A connection allows one writer at a time, so messages are
+// queued for a single goroutine that writes them.
+type wsClient struct {
+	conn *websocket.Conn
+	page string
+	send chan []byte
+	done chan struct{}
+	once sync.Once
+}
+
+func newWSClient(conn *websocket.Conn, page string) *wsClient {
+	c := &wsClient{
+		conn: conn,
+		page: page,
+		send: make(chan []byte, wsSendBuffer),
+		done: make(chan struct{}),
 	}
+	go c.writeLoop()
+	return c
+}
 
-	var sub struct {
-		Email    string   `json:"email"`
-		Services []string `json:"services"`
+// queue hands a message to the writer without waiting. A client whose
+// queue is full has stopped reading, and is closed.
+func (c *wsClient) queue(msg []byte) {
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	default:
+		c.close()
 	}
-	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
-		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
-		return
+}
+
+func (c *wsClient) writeLoop() {
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			return
+		}
 	}
+}
 
-	// In production, you'd save this and send verification email
-	s.jsonResponse(w, map[string]string{
-		"message": "Subscription request received. Please check your email for verification.",
-		"email":   sub.Email,
+// close stops the writer and closes the connection, which ends the reader
+// and removes the client
+func (c *wsClient) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
 	})
 }
 
-// === WebSocket Handler ===
-
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket upgrade error: %v", err)
+		requestLogger(r).Warn("WebSocket upgrade error", "error", err)
 		return
 	}
 
-	s.clientMu.Lock()
-	s.clients[conn] = true
-	s.clientMu.Unlock()
-
-	// Send initial status
-	statuses := s.monitor.GetAllStatuses()
-	overall := s.monitor.GetOverallStatus()
-	incidents := s.storage.GetIncidents(5, true)
+	// Send initial status, queued before the client is registered so it
+	// goes ahead of any broadcast
+	p := s.pageFor(r)
+	statuses := s.pageStatuses(p)
+	overall := s.pageOverall(p, statuses)
+	incidents := s.pageIncidents(p, 5, true)
 
 	initialData := map[string]interface{}{
-		"type":      "initial",
-		"overall":   overall,
-		"services":  statuses,
-		"incidents": incidents,
+		"type":        "initial",
+		"overall":     overall,
+		"services":    statuses,
+		"group_meta":  s.groupInfos(statuses),
+		"incidents":   incidents,
+		"maintenance": s.activeMaintenance(p),
 	}
-	conn.WriteJSON(initialData)
+	msg, err := json.Marshal(initialData)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	client := newWSClient(conn, pageName(p))
+	client.queue(msg)
+
+	s.clientMu.Lock()
+	if s.closing {
+		s.clientMu.Unlock()
+		closeClient(client)
+		return
+	}
+	s.clients[client] = struct{}{}
+	s.clientMu.Unlock()
 
 	// Handle connection close
 	go func() {
 		defer func() {
 			s.clientMu.Lock()
-			delete(s.clients, conn)
+			delete(s.clients, client)
 			s.clientMu.Unlock()
-			conn.Close()
+			client.close()
 		}()
 
 		for {
@@ -1034,25 +1888,27 @@ func (s *Server) broadcastUpdates() {
 	ch := s.monitor.Subscribe()
 	defer s.monitor.Unsubscribe(ch)
 
+	lastStatus := make(map[string]monitor.Status)
 	for status := range ch {
-		s.clientMu.RLock()
-		for client := range s.clients {
-			data := map[string]interface{}{
+		if lastStatus[status.Name] != status.Status {
+			lastStatus[status.Name] = status.Status
+			s.respCache.invalidate()
+		}
+
+		s.broadcastPages(func(p *config.PageConfig) interface{} {
+			if !p.Shows(status.Name, groupName(status)) {
+				return nil
+			}
+			overall := s.monitor.GetOverallStatus()
+			if p != nil {
+				overall = monitor.OverallStatus(s.pageStatuses(p))
+			}
+			return map[string]interface{}{
 				"type":    "update",
 				"service": status,
-				"overall": s.monitor.GetOverallStatus(),
-			}
-			err := client.WriteJSON(data)
-			if err != nil {
-				client.Close()
-				go func(c *websocket.Conn) {
-					s.clientMu.Lock()
-					delete(s.clients, c)
-					s.clientMu.Unlock()
-				}(client)
+				"overall": overall,
 			}
-		}
-		s.clientMu.RUnlock()
+		})
 	}
 }
 
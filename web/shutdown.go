@@ -0,0 +1,60 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// closeWriteTimeout bounds sending a WebSocket client the close frame, so
+// a client that stopped reading doesn't hold up shutdown
+const closeWriteTimeout = time.Second
+
+// Drain reports the server as not ready on /readyz, so load balancers and
+// orchestrators stop sending it traffic. Call it as soon as shutdown
+// starts; requests are served as usual until Stop.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+// handleReadyz answers 200 while the server wants traffic, and 503 once it
+// is shutting down
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if s.draining.Load() {
+		s.jsonError(w, "Shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	s.jsonResponse(w, map[string]string{"status": "ready"})
+}
+
+// Stop stops accepting connections, closes WebSocket connections with a
+// close frame so open pages reconnect, and waits for requests in progress
+// until ctx is done
+func (s *Server) Stop(ctx context.Context) error {
+	// Shutdown closes the listener before running this, so pages reconnect
+	// elsewhere, or to the process taking over
+	s.server.RegisterOnShutdown(s.closeClients)
+	return s.server.Shutdown(ctx)
+}
+
+// closeClients closes every WebSocket connection, and any opened after
+func (s *Server) closeClients() {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+
+	s.closing = true
+	for client := range s.clients {
+		closeClient(client)
+		delete(s.clients, client)
+	}
+}
+
+// closeClient sends a going-away close frame and closes the connection.
+// WriteControl may run alongside the client's writer.
+func closeClient(client *wsClient) {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	client.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(closeWriteTimeout))
+	client.close()
+}
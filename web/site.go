@@ -0,0 +1,142 @@
+package web
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// siteSnapshotKey marks a request rendered for ExportSite; its value is
+// the time of the snapshot
+type siteSnapshotKey struct{}
+
+// siteSnapshot returns the time of the snapshot r renders a page for, and
+// whether it is one
+func siteSnapshot(r *http.Request) (time.Time, bool) {
+	t, ok := r.Context().Value(siteSnapshotKey{}).(time.Time)
+	return t, ok
+}
+
+// siteRecorder captures a handler's response for ExportSite
+type siteRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (r *siteRecorder) Header() http.Header         { return r.header }
+func (r *siteRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+func (r *siteRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+// ExportSite renders the main page, every incident's page, the feeds and
+// the service badges as static files, for hosting on a CDN or object
+// storage as a fallback while the server is down. Each file is passed to
+// put with the URL path it is served at, relative to the base path, so the
+// copy can take over the server's hostname. Pages embed the status and
+// leave out live updates and subscribing, which need the server.
+func (s *Server) ExportSite(put func(name string, data []byte) error) error {
+	ctx := context.WithValue(context.Background(), siteSnapshotKey{}, time.Now())
+	render := func(name, target string, handler http.HandlerFunc) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			return err
+		}
+		rec := &siteRecorder{header: make(http.Header)}
+		handler(rec, req)
+		if rec.code != 0 && rec.code != http.StatusOK {
+			return fmt.Errorf("%s: %d %s", target, rec.code, http.StatusText(rec.code))
+		}
+		return put(name, rec.body.Bytes())
+	}
+
+	endpoints := s.cfg().Endpoints
+	if err := render("index.html", "/", s.handleIndex); err != nil {
+		return err
+	}
+	for _, name := range []string{"favicon.svg", "favicon.ico"} {
+		if err := render(name, "/"+name, s.handleFavicon); err != nil {
+			return err
+		}
+	}
+	for _, inc := range s.pageIncidents(nil, 0, false) {
+		id := url.PathEscape(inc.ID)
+		if err := render("incidents/"+inc.ID+"/index.html", "/incidents/"+id, s.handleIncidentPage); err != nil {
+			return err
+		}
+		if !endpoints.Feeds {
+			continue
+		}
+		if err := render("feed/incidents/"+inc.ID+".atom", "/feed/incidents/"+id+".atom", s.handleIncidentFeed); err != nil {
+			return err
+		}
+	}
+	if endpoints.Feeds {
+		feeds := []struct {
+			name    string
+			handler http.HandlerFunc
+		}{
+			{"feed/rss", s.handleRSSFeed},
+			{"feed/atom", s.handleAtomFeed},
+			{"feed/json", s.handleJSONFeed},
+		}
+		for _, f := range feeds {
+			if err := render(f.name, "/"+f.name, f.handler); err != nil {
+				return err
+			}
+		}
+	}
+	if endpoints.PublicAPI {
+		for _, status := range s.pageStatuses(nil) {
+			if err := render("api/badge/"+status.Name, "/api/badge/"+url.PathEscape(status.Name), s.handleAPIBadge); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// handleSiteExport serves ExportSite's files as a gzipped tar archive
+func (s *Server) handleSiteExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Render everything first, so a failure is still an error response
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	err := s.ExportSite(func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		requestLogger(r).Error("Site export failed", "error", err)
+		s.jsonError(w, "Site export failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="site.tar.gz"`)
+	w.Write(archive.Bytes())
+}
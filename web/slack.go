@@ -0,0 +1,326 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/status/storage"
+)
+
+// === Slack Integration ===
+
+// SlackMessage is a slash-command or interactive response
+type SlackMessage struct {
+	ResponseType    string       `json:"response_type,omitempty"` // in_channel or ephemeral
+	ReplaceOriginal bool         `json:"replace_original,omitempty"`
+	Text            string       `json:"text"`
+	Blocks          []SlackBlock `json:"blocks,omitempty"`
+}
+
+// SlackBlock is a Block Kit layout block
+type SlackBlock struct {
+	Type     string         `json:"type"`
+	Text     *SlackText     `json:"text,omitempty"`
+	Elements []SlackElement `json:"elements,omitempty"`
+}
+
+// SlackText is a Block Kit text object
+type SlackText struct {
+	Type string `json:"type"` // mrkdwn or plain_text
+	Text string `json:"text"`
+}
+
+// SlackElement is a Block Kit interactive element (buttons only)
+type SlackElement struct {
+	Type     string     `json:"type"`
+	Text     *SlackText `json:"text"`
+	ActionID string     `json:"action_id"`
+	Value    string     `json:"value"`
+	Style    string     `json:"style,omitempty"`
+}
+
+// slackInteraction is the subset of Slack's block_actions payload we use
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+const slackHelp = "Usage:\n" +
+	"• `/status list` — show active incidents\n" +
+	"• `/status incident create <minor|major|critical> <title> [| message]`\n" +
+	"• `/status incident update <id> <investigating|identified|monitoring> [message]`\n" +
+	"• `/status resolve <id> [message]`"
+
+// handleSlackCommand handles signed Slack slash commands
+func (s *Server) handleSlackCommand(w http.ResponseWriter, r *http.Request) {
+	form, ok := s.verifySlackRequest(w, r)
+	if !ok {
+		return
+	}
+
+	user := form.Get("user_name")
+	fields := strings.Fields(form.Get("text"))
+	if len(fields) == 0 {
+		s.slackReply(w, SlackMessage{ResponseType: "ephemeral", Text: slackHelp})
+		return
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "list":
+		s.slackReply(w, s.slackListIncidents())
+
+	case "incident":
+		if len(fields) < 2 {
+			s.slackReply(w, SlackMessage{ResponseType: "ephemeral", Text: slackHelp})
+			return
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(form.Get("text")), fields[0]))
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[1]))
+		switch strings.ToLower(fields[1]) {
+		case "create":
+			s.slackReply(w, s.slackCreateIncident(rest, user))
+		case "update":
+			id, status, message := splitSlackArgs(rest)
+			s.slackReply(w, s.slackUpdateIncident(id, status, message, user))
+		default:
+			s.slackReply(w, SlackMessage{ResponseType: "ephemeral", Text: slackHelp})
+		}
+
+	case "resolve":
+		if len(fields) < 2 {
+			s.slackReply(w, SlackMessage{ResponseType: "ephemeral", Text: "Usage: `/status resolve <id> [message]`"})
+			return
+		}
+		message := strings.TrimSpace(strings.Join(fields[2:], " "))
+		s.slackReply(w, s.slackUpdateIncident(fields[1], "resolved", message, user))
+
+	default:
+		s.slackReply(w, SlackMessage{ResponseType: "ephemeral", Text: slackHelp})
+	}
+}
+
+// handleSlackInteractive handles button clicks on incident messages
+func (s *Server) handleSlackInteractive(w http.ResponseWriter, r *http.Request) {
+	form, ok := s.verifySlackRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var payload slackInteraction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	action := payload.Actions[0]
+	var status string
+	switch action.ActionID {
+	case "incident_identified":
+		status = "identified"
+	case "incident_monitoring":
+		status = "monitoring"
+	case "incident_resolve":
+		status = "resolved"
+	default:
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	msg := s.slackUpdateIncident(action.Value, status, "", payload.User.Username)
+	msg.ReplaceOriginal = true
+	s.slackReply(w, msg)
+}
+
+func (s *Server) slackListIncidents() SlackMessage {
+	incidents := s.storage.GetIncidents(10, true)
+	if len(incidents) == 0 {
+		return SlackMessage{ResponseType: "ephemeral", Text: ":white_check_mark: No active incidents"}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("*Active incidents*\n")
+	for _, inc := range incidents {
+		sb.WriteString(fmt.Sprintf("• `%s` [%s] %s — _%s_\n", inc.ID, inc.Severity, inc.Title, inc.Status))
+	}
+	return SlackMessage{ResponseType: "ephemeral", Text: sb.String()}
+}
+
+func (s *Server) slackCreateIncident(args, user string) SlackMessage {
+	severity, title, _ := strings.Cut(args, " ")
+	severity = strings.ToLower(severity)
+	if severity != "minor" && severity != "major" && severity != "critical" {
+		return SlackMessage{ResponseType: "ephemeral", Text: "Severity must be minor, major or critical\n" + slackHelp}
+	}
+
+	title, message, _ := strings.Cut(title, "|")
+	title = strings.TrimSpace(title)
+	message = strings.TrimSpace(message)
+	if title == "" {
+		return SlackMessage{ResponseType: "ephemeral", Text: "Incident title required\n" + slackHelp}
+	}
+	if message == "" {
+		message = "We are investigating this issue."
+	}
+
+	created, err := s.storage.CreateIncident(storage.Incident{
+		Title:    title,
+		Status:   "investigating",
+		Severity: severity,
+		Message:  message,
+	})
+	if err != nil {
+		return SlackMessage{ResponseType: "ephemeral", Text: "Failed to create incident: " + err.Error()}
+	}
+
+	if s.notifier != nil {
+		s.notifier.NotifyIncidentCreated(*created, s.cfg().BaseURL)
+	}
+
+	return s.slackIncidentMessage(*created, fmt.Sprintf("Incident created by @%s", user))
+}
+
+func (s *Server) slackUpdateIncident(id, status, message, user string) SlackMessage {
+	switch status {
+	case "investigating", "identified", "monitoring", "resolved":
+	default:
+		return SlackMessage{ResponseType: "ephemeral", Text: "Status must be investigating, identified, monitoring or resolved"}
+	}
+
+	if s.storage.GetIncident(id) == nil {
+		return SlackMessage{ResponseType: "ephemeral", Text: fmt.Sprintf("Incident `%s` not found", id)}
+	}
+
+	updated, err := s.storage.UpdateIncident(id, status, message)
+	if err != nil || updated == nil {
+		return SlackMessage{ResponseType: "ephemeral", Text: fmt.Sprintf("Failed to update incident `%s`", id)}
+	}
+
+	if s.notifier != nil {
+		if status == "resolved" {
+			s.notifier.NotifyIncidentResolved(*updated, s.cfg().BaseURL)
+		} else {
+			s.notifier.NotifyIncidentUpdated(*updated, s.cfg().BaseURL)
+		}
+	}
+
+	return s.slackIncidentMessage(*updated, fmt.Sprintf("Marked *%s* by @%s", status, user))
+}
+
+// slackIncidentMessage renders an incident with action buttons
+func (s *Server) slackIncidentMessage(inc storage.Incident, context string) SlackMessage {
+	link := fmt.Sprintf("%s/incidents/%s", s.cfg().BaseURL, inc.ID)
+	text := fmt.Sprintf("*<%s|%s>*\nStatus: *%s* · Severity: *%s* · ID: `%s`\n%s",
+		link, inc.Title, inc.Status, inc.Severity, inc.ID, context)
+
+	msg := SlackMessage{
+		ResponseType: "in_channel",
+		Text:         fmt.Sprintf("[%s] %s", inc.Status, inc.Title),
+		Blocks: []SlackBlock{
+			{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: text}},
+		},
+	}
+
+	if inc.Status != "resolved" {
+		button := func(label, actionID, style string) SlackElement {
+			return SlackElement{
+				Type:     "button",
+				Text:     &SlackText{Type: "plain_text", Text: label},
+				ActionID: actionID,
+				Value:    inc.ID,
+				Style:    style,
+			}
+		}
+		msg.Blocks = append(msg.Blocks, SlackBlock{
+			Type: "actions",
+			Elements: []SlackElement{
+				button("Identified", "incident_identified", ""),
+				button("Monitoring", "incident_monitoring", ""),
+				button("Resolve", "incident_resolve", "primary"),
+			},
+		})
+	}
+
+	return msg
+}
+
+// verifySlackRequest checks the Slack request signature and returns the
+// parsed form body. It writes an error response when verification fails.
+func (s *Server) verifySlackRequest(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	slack := s.cfg().Integrations.Slack
+	if !slack.Enabled || slack.SigningSecret == "" {
+		http.NotFound(w, r)
+		return nil, false
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)).Abs() > 5*time.Minute {
+		http.Error(w, "Stale or missing timestamp", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	mac := hmac.New(sha256.New, []byte(slack.SigningSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Slack-Signature"))) {
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "Invalid form body", http.StatusBadRequest)
+		return nil, false
+	}
+	return form, true
+}
+
+func (s *Server) slackReply(w http.ResponseWriter, msg SlackMessage) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
+
+// splitSlackArgs splits "<id> <status> [message]"
+func splitSlackArgs(args string) (id, status, message string) {
+	fields := strings.Fields(args)
+	if len(fields) > 0 {
+		id = fields[0]
+	}
+	if len(fields) > 1 {
+		status = strings.ToLower(fields[1])
+	}
+	if len(fields) > 2 {
+		message = strings.Join(fields[2:], " ")
+	}
+	return id, status, message
+}
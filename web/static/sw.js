@@ -0,0 +1,31 @@
+// Service worker for status page push notifications
+self.addEventListener('push', function(event) {
+    let data = {};
+    try {
+        data = event.data ? event.data.json() : {};
+    } catch (e) {
+        data = { title: 'Status Update', body: event.data.text() };
+    }
+
+    event.waitUntil(self.registration.showNotification(data.title || 'Status Update', {
+        body: data.body || '',
+        tag: data.tag || undefined,
+        renotify: !!data.tag,
+        icon: 'favicon.svg',
+        data: { url: data.url || self.registration.scope }
+    }));
+});
+
+self.addEventListener('notificationclick', function(event) {
+    event.notification.close();
+    const url = event.notification.data && event.notification.data.url;
+
+    event.waitUntil(clients.matchAll({ type: 'window', includeUncontrolled: true }).then(function(windows) {
+        for (const client of windows) {
+            if (client.url === url && 'focus' in client) {
+                return client.focus();
+            }
+        }
+        return clients.openWindow(url);
+    }));
+});
@@ -0,0 +1,236 @@
+package web
+
+import (
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+)
+
+// === Subscription Handlers ===
+
+func (s *Server) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.notifier == nil || !s.notifier.EmailEnabled() {
+		s.jsonError(w, "Email subscriptions are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Email    string   `json:"email"`
+		Services []string `json:"services"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	addr, err := mail.ParseAddress(req.Email)
+	if err != nil {
+		s.jsonError(w, "Invalid email address", http.StatusBadRequest)
+		return
+	}
+
+	// An unknown name would silently filter out every notification
+	if unknown := s.unknownServices(req.Services); len(unknown) > 0 {
+		s.jsonError(w, "Unknown services: "+strings.Join(unknown, ", "), http.StatusBadRequest)
+		return
+	}
+
+	sub, err := s.storage.CreateSubscriber(addr.Address, req.Services)
+	if err != nil {
+		s.jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// A verified subscription is left as it was and its owner is sent the
+	// preferences link, so the answer doesn't reveal who is subscribed
+	send := s.notifier.SendVerification
+	if sub.Verified {
+		send = s.notifier.SendManageLink
+	}
+	if err := send(*sub, s.cfg().BaseURL); err != nil {
+		requestLogger(r).Error("Error sending subscription email", "email", sub.Email, "error", err)
+		s.jsonError(w, "Failed to send verification email", http.StatusBadGateway)
+		return
+	}
+
+	s.jsonResponse(w, map[string]string{
+		"message": "Subscription request received. Please check your email for verification.",
+		"email":   sub.Email,
+	})
+}
+
+func (s *Server) handleSubscribeVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if sub := s.storage.VerifySubscriber(r.URL.Query().Get("token")); sub != nil {
+		s.renderSubscriptionPage(w, http.StatusOK, "Subscription confirmed",
+			"You will now receive email notifications at "+sub.Email+".")
+		return
+	}
+	s.renderSubscriptionPage(w, http.StatusNotFound, "Link expired",
+		"This confirmation link is invalid or has already been used to unsubscribe.")
+}
+
+// handleUnsubscribe supports both the link in each email (GET) and
+// RFC 8058 one-click unsubscribe (POST).
+func (s *Server) handleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.storage.DeleteSubscriberByToken(r.URL.Query().Get("token")) {
+		s.renderSubscriptionPage(w, http.StatusOK, "Unsubscribed",
+			"You will no longer receive email notifications.")
+		return
+	}
+	s.renderSubscriptionPage(w, http.StatusNotFound, "Not subscribed",
+		"This link is invalid or you have already unsubscribed.")
+}
+
+// handleSubscribePreferences shows (GET) and saves (POST) the services a
+// subscriber is emailed about. It is linked from every email.
+func (s *Server) handleSubscribePreferences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		s.jsonError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	sub := s.storage.GetSubscriberByToken(token)
+	if sub == nil {
+		s.renderSubscriptionPage(w, http.StatusNotFound, "Not subscribed",
+			"This link is invalid or you have already unsubscribed.")
+		return
+	}
+
+	saved := false
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form", http.StatusBadRequest)
+			return
+		}
+		services := r.PostForm["services"]
+		if unknown := s.unknownServices(services); len(unknown) > 0 {
+			http.Error(w, "Unknown services: "+strings.Join(unknown, ", "), http.StatusBadRequest)
+			return
+		}
+		if sub = s.storage.UpdateSubscriberServices(token, services); sub == nil {
+			http.Error(w, "Failed to save preferences", http.StatusInternalServerError)
+			return
+		}
+		saved = true
+	}
+
+	tmpl, err := template.ParseFS(templateFiles, "templates/preferences.html")
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		requestLogger(r).Error("Preferences template error", "error", err)
+		return
+	}
+
+	type prefService struct {
+		Name    string
+		Checked bool
+	}
+	type prefGroup struct {
+		Name     string
+		Services []prefService
+	}
+
+	var groups []prefGroup
+	index := make(map[string]int)
+	for _, svc := range s.cfg().Services {
+		name := svc.Group
+		if name == "" {
+			name = "Services"
+		}
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, prefGroup{Name: name})
+		}
+		groups[i].Services = append(groups[i].Services, prefService{
+			Name:    svc.Name,
+			Checked: containsAny([]string{svc.Name}, sub.Services),
+		})
+	}
+
+	data := struct {
+		Title    string
+		BasePath string
+		Email    string
+		Token    string
+		Groups   []prefGroup
+		Saved    bool
+	}{
+		Title:    s.cfg().Title,
+		BasePath: s.cfg().BasePath,
+		Email:    sub.Email,
+		Token:    token,
+		Groups:   groups,
+		Saved:    saved,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := tmpl.Execute(w, data); err != nil {
+		requestLogger(r).Error("Preferences template execution error", "error", err)
+	}
+}
+
+// unknownServices returns the names that are not configured services
+func (s *Server) unknownServices(names []string) []string {
+	var unknown []string
+	for _, name := range names {
+		found := false
+		for _, svc := range s.cfg().Services {
+			if svc.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+func (s *Server) renderSubscriptionPage(w http.ResponseWriter, code int, heading, message string) {
+	tmpl, err := template.ParseFS(templateFiles, "templates/subscription.html")
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		slog.Error("Subscription template error", "error", err)
+		return
+	}
+
+	data := struct {
+		Title    string
+		BasePath string
+		Heading  string
+		Message  string
+	}{
+		Title:    s.cfg().Title,
+		BasePath: s.cfg().BasePath,
+		Heading:  heading,
+		Message:  message,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	if err := tmpl.Execute(w, data); err != nil {
+		slog.Error("Subscription template execution error", "error", err)
+	}
+}
@@ -0,0 +1,63 @@
+package web
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/status/config"
+)
+
+// timezoneCookie remembers a viewer's ?tz= choice
+const timezoneCookie = "tz"
+
+// configLocation returns the configured display zone, or the server's
+// local zone if it cannot be loaded (validation reports that at startup)
+func configLocation(cfg *config.Config) *time.Location {
+	loc, err := cfg.Location()
+	if err != nil {
+		slog.Warn("Invalid timezone, showing local times", "timezone", cfg.Timezone, "error", err)
+		return time.Local
+	}
+	return loc
+}
+
+// location returns the configured display zone
+func (s *Server) location() *time.Location {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.loc
+}
+
+// viewerLocation returns the zone a page is rendered in: the ?tz= query
+// parameter, which is remembered in a cookie, then that cookie, then the
+// configured timezone. Unknown zones are ignored.
+func (s *Server) viewerLocation(w http.ResponseWriter, r *http.Request) *time.Location {
+	if name := r.URL.Query().Get("tz"); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			http.SetCookie(w, &http.Cookie{
+				Name:     timezoneCookie,
+				Value:    loc.String(),
+				Path:     s.cfg().BasePath + "/",
+				MaxAge:   365 * 24 * 60 * 60,
+				SameSite: http.SameSiteLaxMode,
+			})
+			return loc
+		}
+	}
+	if c, err := r.Cookie(timezoneCookie); err == nil && c.Value != "" {
+		if loc, err := time.LoadLocation(c.Value); err == nil {
+			return loc
+		}
+	}
+	return s.location()
+}
+
+// zoneName returns the IANA name of loc for browsers, or "" for the
+// server's local zone, which has none
+func zoneName(loc *time.Location) string {
+	if loc == time.Local {
+		return ""
+	}
+	return loc.String()
+}
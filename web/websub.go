@@ -0,0 +1,425 @@
+package web
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/status/config"
+	"github.com/status/notify"
+	"github.com/status/storage"
+)
+
+// WebSub hub settings
+const (
+	webSubSelfHub      = "self" // feeds.websub.hub value for the built-in hub
+	webSubDebounce     = 2 * time.Second
+	webSubDefaultLease = 7 * 24 * time.Hour
+	webSubMaxLease     = 30 * 24 * time.Hour
+	webSubMaxSecret    = 200 // bytes, per the WebSub spec
+	webSubMaxVerifying = 16  // Intent verifications in flight
+	webSubWorkers      = 8   // Deliveries sent at once
+	webSubTimeout      = 10 * time.Second
+)
+
+// webSub announces feed changes to a WebSub hub: an external hub is pinged
+// with hub.mode=publish, the built-in hub pushes the feed to subscribers
+type webSub struct {
+	hub       string // Hub URL declared in feeds
+	self      bool   // Built-in hub at /websub
+	client    *http.Client
+	callbacks *http.Client  // For subscriber callbacks, which anyone can name
+	verifying chan struct{} // Slots for intent verifications in flight
+	limit     int           // Most subscriptions kept
+	perTopic  int           // Most subscriptions to one feed
+	changed   chan struct{}
+	mu        sync.Mutex
+	published map[string]string // Topic -> ETag last announced
+}
+
+// newWebSub returns the WebSub publisher for cfg's hub, or nil when none is
+// configured
+func newWebSub(cfg config.WebSubConfig, baseURL string) *webSub {
+	hub := cfg.Hub
+	if hub == "" {
+		return nil
+	}
+	ws := &webSub{
+		hub:       hub,
+		client:    &http.Client{Timeout: webSubTimeout},
+		callbacks: notify.PublicClient(webSubTimeout),
+		verifying: make(chan struct{}, webSubMaxVerifying),
+		limit:     cfg.MaxSubscriptions,
+		perTopic:  cfg.MaxPerTopic,
+		changed:   make(chan struct{}, 1),
+		published: make(map[string]string),
+	}
+	if hub == webSubSelfHub {
+		ws.hub = strings.TrimSuffix(baseURL, "/") + "/websub"
+		ws.self = true
+	}
+	return ws
+}
+
+// feedHub returns the hub to declare in a feed. An external hub is only
+// pinged for unfiltered feeds, so filtered feeds declare none.
+func (s *Server) feedHub(filtered bool) string {
+	if s.websub == nil || (filtered && !s.websub.self) {
+		return ""
+	}
+	return s.websub.hub
+}
+
+// feedsChanged schedules a WebSub publish. Calls are coalesced.
+func (s *Server) feedsChanged() {
+	if s.websub == nil {
+		return
+	}
+	select {
+	case s.websub.changed <- struct{}{}:
+	default:
+	}
+}
+
+// runWebSub publishes changed feeds, debouncing bursts of changes
+func (s *Server) runWebSub() {
+	// Remember the current feeds so only later changes are announced
+	for _, topic := range s.webSubTopics() {
+		if rec := s.renderFeedTopic(topic); rec != nil {
+			s.websub.markPublished(topic, rec.etag())
+		}
+	}
+
+	for range s.websub.changed {
+		time.Sleep(webSubDebounce)
+		select {
+		case <-s.websub.changed:
+		default:
+		}
+		s.publishFeeds()
+	}
+}
+
+// webSubTopics returns the feed URLs to watch: every subscribed topic for
+// the built-in hub; the unfiltered feeds, overall and per service and
+// group, for an external hub
+func (s *Server) webSubTopics() []string {
+	seen := make(map[string]bool)
+	var topics []string
+	add := func(topic string) {
+		if !seen[topic] {
+			seen[topic] = true
+			topics = append(topics, topic)
+		}
+	}
+
+	if s.websub.self {
+		now := time.Now()
+		for _, sub := range s.storage.GetWebSubSubscriptions() {
+			if now.After(sub.ExpiresAt) {
+				s.storage.DeleteWebSubSubscription(sub.Callback, sub.Topic)
+				continue
+			}
+			add(sub.Topic)
+		}
+		return topics
+	}
+
+	base := s.cfg().BaseURL + "/feed/"
+	for _, format := range []string{"rss", "atom", "json"} {
+		add(base + format)
+		for _, st := range s.monitor.GetAllStatuses() {
+			add(base + format + "/" + url.PathEscape(st.Name))
+			if st.Group != "" {
+				add(base + format + "?group=" + url.QueryEscape(st.Group))
+			}
+		}
+	}
+	return topics
+}
+
+// publishFeeds announces every watched feed whose content changed
+func (s *Server) publishFeeds() {
+	for _, topic := range s.webSubTopics() {
+		rec := s.renderFeedTopic(topic)
+		if rec == nil || !s.websub.markPublished(topic, rec.etag()) {
+			continue
+		}
+		if s.websub.self {
+			s.distribute(topic, rec)
+		} else {
+			s.pingHub(topic)
+		}
+	}
+}
+
+// markPublished records a topic's ETag and reports whether it changed
+// since the last announcement. A topic seen for the first time has not.
+func (ws *webSub) markPublished(topic, etag string) bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	previous, ok := ws.published[topic]
+	ws.published[topic] = etag
+	return ok && previous != etag
+}
+
+// pingHub tells an external hub that topic has new content
+func (s *Server) pingHub(topic string) {
+	form := url.Values{"hub.mode": {"publish"}, "hub.url": {topic}}
+	resp, err := s.websub.client.PostForm(s.websub.hub, form)
+	if err != nil {
+		slog.Warn("WebSub: error pinging hub", "topic", topic, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("WebSub: hub rejected ping", "topic", topic, "status", resp.StatusCode)
+	}
+}
+
+// distribute pushes a feed to each subscriber of topic on the built-in
+// hub, through a few workers so a slow callback holds up only its own
+// delivery
+func (s *Server) distribute(topic string, rec *feedRecorder) {
+	subs := make(chan storage.WebSubSubscription)
+	var wg sync.WaitGroup
+	for i := 0; i < webSubWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for sub := range subs {
+				s.deliverWebSub(topic, rec, sub)
+			}
+		}()
+	}
+
+	for _, sub := range s.storage.GetWebSubSubscriptions() {
+		if sub.Topic == topic && time.Now().Before(sub.ExpiresAt) {
+			subs <- sub
+		}
+	}
+	close(subs)
+	wg.Wait()
+}
+
+// deliverWebSub pushes a feed to one subscriber
+func (s *Server) deliverWebSub(topic string, rec *feedRecorder, sub storage.WebSubSubscription) {
+	req, err := http.NewRequest(http.MethodPost, sub.Callback, bytes.NewReader(rec.body.Bytes()))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", rec.header.Get("Content-Type"))
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="hub"`, s.websub.hub))
+	req.Header.Add("Link", fmt.Sprintf(`<%s>; rel="self"`, topic))
+	if sub.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(sub.Secret))
+		mac.Write(rec.body.Bytes())
+		req.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.websub.callbacks.Do(req)
+	if err != nil {
+		slog.Warn("WebSub: error delivering", "topic", topic, "callback", sub.Callback, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusGone {
+		// The subscriber is gone for good
+		s.storage.DeleteWebSubSubscription(sub.Callback, sub.Topic)
+	} else if resp.StatusCode >= 300 {
+		slog.Warn("WebSub: delivery rejected", "topic", topic, "callback", sub.Callback, "status", resp.StatusCode)
+	}
+}
+
+// handleWebSubHub implements the built-in hub's subscribe and unsubscribe
+// requests. Intent is verified asynchronously, as the spec allows.
+func (s *Server) handleWebSubHub(w http.ResponseWriter, r *http.Request) {
+	if s.websub == nil || !s.websub.self {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	mode := r.PostForm.Get("hub.mode")
+	callback := r.PostForm.Get("hub.callback")
+	topic := r.PostForm.Get("hub.topic")
+	secret := r.PostForm.Get("hub.secret")
+
+	if mode != "subscribe" && mode != "unsubscribe" {
+		http.Error(w, "hub.mode must be subscribe or unsubscribe", http.StatusBadRequest)
+		return
+	}
+	if u, err := url.Parse(callback); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "hub.callback must be an http(s) URL", http.StatusBadRequest)
+		return
+	} else if !publicCallbackHost(u.Hostname()) {
+		// Names resolving to such addresses are refused when dialed
+		http.Error(w, "hub.callback must be a public address", http.StatusBadRequest)
+		return
+	}
+	if s.renderFeedTopic(topic) == nil {
+		http.Error(w, "hub.topic is not a feed on this site", http.StatusBadRequest)
+		return
+	}
+	if len(secret) > webSubMaxSecret {
+		http.Error(w, "hub.secret is too long", http.StatusBadRequest)
+		return
+	}
+
+	lease := webSubDefaultLease
+	if secs, err := strconv.Atoi(r.PostForm.Get("hub.lease_seconds")); err == nil && secs > 0 {
+		lease = time.Duration(secs) * time.Second
+		if lease > webSubMaxLease {
+			lease = webSubMaxLease
+		}
+	}
+
+	// Each request makes the hub fetch the callback, so only a few may wait
+	select {
+	case s.websub.verifying <- struct{}{}:
+	default:
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, "Too many pending verifications", http.StatusServiceUnavailable)
+		return
+	}
+	go func() {
+		defer func() { <-s.websub.verifying }()
+		s.verifyWebSubIntent(mode, callback, topic, secret, lease)
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// publicCallbackHost reports whether a callback host may be on the
+// internet: not localhost or a loopback, private or link-local address
+func publicCallbackHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return notify.PublicIP(ip)
+	}
+	return true
+}
+
+// verifyWebSubIntent confirms a (un)subscription with the subscriber, which
+// must echo hub.challenge, and applies it
+func (s *Server) verifyWebSubIntent(mode, callback, topic, secret string, lease time.Duration) {
+	challenge := make([]byte, 16)
+	rand.Read(challenge)
+
+	u, _ := url.Parse(callback)
+	q := u.Query()
+	q.Set("hub.mode", mode)
+	q.Set("hub.topic", topic)
+	q.Set("hub.challenge", hex.EncodeToString(challenge))
+	if mode == "subscribe" {
+		q.Set("hub.lease_seconds", strconv.Itoa(int(lease.Seconds())))
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := s.websub.callbacks.Get(u.String())
+	if err != nil {
+		slog.Warn("WebSub: verification failed", "mode", mode, "callback", callback, "error", err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 300 || strings.TrimSpace(string(body)) != hex.EncodeToString(challenge) {
+		slog.Warn("WebSub: not confirmed", "mode", mode, "callback", callback, "status", resp.StatusCode)
+		return
+	}
+
+	if mode == "unsubscribe" {
+		s.storage.DeleteWebSubSubscription(callback, topic)
+		slog.Info("WebSub: unsubscribed", "callback", callback, "topic", topic)
+		return
+	}
+
+	err = s.storage.SaveWebSubSubscription(storage.WebSubSubscription{
+		Callback:  callback,
+		Topic:     topic,
+		Secret:    secret,
+		ExpiresAt: time.Now().Add(lease),
+	}, s.websub.limit, s.websub.perTopic)
+	if errors.Is(err, storage.ErrWebSubLimit) {
+		slog.Warn("WebSub: subscription refused", "callback", callback, "topic", topic, "error", err)
+		return
+	}
+	if err != nil {
+		slog.Error("WebSub: saving subscription", "callback", callback, "error", err)
+		return
+	}
+	if rec := s.renderFeedTopic(topic); rec != nil {
+		s.websub.markPublished(topic, rec.etag())
+	}
+	slog.Info("WebSub: subscribed", "callback", callback, "topic", topic, "lease", lease)
+}
+
+// feedRecorder captures a feed rendered in-process
+type feedRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *feedRecorder) Header() http.Header         { return r.header }
+func (r *feedRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+func (r *feedRecorder) WriteHeader(status int)      { r.status = status }
+
+func (r *feedRecorder) etag() string { return r.header.Get("ETag") }
+
+// renderFeedTopic renders the feed at topic, a URL under this site's
+// /feed, or returns nil if it is not a feed
+func (s *Server) renderFeedTopic(topic string) *feedRecorder {
+	rest, ok := strings.CutPrefix(topic, s.cfg().BaseURL)
+	if !ok || !strings.HasPrefix(rest, "/feed") {
+		return nil
+	}
+	req, err := http.NewRequest(http.MethodGet, rest, nil)
+	if err != nil {
+		return nil
+	}
+
+	var handler http.HandlerFunc
+	switch {
+	case req.URL.Path == "/feed" || req.URL.Path == "/feed/rss" || strings.HasPrefix(req.URL.Path, "/feed/rss/"):
+		handler = s.handleRSSFeed
+	case req.URL.Path == "/feed/atom" || strings.HasPrefix(req.URL.Path, "/feed/atom/"):
+		handler = s.handleAtomFeed
+	case req.URL.Path == "/feed/json" || strings.HasPrefix(req.URL.Path, "/feed/json/"):
+		handler = s.handleJSONFeed
+	case strings.HasPrefix(req.URL.Path, "/feed/incidents/"):
+		handler = s.handleIncidentFeed
+	default:
+		return nil
+	}
+
+	rec := &feedRecorder{header: make(http.Header), status: http.StatusOK}
+	handler(rec, req)
+	if rec.status != http.StatusOK {
+		return nil
+	}
+	return rec
+}